@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
@@ -12,15 +13,153 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// maxConcurrent bounds the requests a batch operation sends at once.
+const maxConcurrent = 32
+
 type Value []byte
 
 type Item struct {
 	Key   string
 	Value []byte
 
+	// Tags are labels that can be passed to InvalidateTags
+	Tags []string
+
+	// Stale is set on an item read past its pool's SoftTTL, which is due a
+	// refresh. Refresh is set for the one reader granted the refresh: the
+	// token to set the refreshed item with, with LeaseSet.
+	Stale   bool
+	Refresh uint64
+
+	// Delta is how long the item took to compute, which weighs the chance
+	// XFetch recomputes it early. GetOrCompute measures it.
+	Delta time.Duration
+
+	// ExpiresAt and SoftExpiresAt are when a read item expires and becomes
+	// stale, by its pool's TTL and SoftTTL; the zero time if never. They are
+	// ignored on writes.
+	ExpiresAt     time.Time
+	SoftExpiresAt time.Time
+
 	casID int64
 }
 
+type JobState int
+
+const (
+	JobRunning JobState = iota
+	JobDone
+	JobCancelled
+	JobFailed
+)
+
+// Job is the status of a background job, such as a prefix delete.
+type Job struct {
+	ID    string
+	Kind  string
+	State JobState
+	Err   string
+
+	// progress, in units of work
+	Done  uint64
+	Total uint64
+
+	// Count is the job specific result count, e.g. keys removed
+	Count uint64
+
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+type EvictionPolicy int
+
+const (
+	PolicyLRU EvictionPolicy = iota
+	PolicyFIFO
+)
+
+// HashStrategy is how a pool maps keys to its caches.
+type HashStrategy int
+
+const (
+	HashRing HashStrategy = iota
+	HashJump
+	HashRendezvous
+	HashMaglev
+)
+
+// PoolConfig defines a named cache pool.
+type PoolConfig struct {
+	Name string
+
+	// Capacity is the default namespace capacity, in bytes
+	Capacity   uint64
+	CacheCount int
+	Replicas   int
+	Policy     EvictionPolicy
+
+	// TTL is how long items live after they are set; zero if forever
+	TTL time.Duration
+
+	// SoftTTL, if positive, is how long items are fresh after they are set.
+	// Past it, and until TTL, items are returned marked Stale.
+	SoftTTL time.Duration
+
+	// Namespaces maps additional namespace names to their capacity, in bytes
+	Namespaces map[string]uint64
+
+	HashStrategy HashStrategy
+
+	// BoundedLoad, if positive, bounds each cache's load to (1+BoundedLoad)
+	// times the average
+	BoundedLoad float64
+
+	// ReplicationFactor is the number of cluster peers holding each key;
+	// keys are not replicated if it is zero or one
+	ReplicationFactor int
+
+	// ReadQuorum and WriteQuorum are the number of replicas that must
+	// respond to reads and writes; a majority if zero
+	ReadQuorum  int
+	WriteQuorum int
+
+	// Origin, if set, is the HTTP URL or grpc://host:port address the
+	// server loads missed keys from. LoadTimeout bounds each load; 5s if
+	// zero.
+	Origin      string
+	LoadTimeout time.Duration
+
+	// NegativeTTL, if positive, is how long keys the origin does not have
+	// are remembered, and not loaded again
+	NegativeTTL time.Duration
+}
+
+type MemberState int
+
+const (
+	MemberAlive MemberState = iota
+	MemberSuspect
+	MemberDead
+	MemberLeft
+)
+
+// Member is a node of an mc cluster.
+type Member struct {
+	Address string
+	Weight  int
+	State   MemberState
+
+	// Incarnation orders the member's state changes
+	Incarnation uint64
+}
+
+type Pool struct {
+	Config PoolConfig
+
+	Size            uint64
+	CurrentCapacity uint64
+}
+
 var ErrCASConflict = errors.New("compare-and-swap conflict")
 
 type MemcachedClient interface {
@@ -28,19 +167,141 @@ type MemcachedClient interface {
 	Set(ctx context.Context, item *Item) error
 	CompareAndSwap(ctx context.Context, item *Item) error
 	Remove(ctx context.Context, key string) (*Item, error)
-	Clear(ctx context.Context) error
+
+	// LeaseGet gets key, asking for its lease if it is missing. Only one
+	// client at a time is granted a key's lease, and the others are told to
+	// wait for it to fill the key. See GetWithLease.
+	LeaseGet(ctx context.Context, key string) (*Lease, error)
+	// LeaseSet sets an item with the token of the lease granted by LeaseGet.
+	// It returns ErrLeaseInvalid if the key was written since.
+	LeaseSet(ctx context.Context, item *Item, token uint64) error
+
+	// XFetch gets key, reporting whether to recompute it now, before it
+	// expires. The chance rises as the item nears expiry, weighed by its
+	// Delta and scaled by beta, for which 1 is the optimum. See GetOrCompute.
+	XFetch(ctx context.Context, key string, beta float64) (item *Item, recompute bool, err error)
+
+	// GetMulti gets several keys at once, returning the items found by key.
+	GetMulti(ctx context.Context, keys ...string) (map[string]*Item, error)
+	// SetMulti sets several items at once.
+	SetMulti(ctx context.Context, items ...*Item) error
+
+	// ClearNamespace removes every item in a namespace. An empty namespace
+	// clears the client's namespace.
+	ClearNamespace(ctx context.Context, namespace string) error
 	Size(ctx context.Context) (uint64, error)
+
+	// InvalidateTags removes every item carrying at least one of tags,
+	// returning the number of items removed.
+	InvalidateTags(ctx context.Context, tags ...string) (uint64, error)
+
+	// Generation returns the namespace generation. Bumping the generation
+	// invalidates every item in the namespace at once.
+	Generation(ctx context.Context) (uint64, error)
+	SetGeneration(ctx context.Context, generation uint64) error
+	BumpGeneration(ctx context.Context) (uint64, error)
+
+	// DeleteMatching starts a background job removing every key with the
+	// given prefix or matching the given glob pattern, returning its job ID.
+	DeleteMatching(ctx context.Context, prefix, pattern string) (string, error)
+	GetJob(ctx context.Context, jobID string) (*Job, error)
+	CancelJob(ctx context.Context, jobID string) (*Job, error)
+
+	CreatePool(ctx context.Context, config PoolConfig) (*Pool, error)
+	ListPools(ctx context.Context) ([]*Pool, error)
+	DescribePool(ctx context.Context, name string) (*Pool, error)
+	DeletePool(ctx context.Context, name string) error
+	// Reshard changes the number of caches in a pool, returning the ID of
+	// the job moving keys to their new caches.
+	Reshard(ctx context.Context, name string, cacheCount int) (string, error)
+	// Resize changes the capacity, in bytes, of a namespace in a pool. Empty
+	// names select the server's default pool and namespace.
+	Resize(ctx context.Context, name, namespace string, capacity uint64) (*Pool, error)
+
+	// Members lists the nodes of the server's cluster, and their state. It
+	// is empty if the server is not clustered.
+	Members(ctx context.Context) ([]*Member, error)
+	// RepairReplicas compares the server's replicas of a replicated pool,
+	// or of every replicated pool if pool is empty, with its peers' and
+	// repairs the keys they disagree about. It returns the ID of the job
+	// doing so.
+	RepairReplicas(ctx context.Context, pool string) (string, error)
+
+	// Watch streams the changes to keys, and to keys starting with any of
+	// prefixes, or to every key if both are empty, in the client's
+	// namespace. An EventOverflow means changes were missed.
+	Watch(ctx context.Context, keys, prefixes []string) (*Watch, error)
+
+	// Close disconnects from the server, and from the cluster nodes if
+	// requests are routed to them.
+	Close() error
 }
 
 type Config struct {
 	ServiceURI string
+
+	// Servers, if set, are independent servers keys are sharded across with
+	// a consistent hash, instead of using ServiceURI alone
+	Servers []string
+
+	// MaxFailures is the number of consecutive requests to one of Servers
+	// that must fail for its keys to be rehashed to the others, and
+	// RetryInterval how long until it is tried again
+	MaxFailures   int
+	RetryInterval time.Duration
+
+	// Namespace is the namespace used by every request. When empty, the
+	// server's default namespace is used.
+	Namespace string
+
+	// Pool is the cache pool used by every request. When empty, the
+	// server's default pool is used.
+	Pool string
+
+	// ReadQuorum and WriteQuorum override the pool's quorums for requests
+	// to a replicated pool, when positive
+	ReadQuorum  int
+	WriteQuorum int
+
+	// RouteToOwner sends key requests straight to the cluster node owning
+	// the key, rather than through the ServiceURI node, saving a hop. The
+	// client learns the cluster's topology from the ServiceURI node, so the
+	// nodes' cluster addresses must be reachable from it.
+	RouteToOwner bool
+
+	// NearCacheSize, if positive, is the capacity in bytes of an in process
+	// cache of the items the client reads, per server. The server tells the
+	// client when a cached key changes, so items are not served stale for
+	// longer than that takes; the server must have client tracking enabled.
+	NearCacheSize uint64
 }
 
 type client struct {
-	grpc memcached.MemcachedClient
+	conn      *grpc.ClientConn
+	grpc      memcached.MemcachedClient
+	namespace string
+	pool      string
+
+	// router is nil unless key requests are routed to their owner
+	router *router
+
+	// near is nil unless the client caches the items it reads
+	near *nearCache
+
+	readQuorum  int32
+	writeQuorum int32
 }
 
+// New returns a client for the server at config.ServiceURI, or for the
+// servers in config.Servers.
 func New(config Config) (MemcachedClient, error) {
+	if len(config.Servers) > 0 {
+		return newShardedClient(config)
+	}
+	return newClient(config)
+}
+
+func newClient(config Config) (*client, error) {
 	opts := []grpc.DialOption{
 		grpc.WithInsecure(),
 		grpc.WithBackoffMaxDelay(3 * time.Second),
@@ -55,33 +316,120 @@ func New(config Config) (MemcachedClient, error) {
 		return nil, errors.Wrapf(err, "failed to dial %s", addr)
 	}
 	g := memcached.NewMemcachedClient(conn)
-	return &client{grpc: g}, nil
+
+	c := &client{
+		conn:        conn,
+		grpc:        g,
+		namespace:   config.Namespace,
+		pool:        config.Pool,
+		readQuorum:  int32(config.ReadQuorum),
+		writeQuorum: int32(config.WriteQuorum),
+	}
+	if config.RouteToOwner {
+		c.router = newRouter(g, opts)
+	}
+	if config.NearCacheSize > 0 {
+		if c.near, err = newNearCache(g, config.NearCacheSize); err != nil {
+			c.Close()
+			return nil, errors.Wrap(err, "failed to create near cache")
+		}
+	}
+	return c, nil
+}
+
+// do calls fn with a client for the node owning key. If the owner cannot be
+// reached, fn is retried through the ServiceURI node, which forwards it.
+func (c *client) do(key string, fn func(g memcached.MemcachedClient) error) error {
+	if c.router == nil {
+		return fn(c.grpc)
+	}
+
+	g := c.router.route(key)
+	err := fn(g)
+	if err != nil && g != c.grpc && status.Code(err) == codes.Unavailable {
+		err = fn(c.grpc)
+	}
+	return err
 }
 
 func (c *client) Get(ctx context.Context, key string) (*Item, error) {
-	res, err := c.grpc.Get(ctx, &memcached.GetRequest{
-		Key: key,
+	var seq uint64
+	cacheable := false
+	if c.near != nil {
+		if item := c.near.get(key); item != nil {
+			return item, nil
+		}
+		ctx, seq, cacheable = c.near.read(ctx)
+	}
+
+	req := &memcached.GetRequest{
+		Key:        key,
+		Namespace:  c.namespace,
+		Pool:       c.pool,
+		ReadQuorum: c.readQuorum,
+	}
+
+	var res *memcached.GetResponse
+	err := c.do(key, func(g memcached.MemcachedClient) (err error) {
+		res, err = g.Get(ctx, req)
+		return err
 	})
 	if err != nil {
 		return nil, errors.Wrapf(err, "cache get (%s) failed", key)
 	}
 
-	return fromMemcachedItem(res.Item), nil
+	item := fromMemcachedItem(res.Item)
+	if item != nil {
+		item.Stale = res.Stale
+		item.Refresh = res.Lease
+	}
+	if cacheable && item != nil && !item.Stale {
+		c.near.store(seq, item)
+	}
+	return item, nil
 }
 
 func (c *client) Set(ctx context.Context, item *Item) error {
-	_, err := c.grpc.Set(ctx, &memcached.SetRequest{
-		Item: toMemcachedItem(item),
+	req := &memcached.SetRequest{
+		Item:        toMemcachedItem(item),
+		Namespace:   c.namespace,
+		Pool:        c.pool,
+		WriteQuorum: c.writeQuorum,
+	}
+
+	if c.near != nil {
+		defer c.near.invalidate(item.Key)
+	}
+
+	err := c.do(item.Key, func(g memcached.MemcachedClient) error {
+		_, err := g.Set(ctx, req)
+		return err
 	})
 	if err != nil {
+		if status.Code(err) == codes.Aborted {
+			return ErrLeaseRequired
+		}
 		return errors.Wrapf(err, "cache set (%s) failed", item.Key)
 	}
 	return nil
 }
 
 func (c *client) CompareAndSwap(ctx context.Context, item *Item) error {
-	_, err := c.grpc.CompareAndSwap(ctx, &memcached.CompareAndSwapRequest{
-		Item: toMemcachedItem(item),
+	req := &memcached.CompareAndSwapRequest{
+		Item:        toMemcachedItem(item),
+		Namespace:   c.namespace,
+		Pool:        c.pool,
+		ReadQuorum:  c.readQuorum,
+		WriteQuorum: c.writeQuorum,
+	}
+
+	if c.near != nil {
+		defer c.near.invalidate(item.Key)
+	}
+
+	err := c.do(item.Key, func(g memcached.MemcachedClient) error {
+		_, err := g.CompareAndSwap(ctx, req)
+		return err
 	})
 	if err != nil {
 		code := status.Code(err)
@@ -94,8 +442,21 @@ func (c *client) CompareAndSwap(ctx context.Context, item *Item) error {
 }
 
 func (c *client) Remove(ctx context.Context, key string) (*Item, error) {
-	res, err := c.grpc.Remove(ctx, &memcached.RemoveRequest{
-		Key: key,
+	req := &memcached.RemoveRequest{
+		Key:         key,
+		Namespace:   c.namespace,
+		Pool:        c.pool,
+		WriteQuorum: c.writeQuorum,
+	}
+
+	if c.near != nil {
+		defer c.near.invalidate(key)
+	}
+
+	var res *memcached.RemoveResponse
+	err := c.do(key, func(g memcached.MemcachedClient) (err error) {
+		res, err = g.Remove(ctx, req)
+		return err
 	})
 	if err != nil {
 		return nil, errors.Wrapf(err, "cache remove (%s) failed", key)
@@ -103,22 +464,284 @@ func (c *client) Remove(ctx context.Context, key string) (*Item, error) {
 	return fromMemcachedItem(res.Item), nil
 }
 
-func (c *client) Clear(ctx context.Context) error {
-	_, err := c.grpc.Clear(ctx, &memcached.ClearRequest{})
+func (c *client) GetMulti(ctx context.Context, keys ...string) (map[string]*Item, error) {
+	items := make([]*Item, len(keys))
+	err := concurrently(len(keys), func(i int) error {
+		item, err := c.Get(ctx, keys[i])
+		items[i] = item
+		return err
+	})
 	if err != nil {
-		return errors.Wrapf(err, "cache clear failed")
+		return nil, err
+	}
+
+	found := make(map[string]*Item, len(keys))
+	for _, item := range items {
+		if item != nil {
+			found[item.Key] = item
+		}
+	}
+	return found, nil
+}
+
+func (c *client) SetMulti(ctx context.Context, items ...*Item) error {
+	return concurrently(len(items), func(i int) error {
+		return c.Set(ctx, items[i])
+	})
+}
+
+// concurrently calls fn for 0 to n-1, at most maxConcurrent at a time,
+// returning the first error.
+func concurrently(n int, fn func(i int) error) error {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrent)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) ClearNamespace(ctx context.Context, namespace string) error {
+	if namespace == "" {
+		namespace = c.namespace
+	}
+	if c.near != nil && namespace == c.namespace {
+		defer c.near.invalidateAll()
+	}
+	_, err := c.grpc.ClearNamespace(ctx, &memcached.ClearNamespaceRequest{
+		Namespace: namespace,
+		Pool:      c.pool,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cache clear namespace (%s) failed", namespace)
 	}
 	return nil
 }
 
 func (c *client) Size(ctx context.Context) (uint64, error) {
-	res, err := c.grpc.Size(ctx, &memcached.SizeRequest{})
+	res, err := c.grpc.Size(ctx, &memcached.SizeRequest{
+		Namespace: c.namespace,
+		Pool:      c.pool,
+	})
 	if err != nil {
 		return 1, errors.Wrapf(err, "cache get size failed")
 	}
 	return res.Size, nil
 }
 
+func (c *client) InvalidateTags(ctx context.Context, tags ...string) (uint64, error) {
+	if c.near != nil {
+		defer c.near.invalidateAll()
+	}
+	res, err := c.grpc.InvalidateTags(ctx, &memcached.InvalidateTagsRequest{
+		Tags:      tags,
+		Namespace: c.namespace,
+		Pool:      c.pool,
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "cache invalidate tags (%v) failed", tags)
+	}
+	return res.Count, nil
+}
+
+func (c *client) Generation(ctx context.Context) (uint64, error) {
+	res, err := c.grpc.GetGeneration(ctx, &memcached.GetGenerationRequest{
+		Namespace: c.namespace,
+		Pool:      c.pool,
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "cache get generation failed")
+	}
+	return res.Generation, nil
+}
+
+func (c *client) SetGeneration(ctx context.Context, generation uint64) error {
+	if c.near != nil {
+		defer c.near.invalidateAll()
+	}
+	_, err := c.grpc.SetGeneration(ctx, &memcached.SetGenerationRequest{
+		Namespace:  c.namespace,
+		Pool:       c.pool,
+		Generation: generation,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cache set generation (%d) failed", generation)
+	}
+	return nil
+}
+
+func (c *client) BumpGeneration(ctx context.Context) (uint64, error) {
+	if c.near != nil {
+		defer c.near.invalidateAll()
+	}
+	res, err := c.grpc.BumpGeneration(ctx, &memcached.BumpGenerationRequest{
+		Namespace: c.namespace,
+		Pool:      c.pool,
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "cache bump generation failed")
+	}
+	return res.Generation, nil
+}
+
+func (c *client) DeleteMatching(ctx context.Context, prefix, pattern string) (string, error) {
+	res, err := c.grpc.DeleteMatching(ctx, &memcached.DeleteMatchingRequest{
+		Prefix:    prefix,
+		Pattern:   pattern,
+		Namespace: c.namespace,
+		Pool:      c.pool,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "cache delete matching (%s%s) failed", prefix, pattern)
+	}
+	return res.JobID, nil
+}
+
+func (c *client) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	res, err := c.grpc.GetJob(ctx, &memcached.GetJobRequest{
+		JobID: jobID,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cache get job (%s) failed", jobID)
+	}
+	return fromMemcachedJob(res.Job), nil
+}
+
+func (c *client) CancelJob(ctx context.Context, jobID string) (*Job, error) {
+	res, err := c.grpc.CancelJob(ctx, &memcached.CancelJobRequest{
+		JobID: jobID,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cache cancel job (%s) failed", jobID)
+	}
+	return fromMemcachedJob(res.Job), nil
+}
+
+func (c *client) CreatePool(ctx context.Context, config PoolConfig) (*Pool, error) {
+	res, err := c.grpc.CreatePool(ctx, &memcached.CreatePoolRequest{
+		Config: toMemcachedPoolConfig(config),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cache create pool (%s) failed", config.Name)
+	}
+	return fromMemcachedPool(res.Pool), nil
+}
+
+func (c *client) ListPools(ctx context.Context) ([]*Pool, error) {
+	res, err := c.grpc.ListPools(ctx, &memcached.ListPoolsRequest{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cache list pools failed")
+	}
+
+	pools := make([]*Pool, len(res.Pools))
+	for i, pool := range res.Pools {
+		pools[i] = fromMemcachedPool(pool)
+	}
+	return pools, nil
+}
+
+func (c *client) DescribePool(ctx context.Context, name string) (*Pool, error) {
+	res, err := c.grpc.DescribePool(ctx, &memcached.DescribePoolRequest{
+		Name: name,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cache describe pool (%s) failed", name)
+	}
+	return fromMemcachedPool(res.Pool), nil
+}
+
+func (c *client) DeletePool(ctx context.Context, name string) error {
+	_, err := c.grpc.DeletePool(ctx, &memcached.DeletePoolRequest{
+		Name: name,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cache delete pool (%s) failed", name)
+	}
+	return nil
+}
+
+func (c *client) Reshard(ctx context.Context, name string, cacheCount int) (string, error) {
+	res, err := c.grpc.Reshard(ctx, &memcached.ReshardRequest{
+		Name:       name,
+		CacheCount: int32(cacheCount),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "cache reshard (%s) failed", name)
+	}
+	return res.JobID, nil
+}
+
+func (c *client) Resize(ctx context.Context, name, namespace string, capacity uint64) (*Pool, error) {
+	res, err := c.grpc.Resize(ctx, &memcached.ResizeRequest{
+		Name:      name,
+		Namespace: namespace,
+		Capacity:  capacity,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cache resize (%s/%s) failed", name, namespace)
+	}
+	return fromMemcachedPool(res.Pool), nil
+}
+
+func (c *client) Members(ctx context.Context) ([]*Member, error) {
+	res, err := c.grpc.ListMembers(ctx, &memcached.ListMembersRequest{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cache list members failed")
+	}
+
+	members := make([]*Member, len(res.Members))
+	for i, member := range res.Members {
+		members[i] = &Member{
+			Address:     member.Address,
+			Weight:      int(member.Weight),
+			State:       MemberState(member.State),
+			Incarnation: member.Incarnation,
+		}
+	}
+	return members, nil
+}
+
+func (c *client) RepairReplicas(ctx context.Context, pool string) (string, error) {
+	res, err := c.grpc.RepairReplicas(ctx, &memcached.RepairReplicasRequest{
+		Pool: pool,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "cache repair replicas (%s) failed", pool)
+	}
+	return res.JobID, nil
+}
+
+func (c *client) Close() error {
+	if c.near != nil {
+		c.near.close()
+	}
+
+	var err error
+	if c.router != nil {
+		err = c.router.close()
+	}
+	if cerr := c.conn.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	return err
+}
+
 func toMemcachedItem(item *Item) *memcached.Item {
 	if item == nil {
 		return nil
@@ -127,6 +750,8 @@ func toMemcachedItem(item *Item) *memcached.Item {
 		Key:   item.Key,
 		Value: item.Value,
 		CasID: item.casID,
+		Tags:  item.Tags,
+		Delta: int64(item.Delta),
 	}
 }
 
@@ -134,9 +759,100 @@ func fromMemcachedItem(item *memcached.Item) *Item {
 	if item == nil {
 		return nil
 	}
-	return &Item{
+	i := &Item{
 		Key:   item.Key,
 		Value: item.Value,
 		casID: item.CasID,
+		Tags:  item.Tags,
+		Delta: time.Duration(item.Delta),
+	}
+	if item.ExpiresAt != 0 {
+		i.ExpiresAt = time.Unix(0, item.ExpiresAt)
+	}
+	if item.SoftExpiresAt != 0 {
+		i.SoftExpiresAt = time.Unix(0, item.SoftExpiresAt)
+	}
+	return i
+}
+
+func fromMemcachedJob(job *memcached.Job) *Job {
+	if job == nil {
+		return nil
+	}
+
+	j := &Job{
+		ID:        job.JobID,
+		Kind:      job.Kind,
+		State:     JobState(job.State),
+		Err:       job.Error,
+		Done:      job.Done,
+		Total:     job.Total,
+		Count:     job.Count,
+		StartedAt: time.Unix(0, job.StartedAt),
+	}
+	if job.FinishedAt != 0 {
+		j.FinishedAt = time.Unix(0, job.FinishedAt)
+	}
+	return j
+}
+
+func toMemcachedPoolConfig(config PoolConfig) *memcached.PoolConfig {
+	return &memcached.PoolConfig{
+		Name:       config.Name,
+		Capacity:   config.Capacity,
+		CacheCount: int32(config.CacheCount),
+		Replicas:   int32(config.Replicas),
+		Policy:     memcached.PoolConfig_EvictionPolicy(config.Policy),
+		TtlMillis:  int64(config.TTL / time.Millisecond),
+		Namespaces: config.Namespaces,
+
+		SoftTtlMillis: int64(config.SoftTTL / time.Millisecond),
+
+		HashStrategy: memcached.PoolConfig_HashStrategy(config.HashStrategy),
+		BoundedLoad:  config.BoundedLoad,
+
+		ReplicationFactor: int32(config.ReplicationFactor),
+		ReadQuorum:        int32(config.ReadQuorum),
+		WriteQuorum:       int32(config.WriteQuorum),
+
+		Origin:            config.Origin,
+		LoadTimeoutMillis: int64(config.LoadTimeout / time.Millisecond),
+		NegativeTtlMillis: int64(config.NegativeTTL / time.Millisecond),
+	}
+}
+
+func fromMemcachedPool(pool *memcached.Pool) *Pool {
+	if pool == nil {
+		return nil
+	}
+
+	p := &Pool{
+		Size:            pool.Size,
+		CurrentCapacity: pool.CurrentCapacity,
+	}
+	if config := pool.Config; config != nil {
+		p.Config = PoolConfig{
+			Name:       config.Name,
+			Capacity:   config.Capacity,
+			CacheCount: int(config.CacheCount),
+			Replicas:   int(config.Replicas),
+			Policy:     EvictionPolicy(config.Policy),
+			TTL:        time.Duration(config.TtlMillis) * time.Millisecond,
+			Namespaces: config.Namespaces,
+
+			SoftTTL: time.Duration(config.SoftTtlMillis) * time.Millisecond,
+
+			HashStrategy: HashStrategy(config.HashStrategy),
+			BoundedLoad:  config.BoundedLoad,
+
+			ReplicationFactor: int(config.ReplicationFactor),
+			ReadQuorum:        int(config.ReadQuorum),
+			WriteQuorum:       int(config.WriteQuorum),
+
+			Origin:      config.Origin,
+			LoadTimeout: time.Duration(config.LoadTimeoutMillis) * time.Millisecond,
+			NegativeTTL: time.Duration(config.NegativeTtlMillis) * time.Millisecond,
+		}
 	}
+	return p
 }
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultLeaseBackoff    = 10 * time.Millisecond
+	defaultLeaseMaxBackoff = time.Second
+)
+
+// ErrLeaseInvalid is returned by LeaseSet when the lease is no longer the
+// key's, because the key was written since it was granted or it expired.
+var ErrLeaseInvalid = errors.New("lease is no longer valid")
+
+// ErrLeaseRequired is returned by Set when the key was removed since a lease
+// was last granted on it, so that it may only be set with a new lease.
+var ErrLeaseRequired = errors.New("key was removed; set it with a lease")
+
+// Lease is the result of a LeaseGet.
+type Lease struct {
+	// Item is the item found, if any
+	Item *Item
+
+	// Token is set if the key was missed, or Item is stale, and the client
+	// was granted its lease. The client should fill the key with LeaseSet.
+	Token uint64
+
+	// Wait is set if the key was missed and another client holds its lease.
+	// Stale is the key's value before it was last removed, if recent.
+	Wait  bool
+	Stale *Item
+}
+
+// LeaseOptions configure GetWithLease.
+type LeaseOptions struct {
+	// Backoff is how long to wait for another client to fill the key before
+	// trying again, doubling each time up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// UseStale returns the key's stale value, if the server has one, rather
+	// than waiting for another client to fill it
+	UseStale bool
+}
+
+// GetWithLease gets key. If it is missing, and no other client is filling
+// it, fill is called for its item, which is set with the key's lease;
+// otherwise the other client's fill is waited for. fill may return nil if
+// there is no item, in which case the lease is left to expire.
+//
+// A stale item is returned as is, unless the client is granted the lease to
+// refresh it, in which case it is filled the same way. The stale item is
+// returned if that fill fails.
+func GetWithLease(ctx context.Context, c MemcachedClient, key string, options LeaseOptions, fill func(ctx context.Context) (*Item, error)) (*Item, error) {
+	if options.Backoff <= 0 {
+		options.Backoff = defaultLeaseBackoff
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = defaultLeaseMaxBackoff
+	}
+
+	backoff := options.Backoff
+	for {
+		lease, err := c.LeaseGet(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if lease.Item != nil && lease.Token == 0 {
+			return lease.Item, nil
+		}
+
+		if lease.Token != 0 {
+			item, err := leaseFill(ctx, c, key, lease.Token, fill)
+			if lease.Item != nil && (err != nil || item == nil) {
+				return lease.Item, nil
+			}
+			return item, err
+		}
+
+		if options.UseStale && lease.Stale != nil {
+			return lease.Stale, nil
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if backoff *= 2; backoff > options.MaxBackoff {
+			backoff = options.MaxBackoff
+		}
+	}
+}
+
+// leaseFill calls fill for the item of key, and sets it with the key's
+// lease.
+func leaseFill(ctx context.Context, c MemcachedClient, key string, token uint64, fill func(ctx context.Context) (*Item, error)) (*Item, error) {
+	item, err := fill(ctx)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	item.Key = key
+	// a rejected fill is still the value read, if not the latest
+	if err := c.LeaseSet(ctx, item, token); err != nil && err != ErrLeaseInvalid {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (c *client) LeaseGet(ctx context.Context, key string) (*Lease, error) {
+	req := &memcached.GetRequest{
+		Key:       key,
+		Namespace: c.namespace,
+		Pool:      c.pool,
+		Lease:     true,
+	}
+
+	var res *memcached.GetResponse
+	err := c.do(key, func(g memcached.MemcachedClient) (err error) {
+		res, err = g.Get(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cache lease get (%s) failed", key)
+	}
+
+	item := fromMemcachedItem(res.Item)
+	if item != nil {
+		item.Stale = res.Stale
+	}
+	return &Lease{
+		Item:  item,
+		Token: res.Lease,
+		Wait:  res.Wait,
+		Stale: fromMemcachedItem(res.StaleItem),
+	}, nil
+}
+
+func (c *client) LeaseSet(ctx context.Context, item *Item, token uint64) error {
+	req := &memcached.SetRequest{
+		Item:      toMemcachedItem(item),
+		Namespace: c.namespace,
+		Pool:      c.pool,
+		Lease:     token,
+	}
+
+	if c.near != nil {
+		defer c.near.invalidate(item.Key)
+	}
+
+	err := c.do(item.Key, func(g memcached.MemcachedClient) error {
+		_, err := g.Set(ctx, req)
+		return err
+	})
+	if err != nil {
+		if status.Code(err) == codes.Aborted {
+			return ErrLeaseInvalid
+		}
+		return errors.Wrapf(err, "cache lease set (%s) failed", item.Key)
+	}
+	return nil
+}
+
+func (c *shardedClient) LeaseGet(ctx context.Context, key string) (*Lease, error) {
+	var lease *Lease
+	err := c.do(key, func(sc *client) (err error) {
+		lease, err = sc.LeaseGet(ctx, key)
+		return err
+	})
+	return lease, err
+}
+
+func (c *shardedClient) LeaseSet(ctx context.Context, item *Item, token uint64) error {
+	return c.do(item.Key, func(sc *client) error {
+		return sc.LeaseSet(ctx, item, token)
+	})
+}
@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/tracking"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/metadata"
+)
+
+// trackRetryDelay is how long the near cache waits to track again after its
+// Track stream ends.
+const trackRetryDelay = time.Second
+
+// nearCache keeps the items a client reads in process. The server tracks
+// the keys the client reads, and streams an invalidation when one changes
+// or is evicted; items are only cached while that stream is up, and every
+// item is dropped when it goes down. The server only notices an expiry when
+// the key is next read, so items are also dropped at the expiry the server
+// read them with, and once they become stale.
+type nearCache struct {
+	id    string
+	items *cache.LRUCache
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu    sync.Mutex
+	ready bool
+
+	// seq counts the invalidations, so that an item read while one arrives
+	// is not cached
+	seq uint64
+}
+
+func newNearCache(g memcached.MemcachedClient, capacity uint64) (*nearCache, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &nearCache{
+		id:     hex.EncodeToString(id),
+		items:  cache.NewLRUCache(cache.Config{Capacity: capacity}),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go n.run(ctx, g)
+	return n, nil
+}
+
+// run tracks the client's reads until ctx is done, tracking again whenever
+// the stream ends.
+func (n *nearCache) run(ctx context.Context, g memcached.MemcachedClient) {
+	defer close(n.done)
+
+	for {
+		n.track(ctx, g)
+		n.disconnected()
+
+		select {
+		case <-time.After(trackRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (n *nearCache) track(ctx context.Context, g memcached.MemcachedClient) error {
+	stream, err := g.Track(ctx, &memcached.TrackRequest{Client: n.id})
+	if err != nil {
+		return err
+	}
+
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		n.receive(res)
+	}
+}
+
+func (n *nearCache) receive(res *memcached.TrackResponse) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if res.Ready {
+		n.ready = true
+	}
+	if res.Flush {
+		n.clear()
+		return
+	}
+	for _, invalidation := range res.Invalidations {
+		if invalidation.Key == "" {
+			n.clear()
+			continue
+		}
+		n.seq++
+		n.items.Remove(invalidation.Key)
+	}
+}
+
+func (n *nearCache) disconnected() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.ready = false
+	n.clear()
+}
+
+// clear drops every item, with mu locked.
+func (n *nearCache) clear() {
+	n.seq++
+	n.items.Clear()
+}
+
+// get returns the cached item for key, if any. Stale items are left for
+// the server to return, marked stale.
+func (n *nearCache) get(key string) *Item {
+	item := n.items.Get(key)
+	if item == nil || item.Stale(time.Now()) {
+		return nil
+	}
+	return &Item{
+		Key:           item.Key,
+		Value:         item.Value,
+		Tags:          item.Tags,
+		ExpiresAt:     item.ExpiresAt(),
+		SoftExpiresAt: item.SoftExpiresAt(),
+		casID:         item.VersionID(),
+	}
+}
+
+// read prepares a read of a key that was not cached, returning the context
+// to read it with, which has the server track the key, and the sequence to
+// store the item with. ok is false if the item cannot be cached.
+func (n *nearCache) read(ctx context.Context) (tracked context.Context, seq uint64, ok bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.ready {
+		return ctx, 0, false
+	}
+	return metadata.AppendToOutgoingContext(ctx, tracking.MetadataKey, n.id), n.seq, true
+}
+
+// store caches an item read at seq, unless there has been an invalidation
+// since.
+func (n *nearCache) store(seq uint64, item *Item) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.ready || n.seq != seq {
+		return
+	}
+	cached := cache.NewItem(item.Key, item.Value, item.casID)
+	cached.Tags = item.Tags
+	cached.SetExpiry(item.ExpiresAt, item.SoftExpiresAt)
+	n.items.Load(cached)
+}
+
+// invalidate drops the item for key, after the client changes it.
+func (n *nearCache) invalidate(key string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.seq++
+	n.items.Remove(key)
+}
+
+// invalidateAll drops every item, after the client changes the namespace.
+func (n *nearCache) invalidateAll() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.clear()
+}
+
+func (n *nearCache) close() {
+	n.cancel()
+	<-n.done
+}
@@ -0,0 +1,577 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tescherm/mc/core/consistenthash"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultMaxFailures   = 3
+	defaultRetryInterval = 10 * time.Second
+
+	// jobIDSeparator joins the IDs of the jobs a sharded operation started
+	// on each server into one ID
+	jobIDSeparator = ","
+)
+
+// shard is one of the independent servers keys are sharded across.
+type shard struct {
+	address string
+	client  *client
+
+	// failures counts consecutive failed requests. A server that fails
+	// maxFailures in a row is down until downUntil.
+	failures  int
+	downUntil time.Time
+}
+
+// shardedClient shards keys across independent servers with a consistent
+// hash ring. Servers that keep failing are taken off the ring, so that their
+// keys are rehashed to the others, and put back after a while to try again.
+// Requests that are not for a key are sent to every server.
+type shardedClient struct {
+	sync.Mutex
+
+	shards    []*shard
+	byAddress map[string]*shard
+	ring      *consistenthash.ConsistentHash
+
+	maxFailures   int
+	retryInterval time.Duration
+
+	now func() time.Time
+}
+
+func newShardedClient(config Config) (*shardedClient, error) {
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = defaultMaxFailures
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = defaultRetryInterval
+	}
+
+	c := &shardedClient{
+		byAddress:     make(map[string]*shard),
+		maxFailures:   config.MaxFailures,
+		retryInterval: config.RetryInterval,
+		now:           time.Now,
+	}
+
+	for _, address := range config.Servers {
+		if _, ok := c.byAddress[address]; ok {
+			c.Close()
+			return nil, fmt.Errorf("server %s is listed more than once", address)
+		}
+
+		server := config
+		server.ServiceURI = address
+		server.Servers = nil
+
+		sc, err := newClient(server)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+
+		s := &shard{address: address, client: sc}
+		c.shards = append(c.shards, s)
+		c.byAddress[address] = s
+	}
+	c.rehash()
+	return c, nil
+}
+
+// rehash rebuilds the ring from the servers that are up, or from every
+// server if none are.
+func (c *shardedClient) rehash() {
+	var addresses []string
+	for _, s := range c.shards {
+		if s.downUntil.IsZero() {
+			addresses = append(addresses, s.address)
+		}
+	}
+	if len(addresses) == 0 {
+		for _, s := range c.shards {
+			addresses = append(addresses, s.address)
+		}
+	}
+	c.ring = consistenthash.New(addresses, 0)
+}
+
+// pick returns the server for key, first putting back on the ring the
+// servers that have been down for the retry interval.
+func (c *shardedClient) pick(key string) *shard {
+	c.Lock()
+	defer c.Unlock()
+
+	now := c.now()
+	retried := false
+	for _, s := range c.shards {
+		if !s.downUntil.IsZero() && !now.Before(s.downUntil) {
+			// one more failure takes it down again
+			s.downUntil = time.Time{}
+			s.failures = c.maxFailures - 1
+			retried = true
+		}
+	}
+	if retried {
+		c.rehash()
+	}
+
+	return c.byAddress[c.ring.GetNode(key)]
+}
+
+// report records the outcome of a request to a server, taking it off the
+// ring if it has failed too often.
+func (c *shardedClient) report(s *shard, err error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if !failed(err) {
+		s.failures = 0
+		return
+	}
+
+	s.failures++
+	if s.failures >= c.maxFailures && s.downUntil.IsZero() {
+		s.downUntil = c.now().Add(c.retryInterval)
+		c.rehash()
+	}
+}
+
+// failed reports whether err means the server could not serve a request,
+// rather than that the request was refused.
+func failed(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(errors.Cause(err)) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// down returns the addresses of the servers that are off the ring.
+func (c *shardedClient) down() []string {
+	c.Lock()
+	defer c.Unlock()
+
+	var down []string
+	for _, s := range c.shards {
+		if !s.downUntil.IsZero() {
+			down = append(down, s.address)
+		}
+	}
+	return down
+}
+
+// do calls fn with the server for key.
+func (c *shardedClient) do(key string, fn func(c *client) error) error {
+	s := c.pick(key)
+	err := fn(s.client)
+	c.report(s, err)
+	return err
+}
+
+// each calls fn for every server concurrently, returning the first error.
+func (c *shardedClient) each(fn func(i int, c *client) error) error {
+	return concurrently(len(c.shards), func(i int) error {
+		s := c.shards[i]
+		err := fn(i, s.client)
+		c.report(s, err)
+		return err
+	})
+}
+
+// group splits n keys by the server each is for.
+func (c *shardedClient) group(n int, key func(i int) string) map[*shard][]int {
+	groups := make(map[*shard][]int)
+	for i := 0; i < n; i++ {
+		s := c.pick(key(i))
+		groups[s] = append(groups[s], i)
+	}
+	return groups
+}
+
+func (c *shardedClient) Get(ctx context.Context, key string) (*Item, error) {
+	var item *Item
+	err := c.do(key, func(sc *client) (err error) {
+		item, err = sc.Get(ctx, key)
+		return err
+	})
+	return item, err
+}
+
+func (c *shardedClient) Set(ctx context.Context, item *Item) error {
+	return c.do(item.Key, func(sc *client) error {
+		return sc.Set(ctx, item)
+	})
+}
+
+func (c *shardedClient) CompareAndSwap(ctx context.Context, item *Item) error {
+	return c.do(item.Key, func(sc *client) error {
+		return sc.CompareAndSwap(ctx, item)
+	})
+}
+
+func (c *shardedClient) Remove(ctx context.Context, key string) (*Item, error) {
+	var item *Item
+	err := c.do(key, func(sc *client) (err error) {
+		item, err = sc.Remove(ctx, key)
+		return err
+	})
+	return item, err
+}
+
+func (c *shardedClient) GetMulti(ctx context.Context, keys ...string) (map[string]*Item, error) {
+	groups := c.group(len(keys), func(i int) string { return keys[i] })
+
+	var mu sync.Mutex
+	found := make(map[string]*Item, len(keys))
+	err := c.eachGroup(groups, func(sc *client, indexes []int) error {
+		group := make([]string, len(indexes))
+		for i, index := range indexes {
+			group[i] = keys[index]
+		}
+
+		items, err := sc.GetMulti(ctx, group...)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for key, item := range items {
+			found[key] = item
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func (c *shardedClient) SetMulti(ctx context.Context, items ...*Item) error {
+	groups := c.group(len(items), func(i int) string { return items[i].Key })
+
+	return c.eachGroup(groups, func(sc *client, indexes []int) error {
+		group := make([]*Item, len(indexes))
+		for i, index := range indexes {
+			group[i] = items[index]
+		}
+		return sc.SetMulti(ctx, group...)
+	})
+}
+
+// eachGroup calls fn for every server's group of keys concurrently,
+// returning the first error.
+func (c *shardedClient) eachGroup(groups map[*shard][]int, fn func(c *client, indexes []int) error) error {
+	shards := make([]*shard, 0, len(groups))
+	for s := range groups {
+		shards = append(shards, s)
+	}
+
+	return concurrently(len(shards), func(i int) error {
+		s := shards[i]
+		err := fn(s.client, groups[s])
+		c.report(s, err)
+		return err
+	})
+}
+
+func (c *shardedClient) ClearNamespace(ctx context.Context, namespace string) error {
+	return c.each(func(i int, sc *client) error {
+		return sc.ClearNamespace(ctx, namespace)
+	})
+}
+
+func (c *shardedClient) Size(ctx context.Context) (uint64, error) {
+	sizes := make([]uint64, len(c.shards))
+	err := c.each(func(i int, sc *client) (err error) {
+		sizes[i], err = sc.Size(ctx)
+		return err
+	})
+	return sum(sizes), err
+}
+
+func (c *shardedClient) InvalidateTags(ctx context.Context, tags ...string) (uint64, error) {
+	counts := make([]uint64, len(c.shards))
+	err := c.each(func(i int, sc *client) (err error) {
+		counts[i], err = sc.InvalidateTags(ctx, tags...)
+		return err
+	})
+	return sum(counts), err
+}
+
+// Generation returns the highest of the servers' generations.
+func (c *shardedClient) Generation(ctx context.Context) (uint64, error) {
+	generations := make([]uint64, len(c.shards))
+	err := c.each(func(i int, sc *client) (err error) {
+		generations[i], err = sc.Generation(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var max uint64
+	for _, generation := range generations {
+		if generation > max {
+			max = generation
+		}
+	}
+	return max, nil
+}
+
+func (c *shardedClient) SetGeneration(ctx context.Context, generation uint64) error {
+	return c.each(func(i int, sc *client) error {
+		return sc.SetGeneration(ctx, generation)
+	})
+}
+
+// BumpGeneration sets every server's generation to one past the highest,
+// keeping them the same. Unlike a single server's, concurrent bumps may
+// return the same generation.
+func (c *shardedClient) BumpGeneration(ctx context.Context) (uint64, error) {
+	generation, err := c.Generation(ctx)
+	if err != nil {
+		return 0, err
+	}
+	generation++
+	if err := c.SetGeneration(ctx, generation); err != nil {
+		return 0, err
+	}
+	return generation, nil
+}
+
+// jobs starts a job on every server, returning an ID naming them all.
+func (c *shardedClient) jobs(start func(c *client) (string, error)) (string, error) {
+	ids := make([]string, len(c.shards))
+	err := c.each(func(i int, sc *client) (err error) {
+		ids[i], err = start(sc)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(ids, jobIDSeparator), nil
+}
+
+// job calls fn for every server's part of a job started by jobs, merging
+// their statuses.
+func (c *shardedClient) job(jobID string, fn func(c *client, jobID string) (*Job, error)) (*Job, error) {
+	ids := strings.Split(jobID, jobIDSeparator)
+	if len(ids) != len(c.shards) {
+		return nil, fmt.Errorf("job %s was not started by this client", jobID)
+	}
+
+	jobs := make([]*Job, len(c.shards))
+	err := c.each(func(i int, sc *client) (err error) {
+		jobs[i], err = fn(sc, ids[i])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeJobs(jobID, jobs), nil
+}
+
+func (c *shardedClient) DeleteMatching(ctx context.Context, prefix, pattern string) (string, error) {
+	return c.jobs(func(sc *client) (string, error) {
+		return sc.DeleteMatching(ctx, prefix, pattern)
+	})
+}
+
+func (c *shardedClient) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	return c.job(jobID, func(sc *client, jobID string) (*Job, error) {
+		return sc.GetJob(ctx, jobID)
+	})
+}
+
+func (c *shardedClient) CancelJob(ctx context.Context, jobID string) (*Job, error) {
+	return c.job(jobID, func(sc *client, jobID string) (*Job, error) {
+		return sc.CancelJob(ctx, jobID)
+	})
+}
+
+// pool calls fn for every server, merging the pools they return.
+func (c *shardedClient) pool(fn func(c *client) (*Pool, error)) (*Pool, error) {
+	pools := make([]*Pool, len(c.shards))
+	err := c.each(func(i int, sc *client) (err error) {
+		pools[i], err = fn(sc)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergePools(pools), nil
+}
+
+func (c *shardedClient) CreatePool(ctx context.Context, config PoolConfig) (*Pool, error) {
+	return c.pool(func(sc *client) (*Pool, error) {
+		return sc.CreatePool(ctx, config)
+	})
+}
+
+func (c *shardedClient) ListPools(ctx context.Context) ([]*Pool, error) {
+	lists := make([][]*Pool, len(c.shards))
+	err := c.each(func(i int, sc *client) (err error) {
+		lists[i], err = sc.ListPools(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string][]*Pool)
+	for _, list := range lists {
+		for _, pool := range list {
+			byName[pool.Config.Name] = append(byName[pool.Config.Name], pool)
+		}
+	}
+
+	merged := make([]*Pool, 0, len(byName))
+	for _, pools := range byName {
+		merged = append(merged, mergePools(pools))
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Config.Name < merged[j].Config.Name
+	})
+	return merged, nil
+}
+
+func (c *shardedClient) DescribePool(ctx context.Context, name string) (*Pool, error) {
+	return c.pool(func(sc *client) (*Pool, error) {
+		return sc.DescribePool(ctx, name)
+	})
+}
+
+func (c *shardedClient) DeletePool(ctx context.Context, name string) error {
+	return c.each(func(i int, sc *client) error {
+		return sc.DeletePool(ctx, name)
+	})
+}
+
+func (c *shardedClient) Reshard(ctx context.Context, name string, cacheCount int) (string, error) {
+	return c.jobs(func(sc *client) (string, error) {
+		return sc.Reshard(ctx, name, cacheCount)
+	})
+}
+
+// Resize sets the capacity of the namespace on every server, so the
+// capacity across them is the servers' count times capacity.
+func (c *shardedClient) Resize(ctx context.Context, name, namespace string, capacity uint64) (*Pool, error) {
+	return c.pool(func(sc *client) (*Pool, error) {
+		return sc.Resize(ctx, name, namespace, capacity)
+	})
+}
+
+// Members lists the members of every server's cluster.
+func (c *shardedClient) Members(ctx context.Context) ([]*Member, error) {
+	lists := make([][]*Member, len(c.shards))
+	err := c.each(func(i int, sc *client) (err error) {
+		lists[i], err = sc.Members(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var members []*Member
+	for _, list := range lists {
+		members = append(members, list...)
+	}
+	return members, nil
+}
+
+func (c *shardedClient) RepairReplicas(ctx context.Context, pool string) (string, error) {
+	return c.jobs(func(sc *client) (string, error) {
+		return sc.RepairReplicas(ctx, pool)
+	})
+}
+
+func (c *shardedClient) Close() error {
+	var err error
+	for _, s := range c.shards {
+		if cerr := s.client.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func sum(values []uint64) uint64 {
+	var total uint64
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+// mergeJobs merges the statuses of the parts of a job. The job is running
+// until every part is finished, and failed or cancelled if any part was.
+func mergeJobs(jobID string, jobs []*Job) *Job {
+	merged := &Job{
+		ID:    jobID,
+		Kind:  jobs[0].Kind,
+		State: JobDone,
+	}
+
+	finished := true
+	for _, job := range jobs {
+		merged.Done += job.Done
+		merged.Total += job.Total
+		merged.Count += job.Count
+
+		if job.State > merged.State {
+			merged.State = job.State
+		}
+		if merged.Err == "" {
+			merged.Err = job.Err
+		}
+
+		if merged.StartedAt.IsZero() || job.StartedAt.Before(merged.StartedAt) {
+			merged.StartedAt = job.StartedAt
+		}
+		if job.FinishedAt.IsZero() {
+			finished = false
+		} else if job.FinishedAt.After(merged.FinishedAt) {
+			merged.FinishedAt = job.FinishedAt
+		}
+	}
+
+	for _, job := range jobs {
+		if job.State == JobRunning {
+			merged.State = JobRunning
+		}
+	}
+	if !finished {
+		merged.FinishedAt = time.Time{}
+	}
+	return merged
+}
+
+// mergePools merges a pool's descriptions from every server, totalling
+// their sizes.
+func mergePools(pools []*Pool) *Pool {
+	merged := &Pool{
+		Config: pools[0].Config,
+	}
+	for _, pool := range pools {
+		merged.Size += pool.Size
+		merged.CurrentCapacity += pool.CurrentCapacity
+	}
+	return merged
+}
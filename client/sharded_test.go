@@ -0,0 +1,131 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testShardedClient returns a sharded client for servers, without
+// connecting to them.
+func testShardedClient(servers ...string) *shardedClient {
+	c := &shardedClient{
+		byAddress:     make(map[string]*shard),
+		maxFailures:   3,
+		retryInterval: time.Minute,
+		now:           time.Now,
+	}
+	for _, address := range servers {
+		s := &shard{address: address}
+		c.shards = append(c.shards, s)
+		c.byAddress[address] = s
+	}
+	c.rehash()
+	return c
+}
+
+func TestShardedClientPick(t *testing.T) {
+	t.Parallel()
+
+	c := testShardedClient("a:8080", "b:8080", "c:8080")
+
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		s := c.pick(key)
+		counts[s.address]++
+
+		// keys stay on their server
+		require.Equal(t, s, c.pick(key))
+	}
+	for _, address := range []string{"a:8080", "b:8080", "c:8080"} {
+		require.InEpsilon(t, 1000, counts[address], 0.25, address)
+	}
+}
+
+func TestShardedClientFailures(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	c := testShardedClient("a:8080", "b:8080")
+	c.now = func() time.Time { return now }
+
+	// a key on a
+	var key string
+	for i := 0; key == ""; i++ {
+		if k := fmt.Sprintf("key-%d", i); c.pick(k).address == "a:8080" {
+			key = k
+		}
+	}
+	a := c.byAddress["a:8080"]
+
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	// refused requests and successes are not failures
+	c.report(a, unavailable)
+	c.report(a, unavailable)
+	c.report(a, status.Error(codes.NotFound, "not found"))
+	c.report(a, unavailable)
+	c.report(a, unavailable)
+	require.Empty(t, c.down())
+
+	// repeated failures rehash a's keys to b
+	c.report(a, unavailable)
+	require.Equal(t, []string{"a:8080"}, c.down())
+	require.Equal(t, "b:8080", c.pick(key).address)
+
+	// a is tried again after the retry interval
+	now = now.Add(time.Minute)
+	require.Equal(t, "a:8080", c.pick(key).address)
+	require.Empty(t, c.down())
+
+	// and taken down again by one more failure
+	c.report(a, unavailable)
+	require.Equal(t, []string{"a:8080"}, c.down())
+}
+
+func TestShardedClientAllDown(t *testing.T) {
+	t.Parallel()
+
+	c := testShardedClient("a:8080", "b:8080")
+	for _, s := range c.shards {
+		for i := 0; i < 3; i++ {
+			c.report(s, status.Error(codes.DeadlineExceeded, "deadline exceeded"))
+		}
+	}
+	require.Len(t, c.down(), 2)
+
+	// with every server down, keys go to their usual server
+	require.NotNil(t, c.pick("key"))
+}
+
+func TestMergeJobs(t *testing.T) {
+	t.Parallel()
+
+	started := time.Now()
+	finished := started.Add(time.Second)
+
+	job := mergeJobs("1,2", []*Job{
+		{Kind: "delete-matching", State: JobDone, Done: 2, Total: 2, Count: 2, StartedAt: started, FinishedAt: finished},
+		{Kind: "delete-matching", State: JobRunning, Done: 1, Total: 3, Count: 1, StartedAt: started.Add(time.Millisecond)},
+	})
+	require.Equal(t, "1,2", job.ID)
+	require.Equal(t, JobRunning, job.State)
+	require.Equal(t, uint64(3), job.Done)
+	require.Equal(t, uint64(5), job.Total)
+	require.Equal(t, uint64(3), job.Count)
+	require.Equal(t, started, job.StartedAt)
+	require.True(t, job.FinishedAt.IsZero())
+
+	job = mergeJobs("1,2", []*Job{
+		{State: JobDone, StartedAt: started, FinishedAt: finished},
+		{State: JobFailed, Err: "failed", StartedAt: started, FinishedAt: finished.Add(time.Second)},
+	})
+	require.Equal(t, JobFailed, job.State)
+	require.Equal(t, "failed", job.Err)
+	require.Equal(t, finished.Add(time.Second), job.FinishedAt)
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tescherm/mc/core/consistenthash"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// watchRetryDelay is how long the router waits before watching the topology
+// again after the watch fails.
+const watchRetryDelay = time.Second
+
+// router sends key requests to the cluster node owning the key, computing
+// ownership from the topology the seed node reports, as the nodes do. Until
+// the topology is known, or if the seed is not clustered, requests go to the
+// seed, which forwards them.
+type router struct {
+	sync.RWMutex
+
+	seed memcached.MemcachedClient
+	opts []grpc.DialOption
+
+	epoch   uint64
+	ring    *consistenthash.ConsistentHash
+	conns   map[string]*grpc.ClientConn
+	clients map[string]memcached.MemcachedClient
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newRouter(seed memcached.MemcachedClient, opts []grpc.DialOption) *router {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &router{
+		seed:    seed,
+		opts:    opts,
+		conns:   make(map[string]*grpc.ClientConn),
+		clients: make(map[string]memcached.MemcachedClient),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go r.watch(ctx)
+	return r
+}
+
+// route returns a client for the node owning key.
+func (r *router) route(key string) memcached.MemcachedClient {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.ring == nil {
+		return r.seed
+	}
+	if client, ok := r.clients[r.ring.GetNode(key)]; ok {
+		return client
+	}
+	return r.seed
+}
+
+// watch keeps the topology current until ctx is cancelled.
+func (r *router) watch(ctx context.Context) {
+	defer close(r.done)
+
+	for {
+		err := r.watchOnce(ctx)
+		if status.Code(err) == codes.FailedPrecondition || ctx.Err() != nil {
+			// the seed is not clustered
+			return
+		}
+
+		select {
+		case <-time.After(watchRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *router) watchOnce(ctx context.Context) error {
+	r.RLock()
+	epoch := r.epoch
+	r.RUnlock()
+
+	stream, err := r.seed.WatchTopology(ctx, &memcached.WatchTopologyRequest{
+		Epoch: epoch,
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		r.update(res.Topology)
+	}
+}
+
+// update replaces the ring with topology's, connecting to new nodes and
+// disconnecting from those that left.
+func (r *router) update(topology *memcached.Topology) {
+	if topology == nil {
+		return
+	}
+	if strategy, err := consistenthash.ParseStrategy(topology.Strategy); err != nil || strategy != consistenthash.StrategyRing {
+		// an unknown placement; leave routing to the seed
+		return
+	}
+
+	ring := consistenthash.New(nil, int(topology.Replicas))
+	members := make(map[string]bool, len(topology.Peers))
+	for _, peer := range topology.Peers {
+		ring.AddWeighted(peer.Address, int(peer.Weight))
+		members[peer.Address] = true
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for address := range members {
+		if _, ok := r.conns[address]; ok {
+			continue
+		}
+		conn, err := grpc.Dial(address, r.opts...)
+		if err != nil {
+			// requests for the node's keys go to the seed
+			continue
+		}
+		r.conns[address] = conn
+		r.clients[address] = memcached.NewMemcachedClient(conn)
+	}
+	for address, conn := range r.conns {
+		if !members[address] {
+			conn.Close()
+			delete(r.conns, address)
+			delete(r.clients, address)
+		}
+	}
+
+	r.epoch = topology.Epoch
+	r.ring = ring
+}
+
+// close stops watching the topology and disconnects from the nodes.
+func (r *router) close() error {
+	r.cancel()
+	<-r.done
+
+	r.Lock()
+	defer r.Unlock()
+
+	var err error
+	for address, conn := range r.conns {
+		if cerr := conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		delete(r.conns, address)
+		delete(r.clients, address)
+	}
+	r.ring = nil
+	return err
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tescherm/mc/pb"
+)
+
+// watchBuffer is the number of events a watch holds for its reader.
+const watchBuffer = 100
+
+type EventType int
+
+const (
+	EventSet EventType = iota
+	EventRemove
+	EventEvict
+	EventExpire
+	// EventClear reports that every key in the namespace was removed, by a
+	// clear or a generation change; it has no key
+	EventClear
+	// EventOverflow reports that the watch fell behind and missed events;
+	// the watched keys should be read again
+	EventOverflow
+)
+
+// Event is a change to a watched key.
+type Event struct {
+	Type EventType
+	Key  string
+
+	// CasID is the item's version after a set, and the version removed
+	// otherwise
+	CasID int64
+
+	// Missed is the number of events an overflow dropped
+	Missed uint64
+
+	// Node is the cluster node the change happened on, if clustered. The
+	// replicas of a key in a replicated pool each report its changes.
+	Node string
+}
+
+// Watch receives the changes to watched keys.
+type Watch struct {
+	events chan *Event
+	cancel context.CancelFunc
+
+	mu  sync.Mutex
+	err error
+}
+
+// newWatch watches with req on every one of clients, merging their events.
+func newWatch(ctx context.Context, clients []memcached.MemcachedClient, req *memcached.WatchRequest) (*Watch, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	streams := make([]memcached.Memcached_WatchClient, len(clients))
+	for i, g := range clients {
+		stream, err := g.Watch(ctx, req)
+		if err != nil {
+			cancel()
+			return nil, errors.Wrap(err, "cache watch failed")
+		}
+		streams[i] = stream
+	}
+
+	w := &Watch{
+		events: make(chan *Event, watchBuffer),
+		cancel: cancel,
+	}
+
+	var wg sync.WaitGroup
+	for _, stream := range streams {
+		wg.Add(1)
+		go func(stream memcached.Memcached_WatchClient) {
+			defer wg.Done()
+			if err := w.receive(ctx, stream); err != nil {
+				w.fail(err)
+			}
+		}(stream)
+	}
+	go func() {
+		wg.Wait()
+		close(w.events)
+	}()
+
+	return w, nil
+}
+
+func (w *Watch) receive(ctx context.Context, stream memcached.Memcached_WatchClient) error {
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "cache watch failed")
+		}
+
+		for _, event := range res.Events {
+			select {
+			case w.events <- fromMemcachedEvent(event):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// fail ends the watch with err, unless it already ended.
+func (w *Watch) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err == nil {
+		w.err = err
+	}
+	w.cancel()
+}
+
+// Events returns the channel events are received on. It is closed when the
+// watch ends.
+func (w *Watch) Events() <-chan *Event {
+	return w.events
+}
+
+// Err returns why the watch ended, or nil if it was closed or its context
+// was cancelled.
+func (w *Watch) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
+}
+
+// Close ends the watch.
+func (w *Watch) Close() {
+	w.cancel()
+}
+
+func (c *client) Watch(ctx context.Context, keys, prefixes []string) (*Watch, error) {
+	return newWatch(ctx, []memcached.MemcachedClient{c.grpc}, c.watchRequest(keys, prefixes))
+}
+
+func (c *client) watchRequest(keys, prefixes []string) *memcached.WatchRequest {
+	return &memcached.WatchRequest{
+		Keys:      keys,
+		Prefixes:  prefixes,
+		Namespace: c.namespace,
+		Pool:      c.pool,
+	}
+}
+
+func (c *shardedClient) Watch(ctx context.Context, keys, prefixes []string) (*Watch, error) {
+	clients := make([]memcached.MemcachedClient, len(c.shards))
+	for i, s := range c.shards {
+		clients[i] = s.client.grpc
+	}
+	return newWatch(ctx, clients, c.shards[0].client.watchRequest(keys, prefixes))
+}
+
+func fromMemcachedEvent(event *memcached.WatchEvent) *Event {
+	e := &Event{
+		Key:    event.Key,
+		CasID:  event.CasID,
+		Missed: event.Missed,
+		Node:   event.Node,
+	}
+	switch event.Type {
+	case memcached.WatchEvent_SET:
+		e.Type = EventSet
+	case memcached.WatchEvent_REMOVE:
+		e.Type = EventRemove
+	case memcached.WatchEvent_EVICT:
+		e.Type = EventEvict
+	case memcached.WatchEvent_EXPIRE:
+		e.Type = EventExpire
+	case memcached.WatchEvent_CLEAR:
+		e.Type = EventClear
+	case memcached.WatchEvent_OVERFLOW:
+		e.Type = EventOverflow
+	}
+	return e
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tescherm/mc/pb"
+)
+
+// DefaultBeta is the XFetch beta GetOrCompute uses unless given one.
+const DefaultBeta = 1.0
+
+// GetOrCompute gets key, calling compute for its item if it is missing or
+// XFetch says to recompute it before it expires, and setting the item with
+// how long compute took as its Delta. Items recomputed early are refreshed
+// by one caller at a time with high probability, rather than by every
+// caller once they expire. A stale item is recomputed by the caller granted
+// its refresh. If an early recompute fails, the item read is returned.
+// compute may return nil if there is no item.
+func GetOrCompute(ctx context.Context, c MemcachedClient, key string, beta float64, compute func(ctx context.Context) (*Item, error)) (*Item, error) {
+	if beta <= 0 {
+		beta = DefaultBeta
+	}
+
+	current, recompute, err := c.XFetch(ctx, key, beta)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && !recompute && current.Refresh == 0 {
+		return current, nil
+	}
+
+	start := time.Now()
+	item, err := compute(ctx)
+	if err != nil || item == nil {
+		if current != nil {
+			return current, nil
+		}
+		return nil, err
+	}
+	item.Key = key
+	item.Delta = time.Since(start)
+
+	if current != nil && current.Refresh != 0 {
+		// a rejected refresh is still the value computed, if not the latest
+		if err := c.LeaseSet(ctx, item, current.Refresh); err != nil && err != ErrLeaseInvalid {
+			return nil, err
+		}
+		return item, nil
+	}
+	if err := c.Set(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (c *client) XFetch(ctx context.Context, key string, beta float64) (*Item, bool, error) {
+	req := &memcached.GetRequest{
+		Key:        key,
+		Namespace:  c.namespace,
+		Pool:       c.pool,
+		ReadQuorum: c.readQuorum,
+		Beta:       beta,
+	}
+
+	var res *memcached.GetResponse
+	err := c.do(key, func(g memcached.MemcachedClient) (err error) {
+		res, err = g.Get(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "cache xfetch (%s) failed", key)
+	}
+
+	item := fromMemcachedItem(res.Item)
+	if item != nil {
+		item.Stale = res.Stale
+		item.Refresh = res.Lease
+	}
+	return item, res.Recompute, nil
+}
+
+func (c *shardedClient) XFetch(ctx context.Context, key string, beta float64) (*Item, bool, error) {
+	var item *Item
+	var recompute bool
+	err := c.do(key, func(sc *client) (err error) {
+		item, recompute, err = sc.XFetch(ctx, key, beta)
+		return err
+	})
+	return item, recompute, err
+}
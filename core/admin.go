@@ -0,0 +1,312 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/caches"
+	"github.com/tescherm/mc/core/consistenthash"
+	"github.com/tescherm/mc/core/jobs"
+	"github.com/tescherm/mc/core/membership"
+	"github.com/tescherm/mc/core/pools"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *MemcachedService) CreatePool(ctx context.Context, req *memcached.CreatePoolRequest) (*memcached.CreatePoolResponse, error) {
+	if req.Config == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "pool config is required")
+	}
+
+	s.Logger.WithFields(logrus.Fields{
+		"pool":          req.Config.Name,
+		"capacity":      req.Config.Capacity,
+		"cacheCount":    req.Config.CacheCount,
+		"replicas":      req.Config.Replicas,
+		"policy":        req.Config.Policy,
+		"ttlMillis":     req.Config.TtlMillis,
+		"softTtlMillis": req.Config.SoftTtlMillis,
+		"origin":        req.Config.Origin,
+	}).Info("CreatePool")
+
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+
+	var pool *pools.Pool
+	var err error
+	s.Feed.ApplyAll(func() *memcached.Mutation {
+		pool, err = s.Pools.Create(toPoolConfig(req.Config))
+		if err != nil {
+			return nil
+		}
+		return &memcached.Mutation{
+			Op:         memcached.Mutation_CREATE_POOL,
+			Pool:       pool.Config.Name,
+			PoolConfig: fromPoolConfig(pool.Config),
+		}
+	})
+	switch {
+	case err == pools.ErrPoolExists:
+		return nil, status.Errorf(codes.AlreadyExists, "pool %s already exists", req.Config.Name)
+	case err != nil:
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+
+	res := &memcached.CreatePoolResponse{
+		Pool: fromPool(pool),
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) ListPools(ctx context.Context, req *memcached.ListPoolsRequest) (*memcached.ListPoolsResponse, error) {
+	s.Logger.Info("ListPools")
+
+	res := &memcached.ListPoolsResponse{}
+	for _, pool := range s.Pools.List() {
+		res.Pools = append(res.Pools, fromPool(pool))
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) DescribePool(ctx context.Context, req *memcached.DescribePoolRequest) (*memcached.DescribePoolResponse, error) {
+	s.Logger.WithField("pool", req.Name).Info("DescribePool")
+
+	pool, ok := s.Pools.Get(req.Name)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "pool %s not found", req.Name)
+	}
+
+	res := &memcached.DescribePoolResponse{
+		Pool: fromPool(pool),
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) DeletePool(ctx context.Context, req *memcached.DeletePoolRequest) (*memcached.DeletePoolResponse, error) {
+	s.Logger.WithField("pool", req.Name).Info("DeletePool")
+
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	var err error
+	s.Feed.ApplyAll(func() *memcached.Mutation {
+		if err = s.Pools.Delete(req.Name); err != nil {
+			return nil
+		}
+		return &memcached.Mutation{
+			Op:   memcached.Mutation_DELETE_POOL,
+			Pool: req.Name,
+		}
+	})
+	switch {
+	case err == pools.ErrPoolNotFound:
+		return nil, status.Errorf(codes.NotFound, "pool %s not found", req.Name)
+	case err == pools.ErrDefaultPool:
+		return nil, status.Errorf(codes.FailedPrecondition, "%s", err)
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "%s", err)
+	}
+	s.Trees.Drop(req.Name)
+	return &memcached.DeletePoolResponse{}, nil
+}
+
+func (s *MemcachedService) Reshard(ctx context.Context, req *memcached.ReshardRequest) (*memcached.ReshardResponse, error) {
+	s.Logger.WithFields(logrus.Fields{
+		"pool":       req.Name,
+		"cacheCount": req.CacheCount,
+	}).Info("Reshard")
+
+	migration, err := s.Pools.Reshard(req.Name, int(req.CacheCount))
+	switch {
+	case err == pools.ErrPoolNotFound:
+		return nil, status.Errorf(codes.NotFound, "pool %s not found", req.Name)
+	case err == caches.ErrMigrating:
+		return nil, status.Errorf(codes.FailedPrecondition, "%s", err)
+	case err != nil:
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+
+	// the migration cannot be cancelled part way, so the job ignores ctx
+	job := s.Jobs.Start("reshard", func(ctx context.Context, job *jobs.Job) error {
+		migration.Run(job.Progress)
+		return nil
+	})
+
+	res := &memcached.ReshardResponse{
+		JobID: job.Status().ID,
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) Resize(ctx context.Context, req *memcached.ResizeRequest) (*memcached.ResizeResponse, error) {
+	name := req.Name
+	if name == "" {
+		name = pools.DefaultPool
+	}
+	namespace := req.Namespace
+	if namespace == "" {
+		namespace = caches.DefaultNamespace
+	}
+
+	s.Logger.WithFields(logrus.Fields{
+		"pool":      name,
+		"namespace": namespace,
+		"capacity":  req.Capacity,
+	}).Info("Resize")
+
+	pool, err := s.Pools.Resize(name, namespace, req.Capacity)
+	switch {
+	case err == pools.ErrPoolNotFound:
+		return nil, status.Errorf(codes.NotFound, "pool %s not found", name)
+	case err == pools.ErrNamespaceNotFound:
+		return nil, status.Errorf(codes.NotFound, "namespace %s not found", namespace)
+	case err == caches.ErrMigrating:
+		return nil, status.Errorf(codes.FailedPrecondition, "%s", err)
+	case err != nil:
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+
+	res := &memcached.ResizeResponse{
+		Pool: fromPool(pool),
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) ListMembers(ctx context.Context, req *memcached.ListMembersRequest) (*memcached.ListMembersResponse, error) {
+	s.Logger.Info("ListMembers")
+
+	res := &memcached.ListMembersResponse{}
+	switch {
+	case s.Membership != nil:
+		for _, member := range s.Membership.Members() {
+			res.Members = append(res.Members, fromMember(member))
+		}
+	case s.Cluster != nil:
+		// static peers are assumed alive
+		for _, peer := range s.Cluster.Peers() {
+			res.Members = append(res.Members, &memcached.Member{
+				Address: peer.Address,
+				Weight:  int32(peer.Weight),
+				State:   memcached.Member_ALIVE,
+			})
+		}
+	}
+	return res, nil
+}
+
+func fromMember(member membership.Member) *memcached.Member {
+	m := &memcached.Member{
+		Address:     member.Address,
+		Weight:      int32(member.Weight),
+		Incarnation: member.Incarnation,
+	}
+
+	switch member.State {
+	case membership.Suspect:
+		m.State = memcached.Member_SUSPECT
+	case membership.Dead:
+		m.State = memcached.Member_DEAD
+	case membership.Left:
+		m.State = memcached.Member_LEFT
+	default:
+		m.State = memcached.Member_ALIVE
+	}
+	return m
+}
+
+func toPoolConfig(config *memcached.PoolConfig) pools.PoolConfig {
+	c := pools.PoolConfig{
+		Name:       config.Name,
+		Capacity:   config.Capacity,
+		CacheCount: int(config.CacheCount),
+		Replicas:   int(config.Replicas),
+		TTL:        time.Duration(config.TtlMillis) * time.Millisecond,
+		SoftTTL:    time.Duration(config.SoftTtlMillis) * time.Millisecond,
+		Namespaces: config.Namespaces,
+
+		BoundedLoad: config.BoundedLoad,
+
+		ReplicationFactor: int(config.ReplicationFactor),
+		ReadQuorum:        int(config.ReadQuorum),
+		WriteQuorum:       int(config.WriteQuorum),
+
+		Origin:      config.Origin,
+		LoadTimeout: time.Duration(config.LoadTimeoutMillis) * time.Millisecond,
+		NegativeTTL: time.Duration(config.NegativeTtlMillis) * time.Millisecond,
+	}
+
+	switch config.Policy {
+	case memcached.PoolConfig_FIFO:
+		c.Policy = cache.PolicyFIFO
+	default:
+		c.Policy = cache.PolicyLRU
+	}
+
+	switch config.HashStrategy {
+	case memcached.PoolConfig_JUMP:
+		c.HashStrategy = consistenthash.StrategyJump
+	case memcached.PoolConfig_RENDEZVOUS:
+		c.HashStrategy = consistenthash.StrategyRendezvous
+	case memcached.PoolConfig_MAGLEV:
+		c.HashStrategy = consistenthash.StrategyMaglev
+	default:
+		c.HashStrategy = consistenthash.StrategyRing
+	}
+	return c
+}
+
+func fromPoolConfig(config pools.PoolConfig) *memcached.PoolConfig {
+	c := &memcached.PoolConfig{
+		Name:       config.Name,
+		Capacity:   config.Capacity,
+		CacheCount: int32(config.CacheCount),
+		Replicas:   int32(config.Replicas),
+		TtlMillis:  int64(config.TTL / time.Millisecond),
+		Namespaces: config.Namespaces,
+
+		SoftTtlMillis: int64(config.SoftTTL / time.Millisecond),
+
+		BoundedLoad: config.BoundedLoad,
+
+		ReplicationFactor: int32(config.ReplicationFactor),
+		ReadQuorum:        int32(config.ReadQuorum),
+		WriteQuorum:       int32(config.WriteQuorum),
+
+		Origin:            config.Origin,
+		LoadTimeoutMillis: int64(config.LoadTimeout / time.Millisecond),
+		NegativeTtlMillis: int64(config.NegativeTTL / time.Millisecond),
+	}
+
+	switch config.Policy {
+	case cache.PolicyFIFO:
+		c.Policy = memcached.PoolConfig_FIFO
+	default:
+		c.Policy = memcached.PoolConfig_LRU
+	}
+
+	switch config.HashStrategy {
+	case consistenthash.StrategyJump:
+		c.HashStrategy = memcached.PoolConfig_JUMP
+	case consistenthash.StrategyRendezvous:
+		c.HashStrategy = memcached.PoolConfig_RENDEZVOUS
+	case consistenthash.StrategyMaglev:
+		c.HashStrategy = memcached.PoolConfig_MAGLEV
+	default:
+		c.HashStrategy = memcached.PoolConfig_RING
+	}
+	return c
+}
+
+func fromPool(pool *pools.Pool) *memcached.Pool {
+	stats := pool.Caches.Stats()
+
+	return &memcached.Pool{
+		Config:          fromPoolConfig(pool.Config),
+		Size:            pool.Caches.Size(),
+		CurrentCapacity: stats.CurrentCapacity,
+	}
+}
@@ -0,0 +1,524 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/jobs"
+	"github.com/tescherm/mc/core/merkle"
+	"github.com/tescherm/mc/core/pools"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Replicas drift apart when writes miss them during partitions and restarts,
+// and read repair only fixes the keys that are read. Anti-entropy compares
+// every replicated namespace with each peer in the background: both peers
+// keep a Merkle tree over the keys they both replicate, updated as the keys
+// are written, the tree is descended from the root to find the key hash
+// ranges whose hashes differ, and only the keys in those ranges are
+// exchanged, each side keeping the latest write. Deleted items are left out
+// of the trees, and exchanged only when they supersede an item a peer has.
+// Items without a timestamp, stored before their pool was replicated, are
+// left alone.
+
+const (
+	// descendLevels is the number of tree levels each MerkleTree request
+	// descends
+	descendLevels = 5
+
+	// diffBatch bounds the key digests sent in one Diff request
+	diffBatch = 1000
+)
+
+var ErrAntiEntropyRunning = errors.New("anti-entropy is already running")
+
+// AntiEntropyStats counts the anti-entropy work this node has coordinated.
+type AntiEntropyStats struct {
+	Rounds uint64
+
+	// Comparisons counts the namespaces compared with a peer, and
+	// DivergentRanges the tree leaves found to differ
+	Comparisons     uint64
+	DivergentRanges uint64
+
+	// Pulled counts the keys repaired on this node, and Pushed those
+	// repaired on peers
+	Pulled uint64
+	Pushed uint64
+
+	// Errors counts the comparisons that failed
+	Errors uint64
+}
+
+type antiEntropy struct {
+	running int32
+	limiter *limiter
+	stats   AntiEntropyStats
+}
+
+func newAntiEntropy(rate int) *antiEntropy {
+	return &antiEntropy{
+		limiter: newLimiter(rate),
+	}
+}
+
+// limiter spaces events evenly at a fixed rate.
+type limiter struct {
+	sync.Mutex
+
+	interval time.Duration
+	next     time.Time
+}
+
+// newLimiter returns a limiter allowing rate events per second, or nil,
+// allowing any rate, if rate is not positive.
+func newLimiter(rate int) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &limiter{interval: time.Second / time.Duration(rate)}
+}
+
+// wait blocks until the next event is allowed, or ctx is done.
+func (l *limiter) wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.Unlock()
+
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (s *MemcachedService) AntiEntropyStats() AntiEntropyStats {
+	stats := &s.antiEntropy.stats
+	return AntiEntropyStats{
+		Rounds:          atomic.LoadUint64(&stats.Rounds),
+		Comparisons:     atomic.LoadUint64(&stats.Comparisons),
+		DivergentRanges: atomic.LoadUint64(&stats.DivergentRanges),
+		Pulled:          atomic.LoadUint64(&stats.Pulled),
+		Pushed:          atomic.LoadUint64(&stats.Pushed),
+		Errors:          atomic.LoadUint64(&stats.Errors),
+	}
+}
+
+// AntiEntropy compares every namespace of the replicated pool named pool, or
+// of every replicated pool if pool is empty, with each other peer, and
+// repairs the keys they disagree about. After each comparison, progress is
+// called with the number of comparisons done, the total number and the
+// running count of keys repaired. A comparison that fails is logged and
+// skipped. Only one AntiEntropy runs at a time; it returns
+// ErrAntiEntropyRunning otherwise.
+func (s *MemcachedService) AntiEntropy(ctx context.Context, pool string, progress func(done, total int, repaired uint64)) (uint64, error) {
+	if s.Cluster == nil || s.Trees == nil {
+		return 0, nil
+	}
+	if !atomic.CompareAndSwapInt32(&s.antiEntropy.running, 0, 1) {
+		return 0, ErrAntiEntropyRunning
+	}
+	defer atomic.StoreInt32(&s.antiEntropy.running, 0)
+
+	type target struct {
+		config    pools.PoolConfig
+		namespace string
+	}
+	var targets []target
+	for _, p := range s.Pools.List() {
+		if !p.Config.Replicated() || (pool != "" && p.Config.Name != pool) {
+			continue
+		}
+		for _, namespace := range p.Caches.NamespaceNames() {
+			targets = append(targets, target{config: p.Config, namespace: namespace})
+		}
+	}
+
+	var peers []string
+	for _, peer := range s.Cluster.Peers() {
+		if peer.Address != s.Cluster.Self() {
+			peers = append(peers, peer.Address)
+		}
+	}
+
+	stats := &s.antiEntropy.stats
+	total := len(targets) * len(peers)
+	done := 0
+	var repaired uint64
+
+	for _, t := range targets {
+		for _, peer := range peers {
+			if err := ctx.Err(); err != nil {
+				return repaired, err
+			}
+
+			n, err := s.compare(ctx, t.config, t.namespace, peer)
+			repaired += n
+			atomic.AddUint64(&stats.Comparisons, 1)
+			if err != nil {
+				atomic.AddUint64(&stats.Errors, 1)
+				s.Logger.WithError(err).WithFields(logrus.Fields{
+					"pool":      t.config.Name,
+					"namespace": t.namespace,
+					"peer":      peer,
+				}).Warn("anti-entropy comparison failed")
+			}
+
+			done++
+			if progress != nil {
+				progress(done, total, repaired)
+			}
+		}
+	}
+
+	atomic.AddUint64(&stats.Rounds, 1)
+	return repaired, nil
+}
+
+// antiEntropyNamespace returns a replicated pool's configuration, checking
+// that its namespace exists.
+func (s *MemcachedService) antiEntropyNamespace(pool, namespace string) (pools.PoolConfig, error) {
+	if s.Cluster == nil || s.Trees == nil {
+		return pools.PoolConfig{}, status.Errorf(codes.FailedPrecondition, "anti-entropy is not enabled")
+	}
+
+	p, ok := s.Pools.Get(pool)
+	if !ok {
+		return pools.PoolConfig{}, status.Errorf(codes.NotFound, "pool %s not found", pool)
+	}
+	if !p.Config.Replicated() {
+		return pools.PoolConfig{}, status.Errorf(codes.FailedPrecondition, "pool %s is not replicated", pool)
+	}
+	if _, ok := p.Caches.Namespace(namespace); !ok {
+		return pools.PoolConfig{}, status.Errorf(codes.NotFound, "namespace %s not found", namespace)
+	}
+	return p.Config, nil
+}
+
+// shares returns whether this node and peer both replicate a key of the
+// pool.
+func (s *MemcachedService) shares(config pools.PoolConfig, peer string) func(key string) bool {
+	return func(key string) bool {
+		return s.Cluster.Shares(key, config.ReplicationFactor, peer)
+	}
+}
+
+// merkleTree returns the tree of the items in a namespace that this node and
+// peer both replicate.
+func (s *MemcachedService) merkleTree(config pools.PoolConfig, namespace, peer string) *merkle.Tree {
+	return s.Trees.Tree(config.Name, namespace, peer, s.Cluster.Epoch(), s.shares(config, peer))
+}
+
+// fetch returns this node's timestamped item for key, which may be deleted,
+// or nil.
+func (s *MemcachedService) fetch(ctx context.Context, pool, namespace, key string) (*cache.Item, error) {
+	c, err := s.pick(ctx, pool, namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	item := c.Fetch(key)
+	if item == nil || item.Timestamp == 0 {
+		return nil, nil
+	}
+	return item, nil
+}
+
+// compare compares a namespace with a peer's, and repairs the keys they
+// disagree about, returning the number repaired.
+func (s *MemcachedService) compare(ctx context.Context, config pools.PoolConfig, namespace, peer string) (uint64, error) {
+	conn := s.Cluster.Conn(peer)
+	if conn == nil {
+		return 0, fmt.Errorf("peer %s left the cluster", peer)
+	}
+	remote := memcached.NewAntiEntropyClient(conn)
+
+	if _, err := s.antiEntropyNamespace(config.Name, namespace); err != nil {
+		return 0, err
+	}
+	tree := s.merkleTree(config, namespace, peer)
+
+	var leaves []uint32
+	nodes := []uint32{1}
+	for len(nodes) > 0 {
+		res, err := remote.MerkleTree(ctx, &memcached.MerkleTreeRequest{
+			Pool:      config.Name,
+			Namespace: namespace,
+			From:      s.Cluster.Self(),
+			Nodes:     nodes,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if len(res.Hashes) != len(nodes) {
+			return 0, fmt.Errorf("peer %s returned %d hashes for %d nodes", peer, len(res.Hashes), len(nodes))
+		}
+
+		var next []uint32
+		for i, node := range nodes {
+			switch {
+			case tree.Hash(node) == res.Hashes[i]:
+			case tree.IsLeaf(node):
+				leaves = append(leaves, node)
+			default:
+				next = append(next, tree.Descendants(node, descendLevels)...)
+			}
+		}
+		nodes = next
+	}
+
+	atomic.AddUint64(&s.antiEntropy.stats.DivergentRanges, uint64(len(leaves)))
+
+	var repaired uint64
+	shares := s.shares(config, peer)
+	req := &memcached.DiffRequest{
+		Pool:      config.Name,
+		Namespace: namespace,
+		From:      s.Cluster.Self(),
+	}
+	client := memcached.NewMemcachedClient(conn)
+	for _, leaf := range leaves {
+		req.Leaves = append(req.Leaves, leaf)
+		for key, timestamp := range s.Trees.Keys(config.Name, namespace, leaf, shares) {
+			req.Digests = append(req.Digests, &memcached.KeyDigest{Key: key, Timestamp: timestamp})
+		}
+
+		if len(req.Digests) < diffBatch {
+			continue
+		}
+		n, err := s.diff(ctx, remote, client, req)
+		repaired += n
+		if err != nil {
+			return repaired, err
+		}
+		req.Leaves, req.Digests = nil, nil
+	}
+	if len(req.Leaves) > 0 {
+		n, err := s.diff(ctx, remote, client, req)
+		repaired += n
+		if err != nil {
+			return repaired, err
+		}
+	}
+	return repaired, nil
+}
+
+// diff exchanges the keys in a batch of divergent leaves with a peer:
+// merging the peer's newer items here, and sending the peer this node's
+// newer items.
+func (s *MemcachedService) diff(ctx context.Context, remote memcached.AntiEntropyClient, peer memcached.MemcachedClient, req *memcached.DiffRequest) (uint64, error) {
+	stream, err := remote.Diff(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	stats := &s.antiEntropy.stats
+	var repaired uint64
+	push := func(item *memcached.Item) error {
+		if err := s.antiEntropy.limiter.wait(ctx); err != nil {
+			return err
+		}
+		if _, err := replicaWrite(ctx, peer, req.Pool, req.Namespace, item); err != nil {
+			return err
+		}
+		atomic.AddUint64(&stats.Pushed, 1)
+		repaired++
+		return nil
+	}
+
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return repaired, nil
+		}
+		if err != nil {
+			return repaired, err
+		}
+
+		if res.Item != nil {
+			if err := s.antiEntropy.limiter.wait(ctx); err != nil {
+				return repaired, err
+			}
+			previous, err := s.merge(ctx, req.Pool, req.Namespace, res.Item)
+			if err != nil {
+				return repaired, err
+			}
+			if previous != nil && previous.Timestamp > res.Item.Timestamp {
+				// deleted items are not in the trees, so the peer sends the
+				// items they removed here: the delete is sent back instead
+				if err := push(previous); err != nil {
+					return repaired, err
+				}
+				continue
+			}
+			atomic.AddUint64(&stats.Pulled, 1)
+			repaired++
+		}
+
+		for _, key := range res.Wanted {
+			item, err := s.fetch(ctx, req.Pool, req.Namespace, key)
+			if err != nil {
+				return repaired, err
+			}
+			if item == nil {
+				continue
+			}
+			if err := push(fromCacheItem(item)); err != nil {
+				return repaired, err
+			}
+		}
+	}
+}
+
+func (s *MemcachedService) MerkleTree(ctx context.Context, req *memcached.MerkleTreeRequest) (*memcached.MerkleTreeResponse, error) {
+	s.Logger.WithFields(logrus.Fields{
+		"from":      req.From,
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+		"nodes":     len(req.Nodes),
+	}).Debug("MerkleTree")
+
+	config, err := s.antiEntropyNamespace(req.Pool, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	tree := s.merkleTree(config, req.Namespace, req.From)
+
+	hashes := make([]uint64, len(req.Nodes))
+	for i, node := range req.Nodes {
+		hashes[i] = tree.Hash(node)
+	}
+
+	res := &memcached.MerkleTreeResponse{
+		Hashes: hashes,
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) Diff(req *memcached.DiffRequest, stream memcached.AntiEntropy_DiffServer) error {
+	s.Logger.WithFields(logrus.Fields{
+		"from":      req.From,
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+		"leaves":    len(req.Leaves),
+	}).Debug("Diff")
+
+	config, err := s.antiEntropyNamespace(req.Pool, req.Namespace)
+	if err != nil {
+		return err
+	}
+	ctx := stream.Context()
+
+	digests := make(map[string]uint64, len(req.Digests))
+	for _, digest := range req.Digests {
+		digests[digest.Key] = digest.Timestamp
+	}
+
+	var newer []string
+	var wanted []string
+	shares := s.shares(config, req.From)
+	for _, leaf := range req.Leaves {
+		for key, timestamp := range s.Trees.Keys(config.Name, req.Namespace, leaf, shares) {
+			digest, ok := digests[key]
+			delete(digests, key)
+			switch {
+			case !ok || timestamp > digest:
+				newer = append(newer, key)
+			case timestamp < digest:
+				wanted = append(wanted, key)
+			}
+		}
+	}
+
+	send := func(item *cache.Item) error {
+		return stream.Send(&memcached.DiffResponse{Item: fromCacheItem(item)})
+	}
+	for _, key := range newer {
+		item, err := s.fetch(ctx, config.Name, req.Namespace, key)
+		if err != nil {
+			return err
+		}
+		if item != nil {
+			if err := send(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the requesting peer's remaining keys are missing here, or deleted,
+	// since deleted items are not in the trees
+	for key, digest := range digests {
+		item, err := s.fetch(ctx, config.Name, req.Namespace, key)
+		if err != nil {
+			return err
+		}
+		if item == nil || item.Timestamp < digest {
+			wanted = append(wanted, key)
+			continue
+		}
+		if item.Timestamp > digest {
+			if err := send(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(wanted) > 0 {
+		return stream.Send(&memcached.DiffResponse{Wanted: wanted})
+	}
+	return nil
+}
+
+func (s *MemcachedService) RepairReplicas(ctx context.Context, req *memcached.RepairReplicasRequest) (*memcached.RepairReplicasResponse, error) {
+	s.Logger.WithFields(logrus.Fields{
+		"pool": req.Pool,
+	}).Info("RepairReplicas")
+
+	if s.Cluster == nil || s.Trees == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "anti-entropy is not enabled")
+	}
+	if req.Pool != "" {
+		p, ok := s.Pools.Get(req.Pool)
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "pool %s not found", req.Pool)
+		}
+		if !p.Config.Replicated() {
+			return nil, status.Errorf(codes.FailedPrecondition, "pool %s is not replicated", req.Pool)
+		}
+	}
+
+	job := s.Jobs.Start("repair-replicas", func(ctx context.Context, job *jobs.Job) error {
+		_, err := s.AntiEntropy(ctx, req.Pool, job.Progress)
+		return err
+	})
+
+	res := &memcached.RepairReplicasResponse{
+		JobID: job.Status().ID,
+	}
+	return res, nil
+}
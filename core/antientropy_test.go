@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/pb"
+)
+
+// localMerge merges an item into a node's own replica, as a write that
+// reached only that node would.
+func (n *testNode) localMerge(t *testing.T, pool string, item *cache.Item) {
+	c, err := n.service.pick(context.Background(), pool, "", item.Key)
+	require.NoError(t, err)
+	c.Merge(item)
+}
+
+func TestAntiEntropy(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 3)
+	defer closeAll()
+	createReplicatedPool(t, nodes, "replicated", 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := 0; i < 100; i++ {
+		_, err := nodes[0].client.Set(ctx, &memcached.SetRequest{
+			Item:        &memcached.Item{Key: fmt.Sprintf("key-%d", i), Value: []byte("v1")},
+			Pool:        "replicated",
+			WriteQuorum: 3,
+		})
+		require.NoError(t, err)
+	}
+
+	// replicas in step have nothing to repair
+	repaired, err := nodes[0].service.AntiEntropy(ctx, "", nil)
+	require.NoError(t, err)
+	require.Zero(t, repaired)
+	require.Zero(t, nodes[0].service.AntiEntropyStats().DivergentRanges)
+
+	// writes that reached a single replica each
+	clock := nodes[0].service.Cluster.Clock()
+	nodes[1].localMerge(t, "replicated", &cache.Item{Key: "key-1", Value: []byte("v2"), Timestamp: uint64(clock.Now())})
+	nodes[2].localMerge(t, "replicated", &cache.Item{Key: "key-2", Timestamp: uint64(clock.Now()), Deleted: true})
+	nodes[0].localMerge(t, "replicated", &cache.Item{Key: "new", Value: []byte("v1"), Timestamp: uint64(clock.Now())})
+
+	var progress []int
+	repaired, err = nodes[0].service.AntiEntropy(ctx, "replicated", func(done, total int, repaired uint64) {
+		require.Equal(t, 2, total)
+		progress = append(progress, done)
+	})
+	require.NoError(t, err)
+	require.NotZero(t, repaired)
+	require.Equal(t, []int{1, 2}, progress)
+
+	// a second round from another node spreads what the first pulled
+	_, err = nodes[1].service.AntiEntropy(ctx, "", nil)
+	require.NoError(t, err)
+
+	for _, node := range nodes {
+		require.Equal(t, []byte("v2"), node.localFetch("replicated", "key-1").Value, "node=%s", node.address)
+		require.True(t, node.localFetch("replicated", "key-2").Deleted, "node=%s", node.address)
+		require.Equal(t, []byte("v1"), node.localFetch("replicated", "new").Value, "node=%s", node.address)
+	}
+
+	stats := nodes[0].service.AntiEntropyStats()
+	require.EqualValues(t, 2, stats.Rounds)
+	require.EqualValues(t, 4, stats.Comparisons)
+	require.NotZero(t, stats.DivergentRanges)
+	require.EqualValues(t, repaired, stats.Pulled+stats.Pushed)
+	require.EqualValues(t, 2, stats.Pulled)
+	require.EqualValues(t, 3, stats.Pushed)
+	require.Zero(t, stats.Errors)
+
+	// replicas are back in step
+	repaired, err = nodes[2].service.AntiEntropy(ctx, "", nil)
+	require.NoError(t, err)
+	require.Zero(t, repaired)
+}
+
+func TestRepairReplicas(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 2)
+	defer closeAll()
+	createReplicatedPool(t, nodes, "replicated", 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := nodes[0].client.RepairReplicas(ctx, &memcached.RepairReplicasRequest{Pool: "missing"})
+	require.Error(t, err)
+	_, err = nodes[0].client.RepairReplicas(ctx, &memcached.RepairReplicasRequest{Pool: "default"})
+	require.Error(t, err)
+
+	nodes[1].localMerge(t, "replicated", &cache.Item{
+		Key:       "key",
+		Value:     []byte("value"),
+		Timestamp: uint64(nodes[1].service.Cluster.Clock().Now()),
+	})
+
+	res, err := nodes[0].client.RepairReplicas(ctx, &memcached.RepairReplicasRequest{})
+	require.NoError(t, err)
+
+	var job *memcached.Job
+	for i := 0; i < 100; i++ {
+		res, err := nodes[0].client.GetJob(ctx, &memcached.GetJobRequest{JobID: res.JobID})
+		require.NoError(t, err)
+		job = res.Job
+		if job.State != memcached.Job_RUNNING {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, memcached.Job_DONE, job.State)
+	require.EqualValues(t, 1, job.Count)
+	require.Equal(t, []byte("value"), nodes[0].localFetch("replicated", "key").Value)
+}
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	l := newLimiter(100)
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		require.NoError(t, l.wait(ctx))
+	}
+	require.True(t, time.Since(start) >= 100*time.Millisecond)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.Error(t, l.wait(cancelled))
+
+	// a nil limiter allows any rate
+	require.NoError(t, newLimiter(0).wait(ctx))
+}
@@ -0,0 +1,52 @@
+package cache
+
+// EventType is the kind of change an Event reports.
+type EventType int
+
+const (
+	// EventSet reports an item set, with its new version
+	EventSet EventType = iota
+	// EventRemove reports an item removed by a remove, a pattern or tag
+	// invalidation, or a replicated delete
+	EventRemove
+	// EventEvict reports an item evicted to make room
+	EventEvict
+	// EventExpire reports an expired item, when the cache notices it
+	EventExpire
+	// EventClear reports that every item was removed; it has no key
+	EventClear
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventRemove:
+		return "remove"
+	case EventEvict:
+		return "evict"
+	case EventExpire:
+		return "expire"
+	case EventClear:
+		return "clear"
+	}
+	return "unknown"
+}
+
+// Event is a change to a cache's contents.
+type Event struct {
+	Type EventType
+	Key  string
+
+	// VersionID is the item's version after a set, and the version removed
+	// otherwise
+	VersionID int64
+
+	// Timestamp is the item's replicated write time after a set, and the
+	// time of the write removed otherwise; zero if it was not replicated
+	Timestamp uint64
+}
+
+// Listener is called with every change to a cache, while the cache is
+// locked: it must not block or call the cache.
+type Listener func(event Event)
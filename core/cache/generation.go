@@ -0,0 +1,27 @@
+package cache
+
+import "sync/atomic"
+
+// Generation is a counter shared by the caches of a namespace. Items are
+// stored under the generation current when they were set, and items stored
+// under any other generation are unreachable. Bumping the generation
+// therefore invalidates every item at once; the unreachable items age out
+// through normal LRU eviction.
+type Generation struct {
+	n uint64
+}
+
+func (g *Generation) Load() uint64 {
+	return atomic.LoadUint64(&g.n)
+}
+
+// Store sets the generation. Setting a previous generation makes any of its
+// items that have not yet been evicted reachable again.
+func (g *Generation) Store(n uint64) {
+	atomic.StoreUint64(&g.n, n)
+}
+
+// Bump advances the generation, returning the new value.
+func (g *Generation) Bump() uint64 {
+	return atomic.AddUint64(&g.n, 1)
+}
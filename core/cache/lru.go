@@ -2,14 +2,40 @@ package cache
 
 import (
 	"bytes"
+	"fmt"
 	"sync"
+	"time"
 )
 
 type Item struct {
 	Key   string
 	Value []byte
 
-	versionID int64
+	// Tags are labels that can be used to invalidate groups of items
+	Tags []string
+
+	// Timestamp is the hybrid logical clock time of a replicated write,
+	// which orders writes to the item across replicas; zero if the item is
+	// not replicated
+	Timestamp uint64
+
+	// Deleted marks a replicated remove. Deleted items are kept so that
+	// older writes are not merged over them; they are never returned by Get.
+	Deleted bool
+
+	// Delta is how long the item took to compute, which weighs the chance
+	// of recomputing it early; zero if unknown
+	Delta time.Duration
+
+	versionID  int64
+	generation uint64
+
+	// expiresAt is when the item expires, in unix nanoseconds; zero if never
+	expiresAt int64
+
+	// softExpiresAt is when the item becomes stale, in unix nanoseconds;
+	// zero if never
+	softExpiresAt int64
 }
 
 func NewItem(key string, value []byte, versionID int64) *Item {
@@ -24,18 +50,94 @@ func (i *Item) VersionID() int64 {
 	return i.versionID
 }
 
+// ExpiresAt is when the item expires. It is the zero time if the item does
+// not expire.
+func (i *Item) ExpiresAt() time.Time {
+	if i.expiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, i.expiresAt)
+}
+
+// SoftExpiresAt is when the item becomes stale. It is the zero time if the
+// item does not.
+func (i *Item) SoftExpiresAt() time.Time {
+	if i.softExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, i.softExpiresAt)
+}
+
+// SetExpiry sets when the item expires and becomes stale; the zero time if
+// never. It is kept by Load, and replaced by the cache's ttls otherwise.
+func (i *Item) SetExpiry(expiresAt, softExpiresAt time.Time) {
+	i.expiresAt = unixNano(expiresAt)
+	i.softExpiresAt = unixNano(softExpiresAt)
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// Stale reports whether the item is past its soft expiry at now. Stale items
+// are still returned until they expire, but are due a refresh.
+func (i *Item) Stale(now time.Time) bool {
+	return i.softExpiresAt != 0 && i.softExpiresAt <= now.UnixNano()
+}
+
 func (i *Item) Size() uint64 {
-	return uint64(len(i.Key) + len(i.Value))
+	size := len(i.Key) + len(i.Value)
+	for _, tag := range i.Tags {
+		size += len(tag)
+	}
+	return uint64(size)
 }
 
 type Cache interface {
 	Get(key string) *Item
 	Set(item *Item)
 	CompareAndSwap(item *Item) (swapped bool)
+	// Fill sets item, as a miss loaded from elsewhere, unless the cache
+	// holds a reachable item for its key or valid returns false. valid is
+	// called with the cache locked, so that a write racing the load can
+	// veto it; it must not call the cache.
+	Fill(item *Item, valid func() bool) (filled bool)
 	Remove(key string) *Item
+	RemoveMatching(match func(key string) bool) uint64
+	InvalidateTags(tags []string) uint64
 	Clear()
 	Size() uint64
 	Stats() Stats
+
+	// Keys returns the keys of the reachable items in the cache, including
+	// deleted items.
+	Keys() []string
+	// Take removes and returns the item for key without counting it as a
+	// remove. Take and Load move items between caches.
+	Take(key string) *Item
+	// Load stores item as is, preserving its version, unless the cache
+	// already holds a reachable item for its key. It is not counted as a set.
+	Load(item *Item) (loaded bool)
+	// SetCapacity changes the cache capacity, in bytes, evicting items if
+	// the cache no longer fits.
+	SetCapacity(capacity uint64)
+	// Trim evicts items until the cache holds at most size bytes, leaving
+	// its capacity unchanged. It returns the number of bytes freed.
+	Trim(size uint64) uint64
+
+	// Fetch is Get, except that it also returns deleted items, which are
+	// counted as misses.
+	Fetch(key string) *Item
+	// Merge stores item as is, preserving its version, if it has a later
+	// timestamp than the reachable item for its key: the last writer wins.
+	// Deleted items are merged like any other.
+	Merge(item *Item) (merged bool)
+	// Scan calls fn with a copy of every reachable item, including deleted
+	// items, without counting or touching them. fn must not call the cache.
+	Scan(fn func(item *Item))
 }
 
 type cacheNode struct {
@@ -45,8 +147,13 @@ type cacheNode struct {
 	next *cacheNode
 }
 
+// Size is the item size plus the size of the node's tag index entries.
 func (n *cacheNode) Size() uint64 {
-	return n.item.Size()
+	size := n.item.Size()
+	for _, tag := range n.item.Tags {
+		size += uint64(len(tag) + len(n.item.Key))
+	}
+	return size
 }
 
 type cacheList struct {
@@ -128,29 +235,118 @@ func (l *cacheList) clear() {
 	l.tail = nil
 }
 
+// Policy selects the order in which a cache evicts items.
+type Policy int
+
+const (
+	// PolicyLRU evicts the least recently used item
+	PolicyLRU Policy = iota
+	// PolicyFIFO evicts the least recently set item; reads do not affect
+	// eviction order
+	PolicyFIFO
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyLRU:
+		return "lru"
+	case PolicyFIFO:
+		return "fifo"
+	default:
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+}
+
+func ParsePolicy(s string) (Policy, error) {
+	switch s {
+	case "", "lru":
+		return PolicyLRU, nil
+	case "fifo":
+		return PolicyFIFO, nil
+	default:
+		return 0, fmt.Errorf("unknown eviction policy %q", s)
+	}
+}
+
+func (p Policy) MarshalText() ([]byte, error) {
+	if p != PolicyLRU && p != PolicyFIFO {
+		return nil, fmt.Errorf("unknown eviction policy %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+func (p *Policy) UnmarshalText(text []byte) error {
+	policy, err := ParsePolicy(string(text))
+	if err != nil {
+		return err
+	}
+	*p = policy
+	return nil
+}
+
 type Config struct {
 	// Capacity is the cache capacity, in bytes
 	Capacity uint64
+
+	// Policy is the eviction policy, LRU by default
+	Policy Policy
+
+	// TTL is how long items live after they are set. Zero means items do
+	// not expire.
+	TTL time.Duration
+
+	// SoftTTL, if positive, is how long items are fresh after they are set.
+	// Past it, and until TTL, items are stale.
+	SoftTTL time.Duration
+
+	// Generation is the generation items are stored under. It is typically
+	// shared by every cache in a namespace. If nil, the cache has its own.
+	Generation *Generation
+
+	// Listener, if set, is called with every change to the cache. Moving
+	// items between caches with Take and Load is not a change.
+	Listener Listener
 }
 
 type Stats struct {
-	Evicts  uint64
-	Removes uint64
-	Clears  uint64
-	Sets    uint64
-	Hits    uint64
-	Misses  uint64
+	Evicts         uint64
+	Removes        uint64
+	PatternRemoves uint64
+	TagRemoves     uint64
+	Expires        uint64
+	Clears         uint64
+	Sets           uint64
+	Hits           uint64
+	Misses         uint64
+
+	// StaleHits counts the hits on stale items, which are also hits
+	StaleHits uint64
 
 	CurrentCapacity uint64
+	// MaxCapacity is the capacity the cache evicts down to, in bytes
+	MaxCapacity uint64
 }
 
 func NewLRUCache(conf Config) *LRUCache {
 	nodeMap := make(map[string]*cacheNode, 0)
+	tagMap := make(map[string]map[string]*cacheNode, 0)
 	list := &cacheList{}
 
+	generation := conf.Generation
+	if generation == nil {
+		generation = &Generation{}
+	}
+
 	return &LRUCache{
 		nodeMap:         nodeMap,
+		tagMap:          tagMap,
 		list:            list,
+		generation:      generation,
+		policy:          conf.Policy,
+		ttl:             conf.TTL,
+		softTTL:         conf.SoftTTL,
+		listener:        conf.Listener,
+		now:             time.Now,
 		maxCapacity:     conf.Capacity,
 		currentCapacity: 0,
 	}
@@ -162,33 +358,63 @@ type LRUCache struct {
 	nodeMap map[string]*cacheNode
 	list    *cacheList
 
+	// tag -> key -> node index
+	tagMap map[string]map[string]*cacheNode
+
+	generation *Generation
+
+	policy   Policy
+	ttl      time.Duration
+	softTTL  time.Duration
+	listener Listener
+	now      func() time.Time
+
 	// current and max cache capacity, in bytes
 	maxCapacity     uint64
 	currentCapacity uint64
 
 	// stats
-	evicts  uint64
-	removes uint64
-	clears  uint64
-	sets    uint64
-	hits    uint64
-	misses  uint64
+	evicts         uint64
+	removes        uint64
+	patternRemoves uint64
+	tagRemoves     uint64
+	expires        uint64
+	clears         uint64
+	sets           uint64
+	hits           uint64
+	misses         uint64
+	staleHits      uint64
 }
 
 func (c *LRUCache) Get(key string) *Item {
+	item := c.Fetch(key)
+	if item == nil || item.Deleted {
+		return nil
+	}
+	return item
+}
+
+func (c *LRUCache) Fetch(key string) *Item {
 	c.Lock()
 	defer c.Unlock()
 
-	node, ok := c.nodeMap[key]
+	node, ok := c.lookup(key)
 	if !ok {
 		c.misses++
 		return nil
 	}
 
-	c.hits++
-	c.list.setUsed(node)
-
 	item := Item(*node.item)
+	if item.Deleted {
+		c.misses++
+		return &item
+	}
+
+	c.hits++
+	if item.Stale(c.now()) {
+		c.staleHits++
+	}
+	c.touch(node)
 	return &item
 }
 
@@ -203,9 +429,9 @@ func (c *LRUCache) CompareAndSwap(item *Item) bool {
 	c.Lock()
 	defer c.Unlock()
 
-	node, ok := c.nodeMap[item.Key]
+	node, ok := c.lookup(item.Key)
 
-	if ok {
+	if ok && !node.item.Deleted {
 		if node.item.VersionID() != item.VersionID() {
 			return false
 		}
@@ -215,15 +441,31 @@ func (c *LRUCache) CompareAndSwap(item *Item) bool {
 	return true
 }
 
+func (c *LRUCache) Fill(item *Item, valid func() bool) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if _, ok := c.lookup(item.Key); ok {
+		return false
+	}
+	if !valid() {
+		return false
+	}
+
+	c.doSet(item)
+	return true
+}
+
 func (c *LRUCache) doSet(item *Item) {
 	node, ok := c.nodeMap[item.Key]
 
 	if ok {
 		// entry already exists
-		node.item = item
-		c.list.setUsed(node)
-
 		c.currentCapacity -= node.Size()
+		c.unindexTags(node)
+
+		node.item = item
+		c.touch(node)
 	} else {
 		// we are seeing this item for the first time
 		node = &cacheNode{
@@ -233,51 +475,340 @@ func (c *LRUCache) doSet(item *Item) {
 	}
 
 	node.item.versionID++
+	node.item.generation = c.generation.Load()
+	c.setExpiry(node.item)
 
 	c.nodeMap[item.Key] = node
+	c.indexTags(node)
 	c.currentCapacity += node.Size()
 	c.sets++
+	c.notify(EventSet, node.item)
+
+	c.evict()
+}
+
+// setExpiry sets when an item being stored expires, and becomes stale.
+func (c *LRUCache) setExpiry(item *Item) {
+	now := c.now()
+	item.expiresAt = 0
+	if c.ttl > 0 {
+		item.expiresAt = now.Add(c.ttl).UnixNano()
+	}
+	item.softExpiresAt = 0
+	if c.softTTL > 0 {
+		item.softExpiresAt = now.Add(c.softTTL).UnixNano()
+	}
+}
 
+// evict removes items until the cache fits its capacity.
+func (c *LRUCache) evict() {
 	for c.currentCapacity > c.maxCapacity {
 		// evict the least recently used by removing at the head
-		del := c.list.shift()
-		delete(c.nodeMap, del.item.Key)
+		c.evictNode(c.list.head)
+	}
+}
+
+func (c *LRUCache) evictNode(node *cacheNode) {
+	c.removeNode(node)
+	c.evicts++
+	if !node.item.Deleted {
+		c.notify(EventEvict, node.item)
+	}
+}
+
+// notify calls the listener, if any, with a change to item.
+func (c *LRUCache) notify(t EventType, item *Item) {
+	if c.listener == nil {
+		return
+	}
+	event := Event{Type: t}
+	if item != nil {
+		event.Key = item.Key
+		event.VersionID = item.versionID
+		event.Timestamp = item.Timestamp
+	}
+	c.listener(event)
+}
 
-		c.currentCapacity -= del.Size()
-		c.evicts++
+// removeNode unlinks a node from the list and indexes and releases its
+// capacity.
+func (c *LRUCache) removeNode(node *cacheNode) {
+	delete(c.nodeMap, node.item.Key)
+	c.list.remove(node)
+	c.unindexTags(node)
+
+	c.currentCapacity -= node.Size()
+}
+
+func (c *LRUCache) indexTags(node *cacheNode) {
+	for _, tag := range node.item.Tags {
+		keys, ok := c.tagMap[tag]
+		if !ok {
+			keys = make(map[string]*cacheNode)
+			c.tagMap[tag] = keys
+		}
+		keys[node.item.Key] = node
 	}
 }
 
+func (c *LRUCache) unindexTags(node *cacheNode) {
+	for _, tag := range node.item.Tags {
+		keys := c.tagMap[tag]
+		delete(keys, node.item.Key)
+		if len(keys) == 0 {
+			delete(c.tagMap, tag)
+		}
+	}
+}
+
+// touch records a use of node for eviction ordering.
+func (c *LRUCache) touch(node *cacheNode) {
+	if c.policy == PolicyLRU {
+		c.list.setUsed(node)
+	}
+}
+
+// lookup returns the node for key, if it is stored under the current
+// generation and has not expired. Expired nodes are removed.
+func (c *LRUCache) lookup(key string) (*cacheNode, bool) {
+	node, ok := c.nodeMap[key]
+	if !ok || node.item.generation != c.generation.Load() {
+		return nil, false
+	}
+	if c.expired(node, c.now()) {
+		c.removeNode(node)
+		c.expires++
+		if !node.item.Deleted {
+			c.notify(EventExpire, node.item)
+		}
+		return nil, false
+	}
+	return node, true
+}
+
+func (c *LRUCache) expired(node *cacheNode, now time.Time) bool {
+	return node.item.expiresAt != 0 && node.item.expiresAt <= now.UnixNano()
+}
+
+// reachable reports whether a node can be returned to callers.
+func (c *LRUCache) reachable(node *cacheNode, generation uint64, now time.Time) bool {
+	return node.item.generation == generation && !c.expired(node, now)
+}
+
 func (c *LRUCache) Remove(key string) *Item {
 	c.Lock()
 	defer c.Unlock()
 
-	node, ok := c.nodeMap[key]
+	node, ok := c.lookup(key)
 	if !ok {
 		return nil
 	}
 
-	delete(c.nodeMap, key)
-	c.list.remove(node)
-
-	c.currentCapacity -= node.Size()
+	c.removeNode(node)
+	if node.item.Deleted {
+		return nil
+	}
 	c.removes++
+	c.notify(EventRemove, node.item)
 
 	item := Item(*node.item)
 	return &item
 }
 
+// RemoveMatching removes every item whose key satisfies match, returning the
+// number of items removed. These removals are counted as pattern removes
+// rather than removes.
+func (c *LRUCache) RemoveMatching(match func(key string) bool) uint64 {
+	c.Lock()
+	defer c.Unlock()
+
+	generation := c.generation.Load()
+	now := c.now()
+
+	var removed uint64
+	for key, node := range c.nodeMap {
+		if !c.reachable(node, generation, now) || node.item.Deleted || !match(key) {
+			continue
+		}
+
+		c.removeNode(node)
+		c.notify(EventRemove, node.item)
+		removed++
+	}
+
+	c.patternRemoves += removed
+	return removed
+}
+
+// InvalidateTags removes every item carrying at least one of tags, returning
+// the number of items removed. Like RemoveMatching, it leaves deleted items,
+// which must be kept to order later writes.
+func (c *LRUCache) InvalidateTags(tags []string) uint64 {
+	c.Lock()
+	defer c.Unlock()
+
+	generation := c.generation.Load()
+	now := c.now()
+
+	var removed uint64
+	for _, tag := range tags {
+		for _, node := range c.tagMap[tag] {
+			if !c.reachable(node, generation, now) || node.item.Deleted {
+				continue
+			}
+			c.removeNode(node)
+			c.notify(EventRemove, node.item)
+			removed++
+		}
+	}
+
+	c.tagRemoves += removed
+	return removed
+}
+
+func (c *LRUCache) Keys() []string {
+	c.RLock()
+	defer c.RUnlock()
+
+	generation := c.generation.Load()
+	now := c.now()
+
+	keys := make([]string, 0, len(c.nodeMap))
+	for key, node := range c.nodeMap {
+		if c.reachable(node, generation, now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (c *LRUCache) Scan(fn func(item *Item)) {
+	c.RLock()
+	defer c.RUnlock()
+
+	generation := c.generation.Load()
+	now := c.now()
+
+	for _, node := range c.nodeMap {
+		if c.reachable(node, generation, now) {
+			item := Item(*node.item)
+			fn(&item)
+		}
+	}
+}
+
+func (c *LRUCache) Take(key string) *Item {
+	c.Lock()
+	defer c.Unlock()
+
+	node, ok := c.lookup(key)
+	if !ok {
+		return nil
+	}
+
+	c.removeNode(node)
+	return node.item
+}
+
+func (c *LRUCache) Load(item *Item) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if _, ok := c.lookup(item.Key); ok {
+		return false
+	}
+
+	// replace any unreachable item stored under the key
+	if node, ok := c.nodeMap[item.Key]; ok {
+		c.removeNode(node)
+	}
+
+	node := &cacheNode{
+		item: item,
+	}
+	c.list.add(node)
+	c.nodeMap[item.Key] = node
+	c.indexTags(node)
+	c.currentCapacity += node.Size()
+
+	c.evict()
+	return true
+}
+
+func (c *LRUCache) Merge(item *Item) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	var replaced *Item
+	if node, ok := c.lookup(item.Key); ok {
+		if node.item.Timestamp >= item.Timestamp {
+			return false
+		}
+		if !node.item.Deleted {
+			replaced = node.item
+		}
+	}
+
+	// replace the older or unreachable item stored under the key
+	if node, ok := c.nodeMap[item.Key]; ok {
+		c.removeNode(node)
+	}
+
+	item.generation = c.generation.Load()
+	c.setExpiry(item)
+
+	node := &cacheNode{
+		item: item,
+	}
+	c.list.add(node)
+	c.nodeMap[item.Key] = node
+	c.indexTags(node)
+	c.currentCapacity += node.Size()
+	if !item.Deleted {
+		c.sets++
+		c.notify(EventSet, item)
+	} else if replaced != nil {
+		c.notify(EventRemove, replaced)
+	}
+
+	c.evict()
+	return true
+}
+
+func (c *LRUCache) SetCapacity(capacity uint64) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.maxCapacity = capacity
+	c.evict()
+}
+
+func (c *LRUCache) Trim(size uint64) uint64 {
+	c.Lock()
+	defer c.Unlock()
+
+	before := c.currentCapacity
+	for c.currentCapacity > size && c.list.head != nil {
+		c.evictNode(c.list.head)
+	}
+	return before - c.currentCapacity
+}
+
 func (c *LRUCache) Clear() {
 	c.Lock()
 	defer c.Unlock()
 
 	c.nodeMap = make(map[string]*cacheNode, 0)
+	c.tagMap = make(map[string]map[string]*cacheNode, 0)
 	c.list.clear()
 	c.currentCapacity = 0
 
 	c.clears++
+	c.notify(EventClear, nil)
 }
 
+// Size is the number of items in the cache, including items from other
+// generations that have not yet been evicted.
 func (c *LRUCache) Size() uint64 {
 	c.RLock()
 	defer c.RUnlock()
@@ -294,9 +825,14 @@ func (c *LRUCache) Stats() Stats {
 		Evicts:          c.evicts,
 		Hits:            c.hits,
 		Misses:          c.misses,
+		StaleHits:       c.staleHits,
 		Removes:         c.removes,
+		PatternRemoves:  c.patternRemoves,
+		TagRemoves:      c.tagRemoves,
+		Expires:         c.expires,
 		Sets:            c.sets,
 		CurrentCapacity: c.currentCapacity,
+		MaxCapacity:     c.maxCapacity,
 	}
 }
 
@@ -2,8 +2,10 @@ package cache
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -158,6 +160,96 @@ func TestCacheRemove(t *testing.T) {
 	checkSize(t, cache, 0)
 }
 
+func TestCacheRemoveMatching(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRUCache(Config{Capacity: 100000})
+	set(cache, "tenant:1:key1", value)
+	set(cache, "tenant:1:key2", value)
+	set(cache, "tenant:2:key1", value)
+
+	removed := cache.RemoveMatching(func(key string) bool {
+		return strings.HasPrefix(key, "tenant:1:")
+	})
+	require.EqualValues(t, 2, removed)
+
+	checkMiss(t, cache, "tenant:1:key1")
+	checkMiss(t, cache, "tenant:1:key2")
+	checkHit(t, cache, "tenant:2:key1", value)
+
+	stats := cache.Stats()
+	require.EqualValues(t, 3, stats.Sets)
+	require.EqualValues(t, 0, stats.Removes)
+	require.EqualValues(t, 2, stats.PatternRemoves)
+
+	require.EqualValues(t, len("tenant:2:key1")+len(value), stats.CurrentCapacity)
+	checkSize(t, cache, 1)
+}
+
+func setTagged(c Cache, key string, val []byte, tags ...string) {
+	item := &Item{
+		Key:   key,
+		Value: val,
+		Tags:  tags,
+	}
+	c.Set(item)
+}
+
+func TestCacheInvalidateTags(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRUCache(Config{Capacity: 100000})
+	setTagged(cache, "key1", value, "red", "big")
+	setTagged(cache, "key2", value, "red")
+	setTagged(cache, "key3", value, "blue")
+
+	removed := cache.InvalidateTags([]string{"red", "big"})
+	require.EqualValues(t, 2, removed)
+
+	checkMiss(t, cache, "key1")
+	checkMiss(t, cache, "key2")
+	checkHit(t, cache, "key3", value)
+
+	stats := cache.Stats()
+	require.EqualValues(t, 0, stats.Removes)
+	require.EqualValues(t, 2, stats.TagRemoves)
+	require.Len(t, cache.tagMap, 1)
+
+	// item tags and their index entries count toward capacity
+	require.EqualValues(t, kvSize+len("blue")+len("blue")+len("key3"), stats.CurrentCapacity)
+
+	// tombstones carrying a tag are neither removed nor counted
+	cache.Merge(&Item{Key: "key4", Timestamp: 1, Deleted: true, Tags: []string{"blue"}})
+	require.EqualValues(t, 1, cache.InvalidateTags([]string{"blue"}))
+	require.NotNil(t, cache.Fetch("key4"))
+	require.EqualValues(t, 3, cache.Stats().TagRemoves)
+}
+
+func TestCacheTagIndexCleanup(t *testing.T) {
+	t.Parallel()
+
+	tagSize := len("tag") + len("tag") + len("key1")
+	cache := NewLRUCache(Config{Capacity: uint64(kvSize + tagSize)})
+
+	// updating an item replaces its tags
+	setTagged(cache, "key1", value, "tag")
+	setTagged(cache, "key1", value, "tag")
+	require.Len(t, cache.tagMap["tag"], 1)
+	require.EqualValues(t, kvSize+tagSize, cache.Stats().CurrentCapacity)
+
+	// eviction drops the evicted item from the index
+	setTagged(cache, "key2", value, "tag")
+	checkMiss(t, cache, "key1")
+	require.Len(t, cache.tagMap["tag"], 1)
+	require.NotNil(t, cache.tagMap["tag"]["key2"])
+
+	// removal drops the index entry
+	remove(cache, "key2")
+	require.Empty(t, cache.tagMap)
+	require.EqualValues(t, 0, cache.Stats().CurrentCapacity)
+	require.EqualValues(t, 0, cache.InvalidateTags([]string{"tag"}))
+}
+
 func BenchmarkCacheRemove(b *testing.B) {
 	cache := NewLRUCache(Config{Capacity: 100000})
 
@@ -287,6 +379,227 @@ func TestCacheSet(t *testing.T) {
 	require.EqualValues(t, 6, item.VersionID())
 }
 
+func TestCacheGeneration(t *testing.T) {
+	t.Parallel()
+
+	generation := &Generation{}
+	cache := NewLRUCache(Config{Capacity: 2 * kvSize, Generation: generation})
+
+	set(cache, "key1", value)
+	checkHit(t, cache, "key1", value)
+
+	require.EqualValues(t, 1, generation.Bump())
+	checkMiss(t, cache, "key1")
+	require.Nil(t, cache.Remove("key1"))
+
+	// old generation items are still stored until they age out
+	checkSize(t, cache, 1)
+	set(cache, "key2", value)
+	set(cache, "key3", value)
+	checkSize(t, cache, 2)
+
+	stats := cache.Stats()
+	require.EqualValues(t, 1, stats.Evicts)
+	require.EqualValues(t, 2*kvSize, stats.CurrentCapacity)
+
+	// returning to a previous generation makes its remaining items reachable
+	set(cache, "key1", value)
+	generation.Store(0)
+	checkMiss(t, cache, "key1")
+	checkMiss(t, cache, "key2")
+	generation.Store(1)
+	checkHit(t, cache, "key1", value)
+}
+
+func TestCompareAndSwapGeneration(t *testing.T) {
+	t.Parallel()
+
+	generation := &Generation{}
+	cache := NewLRUCache(Config{Capacity: 100000, Generation: generation})
+
+	set(cache, "key", value)
+	item := cache.Get("key")
+	require.EqualValues(t, 1, item.VersionID())
+
+	generation.Bump()
+
+	// an unreachable item does not conflict
+	stale := NewItem("key", value, 7)
+	require.True(t, cache.CompareAndSwap(stale))
+	checkHit(t, cache, "key", value)
+}
+
+func TestCacheTTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	cache := NewLRUCache(Config{Capacity: 100000, TTL: time.Minute})
+	cache.now = func() time.Time { return now }
+
+	set(cache, "key1", value)
+	item := cache.Get("key1")
+	require.Equal(t, now.Add(time.Minute).UnixNano(), item.ExpiresAt().UnixNano())
+
+	now = now.Add(30 * time.Second)
+	set(cache, "key2", value)
+
+	now = now.Add(30 * time.Second)
+	checkMiss(t, cache, "key1")
+	checkHit(t, cache, "key2", value)
+
+	stats := cache.Stats()
+	require.EqualValues(t, 1, stats.Expires)
+	require.EqualValues(t, 1*kvSize, stats.CurrentCapacity)
+	checkSize(t, cache, 1)
+}
+
+func TestCacheSoftTTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	cache := NewLRUCache(Config{Capacity: 100000, TTL: time.Minute, SoftTTL: 10 * time.Second})
+	cache.now = func() time.Time { return now }
+
+	set(cache, "key1", value)
+	item := cache.Get("key1")
+	require.Equal(t, now.Add(10*time.Second).UnixNano(), item.SoftExpiresAt().UnixNano())
+	require.False(t, item.Stale(now))
+
+	// stale items are still hits, until they expire
+	now = now.Add(10 * time.Second)
+	item = cache.Get("key1")
+	require.True(t, item.Stale(now))
+	checkHit(t, cache, "key1", value)
+
+	// and are fresh again once set
+	set(cache, "key1", value)
+	require.False(t, cache.Get("key1").Stale(now))
+
+	now = now.Add(time.Minute)
+	checkMiss(t, cache, "key1")
+
+	stats := cache.Stats()
+	require.EqualValues(t, 4, stats.Hits)
+	require.EqualValues(t, 2, stats.StaleHits)
+}
+
+func TestItemRecompute(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	cache := NewLRUCache(Config{Capacity: 100000, TTL: time.Minute})
+	cache.now = func() time.Time { return now }
+
+	item := NewItem("key", value, 0)
+	item.Delta = time.Second
+	cache.Set(item)
+	item = cache.Get("key")
+	require.Equal(t, time.Second, item.Delta)
+
+	// far from expiry, only the unlikeliest draws recompute
+	require.False(t, item.xfetch(now, 1, 0.5))
+	require.False(t, item.xfetch(now, 1, 0.999999))
+	require.True(t, item.xfetch(now, 10, 0.999999))
+
+	// near it, most do, more so with a larger beta
+	near := now.Add(time.Minute - time.Second)
+	require.True(t, item.xfetch(near, 1, 0.7))
+	require.False(t, item.xfetch(near, 1, 0.5))
+	require.True(t, item.xfetch(near, 2, 0.5))
+
+	// items without a delta, or an expiry, are not recomputed early
+	item.Delta = 0
+	require.False(t, item.xfetch(near, 1, 0.99))
+	forever := NewItem("key", value, 0)
+	forever.Delta = time.Second
+	require.False(t, forever.xfetch(near, 1, 0.99))
+}
+
+func TestCacheFIFO(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRUCache(Config{Capacity: 2 * kvSize, Policy: PolicyFIFO})
+
+	set(cache, "key1", value)
+	set(cache, "key2", value)
+	checkHit(t, cache, "key1", value)
+	set(cache, "key3", value)
+
+	// reads do not protect key1 from eviction
+	checkMiss(t, cache, "key1")
+	checkHit(t, cache, "key2", value)
+	checkHit(t, cache, "key3", value)
+}
+
+func TestCacheTakeLoad(t *testing.T) {
+	t.Parallel()
+
+	from := NewLRUCache(Config{Capacity: 100000})
+	to := NewLRUCache(Config{Capacity: 100000})
+
+	from.Set(NewItem("key1", value, 6))
+	set(from, "key2", value)
+	require.ElementsMatch(t, []string{"key1", "key2"}, from.Keys())
+
+	item := from.Take("key1")
+	require.NotNil(t, item)
+	require.Nil(t, from.Take("key1"))
+	checkMiss(t, from, "key1")
+
+	// loading keeps the version, and does not replace a newer item
+	require.True(t, to.Load(item))
+	require.EqualValues(t, 7, to.Get("key1").VersionID())
+	require.False(t, to.Load(NewItem("key1", []byte("other"), 1)))
+	checkHit(t, to, "key1", value)
+
+	fromStats := from.Stats()
+	require.EqualValues(t, 0, fromStats.Removes)
+	require.EqualValues(t, 1*kvSize, fromStats.CurrentCapacity)
+
+	toStats := to.Stats()
+	require.EqualValues(t, 0, toStats.Sets)
+	require.EqualValues(t, 1*kvSize, toStats.CurrentCapacity)
+}
+
+func TestCacheSetCapacity(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRUCache(Config{Capacity: 3 * kvSize})
+
+	set(cache, "key1", value)
+	set(cache, "key2", value)
+	set(cache, "key3", value)
+
+	cache.SetCapacity(1 * kvSize)
+	checkSize(t, cache, 1)
+	checkHit(t, cache, "key3", value)
+	require.EqualValues(t, 2, cache.Stats().Evicts)
+
+	cache.SetCapacity(2 * kvSize)
+	set(cache, "key4", value)
+	checkSize(t, cache, 2)
+}
+
+func TestCacheTrim(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRUCache(Config{Capacity: 3 * kvSize})
+
+	set(cache, "key1", value)
+	set(cache, "key2", value)
+	set(cache, "key3", value)
+
+	require.EqualValues(t, 2*kvSize, cache.Trim(1*kvSize))
+	checkSize(t, cache, 1)
+	checkHit(t, cache, "key3", value)
+
+	// the capacity is unchanged
+	set(cache, "key4", value)
+	set(cache, "key5", value)
+	checkSize(t, cache, 3)
+	require.EqualValues(t, 3*kvSize, cache.Stats().MaxCapacity)
+}
+
 func TestCompareAndSwap(t *testing.T) {
 	cache := NewLRUCache(Config{Capacity: 100000})
 
@@ -319,6 +632,80 @@ func TestCompareAndSwap(t *testing.T) {
 	require.True(t, swapped)
 }
 
+func TestCacheFill(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRUCache(Config{Capacity: 100000})
+	valid := func() bool { return true }
+
+	// misses are filled
+	require.True(t, cache.Fill(NewItem("key", []byte("loaded"), 0), valid))
+	checkHit(t, cache, "key", []byte("loaded"))
+	require.EqualValues(t, 1, cache.Get("key").VersionID())
+
+	// but never over a reachable item
+	require.False(t, cache.Fill(NewItem("key", []byte("other"), 0), valid))
+	checkHit(t, cache, "key", []byte("loaded"))
+
+	// and not once valid is false
+	require.False(t, cache.Fill(NewItem("key2", []byte("loaded"), 0), func() bool { return false }))
+	require.Nil(t, cache.Get("key2"))
+}
+
+func TestCacheMerge(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRUCache(Config{Capacity: 100000})
+
+	require.True(t, cache.Merge(&Item{Key: "key", Value: []byte{2}, Timestamp: 2}))
+	checkHit(t, cache, "key", []byte{2})
+
+	// older and concurrent writes lose
+	require.False(t, cache.Merge(&Item{Key: "key", Value: []byte{1}, Timestamp: 1}))
+	require.False(t, cache.Merge(&Item{Key: "key", Value: []byte{3}, Timestamp: 2}))
+	checkHit(t, cache, "key", []byte{2})
+
+	// deletes are kept, hidden from Get but not from Fetch
+	require.True(t, cache.Merge(&Item{Key: "key", Timestamp: 3, Deleted: true}))
+	checkMiss(t, cache, "key")
+	item := cache.Fetch("key")
+	require.NotNil(t, item)
+	require.True(t, item.Deleted)
+	require.EqualValues(t, 3, item.Timestamp)
+
+	// a write older than the delete cannot resurrect the key
+	require.False(t, cache.Merge(&Item{Key: "key", Value: []byte{2}, Timestamp: 2}))
+	checkMiss(t, cache, "key")
+
+	require.True(t, cache.Merge(&Item{Key: "key", Value: []byte{4}, Timestamp: 4}))
+	checkHit(t, cache, "key", []byte{4})
+
+	// a deleted item does not block a swap
+	cache.Merge(&Item{Key: "other", Timestamp: 1, Deleted: true})
+	require.True(t, cache.CompareAndSwap(&Item{Key: "other", Value: []byte{1}}))
+	checkHit(t, cache, "other", []byte{1})
+}
+
+func TestCacheScan(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRUCache(Config{Capacity: 100000})
+	set(cache, "a", value)
+	cache.Merge(&Item{Key: "b", Timestamp: 1, Deleted: true})
+
+	items := make(map[string]*Item)
+	cache.Scan(func(item *Item) {
+		items[item.Key] = item
+	})
+	require.Len(t, items, 2)
+	require.Equal(t, value, items["a"].Value)
+	require.True(t, items["b"].Deleted)
+
+	// scanning counts neither hits nor misses
+	require.Zero(t, cache.Stats().Hits)
+	require.Zero(t, cache.Stats().Misses)
+}
+
 func TestCacheClear(t *testing.T) {
 	t.Parallel()
 
@@ -341,6 +728,62 @@ func TestCacheClear(t *testing.T) {
 	checkSize(t, cache, 0)
 }
 
+func TestCacheEvents(t *testing.T) {
+	t.Parallel()
+
+	var events []Event
+	now := time.Now()
+	cache := NewLRUCache(Config{
+		Capacity: 3 * kvSize,
+		TTL:      time.Minute,
+		Listener: func(event Event) {
+			events = append(events, event)
+		},
+	})
+	cache.now = func() time.Time { return now }
+
+	set(cache, "key1", value)
+	set(cache, "key1", value)
+	set(cache, "key2", value)
+	cache.Remove("key2")
+	set(cache, "key3", value)
+	set(cache, "key4", value)
+	set(cache, "key5", value)
+
+	now = now.Add(time.Minute)
+	checkMiss(t, cache, "key5")
+	cache.Clear()
+
+	// moving items between caches is not a change
+	item := &Item{Key: "key6", Value: value}
+	cache.Load(item)
+	cache.Take("key6")
+
+	require.Equal(t, []Event{
+		{Type: EventSet, Key: "key1", VersionID: 1},
+		{Type: EventSet, Key: "key1", VersionID: 1},
+		{Type: EventSet, Key: "key2", VersionID: 1},
+		{Type: EventRemove, Key: "key2", VersionID: 1},
+		{Type: EventSet, Key: "key3", VersionID: 1},
+		{Type: EventSet, Key: "key4", VersionID: 1},
+		{Type: EventSet, Key: "key5", VersionID: 1},
+		{Type: EventEvict, Key: "key1", VersionID: 1},
+		{Type: EventExpire, Key: "key5", VersionID: 1},
+		{Type: EventClear},
+	}, events)
+
+	// replicated deletes report the item they remove, if any, with its
+	// write time
+	events = nil
+	cache.Merge(&Item{Key: "key", Value: value, Timestamp: 1})
+	cache.Merge(&Item{Key: "key", Timestamp: 2, Deleted: true})
+	cache.Merge(&Item{Key: "other", Timestamp: 1, Deleted: true})
+	require.Equal(t, []Event{
+		{Type: EventSet, Key: "key", Timestamp: 1},
+		{Type: EventRemove, Key: "key", Timestamp: 1},
+	}, events)
+}
+
 func BenchmarkCacheClear(b *testing.B) {
 	cache := NewLRUCache(Config{Capacity: 100000})
 
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Recompute reports whether an item should be recomputed before it expires,
+// by the XFetch algorithm of "Optimal Probabilistic Cache Stampede
+// Prevention" (Vattani et al.). The chance rises as the item nears its
+// expiry, or its soft expiry if it has one, and with its Delta, the time it
+// took to compute; beta scales it, with 1 the optimum. Items that do not
+// expire, or whose Delta is unknown, are not recomputed early.
+func (i *Item) Recompute(now time.Time, beta float64) bool {
+	return i.xfetch(now, beta, rand.Float64())
+}
+
+// xfetch is Recompute, with r a uniform random number in [0, 1).
+func (i *Item) xfetch(now time.Time, beta, r float64) bool {
+	expiry := i.softExpiresAt
+	if expiry == 0 {
+		expiry = i.expiresAt
+	}
+	if expiry == 0 || i.Delta <= 0 || beta <= 0 {
+		return false
+	}
+
+	// -ln(1-r) is exponentially distributed, and never infinite
+	gap := float64(i.Delta) * beta * -math.Log(1-r)
+	return float64(now.UnixNano())+gap >= float64(expiry)
+}
@@ -0,0 +1,213 @@
+package caches
+
+import (
+	"sync/atomic"
+
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/consistenthash"
+)
+
+// boundedCacheForKey returns the cache for key when caches have bounded
+// loads. If the key's owner is over its load bound, the key spills to the
+// next cache in its walk that is not.
+//
+// A key is kept in at most one cache: writes remove it from the other
+// caches, and other operations first move it to the chosen cache. Reads
+// look in the caches earlier in the key's walk, and only look in every
+// cache on a miss.
+func (n *Namespace) boundedCacheForKey(key string) cache.Cache {
+	s := n.caches
+
+	walker, ok := s.hash.(consistenthash.Walker)
+	if !ok {
+		return n.cacheMap[s.hash.GetNode(key)]
+	}
+
+	cacheIDs := consistenthash.NewBounded(walker, s.boundedLoad, s.loads).Candidates(key)
+	if len(cacheIDs) > 1 {
+		atomic.AddUint64(&s.spills, 1)
+	}
+
+	candidates := make([]cache.Cache, len(cacheIDs))
+	for i, cacheID := range cacheIDs {
+		candidates[i] = n.cacheMap[cacheID]
+	}
+
+	return &boundedCache{
+		ns:         n,
+		cacheID:    cacheIDs[len(cacheIDs)-1],
+		to:         candidates[len(candidates)-1],
+		candidates: candidates[:len(candidates)-1],
+	}
+}
+
+// boundedCache is the cache chosen for a key under bounded loads. Each
+// operation counts towards the chosen cache's load while it runs.
+// Operations that are not about a single key apply to the chosen cache.
+type boundedCache struct {
+	ns *Namespace
+
+	cacheID string
+	to      cache.Cache
+
+	// candidates are the caches earlier in the key's walk than to
+	candidates []cache.Cache
+}
+
+// acquire locks key and counts the operation towards the chosen cache's
+// load.
+func (c *boundedCache) acquire(key string) (release func()) {
+	unlock := c.ns.caches.locks.lock(key)
+	done := c.ns.caches.loads.Acquire(c.cacheID)
+
+	return func() {
+		done()
+		unlock()
+	}
+}
+
+// gather moves key to the chosen cache from wherever it is.
+func (c *boundedCache) gather(key string) bool {
+	c.ns.caches.RLock()
+	defer c.ns.caches.RUnlock()
+
+	for _, other := range c.ns.cacheMap {
+		if other != c.to && move(key, other, c.to) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeOthers removes key from every cache but the chosen one.
+func (c *boundedCache) removeOthers(key string) {
+	c.ns.caches.RLock()
+	defer c.ns.caches.RUnlock()
+
+	for _, other := range c.ns.cacheMap {
+		if other != c.to {
+			other.Take(key)
+		}
+	}
+}
+
+func (c *boundedCache) Get(key string) *cache.Item {
+	defer c.acquire(key)()
+
+	for _, from := range c.candidates {
+		move(key, from, c.to)
+	}
+
+	item := c.to.Get(key)
+	if item == nil && c.gather(key) {
+		item = c.to.Get(key)
+	}
+	return item
+}
+
+func (c *boundedCache) Set(item *cache.Item) {
+	defer c.acquire(item.Key)()
+
+	c.to.Set(item)
+	c.removeOthers(item.Key)
+}
+
+func (c *boundedCache) CompareAndSwap(item *cache.Item) bool {
+	defer c.acquire(item.Key)()
+
+	c.gather(item.Key)
+	if !c.to.CompareAndSwap(item) {
+		return false
+	}
+	c.removeOthers(item.Key)
+	return true
+}
+
+func (c *boundedCache) Fill(item *cache.Item, valid func() bool) bool {
+	defer c.acquire(item.Key)()
+
+	c.gather(item.Key)
+	if !c.to.Fill(item, valid) {
+		return false
+	}
+	c.removeOthers(item.Key)
+	return true
+}
+
+func (c *boundedCache) Remove(key string) *cache.Item {
+	defer c.acquire(key)()
+
+	c.gather(key)
+	return c.to.Remove(key)
+}
+
+func (c *boundedCache) Take(key string) *cache.Item {
+	defer c.acquire(key)()
+
+	c.gather(key)
+	return c.to.Take(key)
+}
+
+func (c *boundedCache) Load(item *cache.Item) bool {
+	defer c.acquire(item.Key)()
+
+	c.gather(item.Key)
+	return c.to.Load(item)
+}
+
+func (c *boundedCache) Fetch(key string) *cache.Item {
+	defer c.acquire(key)()
+
+	for _, from := range c.candidates {
+		move(key, from, c.to)
+	}
+
+	item := c.to.Fetch(key)
+	if item == nil && c.gather(key) {
+		item = c.to.Fetch(key)
+	}
+	return item
+}
+
+func (c *boundedCache) Merge(item *cache.Item) bool {
+	defer c.acquire(item.Key)()
+
+	c.gather(item.Key)
+	return c.to.Merge(item)
+}
+
+func (c *boundedCache) RemoveMatching(match func(key string) bool) uint64 {
+	return c.to.RemoveMatching(match)
+}
+
+func (c *boundedCache) InvalidateTags(tags []string) uint64 {
+	return c.to.InvalidateTags(tags)
+}
+
+func (c *boundedCache) Clear() {
+	c.to.Clear()
+}
+
+func (c *boundedCache) Size() uint64 {
+	return c.to.Size()
+}
+
+func (c *boundedCache) Stats() cache.Stats {
+	return c.to.Stats()
+}
+
+func (c *boundedCache) Keys() []string {
+	return c.to.Keys()
+}
+
+func (c *boundedCache) Scan(fn func(item *cache.Item)) {
+	c.to.Scan(fn)
+}
+
+func (c *boundedCache) SetCapacity(capacity uint64) {
+	c.to.SetCapacity(capacity)
+}
+
+func (c *boundedCache) Trim(size uint64) uint64 {
+	return c.to.Trim(size)
+}
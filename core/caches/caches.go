@@ -2,7 +2,10 @@ package caches
 
 import (
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/tescherm/mc/core/cache"
 	"github.com/tescherm/mc/core/consistenthash"
@@ -11,17 +14,85 @@ import (
 type Config struct {
 	CacheCount int
 	Replicas   int
-	Capacity   uint64
+
+	// Capacity is the capacity of the default namespace, in bytes
+	Capacity uint64
+
+	// Namespaces maps additional namespace names to their capacity, in bytes
+	Namespaces map[string]uint64
+
+	// Policy is the eviction policy of every cache
+	Policy cache.Policy
+
+	// TTL is how long items live after they are set; zero if forever
+	TTL time.Duration
+
+	// SoftTTL, if positive, is how long items are fresh after they are set,
+	// before they are stale
+	SoftTTL time.Duration
+
+	// HashStrategy is how keys are mapped to caches
+	HashStrategy consistenthash.Strategy
+
+	// BoundedLoad, if positive, is the epsilon of consistent hashing with
+	// bounded loads: a key whose cache has more than (1+BoundedLoad) times
+	// the average number of in-flight operations spills to another cache.
+	// It requires the ring or rendezvous hash strategy.
+	BoundedLoad float64
+
+	// Listener, if set, is called with every change to the caches' contents
+	Listener Listener
+
+	// Loader, if set, loads the keys the caches miss from their origin.
+	// LoadTimeout bounds each load, 5s if zero.
+	Loader      Loader
+	LoadTimeout time.Duration
+
+	// NegativeTTL, if positive, is how long keys the origin does not have
+	// are remembered, and not loaded again
+	NegativeTTL time.Duration
 }
 
+// Listener is called with changes to the items of a namespace, while the
+// cache holding them is locked: it must not block or call the caches. A
+// generation change is reported as a clear.
+type Listener func(namespace string, event cache.Event)
+
 type Stats struct {
 	Evicts          uint64
 	Removes         uint64
+	PatternRemoves  uint64
+	TagRemoves      uint64
+	Expires         uint64
 	Clears          uint64
 	Sets            uint64
 	Hits            uint64
 	Misses          uint64
+	StaleHits       uint64
 	CurrentCapacity uint64
+	MaxCapacity     uint64
+
+	// Spills is the number of operations that went to a cache other than
+	// the key's owner because of bounded loads
+	Spills uint64
+
+	// Loads are the stats of the loads of missed keys
+	Loads LoadStats
+
+	// Caches are the per cache stats, summed over namespaces
+	Caches []cache.Stats
+
+	Namespaces map[string]NamespaceStats
+}
+
+type NamespaceStats struct {
+	// Capacity is the namespace quota, in bytes
+	Capacity uint64
+
+	Generation uint64
+
+	// Rebalanced is the capacity moved between caches by Rebalance, in bytes
+	Rebalanced uint64
 
 	Caches []cache.Stats
 }
@@ -29,89 +100,212 @@ type Stats struct {
 type Caches struct {
 	sync.RWMutex
 
-	hash *consistenthash.ConsistentHash
+	hash consistenthash.Hash
+
+	cacheIDs   []string
+	namespaces map[string]*Namespace
+
+	// migration is set while keys move after a Reshard
+	migration *Migration
+
+	boundedLoad float64
+	loads       *consistenthash.InFlight
+	locks       keyLocks
+	spills      uint64
 
-	cacheIDs []string
-	cacheMap map[string]cache.Cache
+	policy   cache.Policy
+	ttl      time.Duration
+	softTTL  time.Duration
+	listener Listener
+
+	// loader is nil unless missed keys are loaded from an origin
+	loader *loader
 }
 
 func New(config Config) *Caches {
-	cacheMap := make(map[string]cache.Cache, config.CacheCount)
 	cacheIDs := make([]string, config.CacheCount)
+	for i := 0; i < config.CacheCount; i++ {
+		cacheIDs[i] = fmt.Sprintf("cache-%d", i)
+	}
 
-	cacheCapacity := config.Capacity / uint64(config.CacheCount)
+	hash := consistenthash.NewHash(config.HashStrategy, cacheIDs, config.Replicas)
 
-	for i := 0; i < config.CacheCount; i++ {
-		cacheID := fmt.Sprintf("cache-%d", i)
-		cacheIDs[i] = cacheID
-		cacheMap[cacheID] = cache.NewLRUCache(cache.Config{
-			Capacity: cacheCapacity,
-		})
+	s := &Caches{
+		cacheIDs:   cacheIDs,
+		namespaces: make(map[string]*Namespace, len(config.Namespaces)+1),
+		hash:       hash,
+		policy:     config.Policy,
+		ttl:        config.TTL,
+		softTTL:    config.SoftTTL,
+		listener:   config.Listener,
 	}
 
-	hash := consistenthash.New(cacheIDs, config.Replicas)
+	if config.Loader != nil {
+		s.loader = newLoader(config.Loader, config.LoadTimeout, config.NegativeTTL)
+	}
+
+	if config.BoundedLoad > 0 {
+		s.boundedLoad = config.BoundedLoad
+		s.loads = consistenthash.NewInFlight()
+	}
 
-	return &Caches{
-		cacheMap: cacheMap,
-		cacheIDs: cacheIDs,
-		hash:     hash,
+	s.namespaces[DefaultNamespace] = newNamespace(s, DefaultNamespace, config.Capacity)
+	for name, capacity := range config.Namespaces {
+		s.namespaces[name] = newNamespace(s, name, capacity)
 	}
+
+	return s
 }
 
+// CacheForKey returns the default namespace cache for key.
 func (s *Caches) CacheForKey(key string) cache.Cache {
 	s.RLock()
 	defer s.RUnlock()
 
-	cacheID := s.hash.GetNode(key)
-	c, ok := s.cacheMap[cacheID]
-	if !ok {
-		return nil
+	return s.namespaces[DefaultNamespace].cacheForKey(key)
+}
+
+// Namespace returns the named namespace, if it exists.
+func (s *Caches) Namespace(name string) (*Namespace, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	ns, ok := s.namespaces[name]
+	return ns, ok
+}
+
+// NamespaceNames returns the sorted namespace names.
+func (s *Caches) NamespaceNames() []string {
+	s.RLock()
+	defer s.RUnlock()
+
+	names := make([]string, 0, len(s.namespaces))
+	for name := range s.namespaces {
+		names = append(names, name)
 	}
-	return c
+	sort.Strings(names)
+	return names
+}
+
+// Loads reports whether the caches load missed keys from an origin.
+func (s *Caches) Loads() bool {
+	return s.loader != nil
+}
+
+// Close releases the caches' origin connections, if any.
+func (s *Caches) Close() error {
+	return s.loader.close()
 }
 
+// Clear clears every namespace.
 func (s *Caches) Clear() {
 	s.Lock()
 	defer s.Unlock()
 
-	for _, v := range s.cacheMap {
-		v.Clear()
+	for _, ns := range s.namespaces {
+		ns.clear()
 	}
 }
 
+// Size is the number of items across all namespaces.
 func (s *Caches) Size() uint64 {
 	s.RLock()
 	defer s.RUnlock()
 
 	var total uint64
-	for _, v := range s.cacheMap {
-		total += v.Size()
+	for _, ns := range s.namespaces {
+		total += ns.size()
 	}
 	return total
 }
 
+// Trim evicts the given fraction of the contents of every cache, leaving
+// capacities unchanged, e.g. to relieve memory pressure. It returns the
+// number of bytes freed.
+func (s *Caches) Trim(fraction float64) uint64 {
+	s.RLock()
+	defer s.RUnlock()
+
+	var freed uint64
+	for _, ns := range s.namespaces {
+		for _, c := range ns.cacheMap {
+			size := c.Stats().CurrentCapacity
+			freed += c.Trim(uint64(float64(size) * (1 - fraction)))
+		}
+	}
+	return freed
+}
+
 func (s *Caches) Stats() Stats {
 	s.RLock()
 	defer s.RUnlock()
 
+	cacheIDs := s.shardIDs()
+
 	stats := Stats{
-		Caches: make([]cache.Stats, len(s.cacheIDs)),
+		Spills:     atomic.LoadUint64(&s.spills),
+		Loads:      s.loader.snapshot(),
+		Caches:     make([]cache.Stats, len(cacheIDs)),
+		Namespaces: make(map[string]NamespaceStats, len(s.namespaces)),
 	}
 
-	for i, cacheID := range s.cacheIDs {
-		c := s.cacheMap[cacheID]
-		s := c.Stats()
+	for name, ns := range s.namespaces {
+		nsStats := NamespaceStats{
+			Capacity:   ns.capacity,
+			Generation: ns.Generation(),
+			Rebalanced: ns.rebalanced,
+			Caches:     make([]cache.Stats, len(cacheIDs)),
+		}
+
+		for i, cacheID := range cacheIDs {
+			c := ns.cacheMap[cacheID]
+			s := c.Stats()
 
-		stats.Clears += s.Clears
-		stats.Removes += s.Removes
-		stats.Evicts += s.Evicts
-		stats.Misses += s.Misses
-		stats.Hits += s.Hits
-		stats.Sets += s.Sets
-		stats.CurrentCapacity += s.CurrentCapacity
+			stats.Clears += s.Clears
+			stats.Removes += s.Removes
+			stats.PatternRemoves += s.PatternRemoves
+			stats.TagRemoves += s.TagRemoves
+			stats.Expires += s.Expires
+			stats.Evicts += s.Evicts
+			stats.Misses += s.Misses
+			stats.Hits += s.Hits
+			stats.StaleHits += s.StaleHits
+			stats.Sets += s.Sets
+			stats.CurrentCapacity += s.CurrentCapacity
+			stats.MaxCapacity += s.MaxCapacity
 
-		stats.Caches[i] = s
+			stats.Caches[i] = addStats(stats.Caches[i], s)
+			nsStats.Caches[i] = s
+		}
+
+		stats.Namespaces[name] = nsStats
 	}
 
 	return stats
 }
+
+// shardIDs returns the IDs of every shard, including shards that a running
+// migration is removing.
+func (s *Caches) shardIDs() []string {
+	if s.migration == nil {
+		return s.cacheIDs
+	}
+	return append(append([]string(nil), s.cacheIDs...), s.migration.retired...)
+}
+
+func addStats(a, b cache.Stats) cache.Stats {
+	return cache.Stats{
+		Evicts:          a.Evicts + b.Evicts,
+		Removes:         a.Removes + b.Removes,
+		PatternRemoves:  a.PatternRemoves + b.PatternRemoves,
+		TagRemoves:      a.TagRemoves + b.TagRemoves,
+		Expires:         a.Expires + b.Expires,
+		Clears:          a.Clears + b.Clears,
+		Sets:            a.Sets + b.Sets,
+		Hits:            a.Hits + b.Hits,
+		Misses:          a.Misses + b.Misses,
+		StaleHits:       a.StaleHits + b.StaleHits,
+		CurrentCapacity: a.CurrentCapacity + b.CurrentCapacity,
+		MaxCapacity:     a.MaxCapacity + b.MaxCapacity,
+	}
+}
@@ -1,13 +1,16 @@
 package caches
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/consistenthash"
 )
 
 const kvSize = 4 + 5
@@ -64,6 +67,12 @@ func checkSize(t *testing.T, c *Caches, size int) {
 	require.EqualValues(t, size, c.Size())
 }
 
+func defaultNamespace(t *testing.T, c *Caches) *Namespace {
+	ns, ok := c.Namespace(DefaultNamespace)
+	require.True(t, ok)
+	return ns
+}
+
 func checkHitInRange(t *testing.T, c *Caches, key string, vals [][]byte) {
 	keyCache := c.CacheForKey(key)
 	i := get(keyCache, key)
@@ -183,6 +192,570 @@ func TestCachesRemove(t *testing.T) {
 	checkSize(t, caches, 0)
 }
 
+func TestCachesRemoveMatching(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount: 5,
+		Capacity:   100000,
+		Replicas:   160,
+	})
+
+	for i := 0; i < 100; i++ {
+		set(caches, fmt.Sprintf("tenant:1:key%d", i), value)
+		set(caches, fmt.Sprintf("tenant:2:key%d", i), value)
+	}
+
+	var progressCalls int
+	removed, err := defaultNamespace(t, caches).RemoveMatching(context.Background(), MatchPrefix("tenant:1:"), func(done, total int, removed uint64) {
+		progressCalls++
+		require.Equal(t, progressCalls, done)
+		require.Equal(t, 5, total)
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 100, removed)
+	require.Equal(t, 5, progressCalls)
+
+	checkMiss(t, caches, "tenant:1:key0")
+	checkHit(t, caches, "tenant:2:key0", value)
+	checkSize(t, caches, 100)
+
+	stats := caches.Stats()
+	require.EqualValues(t, 0, stats.Removes)
+	require.EqualValues(t, 100, stats.PatternRemoves)
+}
+
+func TestCachesRemoveMatchingCancelled(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount: 5,
+		Capacity:   100000,
+		Replicas:   160,
+	})
+
+	set(caches, "key", value)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	removed, err := defaultNamespace(t, caches).RemoveMatching(ctx, MatchPrefix(""), nil)
+	require.Equal(t, context.Canceled, err)
+	require.EqualValues(t, 0, removed)
+	checkSize(t, caches, 1)
+}
+
+func TestCachesInvalidateTags(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount: 5,
+		Capacity:   100000,
+		Replicas:   160,
+	})
+
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("key%d", i)
+		tag := "even"
+		if i%2 == 1 {
+			tag = "odd"
+		}
+		caches.CacheForKey(key).Set(&cache.Item{
+			Key:   key,
+			Value: value,
+			Tags:  []string{tag},
+		})
+	}
+
+	removed := defaultNamespace(t, caches).InvalidateTags([]string{"odd"})
+	require.EqualValues(t, 25, removed)
+
+	checkHit(t, caches, "key0", value)
+	checkMiss(t, caches, "key1")
+	checkSize(t, caches, 25)
+
+	stats := caches.Stats()
+	require.EqualValues(t, 25, stats.TagRemoves)
+}
+
+func TestCachesNamespaces(t *testing.T) {
+	t.Parallel()
+
+	cacheCount := 1
+
+	caches := New(Config{
+		CacheCount: cacheCount,
+		Capacity:   uint64(cacheCount * (1 * kvSize)),
+		Replicas:   160,
+		Namespaces: map[string]uint64{
+			"team": uint64(cacheCount * (3 * kvSize)),
+		},
+	})
+
+	require.Equal(t, []string{"default", "team"}, caches.NamespaceNames())
+
+	team, ok := caches.Namespace("team")
+	require.True(t, ok)
+	require.EqualValues(t, 3*kvSize, team.Capacity())
+
+	_, ok = caches.Namespace("other")
+	require.False(t, ok)
+
+	// the same key is independent in each namespace
+	set(caches, "key0", []byte("dflt0"))
+	team.CacheForKey("key0").Set(&cache.Item{Key: "key0", Value: value})
+	checkHit(t, caches, "key0", []byte("dflt0"))
+	require.EqualValues(t, value, get(team.CacheForKey("key0"), "key0"))
+
+	// filling the team namespace does not evict from the default namespace
+	for i := 1; i < 5; i++ {
+		key := fmt.Sprintf("key%d", i)
+		team.CacheForKey(key).Set(&cache.Item{Key: key, Value: value})
+	}
+	checkHit(t, caches, "key0", []byte("dflt0"))
+	require.EqualValues(t, 3, team.Size())
+	checkSize(t, caches, 4)
+
+	stats := caches.Stats()
+	require.EqualValues(t, 2, stats.Evicts)
+	require.EqualValues(t, 0, stats.Namespaces["default"].Caches[0].Evicts)
+	require.EqualValues(t, 2, stats.Namespaces["team"].Caches[0].Evicts)
+	require.EqualValues(t, 3*kvSize, stats.Namespaces["team"].Capacity)
+	require.EqualValues(t, 4*kvSize, stats.Caches[0].CurrentCapacity)
+
+	// clearing a namespace leaves the others alone
+	team.Clear()
+	require.EqualValues(t, 0, team.Size())
+	checkHit(t, caches, "key0", []byte("dflt0"))
+}
+
+func TestCachesNamespaceGeneration(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount: 5,
+		Capacity:   100000,
+		Replicas:   160,
+		Namespaces: map[string]uint64{
+			"team": 100000,
+		},
+	})
+	ns := defaultNamespace(t, caches)
+	team, _ := caches.Namespace("team")
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%d", i)
+		set(caches, key, value)
+		team.CacheForKey(key).Set(&cache.Item{Key: key, Value: value})
+	}
+
+	require.EqualValues(t, 0, ns.Generation())
+	require.EqualValues(t, 1, ns.BumpGeneration())
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%d", i)
+		checkMiss(t, caches, key)
+		require.NotNil(t, team.CacheForKey(key).Get(key))
+	}
+
+	ns.SetGeneration(0)
+	checkHit(t, caches, "key0", value)
+
+	stats := caches.Stats()
+	require.EqualValues(t, 0, stats.Namespaces["default"].Generation)
+	require.EqualValues(t, 0, stats.Namespaces["team"].Generation)
+}
+
+func checkOwners(t *testing.T, c *Caches, keys int) {
+	ns := defaultNamespace(t, c)
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("key%d", i)
+		owner := ns.cacheMap[c.hash.GetNode(key)]
+		require.EqualValues(t, value, get(owner, key), "key=%s", key)
+	}
+}
+
+func TestCachesReshardGrow(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount: 4,
+		Capacity:   100000,
+		Replicas:   160,
+	})
+
+	for i := 0; i < 1000; i++ {
+		set(caches, fmt.Sprintf("key%d", i), value)
+	}
+
+	m, err := caches.Reshard(8)
+	require.NoError(t, err)
+	require.True(t, caches.Migrating())
+
+	_, err = caches.Reshard(2)
+	require.Equal(t, ErrMigrating, err)
+
+	// keys are found before the migration moves them
+	for i := 0; i < 100; i++ {
+		checkHit(t, caches, fmt.Sprintf("key%d", i), value)
+	}
+	checkSize(t, caches, 1000)
+
+	var done, total int
+	moved := m.Run(func(d, t int, _ uint64) {
+		done, total = d, t
+	})
+	require.Equal(t, 4, done)
+	require.Equal(t, 4, total)
+	require.True(t, moved > 0)
+	require.False(t, caches.Migrating())
+
+	checkSize(t, caches, 1000)
+	checkOwners(t, caches, 1000)
+
+	stats := caches.Stats()
+	require.Len(t, stats.Caches, 8)
+	require.EqualValues(t, 0, stats.Evicts)
+	require.EqualValues(t, 1000, stats.Sets)
+}
+
+func TestCachesReshardStrategies(t *testing.T) {
+	t.Parallel()
+
+	strategies := []consistenthash.Strategy{
+		consistenthash.StrategyRing,
+		consistenthash.StrategyJump,
+		consistenthash.StrategyRendezvous,
+		consistenthash.StrategyMaglev,
+	}
+
+	for _, strategy := range strategies {
+		caches := New(Config{
+			CacheCount:   3,
+			Capacity:     100000,
+			Replicas:     160,
+			HashStrategy: strategy,
+		})
+
+		for i := 0; i < 500; i++ {
+			set(caches, fmt.Sprintf("key%d", i), value)
+		}
+
+		m, err := caches.Reshard(5)
+		require.NoError(t, err)
+		m.Run(nil)
+
+		checkSize(t, caches, 500)
+		checkOwners(t, caches, 500)
+	}
+}
+
+func TestCachesReshardShrink(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount: 8,
+		Capacity:   100000,
+		Replicas:   160,
+	})
+
+	for i := 0; i < 1000; i++ {
+		set(caches, fmt.Sprintf("key%d", i), value)
+	}
+
+	m, err := caches.Reshard(3)
+	require.NoError(t, err)
+
+	// removed shards still count while the migration runs
+	require.Len(t, caches.Stats().Caches, 8)
+
+	m.Run(nil)
+
+	checkSize(t, caches, 1000)
+	checkOwners(t, caches, 1000)
+	require.Len(t, defaultNamespace(t, caches).cacheMap, 3)
+	require.Len(t, caches.Stats().Caches, 3)
+}
+
+func TestCachesReshardWrites(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount: 2,
+		Capacity:   100000,
+		Replicas:   160,
+	})
+
+	for i := 0; i < 100; i++ {
+		set(caches, fmt.Sprintf("key%d", i), []byte("old"))
+	}
+
+	m, err := caches.Reshard(5)
+	require.NoError(t, err)
+
+	// writes during the migration win over the copies being moved
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if i%2 == 0 {
+			set(caches, key, value)
+		} else {
+			caches.CacheForKey(key).Remove(key)
+		}
+	}
+
+	m.Run(nil)
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if i%2 == 0 {
+			checkHit(t, caches, key, value)
+		} else {
+			checkMiss(t, caches, key)
+		}
+	}
+	checkSize(t, caches, 50)
+}
+
+func TestCachesReshardConcurrency(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount: 3,
+		Capacity:   1000000,
+		Replicas:   160,
+	})
+
+	for i := 0; i < 1000; i++ {
+		set(caches, fmt.Sprintf("key%d", i), value)
+	}
+
+	m, err := caches.Reshard(7)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprintf("key%d", i)
+				require.EqualValues(t, value, get(caches.CacheForKey(key), key))
+			}
+		}()
+	}
+
+	m.Run(nil)
+	wg.Wait()
+
+	checkSize(t, caches, 1000)
+	checkOwners(t, caches, 1000)
+}
+
+func TestCachesNamespaceSetCapacity(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount: 2,
+		Capacity:   2 * 10 * kvSize,
+		Replicas:   160,
+	})
+	ns := defaultNamespace(t, caches)
+
+	for i := 0; i < 20; i++ {
+		set(caches, fmt.Sprintf("key%d", i), value)
+	}
+
+	require.NoError(t, ns.SetCapacity(2*2*kvSize))
+	require.EqualValues(t, 2*2*kvSize, ns.Capacity())
+	require.True(t, caches.Size() <= 4)
+	require.EqualValues(t, 2*2*kvSize, caches.Stats().MaxCapacity)
+
+	require.NoError(t, ns.SetCapacity(2*40*kvSize))
+	for i := 0; i < 20; i++ {
+		set(caches, fmt.Sprintf("key%d", i), value)
+	}
+	checkSize(t, caches, 20)
+
+	require.Error(t, ns.SetCapacity(0))
+
+	// the caches add up to the quota however often it is resized
+	caches = New(Config{
+		CacheCount: 3,
+		Capacity:   1000,
+		Replicas:   160,
+	})
+	ns = defaultNamespace(t, caches)
+	for _, capacity := range []uint64{997, 1001, 7, 12345, 1000} {
+		require.NoError(t, ns.SetCapacity(capacity))
+		require.EqualValues(t, capacity, caches.Stats().MaxCapacity)
+	}
+}
+
+func TestCachesRebalance(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount: 2,
+		Capacity:   2 * 100 * kvSize,
+		Replicas:   160,
+	})
+
+	config := RebalanceConfig{
+		Step:     0.1,
+		MinShare: 0.5,
+		MaxShare: 1.5,
+	}
+
+	// nothing moves without evictions
+	require.EqualValues(t, 0, caches.Rebalance(config))
+
+	// overflow cache-0 with keys it owns, half of which it then misses
+	churn(caches, ownedKeys(caches, "cache-0", 0, 200), 1)
+
+	require.EqualValues(t, 10*kvSize, caches.Rebalance(config))
+
+	stats := caches.Stats()
+	require.EqualValues(t, 110*kvSize, stats.Caches[0].MaxCapacity)
+	require.EqualValues(t, 90*kvSize, stats.Caches[1].MaxCapacity)
+	require.EqualValues(t, 200*kvSize, stats.MaxCapacity)
+	require.EqualValues(t, 10*kvSize, stats.Namespaces["default"].Rebalanced)
+
+	// without new evictions, capacity stays put
+	require.EqualValues(t, 0, caches.Rebalance(config))
+
+	// capacity moves no further than the configured bounds
+	for r := 0; r < 10; r++ {
+		churn(caches, ownedKeys(caches, "cache-0", 1000*(r+1), 400), 1)
+		caches.Rebalance(config)
+	}
+
+	stats = caches.Stats()
+	require.EqualValues(t, 150*kvSize, stats.Caches[0].MaxCapacity)
+	require.EqualValues(t, 50*kvSize, stats.Caches[1].MaxCapacity)
+}
+
+func TestCachesRebalanceHitRate(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount: 2,
+		Capacity:   2 * 100 * kvSize,
+		Replicas:   160,
+	})
+
+	// cache-0 evicts more, but its reads hit its recent keys, while
+	// cache-1 misses a third of its reads
+	busy := ownedKeys(caches, "cache-0", 0, 400)
+	churn(caches, busy, 0)
+	churn(caches, busy[300:], 20)
+	churn(caches, ownedKeys(caches, "cache-1", 0, 150), 1)
+
+	require.EqualValues(t, 5*kvSize, caches.Rebalance(DefaultRebalanceConfig))
+
+	stats := caches.Stats()
+	require.EqualValues(t, 95*kvSize, stats.Caches[0].MaxCapacity)
+	require.EqualValues(t, 105*kvSize, stats.Caches[1].MaxCapacity)
+}
+
+// ownedKeys returns n keys owned by a cache, from the key numbered from.
+func ownedKeys(caches *Caches, cacheID string, from, n int) []string {
+	var keys []string
+	for i := from; len(keys) < n; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if caches.hash.GetNode(key) == cacheID {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// churn sets keys, and then reads them reads times each.
+func churn(caches *Caches, keys []string, reads int) {
+	for _, key := range keys {
+		set(caches, key, value)
+	}
+	for r := 0; r < reads; r++ {
+		for _, key := range keys {
+			get(caches.CacheForKey(key), key)
+		}
+	}
+}
+
+func TestCachesBoundedLoad(t *testing.T) {
+	t.Parallel()
+
+	caches := New(Config{
+		CacheCount:  4,
+		Capacity:    100000,
+		Replicas:    160,
+		BoundedLoad: 0.25,
+	})
+	ns := defaultNamespace(t, caches)
+
+	owner := caches.hash.GetNode("key")
+	set(caches, "key", value)
+	require.EqualValues(t, value, get(ns.cacheMap[owner], "key"))
+
+	// while its owner is busy, the key moves to another cache
+	var releases []func()
+	for i := 0; i < 10; i++ {
+		releases = append(releases, caches.loads.Acquire(owner))
+	}
+	checkHit(t, caches, "key", value)
+	require.Nil(t, get(ns.cacheMap[owner], "key"))
+	checkSize(t, caches, 1)
+	require.EqualValues(t, 1, caches.Stats().Spills)
+
+	for _, release := range releases {
+		release()
+	}
+
+	// writing the key returns it to its owner, leaving one copy
+	set(caches, "key", []byte("other"))
+	require.EqualValues(t, []byte("other"), get(ns.cacheMap[owner], "key"))
+	checkSize(t, caches, 1)
+
+	caches.CacheForKey("key").Remove("key")
+	checkMiss(t, caches, "key")
+	checkSize(t, caches, 0)
+}
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern string
+		key     string
+		match   bool
+	}{
+		{"tenant:*", "tenant:123:key", true},
+		{"tenant:*:key", "tenant:123:key", true},
+		{"tenant:*:key", "tenant:123:other", false},
+		{"tenant:?:key", "tenant:1:key", true},
+		{"tenant:?:key", "tenant:12:key", false},
+		{"tenant:[0-9]*", "tenant:7/a", true},
+		{"tenant:[^0-9]*", "tenant:7", false},
+		{"tenant:[!abc]", "tenant:d", true},
+		{"*", "", true},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+		{"*a*b*", "xxaxxbxx", true},
+		{"*a*b", "xxaxxbxx", false},
+	}
+
+	for _, tc := range testCases {
+		match, err := MatchGlob(tc.pattern)
+		require.NoError(t, err)
+		require.Equal(t, tc.match, match(tc.key), "pattern=%s key=%s", tc.pattern, tc.key)
+	}
+
+	_, err := MatchGlob("tenant:[0-9")
+	require.Error(t, err)
+
+	_, err = MatchGlob(`tenant:\`)
+	require.Error(t, err)
+}
+
 func BenchmarkCachesRemove(b *testing.B) {
 	caches := New(Config{
 		CacheCount: 5,
@@ -359,6 +932,171 @@ func BenchmarkCachesClear(b *testing.B) {
 	}
 }
 
+// testOrigin is a stand-in origin. If release is set, each load blocks on
+// it, after sending its key to started.
+type testOrigin struct {
+	mu     sync.Mutex
+	values map[string]string
+	loads  int
+
+	started chan string
+	release chan struct{}
+}
+
+func (o *testOrigin) Load(ctx context.Context, namespace, key string) (*cache.Item, error) {
+	o.mu.Lock()
+	o.loads++
+	value, ok := o.values[key]
+	o.mu.Unlock()
+
+	if o.release != nil {
+		o.started <- key
+		select {
+		case <-o.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if !ok {
+		return nil, nil
+	}
+	return cache.NewItem(key, []byte(value), 0), nil
+}
+
+func newLoadingCaches(origin *testOrigin, timeout, negativeTTL time.Duration) (*Caches, *Namespace) {
+	caches := New(Config{
+		CacheCount:  5,
+		Capacity:    100000,
+		Replicas:    160,
+		Loader:      origin,
+		LoadTimeout: timeout,
+		NegativeTTL: negativeTTL,
+	})
+	ns, _ := caches.Namespace(DefaultNamespace)
+	return caches, ns
+}
+
+func TestCachesLoad(t *testing.T) {
+	t.Parallel()
+
+	origin := &testOrigin{
+		values:  map[string]string{"key": "loaded"},
+		started: make(chan string, 1),
+		release: make(chan struct{}),
+	}
+	caches, ns := newLoadingCaches(origin, 0, 0)
+	require.True(t, caches.Loads())
+
+	// concurrent misses share one load
+	var wg sync.WaitGroup
+	values := make([]string, 10)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			item, err := ns.Load(context.Background(), "key")
+			if err == nil && item != nil {
+				values[i] = string(item.Value)
+			}
+		}(i)
+	}
+	<-origin.started
+	for caches.Stats().Loads.Coalesced < uint64(len(values)-1) {
+		time.Sleep(time.Millisecond)
+	}
+	close(origin.release)
+	wg.Wait()
+
+	for _, value := range values {
+		require.Equal(t, "loaded", value)
+	}
+	require.Equal(t, 1, origin.loads)
+	checkHit(t, caches, "key", []byte("loaded"))
+
+	stats := caches.Stats()
+	require.EqualValues(t, 1, stats.Loads.Loads)
+	require.EqualValues(t, 9, stats.Loads.Coalesced)
+	require.EqualValues(t, 1, stats.Loads.Latency[len(LoadBuckets)-1])
+	require.EqualValues(t, 1, stats.Sets)
+}
+
+func TestCachesLoadNegative(t *testing.T) {
+	t.Parallel()
+
+	origin := &testOrigin{values: map[string]string{}}
+	caches, ns := newLoadingCaches(origin, 0, time.Minute)
+
+	// keys the origin does not have are remembered
+	for i := 0; i < 3; i++ {
+		item, err := ns.Load(context.Background(), "key")
+		require.NoError(t, err)
+		require.Nil(t, item)
+	}
+	require.Equal(t, 1, origin.loads)
+
+	// until they are written
+	set(caches, "key", value)
+	remove(caches, "key")
+	item, err := ns.Load(context.Background(), "key")
+	require.NoError(t, err)
+	require.Nil(t, item)
+	require.Equal(t, 2, origin.loads)
+
+	stats := caches.Stats().Loads
+	require.EqualValues(t, 2, stats.NotFound)
+	require.EqualValues(t, 2, stats.NegativeHits)
+}
+
+func TestCachesLoadRace(t *testing.T) {
+	t.Parallel()
+
+	origin := &testOrigin{
+		values:  map[string]string{"key": "loaded"},
+		started: make(chan string, 1),
+		release: make(chan struct{}),
+	}
+	caches, ns := newLoadingCaches(origin, 0, 0)
+
+	loaded := make(chan *cache.Item)
+	go func() {
+		item, _ := ns.Load(context.Background(), "key")
+		loaded <- item
+	}()
+
+	// a write during the load is not overwritten by it
+	<-origin.started
+	set(caches, "key", value)
+	close(origin.release)
+
+	item := <-loaded
+	require.Equal(t, "loaded", string(item.Value))
+	checkHit(t, caches, "key", value)
+	require.EqualValues(t, 1, caches.Stats().Loads.Discarded)
+}
+
+func TestCachesLoadTimeout(t *testing.T) {
+	t.Parallel()
+
+	origin := &testOrigin{
+		values:  map[string]string{"key": "loaded"},
+		started: make(chan string, 1),
+		release: make(chan struct{}),
+	}
+	caches, ns := newLoadingCaches(origin, 10*time.Millisecond, 0)
+
+	_, err := ns.Load(context.Background(), "key")
+	require.Equal(t, context.DeadlineExceeded, err)
+	checkMiss(t, caches, "key")
+	require.EqualValues(t, 1, caches.Stats().Loads.Errors)
+
+	// misses give up on their own deadline, too
+	<-origin.started
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ns.Load(ctx, "key")
+	require.Equal(t, context.Canceled, err)
+}
+
 func TestCachesConcurrency(t *testing.T) {
 	t.Parallel()
 
@@ -385,6 +1123,33 @@ func TestCachesConcurrency(t *testing.T) {
 	}
 }
 
+func TestCachesBoundedLoadConcurrency(t *testing.T) {
+	t.Parallel()
+
+	cacheCount := 10
+
+	caches := New(Config{
+		CacheCount:  cacheCount,
+		Capacity:    uint64(cacheCount * (nCacheSize * nKvSize)),
+		Replicas:    160,
+		BoundedLoad: 0.25,
+	})
+
+	var namespaces [][]byte
+	for i := 0; i < nWorkers; i++ {
+		name := []byte(fmt.Sprintf("TestWorker%02d", i))
+		namespaces = append(namespaces, name)
+	}
+
+	for i := uint(0); i < nWorkers; i++ {
+		id := i
+		t.Run(string(namespaces[id]), func(t *testing.T) {
+			t.Parallel()
+			concurrencyWorker(t, caches, namespaces, id)
+		})
+	}
+}
+
 func concurrencyWorker(t *testing.T, caches *Caches, namespaces [][]byte, id uint) {
 	var wg sync.WaitGroup
 	namespace := namespaces[id]
@@ -0,0 +1,297 @@
+package caches
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/tescherm/mc/core/cache"
+)
+
+const (
+	defaultLoadTimeout = 5 * time.Second
+
+	minNegativePrune = 64
+)
+
+// LoadBuckets are the upper bounds, in seconds, of the load latency buckets.
+var LoadBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Loader loads the keys that a pool's caches miss, from the pool's origin.
+type Loader interface {
+	// Load returns the item for key, or nil if the origin does not have it.
+	Load(ctx context.Context, namespace, key string) (*cache.Item, error)
+}
+
+type LoadStats struct {
+	// Loads counts the calls to the origin, and Coalesced the misses that
+	// waited on another miss's load instead
+	Loads     uint64
+	Coalesced uint64
+
+	// NotFound counts the loads of keys the origin does not have, and
+	// NegativeHits the misses answered by remembering them
+	NotFound     uint64
+	NegativeHits uint64
+
+	Errors uint64
+
+	// Discarded counts the loaded items not filled because the key was
+	// written while they loaded
+	Discarded uint64
+
+	// Latency counts the loads that took at most each of LoadBuckets,
+	// cumulatively, and LatencySum is their total duration in seconds
+	Latency    []uint64
+	LatencySum float64
+}
+
+type loadKey struct {
+	namespace string
+	key       string
+}
+
+// flight is a load in progress, which concurrent misses of its key wait on.
+type flight struct {
+	done chan struct{}
+	item *cache.Item
+	err  error
+
+	// invalidated is set, with mu locked, if the key is written during the
+	// load, so that the loaded item is not filled over the write
+	invalidated bool
+}
+
+// loader fills the misses of a pool's caches from its origin, loading each
+// key once however many misses it has.
+type loader struct {
+	mu sync.Mutex
+
+	origin      Loader
+	timeout     time.Duration
+	negativeTTL time.Duration
+
+	flights map[loadKey]*flight
+
+	// negative holds when each key the origin does not have may be loaded
+	// again
+	negative map[loadKey]time.Time
+	pruneAt  int
+
+	stats   LoadStats
+	buckets []uint64
+
+	now func() time.Time
+}
+
+func newLoader(origin Loader, timeout, negativeTTL time.Duration) *loader {
+	if timeout <= 0 {
+		timeout = defaultLoadTimeout
+	}
+
+	return &loader{
+		origin:      origin,
+		timeout:     timeout,
+		negativeTTL: negativeTTL,
+		flights:     make(map[loadKey]*flight),
+		negative:    make(map[loadKey]time.Time),
+		pruneAt:     minNegativePrune,
+		buckets:     make([]uint64, len(LoadBuckets)),
+		now:         time.Now,
+	}
+}
+
+// load returns the item for a key the namespace missed, loading it and
+// filling the namespace with it, or waiting on the load of another miss.
+func (l *loader) load(ctx context.Context, n *Namespace, key string) (*cache.Item, error) {
+	k := loadKey{n.name, key}
+
+	l.mu.Lock()
+	if expiresAt, ok := l.negative[k]; ok {
+		if l.now().Before(expiresAt) {
+			l.stats.NegativeHits++
+			l.mu.Unlock()
+			return nil, nil
+		}
+		delete(l.negative, k)
+	}
+	f, ok := l.flights[k]
+	if ok {
+		l.stats.Coalesced++
+	} else {
+		f = &flight{done: make(chan struct{})}
+		l.flights[k] = f
+		go l.run(n, k, f)
+	}
+	l.mu.Unlock()
+
+	// the load outlives the misses waiting on it, which give up on their own
+	// deadlines
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if f.item == nil {
+		return nil, f.err
+	}
+	item := cache.Item(*f.item)
+	return &item, nil
+}
+
+func (l *loader) run(n *Namespace, k loadKey, f *flight) {
+	defer close(f.done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+
+	start := l.now()
+	item, err := l.origin.Load(ctx, k.namespace, k.key)
+	elapsed := l.now().Sub(start)
+
+	l.mu.Lock()
+	l.observe(elapsed)
+	switch {
+	case err != nil:
+		l.stats.Errors++
+	case item == nil:
+		l.stats.NotFound++
+		if l.negativeTTL > 0 && !f.invalidated {
+			l.negative[k] = l.now().Add(l.negativeTTL)
+			l.prune()
+		}
+	}
+	l.mu.Unlock()
+
+	if err != nil || item == nil {
+		l.finish(k, f, nil, err)
+		return
+	}
+
+	item.Key = k.key
+	if item.Delta == 0 {
+		item.Delta = elapsed
+	}
+	filled := n.CacheForKey(k.key).Fill(item, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		return !f.invalidated
+	})
+	if !filled {
+		l.mu.Lock()
+		l.stats.Discarded++
+		l.mu.Unlock()
+	}
+
+	// the misses waiting on the load began before any write racing it, so
+	// they are given the item even if it was discarded
+	loaded := cache.Item(*item)
+	l.finish(k, f, &loaded, nil)
+}
+
+// finish ends a load, before its waiters are woken.
+func (l *loader) finish(k loadKey, f *flight, item *cache.Item, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f.item, f.err = item, err
+	delete(l.flights, k)
+}
+
+// observe records a load's latency, with mu locked.
+func (l *loader) observe(elapsed time.Duration) {
+	l.stats.Loads++
+	l.stats.LatencySum += elapsed.Seconds()
+	for i, bound := range LoadBuckets {
+		if elapsed.Seconds() <= bound {
+			l.buckets[i]++
+			break
+		}
+	}
+}
+
+// prune drops the expired negative results, once there are twice as many
+// as after the last prune, with mu locked.
+func (l *loader) prune() {
+	if len(l.negative) < l.pruneAt {
+		return
+	}
+	now := l.now()
+	for k, expiresAt := range l.negative {
+		if !now.Before(expiresAt) {
+			delete(l.negative, k)
+		}
+	}
+	l.pruneAt = 2 * len(l.negative)
+	if l.pruneAt < minNegativePrune {
+		l.pruneAt = minNegativePrune
+	}
+}
+
+// written is called with every change to a namespace's items, while the
+// cache holding them is locked. It keeps loads racing a write from being
+// filled, and forgets that the origin did not have a key once it is set. A
+// nil loader ignores it.
+func (l *loader) written(namespace string, event cache.Event) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch event.Type {
+	case cache.EventEvict, cache.EventExpire:
+		return
+	case cache.EventClear:
+		for k, f := range l.flights {
+			if k.namespace == namespace {
+				f.invalidated = true
+			}
+		}
+		for k := range l.negative {
+			if k.namespace == namespace {
+				delete(l.negative, k)
+			}
+		}
+		return
+	}
+
+	k := loadKey{namespace, event.Key}
+	if f, ok := l.flights[k]; ok {
+		f.invalidated = true
+	}
+	delete(l.negative, k)
+}
+
+// snapshot returns the loader's stats; a nil loader has none.
+func (l *loader) snapshot() LoadStats {
+	if l == nil {
+		return LoadStats{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := l.stats
+	stats.Latency = make([]uint64, len(l.buckets))
+	var total uint64
+	for i, count := range l.buckets {
+		total += count
+		stats.Latency[i] = total
+	}
+	return stats
+}
+
+// close closes the origin, if it holds connections. A nil loader ignores it.
+func (l *loader) close() error {
+	if l == nil {
+		return nil
+	}
+	if c, ok := l.origin.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package caches
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/tescherm/mc/core/cache"
+)
+
+const keyLockCount = 64
+
+// keyLocks is a striped set of per key locks.
+type keyLocks [keyLockCount]sync.Mutex
+
+func (l *keyLocks) lock(key string) (unlock func()) {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+
+	m := &l[h.Sum32()%keyLockCount]
+	m.Lock()
+	return m.Unlock
+}
+
+// move moves key between caches, unless to already holds a newer copy.
+// The key's lock must be held.
+func move(key string, from, to cache.Cache) bool {
+	item := from.Take(key)
+	if item == nil {
+		return false
+	}
+	return to.Load(item)
+}
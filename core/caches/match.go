@@ -0,0 +1,158 @@
+package caches
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Matcher reports whether a key should be selected by a bulk operation.
+type Matcher func(key string) bool
+
+// MatchPrefix returns a Matcher selecting keys that begin with prefix.
+func MatchPrefix(prefix string) Matcher {
+	return func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	}
+}
+
+// MatchGlob returns a Matcher selecting keys that match a glob pattern.
+//
+// The pattern syntax is:
+//
+//	'*'         matches any sequence of characters, including none
+//	'?'         matches any single character
+//	'[' ']'     matches a single character from a class, e.g. [abc] or [a-z];
+//	            a leading '^' or '!' negates the class
+//	'\\' c      matches character c literally
+//
+// Unlike path.Match, '/' has no special meaning.
+func MatchGlob(pattern string) (Matcher, error) {
+	if err := validateGlob(pattern); err != nil {
+		return nil, err
+	}
+	return func(key string) bool {
+		return globMatch(pattern, key)
+	}, nil
+}
+
+func validateGlob(pattern string) error {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if i+1 >= len(pattern) {
+				return fmt.Errorf("invalid glob %q: trailing escape", pattern)
+			}
+			i++
+		case '[':
+			end, ok := classEnd(pattern, i)
+			if !ok {
+				return fmt.Errorf("invalid glob %q: unterminated character class", pattern)
+			}
+			i = end
+		}
+	}
+	return nil
+}
+
+// globMatch matches key against a validated pattern. It backtracks only to
+// the most recent '*', which keeps matching linear in practice.
+func globMatch(pattern, key string) bool {
+	p, k := 0, 0
+	starP, starK := -1, -1
+
+	for k < len(key) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				starP, starK = p, k
+				p++
+				continue
+			case '?':
+				p++
+				k++
+				continue
+			case '[':
+				end, _ := classEnd(pattern, p)
+				if classMatch(pattern[p+1:end], key[k]) {
+					p = end + 1
+					k++
+					continue
+				}
+			case '\\':
+				if pattern[p+1] == key[k] {
+					p += 2
+					k++
+					continue
+				}
+			default:
+				if pattern[p] == key[k] {
+					p++
+					k++
+					continue
+				}
+			}
+		}
+
+		if starP < 0 {
+			return false
+		}
+		starK++
+		p, k = starP+1, starK
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// classEnd returns the index of the ']' closing the class opened at start.
+func classEnd(pattern string, start int) (int, bool) {
+	i := start + 1
+	if i < len(pattern) && (pattern[i] == '^' || pattern[i] == '!') {
+		i++
+	}
+	// a ']' immediately after the opening bracket is a literal
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+	for ; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case ']':
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func classMatch(class string, c byte) bool {
+	negate := false
+	if len(class) > 0 && (class[0] == '^' || class[0] == '!') {
+		negate = true
+		class = class[1:]
+	}
+
+	matched := false
+	for i := 0; i < len(class); i++ {
+		lo := class[i]
+		if lo == '\\' && i+1 < len(class) {
+			i++
+			lo = class[i]
+		}
+		hi := lo
+		if i+2 < len(class) && class[i+1] == '-' {
+			hi = class[i+2]
+			if hi == '\\' && i+3 < len(class) {
+				hi = class[i+3]
+				i++
+			}
+			i += 2
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+	return matched != negate
+}
@@ -0,0 +1,323 @@
+package caches
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/consistenthash"
+)
+
+var ErrMigrating = errors.New("a resharding migration is already running")
+
+// Migration moves keys to their new owners after the shard set changes.
+//
+// Until the migration finishes, lookups route by the new hash but check the
+// key's old owner first, moving the key across if it is still there. Shards
+// being removed are kept, and count towards Size, until every key has left
+// them.
+type Migration struct {
+	caches *Caches
+
+	prevHash consistenthash.Hash
+	hash     consistenthash.Hash
+
+	// prevCacheIDs are the shards before the migration
+	prevCacheIDs []string
+	retired      []string
+
+	namespaces []*Namespace
+
+	// locks serialize moving a key with requests for it, so that a key
+	// taken from its old owner cannot be loaded into its new owner after
+	// it has been set or removed there
+	locks keyLocks
+}
+
+// Reshard changes the number of shards to cacheCount and returns the
+// migration that moves existing keys to their new owners. Requests are
+// routed by the new shard set as soon as Reshard returns; the caller must
+// Run the migration to completion.
+//
+// While migrating, shards that remain keep the larger of their old and new
+// capacity, so the pool can briefly hold more than its quota.
+func (s *Caches) Reshard(cacheCount int) (*Migration, error) {
+	if cacheCount <= 0 {
+		return nil, fmt.Errorf("cache count must be positive, got %d", cacheCount)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.migration != nil {
+		return nil, ErrMigrating
+	}
+
+	cacheIDs := make([]string, cacheCount)
+	for i := 0; i < cacheCount; i++ {
+		cacheIDs[i] = fmt.Sprintf("cache-%d", i)
+	}
+
+	current := make(map[string]bool, len(s.cacheIDs))
+	for _, cacheID := range s.cacheIDs {
+		current[cacheID] = true
+	}
+
+	hash := s.hash.Clone()
+	for _, cacheID := range cacheIDs {
+		if !current[cacheID] {
+			hash.Add(cacheID)
+		}
+		delete(current, cacheID)
+	}
+
+	// whatever is left of the current shards is being removed
+	var retired []string
+	for _, cacheID := range s.cacheIDs {
+		if current[cacheID] {
+			hash.Remove(cacheID)
+			retired = append(retired, cacheID)
+		}
+	}
+
+	m := &Migration{
+		caches:       s,
+		prevHash:     s.hash,
+		hash:         hash,
+		prevCacheIDs: s.cacheIDs,
+		retired:      retired,
+	}
+
+	for _, ns := range s.namespaces {
+		cacheCapacity := ns.capacity / uint64(cacheCount)
+
+		for _, cacheID := range cacheIDs {
+			c, ok := ns.cacheMap[cacheID]
+			if !ok {
+				ns.cacheMap[cacheID] = ns.newCache(cacheCapacity)
+				continue
+			}
+			if cacheCapacity > ns.capacity/uint64(len(s.cacheIDs)) {
+				c.SetCapacity(cacheCapacity)
+			}
+		}
+
+		m.namespaces = append(m.namespaces, ns)
+	}
+
+	s.hash = hash
+	s.cacheIDs = cacheIDs
+	s.migration = m
+
+	return m, nil
+}
+
+// Migrating reports whether a resharding migration is running.
+func (s *Caches) Migrating() bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.migration != nil
+}
+
+// Run moves every key whose owner changed, one old shard at a time. After
+// each shard is processed, progress is called with the number of shards
+// done, the total number of shards and the running count of keys moved.
+// Once every key has moved, shards are sized to the new shard count and
+// removed shards are dropped.
+//
+// Run cannot be stopped part way; until it returns, every request pays for
+// a second lookup.
+func (m *Migration) Run(progress func(done, total int, moved uint64)) uint64 {
+	total := len(m.namespaces) * len(m.prevCacheIDs)
+
+	var moved uint64
+	done := 0
+	for _, ns := range m.namespaces {
+		for _, cacheID := range m.prevCacheIDs {
+			moved += m.moveShard(ns, cacheID)
+			done++
+
+			if progress != nil {
+				progress(done, total, moved)
+			}
+		}
+	}
+
+	m.finish()
+	return moved
+}
+
+func (m *Migration) moveShard(ns *Namespace, cacheID string) uint64 {
+	m.caches.RLock()
+	from := ns.cacheMap[cacheID]
+	m.caches.RUnlock()
+
+	var moved uint64
+	for _, key := range from.Keys() {
+		toID := m.hash.GetNode(key)
+		if toID == cacheID {
+			continue
+		}
+
+		m.caches.RLock()
+		to := ns.cacheMap[toID]
+		m.caches.RUnlock()
+
+		unlock := m.locks.lock(key)
+		if move(key, from, to) {
+			moved++
+		}
+		unlock()
+	}
+	return moved
+}
+
+func (m *Migration) finish() {
+	s := m.caches
+
+	s.Lock()
+	defer s.Unlock()
+
+	for _, ns := range m.namespaces {
+		for _, cacheID := range m.retired {
+			delete(ns.cacheMap, cacheID)
+		}
+
+		// resharding resets any rebalancing
+		cacheCapacity := ns.capacity / uint64(len(s.cacheIDs))
+		for _, cacheID := range s.cacheIDs {
+			ns.cacheMap[cacheID].SetCapacity(cacheCapacity)
+		}
+		ns.lastStats = nil
+	}
+
+	s.migration = nil
+}
+
+// cacheForKey returns the cache for key in ns. If the key's owner changed,
+// the returned cache moves the key from its old owner on first use.
+func (m *Migration) cacheForKey(ns *Namespace, key string) cache.Cache {
+	to := ns.cacheMap[m.hash.GetNode(key)]
+	from := ns.cacheMap[m.prevHash.GetNode(key)]
+	if from == nil || from == to {
+		return to
+	}
+
+	return &migratingCache{
+		migration: m,
+		from:      from,
+		to:        to,
+	}
+}
+
+// migratingCache is the cache for a key whose owner changes during a
+// migration. Single key operations first move the key to its new owner.
+// Operations that are not about a single key apply to the new owner, except
+// for removals, which apply to both.
+type migratingCache struct {
+	migration *Migration
+
+	from cache.Cache
+	to   cache.Cache
+}
+
+func (c *migratingCache) Get(key string) *cache.Item {
+	defer c.migration.locks.lock(key)()
+
+	move(key, c.from, c.to)
+	return c.to.Get(key)
+}
+
+func (c *migratingCache) Set(item *cache.Item) {
+	defer c.migration.locks.lock(item.Key)()
+
+	c.to.Set(item)
+	c.from.Take(item.Key)
+}
+
+func (c *migratingCache) CompareAndSwap(item *cache.Item) bool {
+	defer c.migration.locks.lock(item.Key)()
+
+	move(item.Key, c.from, c.to)
+	return c.to.CompareAndSwap(item)
+}
+
+func (c *migratingCache) Fill(item *cache.Item, valid func() bool) bool {
+	defer c.migration.locks.lock(item.Key)()
+
+	move(item.Key, c.from, c.to)
+	return c.to.Fill(item, valid)
+}
+
+func (c *migratingCache) Remove(key string) *cache.Item {
+	defer c.migration.locks.lock(key)()
+
+	move(key, c.from, c.to)
+	return c.to.Remove(key)
+}
+
+func (c *migratingCache) RemoveMatching(match func(key string) bool) uint64 {
+	return c.from.RemoveMatching(match) + c.to.RemoveMatching(match)
+}
+
+func (c *migratingCache) InvalidateTags(tags []string) uint64 {
+	return c.from.InvalidateTags(tags) + c.to.InvalidateTags(tags)
+}
+
+func (c *migratingCache) Clear() {
+	c.from.Clear()
+	c.to.Clear()
+}
+
+func (c *migratingCache) Size() uint64 {
+	return c.to.Size()
+}
+
+func (c *migratingCache) Stats() cache.Stats {
+	return c.to.Stats()
+}
+
+func (c *migratingCache) Keys() []string {
+	return c.to.Keys()
+}
+
+func (c *migratingCache) Scan(fn func(item *cache.Item)) {
+	c.to.Scan(fn)
+}
+
+func (c *migratingCache) Take(key string) *cache.Item {
+	defer c.migration.locks.lock(key)()
+
+	move(key, c.from, c.to)
+	return c.to.Take(key)
+}
+
+func (c *migratingCache) Load(item *cache.Item) bool {
+	defer c.migration.locks.lock(item.Key)()
+
+	move(item.Key, c.from, c.to)
+	return c.to.Load(item)
+}
+
+func (c *migratingCache) Fetch(key string) *cache.Item {
+	defer c.migration.locks.lock(key)()
+
+	move(key, c.from, c.to)
+	return c.to.Fetch(key)
+}
+
+func (c *migratingCache) Merge(item *cache.Item) bool {
+	defer c.migration.locks.lock(item.Key)()
+
+	move(item.Key, c.from, c.to)
+	return c.to.Merge(item)
+}
+
+func (c *migratingCache) SetCapacity(capacity uint64) {
+	c.to.SetCapacity(capacity)
+}
+
+func (c *migratingCache) Trim(size uint64) uint64 {
+	return c.to.Trim(size)
+}
@@ -0,0 +1,266 @@
+package caches
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tescherm/mc/core/cache"
+)
+
+// DefaultNamespace is the namespace used when a request does not name one.
+const DefaultNamespace = "default"
+
+// Namespace is an independent key space with its own capacity quota. Each
+// namespace has its own cache, and so its own eviction domain, in every
+// shard.
+type Namespace struct {
+	caches *Caches
+
+	name     string
+	capacity uint64
+
+	generation *cache.Generation
+
+	cacheMap map[string]cache.Cache
+
+	// lastStats are the cache stats seen by the previous Rebalance
+	lastStats  map[string]cache.Stats
+	rebalanced uint64
+}
+
+func newNamespace(caches *Caches, name string, capacity uint64) *Namespace {
+	n := &Namespace{
+		caches:     caches,
+		name:       name,
+		capacity:   capacity,
+		generation: &cache.Generation{},
+		cacheMap:   make(map[string]cache.Cache, len(caches.cacheIDs)),
+	}
+
+	cacheCapacity := capacity / uint64(len(caches.cacheIDs))
+	for _, cacheID := range caches.cacheIDs {
+		n.cacheMap[cacheID] = n.newCache(cacheCapacity)
+	}
+	return n
+}
+
+func (n *Namespace) newCache(capacity uint64) cache.Cache {
+	var listener cache.Listener
+	if n.caches.listener != nil || n.caches.loader != nil {
+		listener = n.notify
+	}
+
+	return cache.NewLRUCache(cache.Config{
+		Capacity:   capacity,
+		Generation: n.generation,
+		Policy:     n.caches.policy,
+		TTL:        n.caches.ttl,
+		SoftTTL:    n.caches.softTTL,
+		Listener:   listener,
+	})
+}
+
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+// Capacity is the namespace quota, in bytes.
+func (n *Namespace) Capacity() uint64 {
+	n.caches.RLock()
+	defer n.caches.RUnlock()
+
+	return n.capacity
+}
+
+// SetCapacity changes the namespace quota. Each cache's capacity is scaled
+// so that caches keep their share of the quota, and the last cache is given
+// what rounding leaves, so that the caches add up to the quota. Caches that
+// no longer fit evict down to size.
+func (n *Namespace) SetCapacity(capacity uint64) error {
+	if capacity == 0 {
+		return fmt.Errorf("namespace %s: capacity must be positive", n.name)
+	}
+
+	n.caches.Lock()
+	defer n.caches.Unlock()
+
+	if n.caches.migration != nil {
+		return ErrMigrating
+	}
+
+	cacheIDs := n.caches.cacheIDs
+	scale := float64(capacity) / float64(n.capacity)
+	var total uint64
+	for _, cacheID := range cacheIDs[:len(cacheIDs)-1] {
+		c := n.cacheMap[cacheID]
+		cacheCapacity := uint64(float64(c.Stats().MaxCapacity) * scale)
+		if total+cacheCapacity > capacity {
+			cacheCapacity = capacity - total
+		}
+		c.SetCapacity(cacheCapacity)
+		total += cacheCapacity
+	}
+	n.cacheMap[cacheIDs[len(cacheIDs)-1]].SetCapacity(capacity - total)
+	n.capacity = capacity
+	return nil
+}
+
+// Generation is the generation the namespace's items are stored under.
+func (n *Namespace) Generation() uint64 {
+	return n.generation.Load()
+}
+
+// SetGeneration sets the namespace generation, e.g. to match another server.
+func (n *Namespace) SetGeneration(generation uint64) {
+	if n.generation.Load() == generation {
+		return
+	}
+	n.generation.Store(generation)
+	n.notifyClear()
+}
+
+// BumpGeneration advances the namespace generation, making every item in
+// the namespace unreachable. It returns the new generation.
+func (n *Namespace) BumpGeneration() uint64 {
+	generation := n.generation.Bump()
+	n.notifyClear()
+	return generation
+}
+
+// notifyClear reports a generation change, which makes every item
+// unreachable as a clear does.
+func (n *Namespace) notifyClear() {
+	n.notify(cache.Event{Type: cache.EventClear})
+}
+
+// notify reports a change to the namespace's items.
+func (n *Namespace) notify(event cache.Event) {
+	n.caches.loader.written(n.name, event)
+	if n.caches.listener != nil {
+		n.caches.listener(n.name, event)
+	}
+}
+
+// Load loads key from the origin after a miss, filling the namespace with
+// it. Concurrent misses of the key share one load. It returns nil if the
+// origin does not have key, or if the caches have no origin.
+func (n *Namespace) Load(ctx context.Context, key string) (*cache.Item, error) {
+	if n.caches.loader == nil {
+		return nil, nil
+	}
+	return n.caches.loader.load(ctx, n, key)
+}
+
+func (n *Namespace) CacheForKey(key string) cache.Cache {
+	n.caches.RLock()
+	defer n.caches.RUnlock()
+
+	return n.cacheForKey(key)
+}
+
+func (n *Namespace) cacheForKey(key string) cache.Cache {
+	if m := n.caches.migration; m != nil {
+		return m.cacheForKey(n, key)
+	}
+	if n.caches.loads != nil {
+		return n.boundedCacheForKey(key)
+	}
+
+	cacheID := n.caches.hash.GetNode(key)
+	c, ok := n.cacheMap[cacheID]
+	if !ok {
+		return nil
+	}
+	return c
+}
+
+func (n *Namespace) Clear() {
+	n.caches.Lock()
+	defer n.caches.Unlock()
+
+	n.clear()
+}
+
+func (n *Namespace) clear() {
+	for _, v := range n.cacheMap {
+		v.Clear()
+	}
+}
+
+func (n *Namespace) Size() uint64 {
+	n.caches.RLock()
+	defer n.caches.RUnlock()
+
+	return n.size()
+}
+
+func (n *Namespace) size() uint64 {
+	var total uint64
+	for _, v := range n.cacheMap {
+		total += v.Size()
+	}
+	return total
+}
+
+// RemoveMatching removes every key selected by match, one cache at a time.
+// After each cache is processed, progress is called with the number of
+// caches done, the total number of caches and the running removal count.
+// RemoveMatching stops early, returning ctx.Err(), if ctx is cancelled.
+func (n *Namespace) RemoveMatching(ctx context.Context, match Matcher, progress func(done, total int, removed uint64)) (uint64, error) {
+	n.caches.RLock()
+	cacheIDs := n.caches.shardIDs()
+	caches := make([]cache.Cache, len(cacheIDs))
+	for i, cacheID := range cacheIDs {
+		caches[i] = n.cacheMap[cacheID]
+	}
+	n.caches.RUnlock()
+
+	var removed uint64
+	for i, c := range caches {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+
+		removed += c.RemoveMatching(match)
+
+		if progress != nil {
+			progress(i+1, len(caches), removed)
+		}
+	}
+	return removed, nil
+}
+
+// Scan calls fn with a copy of every reachable item in the namespace,
+// including deleted items, one cache at a time. It returns ErrMigrating,
+// without scanning, while the pool is resharding, since keys are then
+// spread across old and new caches.
+func (n *Namespace) Scan(fn func(item *cache.Item)) error {
+	n.caches.RLock()
+	if n.caches.migration != nil {
+		n.caches.RUnlock()
+		return ErrMigrating
+	}
+	caches := make([]cache.Cache, 0, len(n.cacheMap))
+	for _, c := range n.cacheMap {
+		caches = append(caches, c)
+	}
+	n.caches.RUnlock()
+
+	for _, c := range caches {
+		c.Scan(fn)
+	}
+	return nil
+}
+
+// InvalidateTags removes every item carrying at least one of tags, returning
+// the number of items removed.
+func (n *Namespace) InvalidateTags(tags []string) uint64 {
+	n.caches.RLock()
+	defer n.caches.RUnlock()
+
+	var removed uint64
+	for _, v := range n.cacheMap {
+		removed += v.InvalidateTags(tags)
+	}
+	return removed
+}
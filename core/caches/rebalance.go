@@ -0,0 +1,135 @@
+package caches
+
+import (
+	"github.com/tescherm/mc/core/cache"
+)
+
+// RebalanceConfig controls how Rebalance moves capacity between caches. The
+// fractions are relative to a cache's even share of its namespace quota.
+type RebalanceConfig struct {
+	// Step is the fraction of a share moved by each rebalance
+	Step float64
+
+	// MinShare and MaxShare bound the capacity of every cache
+	MinShare float64
+	MaxShare float64
+}
+
+var DefaultRebalanceConfig = RebalanceConfig{
+	Step:     0.05,
+	MinShare: 0.5,
+	MaxShare: 2,
+}
+
+// Rebalance moves capacity, within each namespace, from the cache under the
+// least pressure to the cache under the most. Pressure is the miss rate of a
+// cache's lookups since the previous Rebalance, if it evicted since, so that
+// a cache short of capacity gains it however busy it is; caches that did not
+// evict are under none. Among caches under the same pressure, the one with
+// the lowest hit rate gives up capacity first. Namespace quotas are
+// unchanged. It returns the number of bytes moved.
+//
+// Rebalance is meant to be called periodically, and does nothing while a
+// resharding migration is running.
+func (s *Caches) Rebalance(config RebalanceConfig) uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.migration != nil {
+		return 0
+	}
+
+	var moved uint64
+	for _, ns := range s.namespaces {
+		moved += ns.rebalance(config)
+	}
+	return moved
+}
+
+type cachePressure struct {
+	cache    cache.Cache
+	capacity uint64
+
+	// pressure is the miss rate if the cache evicted, and hitRate the hit
+	// rate, of the lookups since the previous Rebalance
+	pressure float64
+	hitRate  float64
+}
+
+func newCachePressure(c cache.Cache, s, last cache.Stats) cachePressure {
+	p := cachePressure{
+		cache:    c,
+		capacity: s.MaxCapacity,
+	}
+
+	hits, misses := s.Hits-last.Hits, s.Misses-last.Misses
+	if lookups := hits + misses; lookups > 0 {
+		p.hitRate = float64(hits) / float64(lookups)
+		if s.Evicts > last.Evicts {
+			p.pressure = float64(misses) / float64(lookups)
+		}
+	}
+	return p
+}
+
+func (n *Namespace) rebalance(config RebalanceConfig) uint64 {
+	cacheIDs := n.caches.cacheIDs
+
+	pressures := make([]cachePressure, len(cacheIDs))
+	stats := make(map[string]cache.Stats, len(cacheIDs))
+	for i, cacheID := range cacheIDs {
+		c := n.cacheMap[cacheID]
+		s := c.Stats()
+
+		pressures[i] = newCachePressure(c, s, n.lastStats[cacheID])
+		stats[cacheID] = s
+	}
+	n.lastStats = stats
+
+	share := float64(n.capacity) / float64(len(cacheIDs))
+	step := uint64(share * config.Step)
+	minCapacity := uint64(share * config.MinShare)
+	maxCapacity := uint64(share * config.MaxShare)
+	if step == 0 {
+		return 0
+	}
+
+	var to *cachePressure
+	for i := range pressures {
+		p := &pressures[i]
+		if p.pressure == 0 || p.capacity+step > maxCapacity {
+			continue
+		}
+		if to == nil || p.pressure > to.pressure {
+			to = p
+		}
+	}
+	if to == nil {
+		return 0
+	}
+
+	var from *cachePressure
+	for i := range pressures {
+		p := &pressures[i]
+		if p == to || p.capacity < minCapacity+step {
+			continue
+		}
+		// only take from caches that are clearly under less pressure
+		if p.pressure*2 >= to.pressure {
+			continue
+		}
+		if from == nil || p.pressure < from.pressure || (p.pressure == from.pressure && p.hitRate < from.hitRate) {
+			from = p
+		}
+	}
+	if from == nil {
+		return 0
+	}
+
+	// shrink first, so the namespace never exceeds its quota
+	from.cache.SetCapacity(from.capacity - step)
+	to.cache.SetCapacity(to.capacity + step)
+
+	n.rebalanced += step
+	return step
+}
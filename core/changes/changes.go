@@ -0,0 +1,224 @@
+// Package changes sequences the mutations a primary applies and fans them
+// out to the replicas following it.
+package changes
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/tescherm/mc/pb"
+)
+
+const (
+	defaultBuffer = 100000
+
+	// stripes is the number of locks mutations to keys are ordered by
+	stripes = 256
+)
+
+// ErrOverflow is the error of a subscription that fell further behind than
+// its buffer.
+var ErrOverflow = errors.New("replica fell too far behind")
+
+// Change is a sequenced mutation.
+type Change struct {
+	Sequence uint64
+	Time     time.Time
+	Mutation *memcached.Mutation
+}
+
+type Config struct {
+	// Buffer is the number of changes a subscriber may fall behind by
+	// before it is dropped
+	Buffer int
+}
+
+// Stats is a point in time view of a feed.
+type Stats struct {
+	// Sequence is the sequence number of the latest change
+	Sequence uint64
+
+	Subscribers int
+
+	// Dropped counts the subscribers dropped for falling behind
+	Dropped uint64
+}
+
+// Feed numbers mutations in the order they are applied and sends them to
+// every subscriber.
+type Feed struct {
+	mu sync.Mutex
+
+	buffer int
+
+	sequence    uint64
+	subscribers map[*Subscription]bool
+	dropped     uint64
+
+	// keys orders the application and publication of mutations to a key,
+	// and all orders them with the mutations of many keys, which exclude
+	// every other
+	keys [stripes]sync.Mutex
+	all  sync.RWMutex
+
+	now func() time.Time
+}
+
+func New(config Config) *Feed {
+	if config.Buffer <= 0 {
+		config.Buffer = defaultBuffer
+	}
+
+	return &Feed{
+		buffer:      config.Buffer,
+		subscribers: make(map[*Subscription]bool),
+		now:         time.Now,
+	}
+}
+
+// Apply calls apply and publishes the mutation it returns, if any, so that
+// mutations to the same key are published in the order they were applied.
+// A nil feed only calls apply.
+func (f *Feed) Apply(key string, apply func() *memcached.Mutation) {
+	if f == nil {
+		apply()
+		return
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	mu := &f.keys[h.Sum32()%stripes]
+
+	f.all.RLock()
+	defer f.all.RUnlock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	if m := apply(); m != nil {
+		f.Publish(m)
+	}
+}
+
+// ApplyAll is Apply for a mutation of many keys, such as clearing a
+// namespace or creating a pool: no other mutation is applied until it is
+// published, so that every mutation is published on the same side of it
+// as it was applied. A nil feed only calls apply.
+func (f *Feed) ApplyAll(apply func() *memcached.Mutation) {
+	if f == nil {
+		apply()
+		return
+	}
+
+	f.all.Lock()
+	defer f.all.Unlock()
+
+	if m := apply(); m != nil {
+		f.Publish(m)
+	}
+}
+
+// Publish sequences a mutation and sends it to the subscribers, unordered
+// with the mutations being applied; mutations are published with Apply and
+// ApplyAll instead. A nil feed ignores it.
+func (f *Feed) Publish(m *memcached.Mutation) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sequence++
+	m.Sequence = f.sequence
+	change := Change{
+		Sequence: f.sequence,
+		Time:     f.now(),
+		Mutation: m,
+	}
+
+	for sub := range f.subscribers {
+		select {
+		case sub.changes <- change:
+		default:
+			f.drop(sub, ErrOverflow)
+			f.dropped++
+		}
+	}
+}
+
+// Subscribe returns a subscription to the changes after the current one.
+func (f *Feed) Subscribe() *Subscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sub := &Subscription{
+		Start:   f.sequence,
+		changes: make(chan Change, f.buffer),
+		feed:    f,
+	}
+	f.subscribers[sub] = true
+	return sub
+}
+
+// drop ends a subscription with err.
+func (f *Feed) drop(sub *Subscription, err error) {
+	if !f.subscribers[sub] {
+		return
+	}
+	delete(f.subscribers, sub)
+	sub.err = err
+	close(sub.changes)
+}
+
+// Sequence returns the sequence number of the latest change.
+func (f *Feed) Sequence() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.sequence
+}
+
+func (f *Feed) Stats() Stats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return Stats{
+		Sequence:    f.sequence,
+		Subscribers: len(f.subscribers),
+		Dropped:     f.dropped,
+	}
+}
+
+// Subscription receives the changes published after Start, in order.
+type Subscription struct {
+	// Start is the sequence number of the change before the first received
+	Start uint64
+
+	changes chan Change
+	feed    *Feed
+	err     error
+}
+
+// Changes returns the channel changes are received on. It is closed when the
+// subscription ends.
+func (s *Subscription) Changes() <-chan Change {
+	return s.changes
+}
+
+// Err returns why the subscription ended, or nil if it was closed.
+func (s *Subscription) Err() error {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+
+	s.feed.drop(s, nil)
+}
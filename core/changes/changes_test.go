@@ -0,0 +1,130 @@
+package changes
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/pb"
+)
+
+func set(key string) *memcached.Mutation {
+	return &memcached.Mutation{
+		Op:   memcached.Mutation_SET,
+		Item: &memcached.Item{Key: key},
+	}
+}
+
+func TestFeedOrder(t *testing.T) {
+	t.Parallel()
+
+	f := New(Config{})
+	f.Publish(set("before"))
+
+	sub := f.Subscribe()
+	defer sub.Close()
+	require.Equal(t, uint64(1), sub.Start)
+
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		f.Apply(key, func() *memcached.Mutation { return set(key) })
+	}
+
+	// a mutation that was not applied is not published
+	f.Apply("skipped", func() *memcached.Mutation { return nil })
+
+	for i := 0; i < 10; i++ {
+		change := <-sub.Changes()
+		require.Equal(t, uint64(i+2), change.Sequence)
+		require.Equal(t, change.Sequence, change.Mutation.Sequence)
+		require.Equal(t, fmt.Sprintf("key-%d", i), change.Mutation.Item.Key)
+	}
+	require.Empty(t, sub.Changes())
+	require.Equal(t, Stats{Sequence: 11, Subscribers: 1}, f.Stats())
+}
+
+func TestFeedApplyAll(t *testing.T) {
+	t.Parallel()
+
+	f := New(Config{})
+	sub := f.Subscribe()
+	defer sub.Close()
+
+	clearing := make(chan struct{})
+	cleared := make(chan struct{})
+	go f.ApplyAll(func() *memcached.Mutation {
+		close(clearing)
+		<-cleared
+		return &memcached.Mutation{Op: memcached.Mutation_CLEAR_NAMESPACE}
+	})
+	<-clearing
+
+	// a key applied while the namespace is cleared waits for the clear to be
+	// published
+	applied := make(chan struct{})
+	go func() {
+		f.Apply("key", func() *memcached.Mutation { return set("key") })
+		close(applied)
+	}()
+	select {
+	case <-applied:
+		t.Fatal("key applied during ApplyAll")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(cleared)
+	<-applied
+	require.Equal(t, memcached.Mutation_CLEAR_NAMESPACE, (<-sub.Changes()).Mutation.Op)
+	require.Equal(t, "key", (<-sub.Changes()).Mutation.Item.Key)
+}
+
+func TestFeedOverflow(t *testing.T) {
+	t.Parallel()
+
+	f := New(Config{Buffer: 2})
+	slow := f.Subscribe()
+	fast := f.Subscribe()
+	defer fast.Close()
+
+	for i := 0; i < 3; i++ {
+		f.Publish(set("key"))
+		<-fast.Changes()
+	}
+
+	// the slow subscriber is dropped, after the changes it had room for
+	require.Len(t, slow.Changes(), 2)
+	<-slow.Changes()
+	<-slow.Changes()
+	_, ok := <-slow.Changes()
+	require.False(t, ok)
+	require.Equal(t, ErrOverflow, slow.Err())
+
+	require.Equal(t, Stats{Sequence: 3, Subscribers: 1, Dropped: 1}, f.Stats())
+}
+
+func TestFeedClose(t *testing.T) {
+	t.Parallel()
+
+	f := New(Config{})
+	sub := f.Subscribe()
+	sub.Close()
+	sub.Close()
+
+	_, ok := <-sub.Changes()
+	require.False(t, ok)
+	require.NoError(t, sub.Err())
+
+	f.Publish(set("key"))
+	require.Equal(t, Stats{Sequence: 1}, f.Stats())
+
+	// a nil feed applies without publishing
+	var nilFeed *Feed
+	applied := false
+	nilFeed.Apply("key", func() *memcached.Mutation {
+		applied = true
+		return set("key")
+	})
+	nilFeed.Publish(set("key"))
+	require.True(t, applied)
+}
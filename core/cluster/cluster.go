@@ -0,0 +1,540 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tescherm/mc/core/consistenthash"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ForwardedMetadataKey is the request metadata key a node sets, to its own
+// address, when it forwards a request to another node. Forwarded requests
+// are always served by the node that receives them, so a request is
+// forwarded at most once even if nodes disagree about the ring.
+const ForwardedMetadataKey = "mc-forwarded-by"
+
+// SpilledMetadataKey is the request metadata key a node sets when it sends a
+// read to a peer other than the key's owner, because the owner is over its
+// load bound. The peer reads the key through its copy of the key, or from
+// the owner.
+const SpilledMetadataKey = "mc-spilled"
+
+const (
+	defaultReplicas       = 160
+	defaultReplicaTimeout = 5 * time.Second
+)
+
+var ErrNoSelf = errors.New("cluster self address is required")
+
+// Peer is a member of a cluster.
+type Peer struct {
+	// Address is the peer's gRPC address, as host:port
+	Address string
+
+	// Weight is the peer's relative share of keys; zero means one
+	Weight int
+}
+
+type Config struct {
+	// Self is this node's address, as the other peers know it. It is added
+	// to Peers if missing.
+	Self  string
+	Peers []Peer
+
+	// Weight is this node's weight, if it is not in Peers
+	Weight int
+
+	// Replicas is the number of ring points per unit of peer weight
+	Replicas int
+
+	// ReplicaTimeout bounds each request to a replica of a key
+	ReplicaTimeout time.Duration
+
+	Logger logrus.FieldLogger
+}
+
+// PeerStats is a point in time view of a peer.
+type PeerStats struct {
+	Peer
+
+	// Self is true for this node
+	Self bool
+
+	// Forwarded counts requests forwarded to the peer, and Errors the
+	// forwarded requests that failed
+	Forwarded uint64
+	Errors    uint64
+
+	// Spilled counts the reads sent to the peer because their key's owner
+	// was over its load bound
+	Spilled uint64
+}
+
+// Topology is a point in time view of the ring, from which any node or
+// client can compute the owner of a key.
+type Topology struct {
+	// Epoch advances each time the ring changes
+	Epoch uint64
+
+	// Replicas is the number of ring points per unit of peer weight
+	Replicas int
+
+	// Peers are the ring's members, ordered by address
+	Peers []Peer
+}
+
+type peer struct {
+	Peer
+
+	conn   *grpc.ClientConn
+	client memcached.MemcachedClient
+
+	forwarded uint64
+	errors    uint64
+	spilled   uint64
+}
+
+// Cluster places peers on a consistent hash ring, so every key is owned by
+// one peer, and keeps a connection to every other peer for forwarding
+// requests to them.
+type Cluster struct {
+	sync.RWMutex
+
+	self     string
+	replicas int
+
+	ring  *consistenthash.ConsistentHash
+	peers map[string]*peer
+
+	// loads counts the requests in flight to each peer, for bounded loads
+	loads *consistenthash.InFlight
+
+	// epoch counts ring changes, and changed is closed on the next one
+	epoch   uint64
+	changed chan struct{}
+
+	clock          *Clock
+	replicaTimeout time.Duration
+	replication    ReplicationStats
+
+	logger logrus.FieldLogger
+}
+
+func New(config Config) (*Cluster, error) {
+	if config.Self == "" {
+		return nil, ErrNoSelf
+	}
+	if config.Replicas <= 0 {
+		config.Replicas = defaultReplicas
+	}
+	if config.ReplicaTimeout <= 0 {
+		config.ReplicaTimeout = defaultReplicaTimeout
+	}
+
+	c := &Cluster{
+		self:     config.Self,
+		replicas: config.Replicas,
+		ring:     consistenthash.New(nil, config.Replicas),
+		peers:    make(map[string]*peer),
+		loads:    consistenthash.NewInFlight(),
+		epoch:    1,
+		changed:  make(chan struct{}),
+
+		clock:          NewClock(),
+		replicaTimeout: config.ReplicaTimeout,
+
+		logger: config.Logger.WithField("module", "cluster"),
+	}
+
+	hasSelf := false
+	for _, p := range config.Peers {
+		if p.Address == config.Self {
+			hasSelf = true
+		}
+		if err := c.add(p); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if !hasSelf {
+		if err := c.add(Peer{Address: config.Self, Weight: config.Weight}); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *Cluster) add(p Peer) error {
+	if p.Address == "" {
+		return errors.New("peer address is required")
+	}
+	if p.Weight < 0 {
+		return fmt.Errorf("peer %s weight must not be negative", p.Address)
+	}
+	if p.Weight == 0 {
+		p.Weight = 1
+	}
+	if _, ok := c.peers[p.Address]; ok {
+		return fmt.Errorf("peer %s is listed more than once", p.Address)
+	}
+
+	pr := &peer{Peer: p}
+	if p.Address != c.self {
+		conn, err := grpc.Dial(p.Address,
+			grpc.WithInsecure(),
+			grpc.WithBackoffMaxDelay(3*time.Second),
+			grpc.WithUnaryInterceptor(pr.intercept(c.self, c.loads)),
+			grpc.WithStreamInterceptor(pr.interceptStream(c.self)),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to dial peer %s: %s", p.Address, err)
+		}
+		pr.conn = conn
+		pr.client = memcached.NewMemcachedClient(conn)
+	}
+
+	c.peers[p.Address] = pr
+	c.ring.AddWeighted(p.Address, p.Weight)
+	return nil
+}
+
+// Add adds a peer to the ring. If it is already a member with another
+// weight, it is given the new weight instead.
+func (c *Cluster) Add(p Peer) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if existing, ok := c.peers[p.Address]; ok {
+		return c.reweight(existing, p.Weight)
+	}
+	if err := c.add(p); err != nil {
+		return err
+	}
+	c.change()
+
+	c.logger.WithField("peer", p.Address).Info("added peer")
+	return nil
+}
+
+// reweight moves a member's ring points to match a new weight, with c
+// locked.
+func (c *Cluster) reweight(p *peer, weight int) error {
+	if weight < 0 {
+		return fmt.Errorf("peer %s weight must not be negative", p.Address)
+	}
+	if weight == 0 {
+		weight = 1
+	}
+	if weight == p.Weight {
+		return nil
+	}
+
+	c.ring.Remove(p.Address)
+	c.ring.AddWeighted(p.Address, weight)
+	p.Weight = weight
+	c.change()
+
+	c.logger.WithFields(logrus.Fields{
+		"peer":   p.Address,
+		"weight": weight,
+	}).Info("reweighted peer")
+	return nil
+}
+
+// Remove removes a peer from the ring and closes its connection. This node
+// cannot be removed.
+func (c *Cluster) Remove(address string) {
+	c.Lock()
+	defer c.Unlock()
+
+	p, ok := c.peers[address]
+	if !ok || address == c.self {
+		return
+	}
+
+	c.ring.Remove(address)
+	delete(c.peers, address)
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	c.change()
+
+	c.logger.WithField("peer", address).Info("removed peer")
+}
+
+// change advances the epoch and wakes the topology watchers.
+func (c *Cluster) change() {
+	c.epoch++
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// intercept marks requests to the peer as forwarded by self and counts them,
+// and their load while they are in flight.
+func (p *peer) intercept(self string, loads *consistenthash.InFlight) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, ForwardedMetadataKey, self)
+
+		atomic.AddUint64(&p.forwarded, 1)
+		release := loads.Acquire(p.Address)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		release()
+		if err != nil {
+			atomic.AddUint64(&p.errors, 1)
+		}
+		return err
+	}
+}
+
+// interceptStream marks streams to the peer as forwarded by self.
+func (p *peer) interceptStream(self string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = metadata.AppendToOutgoingContext(ctx, ForwardedMetadataKey, self)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// Epoch returns the topology epoch, which advances each time the ring
+// changes.
+func (c *Cluster) Epoch() uint64 {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.epoch
+}
+
+// Self is this node's address.
+func (c *Cluster) Self() string {
+	return c.self
+}
+
+// Clock is the clock timestamping replicated writes.
+func (c *Cluster) Clock() *Clock {
+	return c.clock
+}
+
+// Owner returns the address of the peer owning key.
+func (c *Cluster) Owner(key string) string {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.ring.GetNode(key)
+}
+
+// Forwarded reports whether the request with context ctx was forwarded by
+// another node, and which.
+func Forwarded(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get(ForwardedMetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		return "", false
+	}
+	return values[0], true
+}
+
+// Route returns a client for the peer owning key, or nil if this node should
+// serve the request itself: because it owns key, or because the request was
+// already forwarded.
+func (c *Cluster) Route(ctx context.Context, key string) memcached.MemcachedClient {
+	if from, ok := Forwarded(ctx); ok {
+		if owner := c.Owner(key); owner != c.self {
+			c.logger.WithFields(logrus.Fields{
+				"key":   key,
+				"from":  from,
+				"owner": owner,
+			}).Debug("serving forwarded request for a key owned by another peer")
+		}
+		return nil
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	owner := c.ring.GetNode(key)
+	if owner == c.self {
+		return nil
+	}
+	return c.peers[owner].client
+}
+
+// RouteRead returns a client for the peer to read key from, or nil if this
+// node should serve the read itself. It is the key's owner, as for Route,
+// unless boundedLoad is positive and the owner has more than (1+boundedLoad)
+// times the average number of this node's requests in flight to peers,
+// scaled by its weight. The read then spills to the next peer in the key's
+// walk within its bound, and spilled reports it. Writes always go to the
+// owner, so a peer a read spilled to reads the key through its copy of it,
+// or from the owner.
+func (c *Cluster) RouteRead(ctx context.Context, key string, boundedLoad float64) (client memcached.MemcachedClient, spilled bool) {
+	if _, ok := Forwarded(ctx); ok && Spilled(ctx) {
+		c.RLock()
+		defer c.RUnlock()
+
+		if owner := c.ring.GetNode(key); owner != c.self {
+			return c.peers[owner].client, false
+		}
+		return nil, false
+	}
+	if boundedLoad <= 0 {
+		return c.Route(ctx, key), false
+	}
+	if _, ok := Forwarded(ctx); ok {
+		return nil, false
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	candidates := consistenthash.NewBounded(c.ring, boundedLoad, c.loads).Candidates(key)
+	owner, to := candidates[0], candidates[len(candidates)-1]
+	if owner == c.self {
+		return nil, false
+	}
+	// this node reads through its own copy when forwarding anyway
+	if to == owner || to == c.self {
+		return c.peers[owner].client, false
+	}
+
+	p := c.peers[to]
+	atomic.AddUint64(&p.spilled, 1)
+	return p.client, true
+}
+
+// Spilled reports whether the request with context ctx is a read that
+// spilled from its key's owner.
+func Spilled(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	return ok && len(md.Get(SpilledMetadataKey)) > 0
+}
+
+// Others calls fn with the address of and a client for every other peer,
+// unless the request with context ctx was forwarded. It is for requests that
+// apply to every node, such as clearing a namespace.
+func (c *Cluster) Others(ctx context.Context, fn func(address string, client memcached.MemcachedClient) error) error {
+	if _, ok := Forwarded(ctx); ok {
+		return nil
+	}
+
+	c.RLock()
+	var others []*peer
+	for _, p := range c.peers {
+		if p.client != nil {
+			others = append(others, p)
+		}
+	}
+	c.RUnlock()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(others))
+	for i, p := range others {
+		wg.Add(1)
+		go func(i int, p *peer) {
+			defer wg.Done()
+			errs[i] = fn(p.Address, p.client)
+		}(i, p)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Conn returns the connection to the peer at address, or nil if it is not a
+// peer or is this node. Requests sent on it are marked as forwarded.
+func (c *Cluster) Conn(address string) *grpc.ClientConn {
+	c.RLock()
+	defer c.RUnlock()
+
+	p, ok := c.peers[address]
+	if !ok {
+		return nil
+	}
+	return p.conn
+}
+
+// Peers returns every peer, including this node, ordered by address.
+func (c *Cluster) Peers() []Peer {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.sortedPeers()
+}
+
+// Topology returns the current topology, and a channel that is closed when
+// it next changes.
+func (c *Cluster) Topology() (Topology, <-chan struct{}) {
+	c.RLock()
+	defer c.RUnlock()
+
+	topology := Topology{
+		Epoch:    c.epoch,
+		Replicas: c.replicas,
+		Peers:    c.sortedPeers(),
+	}
+	return topology, c.changed
+}
+
+func (c *Cluster) sortedPeers() []Peer {
+	peers := make([]Peer, 0, len(c.peers))
+	for _, p := range c.peers {
+		peers = append(peers, p.Peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Address < peers[j].Address
+	})
+	return peers
+}
+
+func (c *Cluster) Stats() []PeerStats {
+	c.RLock()
+	defer c.RUnlock()
+
+	stats := make([]PeerStats, 0, len(c.peers))
+	for _, p := range c.peers {
+		stats = append(stats, PeerStats{
+			Peer:      p.Peer,
+			Self:      p.Address == c.self,
+			Forwarded: atomic.LoadUint64(&p.forwarded),
+			Errors:    atomic.LoadUint64(&p.errors),
+			Spilled:   atomic.LoadUint64(&p.spilled),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Address < stats[j].Address
+	})
+	return stats
+}
+
+// Close closes the connections to the other peers.
+func (c *Cluster) Close() error {
+	c.Lock()
+	defer c.Unlock()
+
+	var err error
+	for _, p := range c.peers {
+		if p.conn == nil {
+			continue
+		}
+		if cerr := p.conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,300 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+var logger = logrus.NewEntry(logrus.New())
+
+func TestClusterNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(Config{Logger: logger})
+	require.Equal(t, ErrNoSelf, err)
+
+	_, err = New(Config{
+		Self:   "127.0.0.1:1",
+		Peers:  []Peer{{Address: "127.0.0.1:2"}, {Address: "127.0.0.1:2"}},
+		Logger: logger,
+	})
+	require.Error(t, err)
+
+	_, err = New(Config{
+		Self:   "127.0.0.1:1",
+		Peers:  []Peer{{Address: "127.0.0.1:2", Weight: -1}},
+		Logger: logger,
+	})
+	require.Error(t, err)
+
+	// self is added if missing
+	c, err := New(Config{
+		Self:   "127.0.0.1:1",
+		Peers:  []Peer{{Address: "127.0.0.1:2", Weight: 2}},
+		Logger: logger,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.Equal(t, []Peer{
+		{Address: "127.0.0.1:1", Weight: 1},
+		{Address: "127.0.0.1:2", Weight: 2},
+	}, c.Peers())
+
+	stats := c.Stats()
+	require.True(t, stats[0].Self)
+	require.False(t, stats[1].Self)
+}
+
+func TestClusterRoute(t *testing.T) {
+	t.Parallel()
+
+	self := "127.0.0.1:1"
+	other := "127.0.0.1:2"
+
+	c, err := New(Config{
+		Self:   self,
+		Peers:  []Peer{{Address: self}, {Address: other, Weight: 3}},
+		Logger: logger,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	forwarded := metadata.NewIncomingContext(context.Background(), metadata.Pairs(ForwardedMetadataKey, other))
+	from, ok := Forwarded(forwarded)
+	require.True(t, ok)
+	require.Equal(t, other, from)
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		owner := c.Owner(key)
+		counts[owner]++
+
+		peer := c.Route(context.Background(), key)
+		if owner == self {
+			require.Nil(t, peer, "key=%s", key)
+		} else {
+			require.NotNil(t, peer, "key=%s", key)
+		}
+
+		// forwarded requests are never forwarded again
+		require.Nil(t, c.Route(forwarded, key))
+	}
+
+	// the other peer's weight gives it three quarters of the keys
+	require.InEpsilon(t, 7500, counts[other], 0.1)
+}
+
+func TestClusterRouteRead(t *testing.T) {
+	t.Parallel()
+
+	self := "127.0.0.1:1"
+	a := "127.0.0.1:2"
+	b := "127.0.0.1:3"
+
+	c, err := New(Config{
+		Self:   self,
+		Peers:  []Peer{{Address: self}, {Address: a}, {Address: b}},
+		Logger: logger,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	forwarded := metadata.NewIncomingContext(ctx, metadata.Pairs(ForwardedMetadataKey, a))
+	spilled := metadata.NewIncomingContext(ctx, metadata.Pairs(ForwardedMetadataKey, a, SpilledMetadataKey, "1"))
+	require.False(t, Spilled(forwarded))
+	require.True(t, Spilled(spilled))
+
+	// a is over its bound, with every request in flight
+	for i := 0; i < 10; i++ {
+		c.loads.Acquire(a)
+	}
+
+	spills := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		owner := c.Owner(key)
+
+		// without bounded loads reads go to the owner
+		peer, ok := c.RouteRead(ctx, key, 0)
+		require.Equal(t, c.Route(ctx, key), peer)
+		require.False(t, ok)
+
+		peer, ok = c.RouteRead(ctx, key, 0.25)
+		switch owner {
+		case self:
+			require.Nil(t, peer)
+		case a:
+			// a's reads spill to b, or through this node's own copy
+			if ok {
+				require.Equal(t, c.peers[b].client, peer)
+				spills++
+			} else {
+				require.Equal(t, c.peers[a].client, peer)
+			}
+		case b:
+			require.Equal(t, c.peers[b].client, peer)
+			require.False(t, ok)
+		}
+
+		// forwarded reads are served here, unless they spilled, in which
+		// case they are read from the owner
+		peer, _ = c.RouteRead(forwarded, key, 0.25)
+		require.Nil(t, peer)
+		peer, ok = c.RouteRead(spilled, key, 0.25)
+		require.False(t, ok)
+		if owner == self {
+			require.Nil(t, peer)
+		} else {
+			require.Equal(t, c.peers[owner].client, peer)
+		}
+	}
+
+	require.NotZero(t, spills)
+	for _, stats := range c.Stats() {
+		if stats.Address == b {
+			require.Equal(t, uint64(spills), stats.Spilled)
+		} else {
+			require.Zero(t, stats.Spilled)
+		}
+	}
+}
+
+func TestClusterAddRemove(t *testing.T) {
+	t.Parallel()
+
+	self := "127.0.0.1:1"
+	c, err := New(Config{Self: self, Logger: logger})
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.Equal(t, self, c.Owner("key"))
+
+	require.NoError(t, c.Add(Peer{Address: "127.0.0.1:2"}))
+	require.NoError(t, c.Add(Peer{Address: "127.0.0.1:2"}))
+	require.Len(t, c.Peers(), 2)
+
+	c.Remove("127.0.0.1:2")
+	c.Remove(self)
+	require.Equal(t, []Peer{{Address: self, Weight: 1}}, c.Peers())
+	require.Equal(t, self, c.Owner("key"))
+}
+
+func TestClusterTopology(t *testing.T) {
+	t.Parallel()
+
+	self := "127.0.0.1:1"
+	c, err := New(Config{Self: self, Replicas: 10, Logger: logger})
+	require.NoError(t, err)
+	defer c.Close()
+
+	topology, changed := c.Topology()
+	require.Equal(t, uint64(1), topology.Epoch)
+	require.Equal(t, 10, topology.Replicas)
+	require.Equal(t, []Peer{{Address: self, Weight: 1}}, topology.Peers)
+
+	// adding a member again is not a change
+	require.NoError(t, c.Add(Peer{Address: self}))
+	select {
+	case <-changed:
+		t.Fatal("topology changed")
+	default:
+	}
+
+	require.NoError(t, c.Add(Peer{Address: "127.0.0.1:2", Weight: 2}))
+	<-changed
+
+	topology, changed = c.Topology()
+	require.Equal(t, uint64(2), topology.Epoch)
+	require.Equal(t, []Peer{
+		{Address: self, Weight: 1},
+		{Address: "127.0.0.1:2", Weight: 2},
+	}, topology.Peers)
+
+	// a new weight moves the member's ring points
+	require.NoError(t, c.Add(Peer{Address: "127.0.0.1:2", Weight: 3}))
+	<-changed
+
+	topology, changed = c.Topology()
+	require.Equal(t, uint64(3), topology.Epoch)
+	require.Equal(t, []Peer{
+		{Address: self, Weight: 1},
+		{Address: "127.0.0.1:2", Weight: 3},
+	}, topology.Peers)
+	require.Equal(t, 3, c.ring.Weight("127.0.0.1:2"))
+	require.Len(t, c.ring.Keys, 40)
+
+	c.Remove("127.0.0.1:2")
+	<-changed
+
+	topology, _ = c.Topology()
+	require.Equal(t, uint64(4), topology.Epoch)
+}
+
+func TestClusterReplicas(t *testing.T) {
+	t.Parallel()
+
+	self := "127.0.0.1:1"
+	c, err := New(Config{
+		Self:   self,
+		Peers:  []Peer{{Address: self}, {Address: "127.0.0.1:2"}, {Address: "127.0.0.1:3"}},
+		Logger: logger,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+
+		replicas := c.Replicas(key, 2)
+		require.Len(t, replicas, 2)
+		require.Equal(t, c.Owner(key), replicas[0])
+		require.NotEqual(t, replicas[0], replicas[1])
+
+		// there are only three peers to replicate to
+		require.Len(t, c.Replicas(key, 5), 3)
+
+		shared := replicas[0] == self || replicas[1] == self
+		other := replicas[0]
+		if other == self {
+			other = replicas[1]
+		}
+		require.Equal(t, shared, c.Shares(key, 2, other), "key=%s", key)
+		require.True(t, c.Shares(key, 3, "127.0.0.1:2"))
+	}
+}
+
+func TestClock(t *testing.T) {
+	t.Parallel()
+
+	wall := time.Unix(1000, 0)
+	c := NewClock()
+	c.now = func() time.Time { return wall }
+
+	t1 := c.Now()
+	require.Equal(t, wall, t1.Time())
+	require.Zero(t, t1.Logical())
+
+	// the logical counter orders events within a millisecond
+	t2 := c.Now()
+	require.Equal(t, wall, t2.Time())
+	require.EqualValues(t, 1, t2.Logical())
+
+	// the clock never goes backwards with wall time
+	wall = wall.Add(-time.Second)
+	t3 := c.Now()
+	require.True(t, t3 > t2)
+
+	// and orders local events after remote ones
+	remote := Timestamp(wall.Add(time.Minute).UnixNano()/int64(time.Millisecond)) << logicalBits
+	c.Observe(remote)
+	require.True(t, c.Now() > remote)
+}
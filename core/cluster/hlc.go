@@ -0,0 +1,66 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+)
+
+// logicalBits is the number of low timestamp bits holding the logical
+// counter; the rest hold wall time in milliseconds.
+const logicalBits = 16
+
+// Timestamp is a hybrid logical clock time (Kulkarni et al.). Timestamps
+// follow wall time closely but never go backwards, and a timestamp received
+// from another node always orders before any later local timestamp.
+type Timestamp uint64
+
+func (t Timestamp) Time() time.Time {
+	ms := int64(t >> logicalBits)
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+func (t Timestamp) Logical() uint16 {
+	return uint16(t)
+}
+
+// Clock is a hybrid logical clock.
+type Clock struct {
+	sync.Mutex
+
+	last Timestamp
+	now  func() time.Time
+}
+
+func NewClock() *Clock {
+	return &Clock{
+		now: time.Now,
+	}
+}
+
+func (c *Clock) wall() Timestamp {
+	return Timestamp(c.now().UnixNano()/int64(time.Millisecond)) << logicalBits
+}
+
+// Now returns a timestamp for a local event, later than every timestamp
+// returned or observed before.
+func (c *Clock) Now() Timestamp {
+	c.Lock()
+	defer c.Unlock()
+
+	if wall := c.wall(); wall > c.last {
+		c.last = wall
+	} else {
+		c.last++
+	}
+	return c.last
+}
+
+// Observe advances the clock past a timestamp received from another node.
+func (c *Clock) Observe(remote Timestamp) {
+	c.Lock()
+	defer c.Unlock()
+
+	if remote > c.last {
+		c.last = remote
+	}
+}
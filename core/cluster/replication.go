@@ -0,0 +1,218 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReplicaFunc reads or writes the replica of a key on the peer at address,
+// returning the replica's item. client is nil for this node's own replica.
+type ReplicaFunc func(ctx context.Context, address string, client memcached.MemcachedClient) (*memcached.Item, error)
+
+// ReplicationStats counts the quorum operations this node coordinated.
+type ReplicationStats struct {
+	ReadRepairs uint64
+
+	// FailedReads and FailedWrites count operations that did not reach
+	// their quorum
+	FailedReads  uint64
+	FailedWrites uint64
+}
+
+type replicaResult struct {
+	address string
+	client  memcached.MemcachedClient
+	item    *memcached.Item
+	err     error
+}
+
+// Replicas returns the addresses of the n distinct peers holding key: its
+// owner and the next peers clockwise on the ring.
+func (c *Cluster) Replicas(key string, n int) []string {
+	c.RLock()
+	defer c.RUnlock()
+
+	var replicas []string
+	c.ring.Walk(key, func(nodeID string) bool {
+		replicas = append(replicas, nodeID)
+		return len(replicas) < n
+	})
+	return replicas
+}
+
+// Shares reports whether this node and the peer at address are both among
+// the n replicas of key.
+func (c *Cluster) Shares(key string, n int, address string) bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	var self, other bool
+	c.ring.Walk(key, func(nodeID string) bool {
+		n--
+		self = self || nodeID == c.self
+		other = other || nodeID == address
+		return n > 0 && !(self && other)
+	})
+	return self && other
+}
+
+// each calls fn for every replica of key concurrently. The calls are not
+// cancelled with ctx, only bounded by the replica timeout, so they complete
+// after a quorum has responded.
+func (c *Cluster) each(key string, n int, fn ReplicaFunc) <-chan replicaResult {
+	c.RLock()
+	var replicas []*peer
+	c.ring.Walk(key, func(nodeID string) bool {
+		replicas = append(replicas, c.peers[nodeID])
+		return len(replicas) < n
+	})
+	c.RUnlock()
+
+	results := make(chan replicaResult, len(replicas))
+	ctx, cancel := context.WithTimeout(context.Background(), c.replicaTimeout)
+
+	var wg sync.WaitGroup
+	for _, p := range replicas {
+		wg.Add(1)
+		go func(p *peer) {
+			defer wg.Done()
+
+			item, err := fn(ctx, p.Address, p.client)
+			results <- replicaResult{address: p.Address, client: p.client, item: item, err: err}
+		}(p)
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+	return results
+}
+
+// newer reports whether a is newer than b by timestamp. Every item is newer
+// than none.
+func newer(a, b *memcached.Item) bool {
+	if a == nil {
+		return false
+	}
+	return b == nil || a.Timestamp > b.Timestamp
+}
+
+// Read reads key from its n replicas, returning the newest item by timestamp
+// once r have responded; it may be deleted. Once every replica has responded,
+// those whose CAS version differs from the newest item's are repaired with
+// it.
+func (c *Cluster) Read(ctx context.Context, key string, n, r int, read ReplicaFunc, repair func(ctx context.Context, client memcached.MemcachedClient, item *memcached.Item) error) (*memcached.Item, error) {
+	results := c.each(key, n, read)
+
+	var newest *memcached.Item
+	var responded []replicaResult
+	total, failed := cap(results), 0
+
+	for len(responded) < r {
+		if total-failed < r {
+			atomic.AddUint64(&c.replication.FailedReads, 1)
+			return nil, status.Errorf(codes.Unavailable, "read quorum not reached: %d of %d replicas responded", len(responded), r)
+		}
+
+		select {
+		case <-ctx.Done():
+			atomic.AddUint64(&c.replication.FailedReads, 1)
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case res := <-results:
+			if res.err != nil {
+				failed++
+				continue
+			}
+			responded = append(responded, res)
+			if newer(res.item, newest) {
+				newest = res.item
+			}
+		}
+	}
+
+	go c.readRepair(key, newest, responded, total-failed-len(responded), results, repair)
+	return newest, nil
+}
+
+// readRepair waits for the replicas yet to respond, then repairs every
+// replica holding a different version than the newest.
+func (c *Cluster) readRepair(key string, newest *memcached.Item, responded []replicaResult, pending int, results <-chan replicaResult, repair func(ctx context.Context, client memcached.MemcachedClient, item *memcached.Item) error) {
+	for i := 0; i < pending; i++ {
+		res := <-results
+		if res.err != nil {
+			continue
+		}
+		responded = append(responded, res)
+		if newer(res.item, newest) {
+			newest = res.item
+		}
+	}
+	if newest == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.replicaTimeout)
+	defer cancel()
+
+	for _, res := range responded {
+		if res.item != nil && res.item.CasID == newest.CasID {
+			continue
+		}
+
+		if err := repair(ctx, res.client, newest); err != nil {
+			c.logger.WithError(err).WithField("replica", res.address).WithField("key", key).Warn("read repair failed")
+			continue
+		}
+		atomic.AddUint64(&c.replication.ReadRepairs, 1)
+	}
+}
+
+// Write writes key to its n replicas, returning once w have acknowledged.
+// It returns the newest item reported by the replicas that acknowledged,
+// e.g. the item a remove replaced.
+func (c *Cluster) Write(ctx context.Context, key string, n, w int, write ReplicaFunc) (*memcached.Item, error) {
+	results := c.each(key, n, write)
+
+	var newest *memcached.Item
+	total, acks, failed := cap(results), 0, 0
+	var lastErr error
+
+	for acks < w {
+		if total-failed < w {
+			atomic.AddUint64(&c.replication.FailedWrites, 1)
+			return nil, status.Errorf(codes.Unavailable, "write quorum not reached: %d of %d replicas acknowledged: %v", acks, w, lastErr)
+		}
+
+		select {
+		case <-ctx.Done():
+			atomic.AddUint64(&c.replication.FailedWrites, 1)
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case res := <-results:
+			if res.err != nil {
+				c.logger.WithError(res.err).WithField("replica", res.address).WithField("key", key).Debug("replica write failed")
+				lastErr = res.err
+				failed++
+				continue
+			}
+			acks++
+			if newer(res.item, newest) {
+				newest = res.item
+			}
+		}
+	}
+	return newest, nil
+}
+
+func (c *Cluster) ReplicationStats() ReplicationStats {
+	return ReplicationStats{
+		ReadRepairs:  atomic.LoadUint64(&c.replication.ReadRepairs),
+		FailedReads:  atomic.LoadUint64(&c.replication.FailedReads),
+		FailedWrites: atomic.LoadUint64(&c.replication.FailedWrites),
+	}
+}
@@ -0,0 +1,1011 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/client"
+	"github.com/tescherm/mc/core/cluster"
+	"github.com/tescherm/mc/core/hints"
+	"github.com/tescherm/mc/core/hotcache"
+	"github.com/tescherm/mc/core/jobs"
+	"github.com/tescherm/mc/core/leases"
+	"github.com/tescherm/mc/core/merkle"
+	"github.com/tescherm/mc/core/pools"
+	"github.com/tescherm/mc/core/tracking"
+	"github.com/tescherm/mc/core/watch"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testLogger = logrus.NewEntry(logrus.New())
+
+type testNode struct {
+	address string
+	service *MemcachedService
+	client  memcached.MemcachedClient
+}
+
+// startCluster starts n clustered servers on loopback ports.
+func startCluster(t *testing.T, n int) ([]*testNode, func()) {
+	var closers []func()
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+
+	listeners := make([]net.Listener, n)
+	peers := make([]cluster.Peer, n)
+	for i := range listeners {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		listeners[i] = lis
+		peers[i] = cluster.Peer{Address: lis.Addr().String()}
+	}
+
+	nodes := make([]*testNode, n)
+	for i, lis := range listeners {
+		node, closeNode := startNode(t, lis, peers)
+		closers = append(closers, closeNode)
+		nodes[i] = node
+	}
+	return nodes, closeAll
+}
+
+// startNode starts a clustered server on lis.
+func startNode(t *testing.T, lis net.Listener, peers []cluster.Peer) (*testNode, func()) {
+	address := lis.Addr().String()
+
+	w := watch.New(watch.Config{})
+	tr := tracking.New(tracking.Config{})
+	trees := merkle.NewIndex(0)
+	p, err := pools.New(pools.Config{
+		Default: pools.PoolConfig{
+			Capacity:   1 << 20,
+			CacheCount: 4,
+			Replicas:   50,
+		},
+		Listener: pools.Listeners(w.Publish, tr.Publish, trees.Publish),
+	})
+	require.NoError(t, err)
+
+	c, err := cluster.New(cluster.Config{
+		Self:   address,
+		Peers:  peers,
+		Logger: testLogger,
+	})
+	require.NoError(t, err)
+
+	service := New(Config{
+		Pools:    p,
+		Jobs:     jobs.NewManager(jobs.Config{}),
+		Cluster:  c,
+		Trees:    trees,
+		Watches:  w,
+		Tracking: tr,
+		Leases:   leases.New(leases.Config{}),
+		Logger:   testLogger,
+	})
+
+	server := grpc.NewServer()
+	memcached.RegisterMemcachedServer(server, service)
+	memcached.RegisterAntiEntropyServer(server, service)
+	go server.Serve(lis)
+
+	conn, err := grpc.Dial(address, grpc.WithInsecure())
+	require.NoError(t, err)
+
+	node := &testNode{
+		address: address,
+		service: service,
+		client:  memcached.NewMemcachedClient(conn),
+	}
+	return node, func() {
+		conn.Close()
+		server.Stop()
+		c.Close()
+	}
+}
+
+// localGet reads key from a node's own caches, without forwarding.
+func (n *testNode) localGet(key string) *memcached.Item {
+	c, err := n.service.pick(context.Background(), "", "", key)
+	if err != nil {
+		return nil
+	}
+	return fromCacheItem(c.Get(key))
+}
+
+func TestClusterForwarding(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 3)
+	defer closeAll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keys := make([]string, 30)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+
+		// write through every node in turn
+		_, err := nodes[i%len(nodes)].client.Set(ctx, &memcached.SetRequest{
+			Item: &memcached.Item{Key: keys[i], Value: []byte(keys[i])},
+		})
+		require.NoError(t, err)
+	}
+
+	for _, key := range keys {
+		owner := nodes[0].service.Cluster.Owner(key)
+
+		// every node serves every key
+		for _, node := range nodes {
+			res, err := node.client.Get(ctx, &memcached.GetRequest{Key: key})
+			require.NoError(t, err)
+			require.NotNil(t, res.Item, "key=%s node=%s", key, node.address)
+			require.Equal(t, []byte(key), res.Item.Value)
+		}
+
+		// only the owner stores it
+		for _, node := range nodes {
+			if node.address == owner {
+				require.NotNil(t, node.localGet(key), "key=%s node=%s", key, node.address)
+			} else {
+				require.Nil(t, node.localGet(key), "key=%s node=%s", key, node.address)
+			}
+		}
+	}
+
+	forwarded := uint64(0)
+	for _, node := range nodes {
+		for _, stats := range node.service.Cluster.Stats() {
+			forwarded += stats.Forwarded
+			require.Zero(t, stats.Errors)
+		}
+	}
+	require.NotZero(t, forwarded)
+
+	// removes are forwarded too
+	res, err := nodes[0].client.Remove(ctx, &memcached.RemoveRequest{Key: keys[1]})
+	require.NoError(t, err)
+	require.NotNil(t, res.Item)
+	for _, node := range nodes {
+		res, err := node.client.Get(ctx, &memcached.GetRequest{Key: keys[1]})
+		require.NoError(t, err)
+		require.Nil(t, res.Item)
+	}
+}
+
+func TestClusterBroadcast(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 3)
+	defer closeAll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := 0; i < 30; i++ {
+		_, err := nodes[0].client.Set(ctx, &memcached.SetRequest{
+			Item: &memcached.Item{
+				Key:   fmt.Sprintf("key-%d", i),
+				Value: []byte("value"),
+				Tags:  []string{"tag"},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	// tags are invalidated on every node
+	res, err := nodes[1].client.InvalidateTags(ctx, &memcached.InvalidateTagsRequest{Tags: []string{"tag"}})
+	require.NoError(t, err)
+	require.EqualValues(t, 30, res.Count)
+
+	// generations are kept in step
+	bumped, err := nodes[2].client.BumpGeneration(ctx, &memcached.BumpGenerationRequest{})
+	require.NoError(t, err)
+	for _, node := range nodes {
+		res, err := node.client.GetGeneration(ctx, &memcached.GetGenerationRequest{})
+		require.NoError(t, err)
+		require.Equal(t, bumped.Generation, res.Generation, "node=%s", node.address)
+	}
+}
+
+func TestClusterDeleteMatching(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 3)
+	defer closeAll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := 0; i < 30; i++ {
+		for _, prefix := range []string{"user:", "session:"} {
+			_, err := nodes[0].client.Set(ctx, &memcached.SetRequest{
+				Item: &memcached.Item{Key: fmt.Sprintf("%s%d", prefix, i), Value: []byte("value")},
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	// the matching keys are removed on every node, by one job
+	res, err := nodes[1].client.DeleteMatching(ctx, &memcached.DeleteMatchingRequest{Prefix: "user:"})
+	require.NoError(t, err)
+
+	var job *memcached.Job
+	for {
+		res, err := nodes[1].client.GetJob(ctx, &memcached.GetJobRequest{JobID: res.JobID})
+		require.NoError(t, err)
+		job = res.Job
+		if job.State != memcached.Job_RUNNING {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, memcached.Job_DONE, job.State, job.Error)
+	require.EqualValues(t, 30, job.Count)
+	require.Equal(t, job.Total, job.Done)
+
+	for i := 0; i < 30; i++ {
+		for _, node := range nodes {
+			require.Nil(t, node.localGet(fmt.Sprintf("user:%d", i)), "node=%s", node.address)
+		}
+		res, err := nodes[2].client.Get(ctx, &memcached.GetRequest{Key: fmt.Sprintf("session:%d", i)})
+		require.NoError(t, err)
+		require.NotNil(t, res.Item)
+	}
+}
+
+// createReplicatedPool creates a pool replicated to n peers on every node.
+func createReplicatedPool(t *testing.T, nodes []*testNode, name string, n int) {
+	for _, node := range nodes {
+		_, err := node.service.Pools.Create(pools.PoolConfig{
+			Name:              name,
+			Capacity:          1 << 20,
+			CacheCount:        4,
+			Replicas:          50,
+			ReplicationFactor: n,
+		})
+		require.NoError(t, err)
+	}
+}
+
+// localFetch reads key from a node's replica in pool, including deletes.
+func (n *testNode) localFetch(pool, key string) *memcached.Item {
+	c, err := n.service.pick(context.Background(), pool, "", key)
+	if err != nil {
+		return nil
+	}
+	return fromCacheItem(c.Fetch(key))
+}
+
+func TestClusterReplication(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 3)
+	defer closeAll()
+	createReplicatedPool(t, nodes, "replicated", 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// writes with every replica acknowledging land everywhere with the same
+	// version
+	res, err := nodes[0].client.Set(ctx, &memcached.SetRequest{
+		Item:        &memcached.Item{Key: "key", Value: []byte("v1")},
+		Pool:        "replicated",
+		WriteQuorum: 3,
+	})
+	require.NoError(t, err)
+	casID := res.Item.CasID
+	for _, node := range nodes {
+		item := node.localFetch("replicated", "key")
+		require.NotNil(t, item, "node=%s", node.address)
+		require.Equal(t, []byte("v1"), item.Value)
+		require.Equal(t, casID, item.CasID)
+	}
+
+	// reads through any node see the write
+	for _, node := range nodes {
+		res, err := node.client.Get(ctx, &memcached.GetRequest{Key: "key", Pool: "replicated"})
+		require.NoError(t, err)
+		require.NotNil(t, res.Item)
+		require.Equal(t, []byte("v1"), res.Item.Value)
+		require.Equal(t, casID, res.Item.CasID)
+	}
+
+	// a replica that missed a write is repaired by a read
+	stale, err := nodes[2].service.pick(ctx, "replicated", "", "key")
+	require.NoError(t, err)
+	stale.Remove("key")
+
+	res2, err := nodes[0].client.Get(ctx, &memcached.GetRequest{Key: "key", Pool: "replicated", ReadQuorum: 3})
+	require.NoError(t, err)
+	require.Equal(t, []byte("v1"), res2.Item.Value)
+	for i := 0; i < 100 && nodes[0].service.Cluster.ReplicationStats().ReadRepairs == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.EqualValues(t, 1, nodes[0].service.Cluster.ReplicationStats().ReadRepairs)
+	require.Equal(t, casID, nodes[2].localFetch("replicated", "key").CasID)
+
+	// swaps fail against a stale version
+	_, err = nodes[1].client.CompareAndSwap(ctx, &memcached.CompareAndSwapRequest{
+		Item: &memcached.Item{Key: "key", Value: []byte("v2"), CasID: casID - 1},
+		Pool: "replicated",
+	})
+	require.Equal(t, codes.Aborted, status.Code(err))
+
+	swapped, err := nodes[1].client.CompareAndSwap(ctx, &memcached.CompareAndSwapRequest{
+		Item:        &memcached.Item{Key: "key", Value: []byte("v2"), CasID: casID},
+		Pool:        "replicated",
+		WriteQuorum: 3,
+	})
+	require.NoError(t, err)
+	require.True(t, swapped.Item.CasID > casID)
+
+	// removes are replicated as deletes
+	removed, err := nodes[2].client.Remove(ctx, &memcached.RemoveRequest{Key: "key", Pool: "replicated", WriteQuorum: 3})
+	require.NoError(t, err)
+	require.Equal(t, []byte("v2"), removed.Item.Value)
+	for _, node := range nodes {
+		res, err := node.client.Get(ctx, &memcached.GetRequest{Key: "key", Pool: "replicated"})
+		require.NoError(t, err)
+		require.Nil(t, res.Item)
+		require.True(t, node.localFetch("replicated", "key").Deleted)
+	}
+
+	// quorums cannot exceed the replication factor
+	_, err = nodes[0].client.Set(ctx, &memcached.SetRequest{
+		Item:        &memcached.Item{Key: "key", Value: []byte("v3")},
+		Pool:        "replicated",
+		WriteQuorum: 4,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestClusterReplicationUnavailable(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 3)
+	defer closeAll()
+	createReplicatedPool(t, nodes, "replicated", 3)
+
+	// a peer that is not serving cannot acknowledge writes
+	require.NoError(t, nodes[0].service.Cluster.Add(cluster.Peer{Address: "127.0.0.1:1"}))
+	nodes[0].service.Cluster.Remove(nodes[1].address)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var unavailable int
+	for i := 0; i < 20; i++ {
+		_, err := nodes[0].client.Set(ctx, &memcached.SetRequest{
+			Item:        &memcached.Item{Key: fmt.Sprintf("key-%d", i), Value: []byte("value")},
+			Pool:        "replicated",
+			WriteQuorum: 3,
+		})
+		if status.Code(err) == codes.Unavailable {
+			unavailable++
+		} else {
+			require.NoError(t, err)
+		}
+	}
+	require.Equal(t, 20, unavailable)
+	require.EqualValues(t, 20, nodes[0].service.Cluster.ReplicationStats().FailedWrites)
+}
+
+func TestClusterHintedHandoff(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 2)
+	defer closeAll()
+
+	store, err := hints.New(hints.Config{Logger: testLogger})
+	require.NoError(t, err)
+	nodes[0].service.Hints = store
+
+	// a third peer that is down
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	down := lis.Addr().String()
+	lis.Close()
+
+	peers := []cluster.Peer{{Address: down}}
+	for _, node := range nodes {
+		require.NoError(t, node.service.Cluster.Add(cluster.Peer{Address: down}))
+		peers = append(peers, cluster.Peer{Address: node.address})
+	}
+	createReplicatedPool(t, nodes, "replicated", 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err = nodes[0].client.Set(ctx, &memcached.SetRequest{
+		Item:        &memcached.Item{Key: "key", Value: []byte("value")},
+		Pool:        "replicated",
+		WriteQuorum: 2,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 100 && store.Len(down) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, 1, store.Len(down))
+
+	// replays fail until the peer is back
+	_, err = nodes[0].service.ReplayHints(ctx, down)
+	require.Error(t, err)
+	require.Equal(t, 1, store.Len(down))
+
+	lis, err = net.Listen("tcp", down)
+	require.NoError(t, err)
+	node, closeNode := startNode(t, lis, peers)
+	defer closeNode()
+	createReplicatedPool(t, []*testNode{node}, "replicated", 3)
+
+	// the connection to the peer backs off after failures
+	for i := 0; i < 100 && store.Len(down) > 0; i++ {
+		nodes[0].service.ReplayHints(ctx, down)
+		time.Sleep(50 * time.Millisecond)
+	}
+	require.Zero(t, store.Len(down))
+	require.Equal(t, []byte("value"), node.localFetch("replicated", "key").Value)
+}
+
+func TestClusterHotKeys(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 2)
+	defer closeAll()
+
+	for _, node := range nodes {
+		node.service.Hot = hotcache.New(hotcache.Config{
+			Capacity:  1 << 20,
+			Threshold: 3,
+			Window:    time.Minute,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// a key nodes[0] forwards to nodes[1]
+	var key string
+	for i := 0; key == ""; i++ {
+		if k := fmt.Sprintf("key-%d", i); nodes[0].service.Cluster.Owner(k) == nodes[1].address {
+			key = k
+		}
+	}
+
+	_, err := nodes[0].client.Set(ctx, &memcached.SetRequest{
+		Item: &memcached.Item{Key: key, Value: []byte("v1")},
+	})
+	require.NoError(t, err)
+
+	get := func() []byte {
+		res, err := nodes[0].client.Get(ctx, &memcached.GetRequest{Key: key})
+		require.NoError(t, err)
+		require.NotNil(t, res.Item)
+		return res.Item.Value
+	}
+
+	// once hot, the key is served from a copy
+	for i := 0; i < 5; i++ {
+		require.Equal(t, []byte("v1"), get())
+	}
+	stats := nodes[0].service.Hot.Stats()
+	require.Equal(t, uint64(1), stats.Promotions)
+	require.Equal(t, uint64(2), stats.Hits)
+
+	// the owner invalidates the copy when the key changes
+	_, err = nodes[1].client.Set(ctx, &memcached.SetRequest{
+		Item: &memcached.Item{Key: key, Value: []byte("v2")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), nodes[0].service.Hot.Stats().Invalidations)
+	require.Equal(t, []byte("v2"), get())
+
+	// and when it is removed
+	get()
+	_, err = nodes[1].client.Remove(ctx, &memcached.RemoveRequest{Key: key})
+	require.NoError(t, err)
+	res, err := nodes[0].client.Get(ctx, &memcached.GetRequest{Key: key})
+	require.NoError(t, err)
+	require.Nil(t, res.Item)
+}
+
+func TestClusterSpilledReads(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 3)
+	defer closeAll()
+
+	for _, node := range nodes {
+		node.service.Hot = hotcache.New(hotcache.Config{
+			Capacity:  1 << 20,
+			Threshold: 3,
+			Window:    time.Minute,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// a key nodes[2] owns, whose reads nodes[0] spills to nodes[1]
+	var key string
+	for i := 0; key == ""; i++ {
+		if k := fmt.Sprintf("key-%d", i); nodes[0].service.Cluster.Owner(k) == nodes[2].address {
+			key = k
+		}
+	}
+	spilled := metadata.AppendToOutgoingContext(ctx,
+		cluster.ForwardedMetadataKey, nodes[0].address,
+		cluster.SpilledMetadataKey, "1")
+
+	_, err := nodes[0].client.Set(ctx, &memcached.SetRequest{
+		Item: &memcached.Item{Key: key, Value: []byte("v1")},
+	})
+	require.NoError(t, err)
+
+	get := func() []byte {
+		res, err := nodes[1].client.Get(spilled, &memcached.GetRequest{Key: key})
+		require.NoError(t, err)
+		require.NotNil(t, res.Item)
+		return res.Item.Value
+	}
+
+	// the peer reads the key from its owner, and keeps a copy once it is hot
+	for i := 0; i < 5; i++ {
+		require.Equal(t, []byte("v1"), get())
+	}
+	require.Nil(t, nodes[1].localGet(key))
+	require.Equal(t, uint64(2), nodes[1].service.Hot.Stats().Hits)
+
+	// which the owner invalidates when the key changes
+	_, err = nodes[0].client.Set(ctx, &memcached.SetRequest{
+		Item: &memcached.Item{Key: key, Value: []byte("v2")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), nodes[1].service.Hot.Stats().Invalidations)
+	require.Equal(t, []byte("v2"), get())
+}
+
+// forwarded returns the number of requests a node has forwarded.
+func (n *testNode) forwarded() uint64 {
+	var forwarded uint64
+	for _, stats := range n.service.Cluster.Stats() {
+		forwarded += stats.Forwarded
+	}
+	return forwarded
+}
+
+func TestClusterTopology(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 3)
+	defer closeAll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := nodes[0].client.GetTopology(ctx, &memcached.GetTopologyRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "ring", res.Topology.Strategy)
+	require.Len(t, res.Topology.Peers, 3)
+
+	c, err := client.New(client.Config{
+		ServiceURI:   nodes[0].address,
+		RouteToOwner: true,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	// a key nodes[0] would forward
+	var key string
+	for i := 0; key == ""; i++ {
+		if k := fmt.Sprintf("key-%d", i); nodes[0].service.Cluster.Owner(k) != nodes[0].address {
+			key = k
+		}
+	}
+
+	// requests go through nodes[0] until the client has the topology
+	for {
+		forwarded := nodes[0].forwarded()
+		_, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		if nodes[0].forwarded() == forwarded {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// after which every request goes straight to its owner
+	forwarded := make([]uint64, len(nodes))
+	for i, node := range nodes {
+		forwarded[i] = node.forwarded()
+	}
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		require.NoError(t, c.Set(ctx, &client.Item{Key: key, Value: []byte(key)}))
+
+		item, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, []byte(key), item.Value)
+	}
+	for i, node := range nodes {
+		require.Equal(t, forwarded[i], node.forwarded(), "node=%s", node.address)
+	}
+}
+
+func TestClusterWatch(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 3)
+	defer closeAll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := client.New(client.Config{ServiceURI: nodes[0].address})
+	require.NoError(t, err)
+	defer c.Close()
+
+	w, err := c.Watch(ctx, []string{"key-0"}, []string{"config/"})
+	require.NoError(t, err)
+	defer w.Close()
+
+	// the watch is forwarded to every node
+	for _, node := range nodes {
+		for node.service.Watches.Stats().Watchers == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// keys owned by every node
+	owners := make(map[string]string)
+	for i := 0; len(owners) < len(nodes); i++ {
+		key := fmt.Sprintf("config/%d", i)
+		owner := nodes[0].service.Cluster.Owner(key)
+		if _, ok := owners[owner]; !ok {
+			owners[owner] = key
+		}
+	}
+
+	expected := make(map[string]string)
+	for owner, key := range owners {
+		require.NoError(t, c.Set(ctx, &client.Item{Key: key, Value: []byte("value")}))
+		expected[key] = owner
+	}
+	require.NoError(t, c.Set(ctx, &client.Item{Key: "key-0", Value: []byte("value")}))
+	expected["key-0"] = nodes[0].service.Cluster.Owner("key-0")
+
+	// unwatched keys are not reported
+	require.NoError(t, c.Set(ctx, &client.Item{Key: "other", Value: []byte("value")}))
+
+	_, err = c.Remove(ctx, "key-0")
+	require.NoError(t, err)
+
+	// events from different nodes may arrive in any order
+	sets := make(map[string]string)
+	removed := false
+	for len(sets) < len(expected) || !removed {
+		event := <-w.Events()
+		require.NotNil(t, event, "watch ended: %v", w.Err())
+		if event.Type == client.EventRemove {
+			require.Equal(t, "key-0", event.Key)
+			require.Contains(t, sets, "key-0")
+			removed = true
+			continue
+		}
+		require.Equal(t, client.EventSet, event.Type)
+		require.Equal(t, int64(1), event.CasID)
+		sets[event.Key] = event.Node
+	}
+	require.Equal(t, expected, sets)
+
+	// every node reports its clear
+	require.NoError(t, c.ClearNamespace(ctx, ""))
+	cleared := make(map[string]bool)
+	for len(cleared) < len(nodes) {
+		event := <-w.Events()
+		require.NotNil(t, event, "watch ended: %v", w.Err())
+		require.Equal(t, client.EventClear, event.Type)
+		cleared[event.Node] = true
+	}
+
+	w.Close()
+	for range w.Events() {
+	}
+	require.NoError(t, w.Err())
+}
+
+func TestClusterNearCache(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 3)
+	defer closeAll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// a key nodes[0] forwards
+	var key string
+	var owner *testNode
+	for i := 0; owner == nil; i++ {
+		key = fmt.Sprintf("key-%d", i)
+		for _, node := range nodes[1:] {
+			if nodes[0].service.Cluster.Owner(key) == node.address {
+				owner = node
+			}
+		}
+	}
+
+	writer, err := client.New(client.Config{ServiceURI: nodes[0].address})
+	require.NoError(t, err)
+	defer writer.Close()
+	require.NoError(t, writer.Set(ctx, &client.Item{Key: key, Value: []byte("a")}))
+
+	c, err := client.New(client.Config{
+		ServiceURI:    nodes[0].address,
+		NearCacheSize: 1 << 20,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	// reads are tracked by the owner once the client is tracked everywhere
+	waitFor(t, func() bool {
+		item, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, "a", string(item.Value))
+		return owner.service.Tracking.Stats().Keys == 1
+	})
+
+	// and then served by the client
+	cached, err := owner.service.pick(ctx, "", "", key)
+	require.NoError(t, err)
+	hits := cached.Stats().Hits
+	item, err := c.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, "a", string(item.Value))
+	require.Equal(t, hits, cached.Stats().Hits)
+
+	// until another client changes the key
+	require.NoError(t, writer.Set(ctx, &client.Item{Key: key, Value: []byte("b")}))
+	waitFor(t, func() bool {
+		item, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		return string(item.Value) == "b"
+	})
+
+	// the client's own writes are read back
+	require.NoError(t, c.Set(ctx, &client.Item{Key: key, Value: []byte("c")}))
+	item, err = c.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, "c", string(item.Value))
+
+	// clears drop the tracked keys
+	waitFor(t, func() bool {
+		_, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		return owner.service.Tracking.Stats().Keys == 1
+	})
+	require.NoError(t, writer.ClearNamespace(ctx, ""))
+	waitFor(t, func() bool {
+		item, err := c.Get(ctx, key)
+		require.NoError(t, err)
+		return item == nil
+	})
+}
+
+func TestClusterNearCacheExpiry(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 1)
+	defer closeAll()
+	node := nodes[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for name, config := range map[string]pools.PoolConfig{
+		"ttl":  {TTL: time.Second},
+		"soft": {TTL: time.Minute, SoftTTL: time.Second},
+	} {
+		config.Name = name
+		config.Capacity = 1 << 20
+		config.CacheCount = 4
+		_, err := node.service.Pools.Create(config)
+		require.NoError(t, err)
+	}
+
+	// readNear reads key into a client's near cache, and returns the client
+	readNear := func(pool string) client.MemcachedClient {
+		c, err := client.New(client.Config{
+			ServiceURI:    node.address,
+			Pool:          pool,
+			NearCacheSize: 1 << 20,
+		})
+		require.NoError(t, err)
+		require.NoError(t, c.Set(ctx, &client.Item{Key: "key", Value: []byte("value")}))
+
+		waitFor(t, func() bool {
+			item, err := c.Get(ctx, "key")
+			require.NoError(t, err)
+			require.NotZero(t, item.ExpiresAt)
+			return node.service.Tracking.Stats().Keys > 0
+		})
+		cached, err := node.service.pick(ctx, pool, "", "key")
+		require.NoError(t, err)
+		hits := cached.Stats().Hits
+		_, err = c.Get(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, hits, cached.Stats().Hits)
+		return c
+	}
+
+	// the server never hears of an expiry it does not read, so the client
+	// expires its copy itself
+	c := readNear("ttl")
+	defer c.Close()
+	waitFor(t, func() bool {
+		item, err := c.Get(ctx, "key")
+		require.NoError(t, err)
+		return item == nil
+	})
+
+	// and leaves a copy that becomes stale for the server to mark
+	c = readNear("soft")
+	defer c.Close()
+	waitFor(t, func() bool {
+		item, err := c.Get(ctx, "key")
+		require.NoError(t, err)
+		return item.Stale
+	})
+}
+
+func TestClusterLeases(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 3)
+	defer closeAll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := client.New(client.Config{ServiceURI: nodes[0].address})
+	require.NoError(t, err)
+	defer c.Close()
+
+	// of many clients missing a key at once, one fills it
+	var fills int32
+	fill := func(ctx context.Context) (*client.Item, error) {
+		atomic.AddInt32(&fills, 1)
+		time.Sleep(50 * time.Millisecond)
+		return &client.Item{Value: []byte("value")}, nil
+	}
+	items := make([]*client.Item, 10)
+	errs := make([]error, len(items))
+	var wg sync.WaitGroup
+	for i := range items {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			items[i], errs[i] = client.GetWithLease(ctx, c, "key", client.LeaseOptions{}, fill)
+		}(i)
+	}
+	wg.Wait()
+	for i, item := range items {
+		require.NoError(t, errs[i])
+		require.Equal(t, "value", string(item.Value))
+	}
+	require.Equal(t, int32(1), fills)
+
+	// a fill after the key is removed is rejected, and the waiting clients
+	// may have the removed value
+	_, err = c.Remove(ctx, "key")
+	require.NoError(t, err)
+	lease, err := c.LeaseGet(ctx, "key")
+	require.NoError(t, err)
+	require.NotZero(t, lease.Token)
+
+	waiting, err := c.LeaseGet(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, waiting.Wait)
+	require.Equal(t, "value", string(waiting.Stale.Value))
+	item, err := client.GetWithLease(ctx, c, "key", client.LeaseOptions{UseStale: true}, fill)
+	require.NoError(t, err)
+	require.Equal(t, "value", string(item.Value))
+
+	_, err = c.Remove(ctx, "key")
+	require.NoError(t, err)
+	err = c.LeaseSet(ctx, &client.Item{Key: "key", Value: []byte("stale")}, lease.Token)
+	require.Equal(t, client.ErrLeaseInvalid, err)
+	item, err = c.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Nil(t, item)
+}
+
+func TestClusterStaleRefresh(t *testing.T) {
+	t.Parallel()
+
+	nodes, closeAll := startCluster(t, 1)
+	defer closeAll()
+	node := nodes[0]
+	node.service.Leases = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := node.service.Pools.Create(pools.PoolConfig{
+		Name:       "soft",
+		Capacity:   1 << 20,
+		CacheCount: 4,
+		TTL:        time.Minute,
+		SoftTTL:    50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	c, err := client.New(client.Config{
+		ServiceURI: node.address,
+		Pool:       "soft",
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.NoError(t, c.Set(ctx, &client.Item{Key: "key", Value: []byte("old")}))
+	time.Sleep(50 * time.Millisecond)
+
+	// with leases disabled, exactly one of the concurrent stale readers is
+	// granted the refresh, and every reader is served the stale item
+	items := make([]*client.Item, 20)
+	var wg sync.WaitGroup
+	for i := range items {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			item, err := c.Get(ctx, "key")
+			require.NoError(t, err)
+			items[i] = item
+		}(i)
+	}
+	wg.Wait()
+
+	var token uint64
+	for _, item := range items {
+		require.True(t, item.Stale)
+		require.Equal(t, "old", string(item.Value))
+		if item.Refresh != 0 {
+			require.Zero(t, token)
+			token = item.Refresh
+		}
+	}
+	require.NotZero(t, token)
+
+	// only its token refreshes the item
+	err = c.LeaseSet(ctx, &client.Item{Key: "key", Value: []byte("stale")}, token+1)
+	require.Equal(t, client.ErrLeaseInvalid, err)
+	require.NoError(t, c.LeaseSet(ctx, &client.Item{Key: "key", Value: []byte("new")}, token))
+
+	item, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, item.Stale)
+	require.Equal(t, "new", string(item.Value))
+
+	// and leases on missed keys stay disabled
+	_, err = c.LeaseGet(ctx, "missing")
+	require.Equal(t, codes.Unimplemented, status.Code(errors.Cause(err)))
+}
@@ -0,0 +1,164 @@
+package consistenthash
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Walker is a Hash that can list every node in its order of preference for
+// a key. The ring and rendezvous strategies are walkers.
+type Walker interface {
+	Hash
+
+	// Walk calls fn with each node, most preferred first, until fn returns
+	// false
+	Walk(key string, fn func(nodeID string) bool)
+
+	// Weight is a node's relative share of keys
+	Weight(nodeID string) int
+}
+
+// Loads reports the current load of nodes, e.g. their in-flight requests.
+type Loads interface {
+	Load(nodeID string) int64
+	Total() int64
+}
+
+// Bounded is consistent hashing with bounded loads (Mirrokni, Thorup and
+// Zadimoghaddam). A key goes to the first node in its walk whose load is
+// below (1+epsilon) times the average load, scaled by the node's weight, so
+// no node is overloaded by hot keys. Nodes under the bound keep their keys.
+type Bounded struct {
+	hash    Walker
+	epsilon float64
+	loads   Loads
+}
+
+func NewBounded(hash Walker, epsilon float64, loads Loads) *Bounded {
+	return &Bounded{
+		hash:    hash,
+		epsilon: epsilon,
+		loads:   loads,
+	}
+}
+
+func (b *Bounded) Add(nodeID string) {
+	b.hash.Add(nodeID)
+}
+
+func (b *Bounded) Remove(nodeID string) {
+	b.hash.Remove(nodeID)
+}
+
+func (b *Bounded) GetNode(key string) string {
+	candidates := b.Candidates(key)
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[len(candidates)-1]
+}
+
+// Candidates returns the nodes in key's walk from its owner up to and
+// including the first node within its load bound, which is the node to use.
+// If every node is above its bound, only the owner is returned.
+func (b *Bounded) Candidates(key string) []string {
+	nodes := b.hash.Nodes()
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	totalWeight := 0
+	for _, nodeID := range nodes {
+		totalWeight += b.hash.Weight(nodeID)
+	}
+
+	// the average load counts the request being placed
+	average := float64(b.loads.Total()+1) / float64(totalWeight)
+
+	var candidates []string
+	found := false
+	b.hash.Walk(key, func(nodeID string) bool {
+		candidates = append(candidates, nodeID)
+
+		bound := math.Ceil((1 + b.epsilon) * average * float64(b.hash.Weight(nodeID)))
+		if float64(b.loads.Load(nodeID)+1) <= bound {
+			found = true
+			return false
+		}
+		return true
+	})
+
+	if !found {
+		return candidates[:1]
+	}
+	return candidates
+}
+
+func (b *Bounded) Nodes() []string {
+	return b.hash.Nodes()
+}
+
+func (b *Bounded) Clone() Hash {
+	return NewBounded(b.hash.Clone().(Walker), b.epsilon, b.loads)
+}
+
+// InFlight counts in-flight requests per node. It implements Loads.
+type InFlight struct {
+	sync.RWMutex
+
+	counts map[string]*int64
+	total  int64
+}
+
+func NewInFlight() *InFlight {
+	return &InFlight{
+		counts: make(map[string]*int64),
+	}
+}
+
+// Acquire counts a request to nodeID until release is called.
+func (f *InFlight) Acquire(nodeID string) (release func()) {
+	count := f.count(nodeID)
+	atomic.AddInt64(count, 1)
+	atomic.AddInt64(&f.total, 1)
+
+	return func() {
+		atomic.AddInt64(count, -1)
+		atomic.AddInt64(&f.total, -1)
+	}
+}
+
+func (f *InFlight) Load(nodeID string) int64 {
+	f.RLock()
+	count, ok := f.counts[nodeID]
+	f.RUnlock()
+
+	if !ok {
+		return 0
+	}
+	return atomic.LoadInt64(count)
+}
+
+func (f *InFlight) Total() int64 {
+	return atomic.LoadInt64(&f.total)
+}
+
+func (f *InFlight) count(nodeID string) *int64 {
+	f.RLock()
+	count, ok := f.counts[nodeID]
+	f.RUnlock()
+	if ok {
+		return count
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if count, ok := f.counts[nodeID]; ok {
+		return count
+	}
+	count = new(int64)
+	f.counts[nodeID] = count
+	return count
+}
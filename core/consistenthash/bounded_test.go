@@ -0,0 +1,123 @@
+package consistenthash
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeightedRing(t *testing.T) {
+	t.Parallel()
+
+	hash := New([]string{"node-0"}, 160)
+	hash.AddWeighted("node-1", 3)
+
+	require.Equal(t, 4*160, hash.ringLength())
+	require.Equal(t, 3, hash.Weight("node-1"))
+	require.Equal(t, 1, hash.Weight("node-0"))
+
+	counts := make(map[string]int)
+	for i := 0; i < testKeys; i++ {
+		counts[hash.GetNode(fmt.Sprintf("key-%d", i))]++
+	}
+	require.InEpsilon(t, 0.75*testKeys, float64(counts["node-1"]), 0.1)
+
+	clone := hash.Clone().(*ConsistentHash)
+	require.Equal(t, 3, clone.Weight("node-1"))
+
+	hash.Remove("node-1")
+	require.Equal(t, 160, hash.ringLength())
+	require.Len(t, hash.Keys, 160)
+	require.Equal(t, 1, hash.Weight("node-1"))
+	require.Equal(t, 4*160, clone.ringLength())
+}
+
+type testLoads map[string]int64
+
+func (l testLoads) Load(nodeID string) int64 {
+	return l[nodeID]
+}
+
+func (l testLoads) Total() int64 {
+	var total int64
+	for _, load := range l {
+		total += load
+	}
+	return total
+}
+
+func TestBounded(t *testing.T) {
+	t.Parallel()
+
+	for _, hash := range []Walker{New(testNodeIDs(4), 160), NewRendezvous(testNodeIDs(4))} {
+		loads := testLoads{}
+		bounded := NewBounded(hash, 0.25, loads)
+
+		var walk []string
+		hash.Walk("key", func(nodeID string) bool {
+			walk = append(walk, nodeID)
+			return true
+		})
+		require.Len(t, walk, 4)
+		require.Equal(t, hash.GetNode("key"), walk[0])
+
+		// without load, keys stay with their owner
+		require.Equal(t, walk[:1], bounded.Candidates("key"))
+		require.Equal(t, walk[0], bounded.GetNode("key"))
+
+		// an overloaded owner spills to the next node in the walk
+		loads[walk[0]] = 10
+		require.Equal(t, walk[:2], bounded.Candidates("key"))
+		require.Equal(t, walk[1], bounded.GetNode("key"))
+
+		loads[walk[1]] = 10
+		require.Equal(t, walk[2], bounded.GetNode("key"))
+
+		// when every node is overloaded, the owner is used
+		loads[walk[2]] = 10
+		loads[walk[3]] = 10
+		require.Equal(t, walk[:1], bounded.Candidates("key"))
+
+		clone := bounded.Clone()
+		clone.Add("cache-4")
+		require.Len(t, bounded.Nodes(), 4)
+		require.Len(t, clone.Nodes(), 5)
+	}
+}
+
+func TestBoundedWeighted(t *testing.T) {
+	t.Parallel()
+
+	hash := New([]string{"node-0"}, 160)
+	hash.AddWeighted("node-1", 3)
+
+	// node-1's bound is three times node-0's
+	loads := testLoads{"node-0": 2, "node-1": 5}
+	bounded := NewBounded(hash, 0, loads)
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		require.Equal(t, "node-1", bounded.GetNode(key), "key=%s", key)
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	t.Parallel()
+
+	f := NewInFlight()
+	require.EqualValues(t, 0, f.Load("node-0"))
+
+	release0 := f.Acquire("node-0")
+	release1 := f.Acquire("node-0")
+	release2 := f.Acquire("node-1")
+	require.EqualValues(t, 2, f.Load("node-0"))
+	require.EqualValues(t, 1, f.Load("node-1"))
+	require.EqualValues(t, 3, f.Total())
+
+	release0()
+	release1()
+	release2()
+	require.EqualValues(t, 0, f.Load("node-0"))
+	require.EqualValues(t, 0, f.Total())
+}
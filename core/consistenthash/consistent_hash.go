@@ -1,23 +1,28 @@
 package consistenthash
 
 import (
-	"crypto/md5"
-	"fmt"
 	"sort"
+	"strconv"
 )
 
-type keys []string
-type ring map[string]string
+type keys []uint64
+type ring map[uint64]string
 type nodeIDs []string
 
 const defaultReplicas = 160
 
+// ConsistentHash is a hash ring. Each node is placed on the ring at Replicas
+// points times its weight, and a key belongs to the node at the first point
+// at or after the key's hash.
 type ConsistentHash struct {
 	Ring    ring
 	Keys    keys
 	NodeIDs nodeIDs
 
 	Replicas int
+
+	// Weights holds the weight of nodes added with AddWeighted
+	Weights map[string]int
 }
 
 func New(nodes []string, replicas int) *ConsistentHash {
@@ -45,22 +50,40 @@ func New(nodes []string, replicas int) *ConsistentHash {
 }
 
 func (s *ConsistentHash) Add(nodeID string) {
-	s.NodeIDs = append(s.NodeIDs, nodeID)
+	s.AddWeighted(nodeID, 1)
+}
+
+// AddWeighted adds a node with weight times the replicas of a node added
+// with Add, and so weight times its share of keys.
+func (s *ConsistentHash) AddWeighted(nodeID string, weight int) {
+	if weight < 1 {
+		weight = 1
+	}
+	if weight != 1 {
+		if s.Weights == nil {
+			s.Weights = make(map[string]int)
+		}
+		s.Weights[nodeID] = weight
+	}
 
-	for i := 0; i < s.Replicas; i++ {
-		replicaID := fmt.Sprintf("replica-%d", i)
-		keyID := nodeID + ":" + replicaID
+	s.NodeIDs = append(s.NodeIDs, nodeID)
 
-		var key = s.hash(keyID)
+	for i := 0; i < s.Replicas*weight; i++ {
+		key := s.replicaKey(nodeID, i)
 
 		s.Keys = append(s.Keys, key)
 		s.Ring[key] = nodeID
 	}
 
-	sort.Strings(s.Keys)
+	sort.Slice(s.Keys, func(i, j int) bool {
+		return s.Keys[i] < s.Keys[j]
+	})
 }
 
 func (s *ConsistentHash) Remove(nodeID string) {
+	weight := s.Weight(nodeID)
+	delete(s.Weights, nodeID)
+
 	for i := 0; i < len(s.NodeIDs); i++ {
 		if s.NodeIDs[i] == nodeID {
 			s.NodeIDs = append(s.NodeIDs[:i], s.NodeIDs[i+1:]...)
@@ -68,84 +91,121 @@ func (s *ConsistentHash) Remove(nodeID string) {
 		}
 	}
 
-	for i := 0; i < s.Replicas; i++ {
-		replicaID := fmt.Sprintf("replica-%d", i)
-		keyID := nodeID + ":" + replicaID
-
-		key := s.hash(keyID)
+	for i := 0; i < s.Replicas*weight; i++ {
+		key := s.replicaKey(nodeID, i)
 		delete(s.Ring, key)
 
 		for j := 0; j < len(s.Keys); j++ {
 			if s.Keys[j] == key {
-				s.Keys = append(s.Keys[:j], s.NodeIDs[j+1:]...)
+				s.Keys = append(s.Keys[:j], s.Keys[j+1:]...)
 				j--
 			}
 		}
 	}
 }
 
-func (s *ConsistentHash) GetNode(key string) string {
-	nodeID := s.getNodeID(key)
-	return nodeID
-}
+// Clone returns an independent copy of the hash, so that nodes can be added
+// or removed without changing the lookups of the original.
+func (s *ConsistentHash) Clone() Hash {
+	ring := make(ring, len(s.Ring))
+	for k, v := range s.Ring {
+		ring[k] = v
+	}
 
-func (s *ConsistentHash) getNodeID(key string) string {
-	if s.ringLength() == 0 {
-		return ""
+	var weights map[string]int
+	if s.Weights != nil {
+		weights = make(map[string]int, len(s.Weights))
+		for k, v := range s.Weights {
+			weights[k] = v
+		}
 	}
 
-	var h = s.hash(key)
-	var pos = s.getNodePosition(h)
+	return &ConsistentHash{
+		Ring:    ring,
+		Keys:    append(keys(nil), s.Keys...),
+		NodeIDs: append(nodeIDs(nil), s.NodeIDs...),
 
-	return s.Ring[s.Keys[pos]]
+		Replicas: s.Replicas,
+		Weights:  weights,
+	}
 }
 
-func (s *ConsistentHash) getNodePosition(hash string) int {
-	upper := s.ringLength() - 1
-	lower := 0
-	idx := 0
-	comp := 0
+// Weight returns the weight of a node; 1 unless it was added with
+// AddWeighted.
+func (s *ConsistentHash) Weight(nodeID string) int {
+	if w, ok := s.Weights[nodeID]; ok {
+		return w
+	}
+	return 1
+}
 
-	if upper == 0 {
-		return 0
+// Walk calls fn with each node in the order they follow key's hash around
+// the ring, until fn returns false.
+func (s *ConsistentHash) Walk(key string, fn func(nodeID string) bool) {
+	if len(s.Keys) == 0 {
+		return
 	}
 
-	for lower <= upper {
-		idx = (lower + upper) / 2
-		comp = s.compare(s.Keys[idx], hash)
+	seen := make([]string, 0, len(s.NodeIDs))
+	pos := s.getNodePosition(hashString(key))
+	for i := 0; i < len(s.Keys) && len(seen) < len(s.NodeIDs); i++ {
+		nodeID := s.Ring[s.Keys[(pos+i)%len(s.Keys)]]
+		if containsNode(seen, nodeID) {
+			continue
+		}
+		seen = append(seen, nodeID)
 
-		if comp == 0 {
-			return idx
-		} else if comp > 0 {
-			upper = idx - 1
-		} else {
-			lower = idx + 1
+		if !fn(nodeID) {
+			return
 		}
 	}
+}
 
-	if upper < 0 {
-		upper = s.ringLength() - 1
+func containsNode(nodeIDs []string, nodeID string) bool {
+	for _, id := range nodeIDs {
+		if id == nodeID {
+			return true
+		}
 	}
-
-	return upper
+	return false
 }
 
-func (s *ConsistentHash) ringLength() int {
-	return len(s.Ring)
+func (s *ConsistentHash) Nodes() []string {
+	return append([]string(nil), s.NodeIDs...)
 }
 
-func (s *ConsistentHash) hash(id string) string {
-	digest := md5.New()
-	digest.Write([]byte(id))
-	return fmt.Sprintf("%x", digest.Sum(nil))
+func (s *ConsistentHash) GetNode(key string) string {
+	if len(s.Keys) == 0 {
+		return ""
+	}
+
+	pos := s.getNodePosition(hashString(key))
+	return s.Ring[s.Keys[pos]]
 }
 
-func (s *ConsistentHash) compare(v1, v2 string) int {
-	if v1 > v2 {
-		return 1
-	} else if v1 < v2 {
-		return -1
-	} else {
+// getNodePosition returns the position of the first ring key at or after
+// hash, wrapping around to the start of the ring.
+func (s *ConsistentHash) getNodePosition(hash uint64) int {
+	lower, upper := 0, len(s.Keys)
+	for lower < upper {
+		idx := int(uint(lower+upper) >> 1)
+		if s.Keys[idx] < hash {
+			lower = idx + 1
+		} else {
+			upper = idx
+		}
+	}
+
+	if lower == len(s.Keys) {
 		return 0
 	}
+	return lower
+}
+
+func (s *ConsistentHash) ringLength() int {
+	return len(s.Ring)
+}
+
+func (s *ConsistentHash) replicaKey(nodeID string, replica int) uint64 {
+	return hashString(nodeID + ":replica-" + strconv.Itoa(replica))
 }
@@ -21,7 +21,7 @@ func TestHashing(t *testing.T) {
 	require.Equal(t, 3, hash.ringLength())
 
 	testCases := map[string]string{
-		"foo": "node-1",
+		"foo": "node-2",
 		"bar": "node-0",
 		"baz": "node-1",
 	}
@@ -35,7 +35,9 @@ func TestHashing(t *testing.T) {
 
 	require.Equal(t, 4, hash.ringLength())
 
-	testCases["another"] = "node-1"
+	// only keys claimed by the new node move
+	testCases["baz"] = "node-3"
+	testCases["another"] = "node-0"
 
 	for k, v := range testCases {
 		node := hash.GetNode(k)
@@ -60,6 +62,51 @@ func TestAddNode(t *testing.T) {
 	require.Equal(t, 800, hash.ringLength())
 }
 
+func TestRemoveNode(t *testing.T) {
+	t.Parallel()
+
+	nodes := []string{
+		"node-0",
+		"node-1",
+		"node-2",
+	}
+
+	hash := New(nodes, 160)
+	hash.Remove("node-1")
+
+	require.Equal(t, 320, hash.ringLength())
+	require.Len(t, hash.Keys, 320)
+	require.Equal(t, nodeIDs{"node-0", "node-2"}, hash.NodeIDs)
+
+	for i := 0; i < 1000; i++ {
+		require.NotEqual(t, "node-1", hash.GetNode(fmt.Sprintf("key-%d", i)))
+	}
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+
+	nodes := []string{
+		"node-0",
+		"node-1",
+	}
+
+	hash := New(nodes, 160)
+	clone := hash.Clone()
+	clone.Add("node-2")
+
+	require.Equal(t, 320, hash.ringLength())
+	require.Equal(t, 480, clone.(*ConsistentHash).ringLength())
+	require.Equal(t, nodeIDs{"node-0", "node-1"}, hash.NodeIDs)
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if node := clone.GetNode(key); node != "node-2" {
+			require.Equal(t, hash.GetNode(key), node)
+		}
+	}
+}
+
 func TestConsistency(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,134 @@
+package consistenthash
+
+import (
+	"fmt"
+)
+
+// Hash maps keys to nodes. Implementations are not safe for concurrent
+// modification, but GetNode may be called concurrently.
+type Hash interface {
+	Add(nodeID string)
+	Remove(nodeID string)
+	GetNode(key string) string
+
+	// Nodes returns the node IDs, in the order they were added
+	Nodes() []string
+
+	// Clone returns an independent copy of the hash
+	Clone() Hash
+}
+
+// Strategy selects the algorithm used to map keys to nodes.
+type Strategy int
+
+const (
+	// StrategyRing places every node at replicas points on a hash ring
+	StrategyRing Strategy = iota
+	// StrategyJump is Lamping and Veach's jump consistent hash. It only
+	// moves the minimum number of keys when the last node is added or
+	// removed.
+	StrategyJump
+	// StrategyRendezvous is highest random weight hashing. Lookups cost
+	// time linear in the number of nodes.
+	StrategyRendezvous
+	// StrategyMaglev is Google's Maglev hashing, which looks keys up in a
+	// fixed size table.
+	StrategyMaglev
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case StrategyRing:
+		return "ring"
+	case StrategyJump:
+		return "jump"
+	case StrategyRendezvous:
+		return "rendezvous"
+	case StrategyMaglev:
+		return "maglev"
+	default:
+		return fmt.Sprintf("Strategy(%d)", int(s))
+	}
+}
+
+func ParseStrategy(s string) (Strategy, error) {
+	switch s {
+	case "", "ring":
+		return StrategyRing, nil
+	case "jump":
+		return StrategyJump, nil
+	case "rendezvous":
+		return StrategyRendezvous, nil
+	case "maglev":
+		return StrategyMaglev, nil
+	default:
+		return 0, fmt.Errorf("unknown hash strategy %q", s)
+	}
+}
+
+func (s Strategy) MarshalText() ([]byte, error) {
+	if s < StrategyRing || s > StrategyMaglev {
+		return nil, fmt.Errorf("unknown hash strategy %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+func (s *Strategy) UnmarshalText(text []byte) error {
+	strategy, err := ParseStrategy(string(text))
+	if err != nil {
+		return err
+	}
+	*s = strategy
+	return nil
+}
+
+// NewHash returns a hash using strategy. replicas only applies to the ring.
+func NewHash(strategy Strategy, nodes []string, replicas int) Hash {
+	switch strategy {
+	case StrategyJump:
+		return NewJump(nodes)
+	case StrategyRendezvous:
+		return NewRendezvous(nodes)
+	case StrategyMaglev:
+		return NewMaglev(nodes)
+	default:
+		return New(nodes, replicas)
+	}
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// hashString is 64 bit FNV-1a followed by the murmur3 finalizer, which
+// spreads the similar node and key names that FNV alone leaves clustered.
+// Unlike hash/fnv it does not allocate.
+func hashString(s string) uint64 {
+	h := uint64(fnvOffset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime64
+	}
+	return mix(h)
+}
+
+// KeyHash is the 64 bit hash keys are placed by.
+func KeyHash(key string) uint64 {
+	return hashString(key)
+}
+
+// Mix is the murmur3 64 bit finalizer.
+func Mix(h uint64) uint64 {
+	return mix(h)
+}
+
+// mix is the murmur3 64 bit finalizer.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,200 @@
+package consistenthash
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var strategies = []Strategy{
+	StrategyRing,
+	StrategyJump,
+	StrategyRendezvous,
+	StrategyMaglev,
+}
+
+const (
+	testNodes = 10
+	testKeys  = 100000
+)
+
+func testNodeIDs(n int) []string {
+	nodes := make([]string, n)
+	for i := range nodes {
+		nodes[i] = fmt.Sprintf("cache-%d", i)
+	}
+	return nodes
+}
+
+func assignments(hash Hash) []string {
+	nodes := make([]string, testKeys)
+	for i := range nodes {
+		nodes[i] = hash.GetNode(fmt.Sprintf("key-%d", i))
+	}
+	return nodes
+}
+
+func TestStrategyDistribution(t *testing.T) {
+	t.Parallel()
+
+	// the largest deviation from an even share each strategy may have
+	tolerance := map[Strategy]float64{
+		StrategyRing:       0.2,
+		StrategyJump:       0.05,
+		StrategyRendezvous: 0.05,
+		StrategyMaglev:     0.05,
+	}
+
+	for _, strategy := range strategies {
+		hash := NewHash(strategy, testNodeIDs(testNodes), 0)
+
+		counts := make(map[string]int)
+		for _, node := range assignments(hash) {
+			counts[node]++
+		}
+		require.Len(t, counts, testNodes, "strategy=%s", strategy)
+
+		even := float64(testKeys) / testNodes
+		for node, count := range counts {
+			require.InEpsilon(t, even, float64(count), tolerance[strategy], "strategy=%s node=%s", strategy, node)
+		}
+	}
+}
+
+func TestStrategyRemapAdd(t *testing.T) {
+	t.Parallel()
+
+	for _, strategy := range strategies {
+		hash := NewHash(strategy, testNodeIDs(testNodes), 0)
+		before := assignments(hash)
+
+		hash.Add(fmt.Sprintf("cache-%d", testNodes))
+		after := assignments(hash)
+
+		moved, movedElsewhere := 0, 0
+		for i := range before {
+			if before[i] == after[i] {
+				continue
+			}
+			moved++
+			if after[i] != fmt.Sprintf("cache-%d", testNodes) {
+				movedElsewhere++
+			}
+		}
+
+		// ideally, the new node takes its share and nothing else moves
+		ideal := float64(testKeys) / (testNodes + 1)
+		require.InEpsilon(t, ideal, float64(moved), 0.1, "strategy=%s", strategy)
+
+		// maglev trades a little disruption for its even table
+		if strategy == StrategyMaglev {
+			require.True(t, float64(movedElsewhere) < 0.02*testKeys, "strategy=%s moved=%d", strategy, movedElsewhere)
+		} else {
+			require.Zero(t, movedElsewhere, "strategy=%s", strategy)
+		}
+	}
+}
+
+func TestStrategyRemapRemove(t *testing.T) {
+	t.Parallel()
+
+	for _, strategy := range strategies {
+		hash := NewHash(strategy, testNodeIDs(testNodes), 0)
+		before := assignments(hash)
+
+		// jump hash only remaps minimally when the last node is removed
+		removed := fmt.Sprintf("cache-%d", testNodes-1)
+		hash.Remove(removed)
+		require.Len(t, hash.Nodes(), testNodes-1)
+
+		after := assignments(hash)
+
+		moved := 0
+		for i := range before {
+			require.NotEqual(t, removed, after[i], "strategy=%s", strategy)
+			if before[i] != after[i] && before[i] != removed {
+				moved++
+			}
+		}
+
+		if strategy == StrategyMaglev {
+			require.True(t, float64(moved) < 0.02*testKeys, "strategy=%s moved=%d", strategy, moved)
+		} else {
+			require.Zero(t, moved, "strategy=%s", strategy)
+		}
+	}
+}
+
+func TestStrategyClone(t *testing.T) {
+	t.Parallel()
+
+	for _, strategy := range strategies {
+		hash := NewHash(strategy, testNodeIDs(2), 0)
+		clone := hash.Clone()
+		clone.Add("cache-2")
+
+		require.Equal(t, []string{"cache-0", "cache-1"}, hash.Nodes(), "strategy=%s", strategy)
+		require.Equal(t, []string{"cache-0", "cache-1", "cache-2"}, clone.Nodes(), "strategy=%s", strategy)
+
+		for i := 0; i < 1000; i++ {
+			require.NotEqual(t, "cache-2", hash.GetNode(fmt.Sprintf("key-%d", i)), "strategy=%s", strategy)
+		}
+	}
+}
+
+func TestStrategyEmpty(t *testing.T) {
+	t.Parallel()
+
+	for _, strategy := range strategies {
+		hash := NewHash(strategy, nil, 0)
+		require.Equal(t, "", hash.GetNode("key"), "strategy=%s", strategy)
+
+		hash.Add("cache-0")
+		require.Equal(t, "cache-0", hash.GetNode("key"), "strategy=%s", strategy)
+
+		hash.Remove("cache-0")
+		require.Equal(t, "", hash.GetNode("key"), "strategy=%s", strategy)
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	t.Parallel()
+
+	for _, strategy := range strategies {
+		text, err := strategy.MarshalText()
+		require.NoError(t, err)
+
+		var parsed Strategy
+		require.NoError(t, parsed.UnmarshalText(text))
+		require.Equal(t, strategy, parsed)
+	}
+
+	s, err := ParseStrategy("")
+	require.NoError(t, err)
+	require.Equal(t, StrategyRing, s)
+
+	_, err = ParseStrategy("modulo")
+	require.Error(t, err)
+
+	_, err = Strategy(17).MarshalText()
+	require.Error(t, err)
+}
+
+func BenchmarkJump8(b *testing.B)         { benchmarkStrategy(b, StrategyJump, 8) }
+func BenchmarkJump512(b *testing.B)       { benchmarkStrategy(b, StrategyJump, 512) }
+func BenchmarkRendezvous8(b *testing.B)   { benchmarkStrategy(b, StrategyRendezvous, 8) }
+func BenchmarkRendezvous512(b *testing.B) { benchmarkStrategy(b, StrategyRendezvous, 512) }
+func BenchmarkMaglev8(b *testing.B)       { benchmarkStrategy(b, StrategyMaglev, 8) }
+func BenchmarkMaglev512(b *testing.B)     { benchmarkStrategy(b, StrategyMaglev, 512) }
+
+func benchmarkStrategy(b *testing.B, strategy Strategy, nodeCount int) {
+	nodes := testNodeIDs(nodeCount)
+	hash := NewHash(strategy, nodes, 50)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		hash.GetNode(nodes[i&(nodeCount-1)])
+	}
+}
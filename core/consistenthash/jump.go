@@ -0,0 +1,52 @@
+package consistenthash
+
+// Jump is Lamping and Veach's jump consistent hash, "A Fast, Minimal Memory,
+// Consistent Hash Algorithm". Nodes are numbered buckets, so removing any
+// node but the last renumbers, and remaps, the nodes after it.
+type Jump struct {
+	nodeIDs nodeIDs
+}
+
+func NewJump(nodes []string) *Jump {
+	return &Jump{
+		nodeIDs: append(nodeIDs(nil), nodes...),
+	}
+}
+
+func (j *Jump) Add(nodeID string) {
+	j.nodeIDs = append(j.nodeIDs, nodeID)
+}
+
+func (j *Jump) Remove(nodeID string) {
+	for i := 0; i < len(j.nodeIDs); i++ {
+		if j.nodeIDs[i] == nodeID {
+			j.nodeIDs = append(j.nodeIDs[:i], j.nodeIDs[i+1:]...)
+			i--
+		}
+	}
+}
+
+func (j *Jump) GetNode(key string) string {
+	if len(j.nodeIDs) == 0 {
+		return ""
+	}
+	return j.nodeIDs[jumpHash(hashString(key), len(j.nodeIDs))]
+}
+
+func (j *Jump) Nodes() []string {
+	return append([]string(nil), j.nodeIDs...)
+}
+
+func (j *Jump) Clone() Hash {
+	return NewJump(j.nodeIDs)
+}
+
+func jumpHash(key uint64, buckets int) int {
+	var b, i int64 = -1, 0
+	for i < int64(buckets) {
+		b = i
+		key = key*2862933555777941757 + 1
+		i = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
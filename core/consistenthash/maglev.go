@@ -0,0 +1,102 @@
+package consistenthash
+
+// maglevTableSize is the lookup table size. It must be prime, and much
+// larger than the number of nodes for keys to spread evenly.
+const maglevTableSize = 65537
+
+// Maglev is the consistent hash from "Maglev: A Fast and Reliable Software
+// Network Load Balancer". Each node fills the lookup table in its own
+// permutation order, so nodes get near equal shares and a change of nodes
+// moves few keys other than those of the node added or removed. The table
+// is rebuilt on every Add and Remove.
+type Maglev struct {
+	nodeIDs nodeIDs
+
+	// table holds indexes into nodeIDs. It is replaced, never modified, so
+	// clones can share it.
+	table []int32
+}
+
+func NewMaglev(nodes []string) *Maglev {
+	m := &Maglev{
+		nodeIDs: append(nodeIDs(nil), nodes...),
+	}
+	m.populate()
+	return m
+}
+
+func (m *Maglev) Add(nodeID string) {
+	m.nodeIDs = append(m.nodeIDs, nodeID)
+	m.populate()
+}
+
+func (m *Maglev) Remove(nodeID string) {
+	for i := 0; i < len(m.nodeIDs); i++ {
+		if m.nodeIDs[i] == nodeID {
+			m.nodeIDs = append(m.nodeIDs[:i], m.nodeIDs[i+1:]...)
+			i--
+		}
+	}
+	m.populate()
+}
+
+func (m *Maglev) GetNode(key string) string {
+	if len(m.nodeIDs) == 0 {
+		return ""
+	}
+	return m.nodeIDs[m.table[hashString(key)%maglevTableSize]]
+}
+
+func (m *Maglev) Nodes() []string {
+	return append([]string(nil), m.nodeIDs...)
+}
+
+func (m *Maglev) Clone() Hash {
+	return &Maglev{
+		nodeIDs: append(nodeIDs(nil), m.nodeIDs...),
+		table:   m.table,
+	}
+}
+
+// populate fills the lookup table by letting each node in turn claim the
+// next free entry in its permutation.
+func (m *Maglev) populate() {
+	n := len(m.nodeIDs)
+	if n == 0 {
+		m.table = nil
+		return
+	}
+
+	offsets := make([]uint64, n)
+	skips := make([]uint64, n)
+	next := make([]uint64, n)
+	for i, nodeID := range m.nodeIDs {
+		h := hashString(nodeID)
+		offsets[i] = h % maglevTableSize
+		skips[i] = mix(h^0x9e3779b97f4a7c15)%(maglevTableSize-1) + 1
+	}
+
+	table := make([]int32, maglevTableSize)
+	for i := range table {
+		table[i] = -1
+	}
+
+	for filled := 0; ; {
+		for i := 0; i < n; i++ {
+			c := (offsets[i] + next[i]*skips[i]) % maglevTableSize
+			for table[c] >= 0 {
+				next[i]++
+				c = (offsets[i] + next[i]*skips[i]) % maglevTableSize
+			}
+
+			table[c] = int32(i)
+			next[i]++
+			filled++
+
+			if filled == maglevTableSize {
+				m.table = table
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package consistenthash
+
+import (
+	"sort"
+)
+
+// Rendezvous is highest random weight hashing: a key belongs to the node
+// with the highest hash of the key and node together. Adding or removing a
+// node only moves the keys that node wins or loses.
+type Rendezvous struct {
+	nodeIDs    nodeIDs
+	nodeHashes []uint64
+}
+
+func NewRendezvous(nodes []string) *Rendezvous {
+	r := &Rendezvous{}
+	for _, node := range nodes {
+		r.Add(node)
+	}
+	return r
+}
+
+func (r *Rendezvous) Add(nodeID string) {
+	r.nodeIDs = append(r.nodeIDs, nodeID)
+	r.nodeHashes = append(r.nodeHashes, hashString(nodeID))
+}
+
+func (r *Rendezvous) Remove(nodeID string) {
+	for i := 0; i < len(r.nodeIDs); i++ {
+		if r.nodeIDs[i] == nodeID {
+			r.nodeIDs = append(r.nodeIDs[:i], r.nodeIDs[i+1:]...)
+			r.nodeHashes = append(r.nodeHashes[:i], r.nodeHashes[i+1:]...)
+			i--
+		}
+	}
+}
+
+func (r *Rendezvous) GetNode(key string) string {
+	if len(r.nodeIDs) == 0 {
+		return ""
+	}
+
+	keyHash := hashString(key)
+
+	best, bestWeight := 0, uint64(0)
+	for i, nodeHash := range r.nodeHashes {
+		if weight := mix(keyHash ^ nodeHash); weight > bestWeight {
+			best, bestWeight = i, weight
+		}
+	}
+	return r.nodeIDs[best]
+}
+
+// Weight returns 1; rendezvous nodes are not weighted.
+func (r *Rendezvous) Weight(nodeID string) int {
+	return 1
+}
+
+// Walk calls fn with each node in descending order of weight for key, until
+// fn returns false.
+func (r *Rendezvous) Walk(key string, fn func(nodeID string) bool) {
+	keyHash := hashString(key)
+
+	order := make([]int, len(r.nodeIDs))
+	weights := make([]uint64, len(r.nodeIDs))
+	for i, nodeHash := range r.nodeHashes {
+		order[i] = i
+		weights[i] = mix(keyHash ^ nodeHash)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return weights[order[i]] > weights[order[j]]
+	})
+
+	for _, i := range order {
+		if !fn(r.nodeIDs[i]) {
+			return
+		}
+	}
+}
+
+func (r *Rendezvous) Nodes() []string {
+	return append([]string(nil), r.nodeIDs...)
+}
+
+func (r *Rendezvous) Clone() Hash {
+	return &Rendezvous{
+		nodeIDs:    append(nodeIDs(nil), r.nodeIDs...),
+		nodeHashes: append([]uint64(nil), r.nodeHashes...),
+	}
+}
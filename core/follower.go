@@ -0,0 +1,213 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tescherm/mc/core/caches"
+	"github.com/tescherm/mc/core/pools"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReplicaStats is a point in time view of a replica following its primary.
+type ReplicaStats struct {
+	Connected bool
+
+	// Sequence is the primary's sequence number of the last mutation
+	// applied, and Latest the primary's latest as last heard
+	Sequence uint64
+	Latest   uint64
+
+	// Lag is how long before the last message was received the primary
+	// applied its mutations, or sent it if it had none
+	Lag         time.Duration
+	LastContact time.Time
+
+	Applied   uint64
+	Snapshots uint64
+	Errors    uint64
+}
+
+type follower struct {
+	sync.Mutex
+
+	stats ReplicaStats
+}
+
+// ReplicaStats returns the replica's view of its primary.
+func (s *MemcachedService) ReplicaStats() ReplicaStats {
+	s.follower.Lock()
+	defer s.follower.Unlock()
+
+	return s.follower.stats
+}
+
+// Follow replicates primary until ctx is cancelled or the stream fails. The
+// replica's contents are replaced by the snapshot the stream starts with.
+func (s *MemcachedService) Follow(ctx context.Context, primary memcached.MemcachedClient, name string) error {
+	stream, err := primary.Replicate(ctx, &memcached.ReplicateRequest{
+		Replica: name,
+	})
+	if err != nil {
+		return err
+	}
+	defer s.disconnected()
+
+	first := true
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		if first {
+			if !res.Snapshot {
+				return status.Errorf(codes.Internal, "replication stream did not start with a snapshot")
+			}
+			s.clearAll()
+			first = false
+		}
+
+		for _, m := range res.Mutations {
+			// snapshot items are ordered before the mutations that follow
+			sequence := m.Sequence
+			if res.Snapshot {
+				sequence = res.Sequence
+			}
+			if err := s.applyReplicated(ctx, m, sequence); err != nil {
+				s.follower.Lock()
+				s.follower.stats.Errors++
+				s.follower.Unlock()
+
+				s.Logger.WithError(err).WithFields(logrus.Fields{
+					"op":        m.Op,
+					"pool":      m.Pool,
+					"namespace": m.Namespace,
+					"sequence":  m.Sequence,
+				}).Warn("unable to apply replicated mutation")
+			}
+		}
+
+		s.received(res)
+	}
+}
+
+// received records a message from the primary.
+func (s *MemcachedService) received(res *memcached.ReplicateResponse) {
+	now := time.Now()
+	lag := now.Sub(time.Unix(0, res.Timestamp))
+	if lag < 0 {
+		lag = 0
+	}
+
+	s.follower.Lock()
+	defer s.follower.Unlock()
+
+	stats := &s.follower.stats
+	stats.Connected = true
+	stats.Sequence = res.Sequence
+	stats.Latest = res.Latest
+	stats.Lag = lag
+	stats.LastContact = now
+	stats.Applied += uint64(len(res.Mutations))
+	if res.SnapshotDone {
+		stats.Snapshots++
+	}
+}
+
+func (s *MemcachedService) disconnected() {
+	s.follower.Lock()
+	defer s.follower.Unlock()
+
+	s.follower.stats.Connected = false
+}
+
+// clearAll removes every item from every pool, before a snapshot.
+func (s *MemcachedService) clearAll() {
+	for _, pool := range s.Pools.List() {
+		pool.Caches.Clear()
+	}
+}
+
+// applyReplicated applies a mutation from the primary. Items are stored with
+// the sequence number as their timestamp, so that they keep their version
+// from the primary.
+func (s *MemcachedService) applyReplicated(ctx context.Context, m *memcached.Mutation, sequence uint64) error {
+	switch m.Op {
+	case memcached.Mutation_CREATE_POOL:
+		if m.PoolConfig == nil {
+			return nil
+		}
+		_, err := s.Pools.Create(toPoolConfig(m.PoolConfig))
+		if err == pools.ErrPoolExists {
+			return nil
+		}
+		return err
+
+	case memcached.Mutation_DELETE_POOL:
+		err := s.Pools.Delete(m.Pool)
+		if err == pools.ErrPoolNotFound {
+			return nil
+		}
+		return err
+	}
+
+	ns, err := s.namespace(ctx, m.Pool, m.Namespace)
+	if err != nil {
+		return err
+	}
+
+	switch m.Op {
+	case memcached.Mutation_SET:
+		if m.Item == nil {
+			return nil
+		}
+		c := ns.CacheForKey(m.Item.Key)
+		if c == nil {
+			return nil
+		}
+		item := toCacheItem(m.Item)
+		item.Timestamp = sequence
+		item.Deleted = false
+		c.Merge(item)
+
+	case memcached.Mutation_REMOVE:
+		if m.Item == nil {
+			return nil
+		}
+		if c := ns.CacheForKey(m.Item.Key); c != nil {
+			c.Remove(m.Item.Key)
+		}
+
+	case memcached.Mutation_CLEAR_NAMESPACE:
+		ns.Clear()
+
+	case memcached.Mutation_INVALIDATE_TAGS:
+		ns.InvalidateTags(m.Tags)
+
+	case memcached.Mutation_SET_GENERATION:
+		ns.SetGeneration(m.Generation)
+
+	case memcached.Mutation_DELETE_MATCHING:
+		// applied in line, so that it does not remove later sets
+		var match caches.Matcher
+		if m.Prefix != "" {
+			match = caches.MatchPrefix(m.Prefix)
+		} else {
+			match, err = caches.MatchGlob(m.Pattern)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := ns.RemoveMatching(ctx, match, nil)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package governor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultCgroupRoot is where the cgroup filesystem is mounted.
+const DefaultCgroupRoot = "/sys/fs/cgroup"
+
+// cgroup v1 reports no limit as a very large page aligned number
+const cgroupUnlimited = 1 << 62
+
+// cgroupMemory is the memory limit and usage of the process cgroup.
+type cgroupMemory struct {
+	// Limit is zero if the cgroup has no memory limit
+	Limit uint64
+	Usage uint64
+}
+
+// readCgroupMemory reads the cgroup memory limit and usage under root,
+// supporting both the unified (v2) and legacy (v1) hierarchies. It returns
+// false if root has no memory controller.
+func readCgroupMemory(root string) (cgroupMemory, bool, error) {
+	// the unified hierarchy has a cgroup.controllers file at its root
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return readCgroupFiles(root, "memory.max", "memory.current")
+	}
+
+	v1 := filepath.Join(root, "memory")
+	if _, err := os.Stat(v1); err == nil {
+		return readCgroupFiles(v1, "memory.limit_in_bytes", "memory.usage_in_bytes")
+	}
+
+	return cgroupMemory{}, false, nil
+}
+
+func readCgroupFiles(dir, limitFile, usageFile string) (cgroupMemory, bool, error) {
+	var m cgroupMemory
+
+	limit, err := readCgroupValue(filepath.Join(dir, limitFile))
+	if os.IsNotExist(err) {
+		// e.g. the root cgroup, which has no limit
+		return m, false, nil
+	}
+	if err != nil {
+		return m, false, err
+	}
+	if limit < cgroupUnlimited {
+		m.Limit = limit
+	}
+
+	usage, err := readCgroupValue(filepath.Join(dir, usageFile))
+	if err != nil && !os.IsNotExist(err) {
+		return m, false, err
+	}
+	m.Usage = usage
+
+	return m, true, nil
+}
+
+// readCgroupValue reads a cgroup file holding a byte count, or "max".
+func readCgroupValue(path string) (uint64, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	s := strings.TrimSpace(string(b))
+	if s == "max" {
+		return cgroupUnlimited, nil
+	}
+
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to parse %s", path)
+	}
+	return v, nil
+}
@@ -0,0 +1,166 @@
+package governor
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tescherm/mc/core/pools"
+)
+
+const (
+	defaultThreshold = 0.8
+	defaultMaxTrim   = 0.25
+)
+
+type Config struct {
+	Pools *pools.Pools
+
+	// Limit is the process memory limit, in bytes. If zero, the cgroup
+	// memory limit is used.
+	Limit uint64
+
+	// Threshold is the fraction of the limit at which caches are trimmed
+	Threshold float64
+
+	// MaxTrim is the largest fraction of the caches' contents evicted by a
+	// single Check
+	MaxTrim float64
+
+	// CgroupRoot is where the cgroup filesystem is mounted
+	CgroupRoot string
+
+	Logger *logrus.Entry
+}
+
+// Stats is the governor's view of memory, in bytes.
+type Stats struct {
+	// Limit is zero if there is no limit
+	Limit uint64
+	// Usage is the memory held by the process
+	Usage uint64
+
+	HeapInuse   uint64
+	CgroupUsage uint64
+
+	// CacheSize is the logical size of every cache
+	CacheSize uint64
+
+	Trims        uint64
+	TrimmedBytes uint64
+}
+
+// Governor keeps process memory below a fraction of the memory limit by
+// evicting from every cache when it gets close. The caches' logical
+// capacity does not count map, list and allocator overhead, so without it a
+// process configured near its container's limit can be killed for running
+// out of memory.
+type Governor struct {
+	sync.Mutex
+
+	pools *pools.Pools
+
+	limit      uint64
+	threshold  float64
+	maxTrim    float64
+	cgroupRoot string
+
+	logger *logrus.Entry
+
+	// readMemStats is overridden by tests
+	readMemStats func(*runtime.MemStats)
+
+	stats Stats
+}
+
+func New(config Config) *Governor {
+	if config.Threshold <= 0 || config.Threshold > 1 {
+		config.Threshold = defaultThreshold
+	}
+	if config.MaxTrim <= 0 || config.MaxTrim > 1 {
+		config.MaxTrim = defaultMaxTrim
+	}
+	if config.CgroupRoot == "" {
+		config.CgroupRoot = DefaultCgroupRoot
+	}
+	if config.Logger == nil {
+		config.Logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+
+	return &Governor{
+		pools:        config.Pools,
+		limit:        config.Limit,
+		threshold:    config.Threshold,
+		maxTrim:      config.MaxTrim,
+		cgroupRoot:   config.CgroupRoot,
+		logger:       config.Logger,
+		readMemStats: runtime.ReadMemStats,
+	}
+}
+
+// Check measures memory and, if usage is above the threshold, trims every
+// cache in proportion to the excess. It returns the number of bytes freed.
+// Check is meant to be called periodically.
+func (g *Governor) Check() uint64 {
+	g.Lock()
+	defer g.Unlock()
+
+	var m runtime.MemStats
+	g.readMemStats(&m)
+
+	g.stats.Usage = m.Sys - m.HeapReleased
+	g.stats.HeapInuse = m.HeapInuse
+	g.stats.Limit = g.limit
+
+	cgroup, ok, err := readCgroupMemory(g.cgroupRoot)
+	if err != nil {
+		g.logger.WithError(err).Warn("unable to read cgroup memory")
+	}
+	if ok {
+		g.stats.CgroupUsage = cgroup.Usage
+		if g.stats.Limit == 0 {
+			g.stats.Limit = cgroup.Limit
+		}
+	}
+
+	g.stats.CacheSize = 0
+	for _, pool := range g.pools.List() {
+		g.stats.CacheSize += pool.Caches.Stats().CurrentCapacity
+	}
+
+	target := uint64(float64(g.stats.Limit) * g.threshold)
+	if g.stats.Limit == 0 || g.stats.Usage <= target || g.stats.CacheSize == 0 {
+		return 0
+	}
+
+	// assume the excess is made up of cache contents and their overhead
+	fraction := float64(g.stats.Usage-target) / float64(g.stats.Usage)
+	if fraction > g.maxTrim {
+		fraction = g.maxTrim
+	}
+
+	freed := g.pools.Trim(fraction)
+	g.stats.Trims++
+	g.stats.TrimmedBytes += freed
+
+	g.logger.WithFields(logrus.Fields{
+		"usage":    g.stats.Usage,
+		"limit":    g.stats.Limit,
+		"fraction": fraction,
+		"freed":    freed,
+	}).Info("trimmed caches to relieve memory pressure")
+
+	// return the freed memory now, rather than wait for the next GC, so the
+	// next Check does not trim again for memory that is already free
+	debug.FreeOSMemory()
+
+	return freed
+}
+
+func (g *Governor) Stats() Stats {
+	g.Lock()
+	defer g.Unlock()
+
+	return g.stats
+}
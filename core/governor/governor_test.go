@@ -0,0 +1,211 @@
+package governor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/pools"
+)
+
+func tempCgroup(t *testing.T, files map[string]string) (string, func()) {
+	dir, err := ioutil.TempDir("", "cgroup")
+	require.NoError(t, err)
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	return dir, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestReadCgroupMemory(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		files map[string]string
+		ok    bool
+		mem   cgroupMemory
+	}{
+		{
+			name: "v2",
+			files: map[string]string{
+				"cgroup.controllers": "cpu memory",
+				"memory.max":         "134217728\n",
+				"memory.current":     "1048576\n",
+			},
+			ok:  true,
+			mem: cgroupMemory{Limit: 128 << 20, Usage: 1 << 20},
+		},
+		{
+			name: "v2 unlimited",
+			files: map[string]string{
+				"cgroup.controllers": "cpu memory",
+				"memory.max":         "max\n",
+				"memory.current":     "1048576\n",
+			},
+			ok:  true,
+			mem: cgroupMemory{Usage: 1 << 20},
+		},
+		{
+			name: "v2 root",
+			files: map[string]string{
+				"cgroup.controllers": "cpu memory",
+			},
+		},
+		{
+			name: "v1",
+			files: map[string]string{
+				"memory/memory.limit_in_bytes": "268435456\n",
+				"memory/memory.usage_in_bytes": "2097152\n",
+			},
+			ok:  true,
+			mem: cgroupMemory{Limit: 256 << 20, Usage: 2 << 20},
+		},
+		{
+			name: "v1 unlimited",
+			files: map[string]string{
+				"memory/memory.limit_in_bytes": "9223372036854771712\n",
+				"memory/memory.usage_in_bytes": "2097152\n",
+			},
+			ok:  true,
+			mem: cgroupMemory{Usage: 2 << 20},
+		},
+		{
+			name: "none",
+		},
+	}
+
+	for _, tc := range testCases {
+		root, cleanup := tempCgroup(t, tc.files)
+
+		mem, ok, err := readCgroupMemory(root)
+		cleanup()
+
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.ok, ok, tc.name)
+		require.Equal(t, tc.mem, mem, tc.name)
+	}
+
+	root, cleanup := tempCgroup(t, map[string]string{
+		"cgroup.controllers": "memory",
+		"memory.max":         "lots",
+	})
+	defer cleanup()
+
+	_, _, err := readCgroupMemory(root)
+	require.Error(t, err)
+}
+
+func newPools(t *testing.T) *pools.Pools {
+	p, err := pools.New(pools.Config{
+		Default: pools.PoolConfig{
+			Capacity:   1 << 20,
+			CacheCount: 4,
+			Replicas:   160,
+		},
+	})
+	require.NoError(t, err)
+
+	pool, _ := p.Get(pools.DefaultPool)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		pool.Caches.CacheForKey(key).Set(&cache.Item{Key: key, Value: make([]byte, 100)})
+	}
+	return p
+}
+
+func TestGovernorCheck(t *testing.T) {
+	t.Parallel()
+
+	root, cleanup := tempCgroup(t, map[string]string{
+		"cgroup.controllers": "memory",
+		"memory.max":         "1000000",
+		"memory.current":     "900000",
+	})
+	defer cleanup()
+
+	p := newPools(t)
+	pool, _ := p.Get(pools.DefaultPool)
+
+	g := New(Config{
+		Pools:      p,
+		CgroupRoot: root,
+	})
+
+	var usage uint64
+	g.readMemStats = func(m *runtime.MemStats) {
+		*m = runtime.MemStats{Sys: usage, HeapInuse: usage / 2}
+	}
+
+	// below the threshold, nothing is trimmed
+	usage = 700000
+	require.EqualValues(t, 0, g.Check())
+	require.EqualValues(t, 1000, pool.Caches.Size())
+
+	stats := g.Stats()
+	require.EqualValues(t, 1000000, stats.Limit)
+	require.EqualValues(t, 700000, stats.Usage)
+	require.EqualValues(t, 350000, stats.HeapInuse)
+	require.EqualValues(t, 900000, stats.CgroupUsage)
+	require.True(t, stats.CacheSize > 100000)
+
+	// 10% over the threshold trims the excess fraction of usage
+	usage = 1000000
+	freed := g.Check()
+	require.True(t, freed > 0)
+	require.InDelta(t, 800, pool.Caches.Size(), 10)
+
+	// at most MaxTrim is evicted at once
+	usage = 10000000
+	g.Check()
+	require.InDelta(t, 600, pool.Caches.Size(), 10)
+
+	stats = g.Stats()
+	require.EqualValues(t, 2, stats.Trims)
+	require.True(t, stats.TrimmedBytes > freed)
+
+	// capacities are unchanged
+	require.EqualValues(t, 1<<20, pool.Caches.Stats().MaxCapacity)
+}
+
+func TestGovernorLimit(t *testing.T) {
+	t.Parallel()
+
+	root, cleanup := tempCgroup(t, nil)
+	defer cleanup()
+
+	p := newPools(t)
+
+	g := New(Config{
+		Pools:      p,
+		CgroupRoot: root,
+	})
+	g.readMemStats = func(m *runtime.MemStats) {
+		*m = runtime.MemStats{Sys: 1 << 30}
+	}
+
+	// without a cgroup limit or a configured limit, nothing is trimmed
+	require.EqualValues(t, 0, g.Check())
+
+	g = New(Config{
+		Pools:      p,
+		Limit:      1 << 29,
+		CgroupRoot: root,
+	})
+	g.readMemStats = func(m *runtime.MemStats) {
+		*m = runtime.MemStats{Sys: 1 << 30}
+	}
+	require.True(t, g.Check() > 0)
+	require.EqualValues(t, 1<<29, g.Stats().Limit)
+}
@@ -0,0 +1,326 @@
+// Package hints stores the replicated writes that could not reach a replica,
+// so that they can be handed off to it once it is back.
+package hints
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tescherm/mc/pb"
+)
+
+const (
+	defaultMaxHints = 100000
+	defaultTTL      = 3 * time.Hour
+
+	// dropFraction is the fraction of a peer's hints dropped at once when
+	// the store is full, so that a full store does not drop on every add
+	dropFraction = 10
+)
+
+// Hint is a write to a key's replica on a peer that did not reach it.
+type Hint struct {
+	Pool      string          `json:"pool"`
+	Namespace string          `json:"namespace"`
+	Item      *memcached.Item `json:"item"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Config struct {
+	// MaxHints bounds the number of hints held for all peers
+	MaxHints int
+
+	// TTL is how long a hint is kept for a peer that stays away. Writes
+	// older than that are left to anti-entropy.
+	TTL time.Duration
+
+	// Dir, if set, is the directory hints are persisted in, so that they
+	// survive restarts
+	Dir string
+
+	Logger logrus.FieldLogger
+}
+
+// Stats is a point in time view of the store.
+type Stats struct {
+	// Queued is the number of hints held for each peer
+	Queued map[string]int
+
+	Stored   uint64
+	Replayed uint64
+	Expired  uint64
+
+	// Dropped counts the hints dropped because the store was full
+	Dropped uint64
+}
+
+// Store holds hints in memory, in a queue per peer, and optionally in a log
+// per peer on disk.
+type Store struct {
+	sync.Mutex
+
+	maxHints int
+	ttl      time.Duration
+
+	queues    map[string][]Hint
+	count     int
+	replaying map[string]bool
+
+	// trimmed counts the hints removed from each peer's log since it was
+	// last compacted
+	trimmed map[string]int
+
+	stored   uint64
+	replayed uint64
+	expired  uint64
+	dropped  uint64
+
+	logs   *logs
+	now    func() time.Time
+	logger logrus.FieldLogger
+}
+
+// New returns a store, loading any hints persisted in config.Dir.
+func New(config Config) (*Store, error) {
+	if config.MaxHints <= 0 {
+		config.MaxHints = defaultMaxHints
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultTTL
+	}
+
+	s := &Store{
+		maxHints:  config.MaxHints,
+		ttl:       config.TTL,
+		queues:    make(map[string][]Hint),
+		replaying: make(map[string]bool),
+		trimmed:   make(map[string]int),
+		now:       time.Now,
+		logger:    config.Logger.WithField("module", "hints"),
+	}
+
+	if config.Dir != "" {
+		s.logs = &logs{dir: config.Dir}
+
+		queues, err := s.logs.load()
+		if err != nil {
+			return nil, err
+		}
+		for peer, queue := range queues {
+			s.queues[peer] = queue
+			s.count += len(queue)
+			s.rewrite(peer)
+		}
+		s.logger.WithField("hints", s.count).Info("loaded hints")
+
+		// trim what no longer fits or is out of date
+		s.expire()
+		for s.count > s.maxHints {
+			s.dropOldest(s.count - s.maxHints)
+		}
+	}
+	return s, nil
+}
+
+// Add stores a hint for peer. If the store is full, the oldest tenth of the
+// peer's hints is dropped to make room, or the hint itself if the peer has
+// none or its hints are being replayed.
+func (s *Store) Add(peer string, hint Hint) {
+	s.Lock()
+	defer s.Unlock()
+
+	if hint.CreatedAt.IsZero() {
+		hint.CreatedAt = s.now()
+	}
+
+	if s.count >= s.maxHints {
+		queue := s.queues[peer]
+		if len(queue) == 0 || s.replaying[peer] {
+			s.dropped++
+			return
+		}
+		n := len(queue) / dropFraction
+		if n == 0 {
+			n = 1
+		}
+		s.trim(peer, n)
+		s.dropped += uint64(n)
+	}
+
+	s.queues[peer] = append(s.queues[peer], hint)
+	s.count++
+	s.stored++
+
+	if s.logs != nil {
+		if err := s.logs.append(peer, hint); err != nil {
+			s.logger.WithError(err).WithField("peer", peer).Error("unable to persist hint")
+		}
+	}
+}
+
+// Replay hands the hints held for peer to send, oldest first, removing each
+// that is sent. It stops at the first that fails, keeping it and the rest,
+// and returns the number sent. Hints for a peer are replayed by one caller
+// at a time; others return immediately.
+func (s *Store) Replay(ctx context.Context, peer string, send func(ctx context.Context, hint Hint) error) (uint64, error) {
+	s.Lock()
+	if s.replaying[peer] || len(s.queues[peer]) == 0 {
+		s.Unlock()
+		return 0, nil
+	}
+	s.replaying[peer] = true
+	queue := s.queues[peer]
+	s.Unlock()
+
+	sent := 0
+	var err error
+	for _, hint := range queue {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		if err = send(ctx, hint); err != nil {
+			break
+		}
+		sent++
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	// hints are only appended to the queue while it is replayed
+	delete(s.replaying, peer)
+	if sent > 0 {
+		s.trim(peer, sent)
+		s.replayed += uint64(sent)
+	}
+
+	return uint64(sent), err
+}
+
+// Expire drops the hints older than the TTL, returning the number dropped.
+func (s *Store) Expire() uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.expire()
+}
+
+func (s *Store) expire() uint64 {
+	cutoff := s.now().Add(-s.ttl)
+
+	var expired uint64
+	for peer, queue := range s.queues {
+		if s.replaying[peer] {
+			continue
+		}
+
+		i := sort.Search(len(queue), func(i int) bool {
+			return queue[i].CreatedAt.After(cutoff)
+		})
+		if i == 0 {
+			continue
+		}
+		s.trim(peer, i)
+		expired += uint64(i)
+	}
+	s.expired += expired
+	return expired
+}
+
+// dropOldest drops up to n of the oldest hints of the longest queue.
+func (s *Store) dropOldest(n int) {
+	var longest string
+	for peer, queue := range s.queues {
+		if len(queue) > len(s.queues[longest]) {
+			longest = peer
+		}
+	}
+	if n > len(s.queues[longest]) {
+		n = len(s.queues[longest])
+	}
+	s.trim(longest, n)
+	s.dropped += uint64(n)
+}
+
+// trim removes the n oldest hints held for peer. Their removal is appended
+// to the peer's log, which is only compacted once it holds more removed
+// hints than held ones, so that removing hints costs as little as adding
+// them.
+func (s *Store) trim(peer string, n int) {
+	s.setQueue(peer, s.queues[peer][n:])
+	s.count -= n
+
+	if s.logs == nil {
+		return
+	}
+	s.trimmed[peer] += n
+	if s.trimmed[peer] > len(s.queues[peer]) {
+		s.rewrite(peer)
+		return
+	}
+	if err := s.logs.trim(peer, n); err != nil {
+		s.logger.WithError(err).WithField("peer", peer).Error("unable to persist hints")
+	}
+}
+
+func (s *Store) setQueue(peer string, queue []Hint) {
+	if len(queue) == 0 {
+		delete(s.queues, peer)
+		return
+	}
+	s.queues[peer] = queue
+}
+
+// rewrite replaces the peer's log with its current queue.
+func (s *Store) rewrite(peer string) {
+	if s.logs == nil {
+		return
+	}
+	delete(s.trimmed, peer)
+	if err := s.logs.rewrite(peer, s.queues[peer]); err != nil {
+		s.logger.WithError(err).WithField("peer", peer).Error("unable to persist hints")
+	}
+}
+
+// Peers returns the peers hints are held for, ordered by address.
+func (s *Store) Peers() []string {
+	s.Lock()
+	defer s.Unlock()
+
+	peers := make([]string, 0, len(s.queues))
+	for peer := range s.queues {
+		peers = append(peers, peer)
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// Len returns the number of hints held for peer.
+func (s *Store) Len(peer string) int {
+	s.Lock()
+	defer s.Unlock()
+
+	return len(s.queues[peer])
+}
+
+func (s *Store) Stats() Stats {
+	s.Lock()
+	defer s.Unlock()
+
+	queued := make(map[string]int, len(s.queues))
+	for peer, queue := range s.queues {
+		queued[peer] = len(queue)
+	}
+
+	return Stats{
+		Queued:   queued,
+		Stored:   s.stored,
+		Replayed: s.replayed,
+		Expired:  s.expired,
+		Dropped:  s.dropped,
+	}
+}
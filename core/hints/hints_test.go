@@ -0,0 +1,187 @@
+package hints
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/pb"
+)
+
+var logger = logrus.NewEntry(logrus.New())
+
+func hint(key string) Hint {
+	return Hint{
+		Pool: "pool",
+		Item: &memcached.Item{Key: key, Value: []byte(key), Timestamp: 1},
+	}
+}
+
+// replayed replays the hints held for peer, returning their keys. The send
+// with key fail fails.
+func replayed(t *testing.T, s *Store, peer, fail string) ([]string, error) {
+	var keys []string
+	_, err := s.Replay(context.Background(), peer, func(ctx context.Context, hint Hint) error {
+		if hint.Item.Key == fail {
+			return errors.New("unavailable")
+		}
+		keys = append(keys, hint.Item.Key)
+		return nil
+	})
+	return keys, err
+}
+
+func TestStoreReplay(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(Config{Logger: logger})
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		s.Add("a", hint(fmt.Sprintf("key-%d", i)))
+	}
+	s.Add("b", hint("other"))
+	require.Equal(t, []string{"a", "b"}, s.Peers())
+
+	// replay stops at the first failure, keeping the rest
+	keys, err := replayed(t, s, "a", "key-2")
+	require.Error(t, err)
+	require.Equal(t, []string{"key-0", "key-1"}, keys)
+	require.Equal(t, 3, s.Len("a"))
+
+	keys, err = replayed(t, s, "a", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"key-2", "key-3", "key-4"}, keys)
+	require.Zero(t, s.Len("a"))
+	require.Equal(t, []string{"b"}, s.Peers())
+
+	stats := s.Stats()
+	require.EqualValues(t, 6, stats.Stored)
+	require.EqualValues(t, 5, stats.Replayed)
+	require.Equal(t, map[string]int{"b": 1}, stats.Queued)
+}
+
+func TestStoreBounded(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(Config{MaxHints: 3, Logger: logger})
+	require.NoError(t, err)
+
+	s.Add("a", hint("key-0"))
+	s.Add("a", hint("key-1"))
+	s.Add("b", hint("key-2"))
+
+	// a full store drops the peer's oldest hint, or the new one if the peer
+	// has none
+	s.Add("a", hint("key-3"))
+	s.Add("c", hint("key-4"))
+
+	keys, err := replayed(t, s, "a", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"key-1", "key-3"}, keys)
+	require.Zero(t, s.Len("c"))
+	require.EqualValues(t, 2, s.Stats().Dropped)
+}
+
+func TestStoreExpire(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(Config{TTL: time.Minute, Logger: logger})
+	require.NoError(t, err)
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.Add("a", hint("old"))
+	now = now.Add(30 * time.Second)
+	s.Add("a", hint("new"))
+
+	require.Zero(t, s.Expire())
+	now = now.Add(45 * time.Second)
+	require.EqualValues(t, 1, s.Expire())
+
+	keys, err := replayed(t, s, "a", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"new"}, keys)
+	require.EqualValues(t, 1, s.Stats().Expired)
+}
+
+func TestStorePersistence(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "hints")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := New(Config{Dir: dir, Logger: logger})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		s.Add("127.0.0.1:8080", hint(fmt.Sprintf("key-%d", i)))
+	}
+	s.Add("127.0.0.1:8081", hint("other"))
+
+	_, err = replayed(t, s, "127.0.0.1:8080", "key-1")
+	require.Error(t, err)
+	_, err = replayed(t, s, "127.0.0.1:8081", "")
+	require.NoError(t, err)
+
+	// the hints not replayed survive a restart
+	s, err = New(Config{Dir: dir, Logger: logger})
+	require.NoError(t, err)
+	require.Equal(t, []string{"127.0.0.1:8080"}, s.Peers())
+
+	keys, err := replayed(t, s, "127.0.0.1:8080", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"key-1", "key-2"}, keys)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestStoreTrimmedLog(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "hints")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := New(Config{MaxHints: 20, Dir: dir, Logger: logger})
+	require.NoError(t, err)
+
+	for i := 0; i < 21; i++ {
+		s.Add("peer", hint(fmt.Sprintf("key-%d", i)))
+	}
+	// a full store drops a batch of the oldest, recorded in the log without
+	// rewriting it
+	require.Equal(t, 19, s.Len("peer"))
+	require.EqualValues(t, 2, s.Stats().Dropped)
+
+	_, err = replayed(t, s, "peer", "key-5")
+	require.Error(t, err)
+	require.Equal(t, 16, s.Len("peer"))
+	require.Equal(t, 5, s.trimmed["peer"])
+
+	b, err := ioutil.ReadFile(s.logs.path("peer"))
+	require.NoError(t, err)
+	require.Contains(t, string(b), `{"trim":2}`)
+	require.Contains(t, string(b), `{"trim":3}`)
+
+	// the trims survive a restart, which compacts the log
+	s, err = New(Config{MaxHints: 20, Dir: dir, Logger: logger})
+	require.NoError(t, err)
+	b, err = ioutil.ReadFile(s.logs.path("peer"))
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "trim")
+
+	keys, err := replayed(t, s, "peer", "key-15")
+	require.Error(t, err)
+	require.Equal(t, []string{"key-5", "key-6", "key-7", "key-8", "key-9", "key-10", "key-11", "key-12", "key-13", "key-14"}, keys)
+}
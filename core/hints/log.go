@@ -0,0 +1,175 @@
+package hints
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const logSuffix = ".hints"
+
+// logs persists each peer's hints as a file of JSON lines, oldest first.
+// Hints are appended as they are added. Since hints are only removed oldest
+// first, a removal is appended too, as a record of the number of hints
+// removed, and the file is rewritten to compact it.
+type logs struct {
+	dir string
+}
+
+// record is a line of a log: a hint, or the removal of the Trim oldest.
+type record struct {
+	Hint
+	Trim int `json:"trim,omitempty"`
+}
+
+type trimRecord struct {
+	Trim int `json:"trim"`
+}
+
+func (l *logs) path(peer string) string {
+	return filepath.Join(l.dir, url.PathEscape(peer)+logSuffix)
+}
+
+func (l *logs) load() (map[string][]Hint, error) {
+	entries, err := ioutil.ReadDir(l.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read hints directory %s", l.dir)
+	}
+
+	queues := make(map[string][]Hint)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, logSuffix) {
+			continue
+		}
+		peer, err := url.PathUnescape(strings.TrimSuffix(name, logSuffix))
+		if err != nil {
+			continue
+		}
+
+		queue, err := l.read(peer)
+		if err != nil {
+			return nil, err
+		}
+		if len(queue) > 0 {
+			queues[peer] = queue
+		}
+	}
+	return queues, nil
+}
+
+func (l *logs) read(peer string) ([]Hint, error) {
+	path := l.path(peer)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read hints %s", path)
+	}
+	defer f.Close()
+
+	var queue []Hint
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// a torn final line, from a crash part way through an append
+			break
+		}
+		if r.Trim == 0 {
+			queue = append(queue, r.Hint)
+			continue
+		}
+		if r.Trim > len(queue) {
+			r.Trim = len(queue)
+		}
+		queue = queue[r.Trim:]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "unable to read hints %s", path)
+	}
+	return queue, nil
+}
+
+func (l *logs) append(peer string, hint Hint) error {
+	return l.write(peer, hint)
+}
+
+// trim records the removal of the peer's n oldest hints.
+func (l *logs) trim(peer string, n int) error {
+	return l.write(peer, trimRecord{Trim: n})
+}
+
+// write appends a record to the peer's log.
+func (l *logs) write(peer string, r interface{}) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "unable to encode hint")
+	}
+
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return errors.Wrapf(err, "unable to create hints directory %s", l.dir)
+	}
+
+	path := l.path(peer)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "unable to write hints %s", path)
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "unable to write hints %s", path)
+	}
+	return f.Close()
+}
+
+// rewrite atomically replaces the peer's file with queue, removing it if
+// queue is empty.
+func (l *logs) rewrite(peer string, queue []Hint) error {
+	path := l.path(peer)
+	if len(queue) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "unable to remove hints %s", path)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return errors.Wrapf(err, "unable to create hints directory %s", l.dir)
+	}
+
+	tmp, err := ioutil.TempFile(l.dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "unable to write hints %s", path)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, hint := range queue {
+		if err := enc.Encode(hint); err != nil {
+			tmp.Close()
+			return errors.Wrapf(err, "unable to write hints %s", path)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "unable to write hints %s", path)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "unable to write hints %s", path)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrapf(err, "unable to write hints %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tescherm/mc/core/cluster"
+	"github.com/tescherm/mc/core/hotcache"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/metadata"
+)
+
+// invalidateTimeout bounds telling a node that a key it holds a copy of
+// changed. A node that is not told keeps serving its copy until it expires.
+const invalidateTimeout = time.Second
+
+// hotGet serves a Get for a key owned by peer from this node's copy of the
+// key if it has one, and otherwise forwards it, keeping a copy of the
+// response if the key is hot.
+func (s *MemcachedService) hotGet(ctx context.Context, peer memcached.MemcachedClient, req *memcached.GetRequest) (*memcached.GetResponse, error) {
+	if item := s.Hot.Get(req.Pool, req.Namespace, req.Key); item != nil {
+		return &memcached.GetResponse{Item: fromCacheItem(item)}, nil
+	}
+
+	token, hot := s.Hot.Sample(req.Pool, req.Namespace, req.Key)
+	if hot {
+		ctx = metadata.AppendToOutgoingContext(ctx, hotcache.MetadataKey, "1")
+	}
+
+	res, err := peer.Get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// stale items are not copied, so that their readers see them refreshed
+	if hot && res.Item != nil && !res.Stale {
+		s.Hot.Add(token, req.Pool, req.Namespace, toCacheItem(res.Item))
+	}
+	return res, nil
+}
+
+// trackCopy records that the node forwarding a Get is keeping a copy of the
+// response.
+func (s *MemcachedService) trackCopy(ctx context.Context, pool, namespace, key string) {
+	if s.Hot == nil {
+		return
+	}
+	from, ok := cluster.Forwarded(ctx)
+	if !ok {
+		return
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md.Get(hotcache.MetadataKey)) == 0 {
+		return
+	}
+
+	pool, namespace = requestNames(ctx, pool, namespace)
+	s.Hot.Track(pool, namespace, key, from)
+}
+
+// invalidateCopies tells the nodes holding copies of a key this node owns
+// that the key changed, waiting for them to drop their copies.
+func (s *MemcachedService) invalidateCopies(ctx context.Context, pool, namespace, key string) {
+	if s.Hot == nil {
+		return
+	}
+
+	pool, namespace = requestNames(ctx, pool, namespace)
+	holders := s.Hot.Holders(pool, namespace, key)
+	if len(holders) == 0 {
+		return
+	}
+
+	req := &memcached.InvalidateHotRequest{
+		Pool:      pool,
+		Namespace: namespace,
+		Keys:      []string{key},
+	}
+
+	var wg sync.WaitGroup
+	for _, address := range holders {
+		conn := s.Cluster.Conn(address)
+		if conn == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(address string, peer memcached.MemcachedClient) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), invalidateTimeout)
+			defer cancel()
+
+			if _, err := peer.InvalidateHot(ctx, req); err != nil {
+				s.Logger.WithError(err).WithFields(logrus.Fields{
+					"peer": address,
+					"key":  key,
+				}).Warn("unable to invalidate hot copy")
+			}
+		}(address, memcached.NewMemcachedClient(conn))
+	}
+	wg.Wait()
+}
+
+// invalidateOwnCopy drops this node's copy of a key it is forwarding a write
+// for, so that it reads its own write.
+func (s *MemcachedService) invalidateOwnCopy(ctx context.Context, pool, namespace, key string) {
+	if s.Hot == nil {
+		return
+	}
+	pool, namespace = requestNames(ctx, pool, namespace)
+	s.Hot.Invalidate(pool, namespace, key)
+}
+
+// invalidateNamespaceCopies drops this node's copies of a namespace's keys.
+func (s *MemcachedService) invalidateNamespaceCopies(ctx context.Context, pool, namespace string) {
+	if s.Hot == nil {
+		return
+	}
+	pool, namespace = requestNames(ctx, pool, namespace)
+	s.Hot.InvalidateNamespace(pool, namespace)
+}
+
+func (s *MemcachedService) InvalidateHot(ctx context.Context, req *memcached.InvalidateHotRequest) (*memcached.InvalidateHotResponse, error) {
+	s.Logger.WithFields(logrus.Fields{
+		"keys":      req.Keys,
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Debug("InvalidateHot")
+
+	if s.Hot != nil {
+		s.Hot.Invalidate(req.Pool, req.Namespace, req.Keys...)
+	}
+	return &memcached.InvalidateHotResponse{}, nil
+}
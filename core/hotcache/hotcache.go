@@ -0,0 +1,288 @@
+// Package hotcache keeps local copies of the keys a node forwards to their
+// owners most often, as groupcache's hot cache does, so that a key read
+// everywhere does not load only its owner.
+package hotcache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tescherm/mc/core/cache"
+)
+
+// MetadataKey is the request metadata key a node sets on a forwarded Get
+// whose response it will keep a copy of. The owner then tells the node when
+// the key changes.
+const MetadataKey = "mc-hot"
+
+const (
+	defaultThreshold  = 8
+	defaultWindow     = 10 * time.Second
+	defaultMaxTracked = 10000
+)
+
+type Config struct {
+	// Capacity is the size of the copies kept, in bytes
+	Capacity uint64
+
+	// Threshold is the number of times a key must be forwarded within a
+	// window before a copy is kept
+	Threshold int
+
+	// Window is the period forwards are counted over. Copies expire after a
+	// window too, in case an invalidation is lost.
+	Window time.Duration
+
+	// MaxTracked bounds the number of keys counted, and of keys the owner
+	// tracks the copies of
+	MaxTracked int
+}
+
+type Stats struct {
+	// Hits and Misses count lookups of keys owned by other nodes
+	Hits   uint64
+	Misses uint64
+
+	// Promotions counts the copies made, and Invalidations those dropped
+	// because their key changed
+	Promotions    uint64
+	Invalidations uint64
+
+	Items           int
+	CurrentCapacity uint64
+	MaxCapacity     uint64
+}
+
+// HotCache holds copies of keys owned by other nodes. It also tracks, for
+// the keys this node owns, the other nodes holding copies.
+type HotCache struct {
+	mu sync.Mutex
+
+	copies *cache.LRUCache
+
+	threshold  int
+	window     time.Duration
+	maxTracked int
+
+	counts      map[string]int
+	windowStart time.Time
+
+	// epoch advances on every invalidation, so that a copy fetched while
+	// its key changed is not kept
+	epoch uint64
+
+	// holders maps a key this node owns to the nodes holding copies, and
+	// when each fetched it
+	holders map[string]map[string]time.Time
+
+	hits          uint64
+	misses        uint64
+	promotions    uint64
+	invalidations uint64
+
+	now func() time.Time
+}
+
+func New(config Config) *HotCache {
+	if config.Threshold <= 0 {
+		config.Threshold = defaultThreshold
+	}
+	if config.Window <= 0 {
+		config.Window = defaultWindow
+	}
+	if config.MaxTracked <= 0 {
+		config.MaxTracked = defaultMaxTracked
+	}
+
+	return &HotCache{
+		copies: cache.NewLRUCache(cache.Config{
+			Capacity: config.Capacity,
+			TTL:      config.Window,
+		}),
+		threshold:  config.Threshold,
+		window:     config.Window,
+		maxTracked: config.MaxTracked,
+		counts:     make(map[string]int),
+		holders:    make(map[string]map[string]time.Time),
+		now:        time.Now,
+	}
+}
+
+// hotKey is the key a copy is stored under.
+func hotKey(pool, namespace, key string) string {
+	return pool + "\x00" + namespace + "\x00" + key
+}
+
+// Get returns the copy of a key, or nil.
+func (h *HotCache) Get(pool, namespace, key string) *cache.Item {
+	item := h.copies.Get(hotKey(pool, namespace, key))
+	if item == nil {
+		atomic.AddUint64(&h.misses, 1)
+		return nil
+	}
+	atomic.AddUint64(&h.hits, 1)
+
+	copied := cache.NewItem(key, item.Value, item.VersionID())
+	copied.Tags = item.Tags
+	return copied
+}
+
+// Sample counts a forward of a key to its owner, and reports whether the key
+// is now hot enough to keep a copy of. If so, the returned token is passed
+// to Add.
+func (h *HotCache) Sample(pool, namespace, key string) (token uint64, hot bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if now := h.now(); now.Sub(h.windowStart) > h.window {
+		h.counts = make(map[string]int)
+		h.windowStart = now
+	}
+
+	k := hotKey(pool, namespace, key)
+	count, ok := h.counts[k]
+	if !ok && len(h.counts) >= h.maxTracked {
+		return 0, false
+	}
+	count++
+	h.counts[k] = count
+
+	return h.epoch, count >= h.threshold
+}
+
+// Add keeps a copy of item, fetched from its owner, unless a key changed
+// since Sample returned token.
+func (h *HotCache) Add(token uint64, pool, namespace string, item *cache.Item) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if token != h.epoch {
+		return false
+	}
+
+	copied := cache.NewItem(hotKey(pool, namespace, item.Key), item.Value, item.VersionID())
+	copied.Tags = item.Tags
+
+	// Merge, unlike Set, keeps the owner's version
+	if !h.copies.Merge(copied) {
+		return false
+	}
+	h.promotions++
+	return true
+}
+
+// Invalidate drops the copies of keys.
+func (h *HotCache) Invalidate(pool, namespace string, keys ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.epoch++
+	for _, key := range keys {
+		if h.copies.Remove(hotKey(pool, namespace, key)) != nil {
+			h.invalidations++
+		}
+	}
+}
+
+// InvalidateNamespace drops the copies of every key in a namespace.
+func (h *HotCache) InvalidateNamespace(pool, namespace string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.epoch++
+	prefix := hotKey(pool, namespace, "")
+	h.invalidations += h.copies.RemoveMatching(func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// InvalidateTags drops the copies of keys carrying any of tags, in any
+// namespace.
+func (h *HotCache) InvalidateTags(tags []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.epoch++
+	h.invalidations += h.copies.InvalidateTags(tags)
+}
+
+// Track records that the node at peer is keeping a copy of a key this node
+// owns.
+func (h *HotCache) Track(pool, namespace, key, peer string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	k := hotKey(pool, namespace, key)
+	peers, ok := h.holders[k]
+	if !ok {
+		if len(h.holders) >= h.maxTracked {
+			h.forgetExpired(now)
+		}
+		if len(h.holders) >= h.maxTracked {
+			// the untracked copy expires after a window
+			return
+		}
+		peers = make(map[string]time.Time)
+		h.holders[k] = peers
+	}
+	peers[peer] = now
+}
+
+// forgetExpired forgets the copies that have expired.
+func (h *HotCache) forgetExpired(now time.Time) {
+	for k, peers := range h.holders {
+		for peer, fetched := range peers {
+			if now.Sub(fetched) > h.window {
+				delete(peers, peer)
+			}
+		}
+		if len(peers) == 0 {
+			delete(h.holders, k)
+		}
+	}
+}
+
+// Holders returns the nodes keeping copies of a key this node owns, and
+// forgets them, since the key is about to change.
+func (h *HotCache) Holders(pool, namespace, key string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	k := hotKey(pool, namespace, key)
+	peers := h.holders[k]
+	if len(peers) == 0 {
+		return nil
+	}
+	delete(h.holders, k)
+
+	now := h.now()
+	addresses := make([]string, 0, len(peers))
+	for peer, fetched := range peers {
+		if now.Sub(fetched) <= h.window {
+			addresses = append(addresses, peer)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+func (h *HotCache) Stats() Stats {
+	h.mu.Lock()
+	promotions, invalidations := h.promotions, h.invalidations
+	h.mu.Unlock()
+
+	stats := h.copies.Stats()
+	return Stats{
+		Hits:            atomic.LoadUint64(&h.hits),
+		Misses:          atomic.LoadUint64(&h.misses),
+		Promotions:      promotions,
+		Invalidations:   invalidations,
+		Items:           len(h.copies.Keys()),
+		CurrentCapacity: stats.CurrentCapacity,
+		MaxCapacity:     stats.MaxCapacity,
+	}
+}
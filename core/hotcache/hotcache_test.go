@@ -0,0 +1,119 @@
+package hotcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/core/cache"
+)
+
+func sample(h *HotCache, key string, times int) (uint64, bool) {
+	var token uint64
+	var hot bool
+	for i := 0; i < times; i++ {
+		token, hot = h.Sample("pool", "ns", key)
+	}
+	return token, hot
+}
+
+func TestHotCachePromotion(t *testing.T) {
+	t.Parallel()
+
+	h := New(Config{Capacity: 1 << 20, Threshold: 3})
+
+	_, hot := sample(h, "key", 2)
+	require.False(t, hot)
+
+	token, hot := sample(h, "key", 1)
+	require.True(t, hot)
+
+	item := cache.NewItem("key", []byte("value"), 42)
+	require.True(t, h.Add(token, "pool", "ns", item))
+
+	copied := h.Get("pool", "ns", "key")
+	require.NotNil(t, copied)
+	require.Equal(t, "key", copied.Key)
+	require.Equal(t, []byte("value"), copied.Value)
+	require.Equal(t, int64(42), copied.VersionID())
+
+	// copies are kept per pool and namespace
+	require.Nil(t, h.Get("pool", "other", "key"))
+
+	stats := h.Stats()
+	require.Equal(t, uint64(1), stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+	require.Equal(t, uint64(1), stats.Promotions)
+	require.Equal(t, 1, stats.Items)
+}
+
+func TestHotCacheWindow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	h := New(Config{Capacity: 1 << 20, Threshold: 3, Window: time.Minute})
+	h.now = func() time.Time { return now }
+
+	_, hot := sample(h, "key", 2)
+	require.False(t, hot)
+
+	// counts start over each window
+	now = now.Add(2 * time.Minute)
+	_, hot = sample(h, "key", 2)
+	require.False(t, hot)
+}
+
+func TestHotCacheInvalidate(t *testing.T) {
+	t.Parallel()
+
+	h := New(Config{Capacity: 1 << 20, Threshold: 1})
+
+	add := func(namespace, key string, tags ...string) {
+		token, hot := h.Sample("pool", namespace, key)
+		require.True(t, hot)
+		item := cache.NewItem(key, []byte(key), 1)
+		item.Tags = tags
+		require.True(t, h.Add(token, "pool", namespace, item))
+	}
+
+	add("ns", "a")
+	add("ns", "b", "tag")
+	add("other", "c")
+
+	h.Invalidate("pool", "ns", "a")
+	require.Nil(t, h.Get("pool", "ns", "a"))
+	require.NotNil(t, h.Get("pool", "ns", "b"))
+
+	h.InvalidateTags([]string{"tag"})
+	require.Nil(t, h.Get("pool", "ns", "b"))
+
+	h.InvalidateNamespace("pool", "other")
+	require.Nil(t, h.Get("pool", "other", "c"))
+
+	require.Equal(t, uint64(3), h.Stats().Invalidations)
+
+	// a copy fetched while a key changed is not kept
+	token, _ := h.Sample("pool", "ns", "a")
+	h.Invalidate("pool", "ns", "a")
+	require.False(t, h.Add(token, "pool", "ns", cache.NewItem("a", []byte("a"), 1)))
+	require.Nil(t, h.Get("pool", "ns", "a"))
+}
+
+func TestHotCacheHolders(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	h := New(Config{Capacity: 1 << 20, Window: time.Minute})
+	h.now = func() time.Time { return now }
+
+	h.Track("pool", "ns", "key", "b:8080")
+	h.Track("pool", "ns", "key", "a:8080")
+	now = now.Add(2 * time.Minute)
+	h.Track("pool", "ns", "key", "c:8080")
+
+	// copies that have expired are not invalidated
+	require.Equal(t, []string{"c:8080"}, h.Holders("pool", "ns", "key"))
+
+	// the holders are forgotten once returned
+	require.Empty(t, h.Holders("pool", "ns", "key"))
+}
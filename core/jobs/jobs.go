@@ -0,0 +1,180 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	Running State = iota
+	Done
+	Cancelled
+	Failed
+)
+
+func (s State) String() string {
+	switch s {
+	case Running:
+		return "running"
+	case Done:
+		return "done"
+	case Cancelled:
+		return "cancelled"
+	case Failed:
+		return "failed"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Status is a point in time view of a job.
+type Status struct {
+	ID    string
+	Kind  string
+	State State
+	Err   error
+
+	// progress, in units of work (e.g. caches scanned)
+	Done  uint64
+	Total uint64
+
+	// Count is the job specific result count, e.g. keys removed
+	Count uint64
+
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+type Job struct {
+	mu sync.Mutex
+
+	status Status
+	cancel context.CancelFunc
+}
+
+// Progress records the job's progress so far.
+func (j *Job) Progress(done, total int, count uint64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status.Done = uint64(done)
+	j.status.Total = uint64(total)
+	j.status.Count = count
+}
+
+func (j *Job) Status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.status
+}
+
+// Cancel requests that the job stop. The job's state becomes Cancelled
+// once its function returns.
+func (j *Job) Cancel() {
+	j.cancel()
+}
+
+func (j *Job) finish(ctx context.Context, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	switch {
+	case err == nil:
+		j.status.State = Done
+	case ctx.Err() == context.Canceled:
+		j.status.State = Cancelled
+	default:
+		j.status.State = Failed
+		j.status.Err = err
+	}
+	j.status.FinishedAt = time.Now()
+}
+
+// Func is the body of a job. It should return promptly once ctx is done.
+type Func func(ctx context.Context, job *Job) error
+
+type Config struct {
+	// Retain is the number of finished jobs kept for status queries
+	Retain int
+}
+
+const defaultRetain = 100
+
+// Manager runs jobs in the background and tracks their status.
+type Manager struct {
+	sync.Mutex
+
+	retain int
+	nextID uint64
+
+	jobs     map[string]*Job
+	finished []string
+}
+
+func NewManager(config Config) *Manager {
+	retain := config.Retain
+	if retain == 0 {
+		retain = defaultRetain
+	}
+
+	return &Manager{
+		retain: retain,
+		jobs:   make(map[string]*Job),
+	}
+}
+
+// Start runs fn in a new goroutine and returns its job.
+func (m *Manager) Start(kind string, fn Func) *Job {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.Lock()
+	m.nextID++
+	job := &Job{
+		status: Status{
+			ID:        fmt.Sprintf("%s-%d", kind, m.nextID),
+			Kind:      kind,
+			State:     Running,
+			StartedAt: time.Now(),
+		},
+		cancel: cancel,
+	}
+	m.jobs[job.status.ID] = job
+	m.Unlock()
+
+	go func() {
+		defer cancel()
+
+		err := fn(ctx, job)
+		job.finish(ctx, err)
+
+		m.retire(job.status.ID)
+	}()
+
+	return job
+}
+
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+// retire records a finished job, forgetting the oldest finished jobs beyond
+// the retention limit.
+func (m *Manager) retire(id string) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.finished = append(m.finished, id)
+	for len(m.finished) > m.retain {
+		delete(m.jobs, m.finished[0])
+		m.finished = m.finished[1:]
+	}
+}
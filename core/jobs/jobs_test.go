@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func waitFinished(t *testing.T, job *Job) Status {
+	for i := 0; i < 100; i++ {
+		st := job.Status()
+		if st.State != Running {
+			return st
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Fail(t, "job did not finish")
+	return Status{}
+}
+
+func TestJobDone(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(Config{})
+	job := m.Start("test", func(ctx context.Context, job *Job) error {
+		job.Progress(1, 2, 10)
+		job.Progress(2, 2, 20)
+		return nil
+	})
+
+	st := waitFinished(t, job)
+	require.Equal(t, Done, st.State)
+	require.EqualValues(t, 2, st.Done)
+	require.EqualValues(t, 2, st.Total)
+	require.EqualValues(t, 20, st.Count)
+	require.False(t, st.FinishedAt.IsZero())
+
+	found, ok := m.Get(st.ID)
+	require.True(t, ok)
+	require.Equal(t, job, found)
+}
+
+func TestJobCancel(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(Config{})
+	job := m.Start("test", func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	job.Cancel()
+
+	st := waitFinished(t, job)
+	require.Equal(t, Cancelled, st.State)
+	require.NoError(t, st.Err)
+}
+
+func TestJobFailed(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(Config{})
+	job := m.Start("test", func(ctx context.Context, job *Job) error {
+		return errors.New("boom")
+	})
+
+	st := waitFinished(t, job)
+	require.Equal(t, Failed, st.State)
+	require.EqualError(t, st.Err, "boom")
+}
+
+func TestJobRetention(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(Config{Retain: 2})
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		job := m.Start("test", func(ctx context.Context, job *Job) error {
+			return nil
+		})
+		waitFinished(t, job)
+		ids = append(ids, job.Status().ID)
+	}
+
+	// retirement happens after the state change, so wait for it
+	for i := 0; i < 100; i++ {
+		if _, ok := m.Get(ids[0]); !ok {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, ok := m.Get(ids[0])
+	require.False(t, ok)
+
+	_, ok = m.Get(ids[2])
+	require.True(t, ok)
+}
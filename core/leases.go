@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/leases"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// leasable returns an error unless a request may use leases in pool.
+// Leases are granted by the node holding a key, so they are not supported by
+// replicated pools, whose keys are held by several.
+func (s *MemcachedService) leasable(ctx context.Context, pool string) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if _, ok := s.replicated(ctx, pool); ok {
+		return status.Errorf(codes.Unimplemented, "leases are not supported by replicated pools")
+	}
+	return nil
+}
+
+// leaseMiss grants the client a lease on a key it missed, or tells it to
+// wait for another client to fill the key.
+func (s *MemcachedService) leaseMiss(ctx context.Context, req *memcached.GetRequest, res *memcached.GetResponse) {
+	pool, namespace := requestNames(ctx, req.Pool, req.Namespace)
+	token, wait, stale := s.Leases.Miss(pool, namespace, req.Key)
+	res.Lease = token
+	res.Wait = wait
+	res.StaleItem = fromCacheItem(stale)
+}
+
+// leaseTable is the table of the leases of keys: Leases, or with leases
+// disabled, the table of the leases to refresh stale keys.
+func (s *MemcachedService) leaseTable() *leases.Table {
+	if s.Leases != nil {
+		return s.Leases
+	}
+	return s.refreshes
+}
+
+// leaseRefresh grants the client the lease to refresh a stale key, unless
+// another client holds it. Exactly one reader refreshes a stale key, so it is
+// granted whether or not leases are enabled.
+func (s *MemcachedService) leaseRefresh(ctx context.Context, req *memcached.GetRequest, res *memcached.GetResponse) {
+	pool, namespace := requestNames(ctx, req.Pool, req.Namespace)
+	res.Lease = s.leaseTable().Refresh(pool, namespace, req.Key)
+}
+
+// fill calls set if token is still the key's lease.
+func (s *MemcachedService) fill(ctx context.Context, pool, namespace, key string, token uint64, set func()) error {
+	pool, namespace = requestNames(ctx, pool, namespace)
+	if !s.leaseTable().Fill(pool, namespace, key, token, set) {
+		return status.Errorf(codes.Aborted, "lease is no longer valid")
+	}
+	return nil
+}
+
+// leaseSet calls set, which writes key without a lease, and cancels the
+// key's lease. A key removed since its last lease may only be set with a new
+// one.
+func (s *MemcachedService) leaseSet(ctx context.Context, pool, namespace, key string, set func()) error {
+	pool, namespace = requestNames(ctx, pool, namespace)
+	if !s.leaseTable().Set(pool, namespace, key, set) {
+		return status.Errorf(codes.Aborted, "key was removed; set it with a lease")
+	}
+	return nil
+}
+
+// leaseRemove calls remove, which removes key, and cancels the key's lease.
+// remove returns the item it removed, if any, to serve stale.
+func (s *MemcachedService) leaseRemove(ctx context.Context, pool, namespace, key string, remove func() (removed *cache.Item)) {
+	pool, namespace = requestNames(ctx, pool, namespace)
+	s.Leases.Remove(pool, namespace, key, remove)
+}
+
+// leaseRemoved cancels the leases of removed keys of a namespace.
+func (s *MemcachedService) leaseRemoved(ctx context.Context, pool, namespace string, keys []string) {
+	pool, namespace = requestNames(ctx, pool, namespace)
+	s.Leases.Removed(pool, namespace, keys)
+}
+
+// clearLeases cancels the leases of a namespace.
+func (s *MemcachedService) clearLeases(ctx context.Context, pool, namespace string) {
+	pool, namespace = requestNames(ctx, pool, namespace)
+	s.leaseTable().Clear(pool, namespace)
+}
@@ -0,0 +1,359 @@
+// Package leases hands out leases on missed keys, as memcache does at
+// Facebook. The first client to miss a key gets a lease token, and only it
+// may fill the key; the others are told to wait, and are given the value the
+// key had before it was removed, if it was removed recently. A write to the
+// key cancels its lease, so that a slow client cannot set a stale value
+// after an invalidation, and once a key is removed it may only be set with a
+// lease granted since.
+package leases
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/tescherm/mc/core/cache"
+)
+
+const (
+	defaultTTL      = 10 * time.Second
+	defaultStaleTTL = 10 * time.Second
+
+	keyLockCount = 64
+)
+
+type Config struct {
+	// TTL is how long a lease is valid for. Another lease on the key is
+	// only handed out once it expires, in case its holder never fills it.
+	TTL time.Duration
+
+	// StaleTTL is how long a removed key is remembered: its value is kept
+	// for the clients waiting on its lease, and sets without a lease are
+	// rejected until one is granted
+	StaleTTL time.Duration
+}
+
+// Stats is a point in time view of a table.
+type Stats struct {
+	// Leases is the number of keys with a live lease
+	Leases int
+
+	// Granted counts the leases handed out, Waits the misses told to wait
+	// for one, and Stale the waits given a stale value
+	Granted uint64
+	Waits   uint64
+	Stale   uint64
+
+	// Refreshes counts the leases handed out to refresh stale keys, which
+	// are also counted as granted
+	Refreshes uint64
+
+	// Filled counts the sets made with a lease, and Rejected those whose
+	// lease was no longer valid, or that had no lease for a removed key
+	Filled   uint64
+	Rejected uint64
+}
+
+type leaseKey struct {
+	pool      string
+	namespace string
+	key       string
+}
+
+type lease struct {
+	// token is zero if the key has no lease, only a stale value
+	token     uint64
+	expiresAt time.Time
+
+	// deleted is set if the key was removed and no lease has been granted
+	// on it since. It is forgotten with the stale value.
+	deleted        bool
+	stale          *cache.Item
+	staleExpiresAt time.Time
+}
+
+func (l *lease) live(now time.Time) bool {
+	return l.token != 0 && now.Before(l.expiresAt)
+}
+
+func (l *lease) staleItem(now time.Time) *cache.Item {
+	if l.stale == nil || !now.Before(l.staleExpiresAt) {
+		return nil
+	}
+	return l.stale
+}
+
+func (l *lease) removed(now time.Time) bool {
+	return l.deleted && now.Before(l.staleExpiresAt)
+}
+
+// Table holds the leases of every namespace of a node.
+type Table struct {
+	mu sync.Mutex
+
+	ttl      time.Duration
+	staleTTL time.Duration
+
+	leases map[leaseKey]*lease
+
+	// pruneAt is the number of entries at which expired ones are pruned
+	pruneAt int
+
+	// next is the next token, seeded from the clock so that tokens are not
+	// reused across restarts
+	next uint64
+
+	granted   uint64
+	waits     uint64
+	stale     uint64
+	refreshes uint64
+	filled    uint64
+	rejected  uint64
+
+	// keys orders a key's leased sets against its other writes
+	keys [keyLockCount]sync.Mutex
+
+	now func() time.Time
+}
+
+func New(config Config) *Table {
+	if config.TTL <= 0 {
+		config.TTL = defaultTTL
+	}
+	if config.StaleTTL <= 0 {
+		config.StaleTTL = defaultStaleTTL
+	}
+
+	return &Table{
+		ttl:      config.TTL,
+		staleTTL: config.StaleTTL,
+		leases:   make(map[leaseKey]*lease),
+		pruneAt:  keyLockCount,
+		next:     uint64(time.Now().UnixNano()),
+		now:      time.Now,
+	}
+}
+
+// Miss is called when a client asking for a lease misses key. It returns a
+// new lease token, or wait if another client holds the key's lease, with the
+// key's value before it was last removed, if it is recent enough.
+func (t *Table) Miss(pool, namespace, key string) (token uint64, wait bool, stale *cache.Item) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	k := leaseKey{pool, namespace, key}
+	l, ok := t.leases[k]
+	if ok && l.live(now) {
+		t.waits++
+		if stale = l.staleItem(now); stale != nil {
+			t.stale++
+		}
+		return 0, true, stale
+	}
+	return t.grant(k, now), false, nil
+}
+
+// Refresh is called when a client asking for a lease reads a stale key. It
+// returns a new lease token, or zero if another client holds the key's
+// lease and is refreshing it.
+func (t *Table) Refresh(pool, namespace, key string) (token uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	k := leaseKey{pool, namespace, key}
+	if l, ok := t.leases[k]; ok && l.live(now) {
+		return 0
+	}
+	t.refreshes++
+	return t.grant(k, now)
+}
+
+// grant hands out a new lease on a key, with mu locked.
+func (t *Table) grant(k leaseKey, now time.Time) uint64 {
+	l, ok := t.leases[k]
+	if !ok {
+		l = &lease{}
+		t.leases[k] = l
+		t.prune(now)
+	}
+
+	t.next++
+	if t.next == 0 {
+		t.next++
+	}
+	l.token = t.next
+	l.expiresAt = now.Add(t.ttl)
+	l.deleted = false
+	t.granted++
+	return l.token
+}
+
+// prune drops the expired entries, once there are twice as many entries as
+// after the last prune.
+func (t *Table) prune(now time.Time) {
+	if len(t.leases) < t.pruneAt {
+		return
+	}
+	for k, l := range t.leases {
+		if !l.live(now) && !l.removed(now) && l.staleItem(now) == nil {
+			delete(t.leases, k)
+		}
+	}
+	t.pruneAt = 2 * len(t.leases)
+	if t.pruneAt < keyLockCount {
+		t.pruneAt = keyLockCount
+	}
+}
+
+// Fill calls set if token is the key's live lease, ending the lease, and
+// reports whether it did.
+func (t *Table) Fill(pool, namespace, key string, token uint64, set func()) bool {
+	unlock := t.lock(key)
+	defer unlock()
+
+	t.mu.Lock()
+	k := leaseKey{pool, namespace, key}
+	l, ok := t.leases[k]
+	valid := ok && l.token == token && l.live(t.now())
+	if valid {
+		delete(t.leases, k)
+		t.filled++
+	} else {
+		t.rejected++
+	}
+	t.mu.Unlock()
+
+	if valid {
+		set()
+	}
+	return valid
+}
+
+// Set calls set, which writes key without a lease, and then cancels the
+// key's lease. It reports whether it did: a key removed since the last lease
+// granted on it may only be set with a new lease. A nil table just calls
+// set.
+func (t *Table) Set(pool, namespace, key string, set func()) bool {
+	if t == nil {
+		set()
+		return true
+	}
+
+	unlock := t.lock(key)
+	defer unlock()
+
+	t.mu.Lock()
+	k := leaseKey{pool, namespace, key}
+	if l, ok := t.leases[k]; ok && l.removed(t.now()) {
+		t.rejected++
+		t.mu.Unlock()
+		return false
+	}
+	t.mu.Unlock()
+
+	set()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.leases, k)
+	return true
+}
+
+// Remove calls remove, which removes key, and then cancels the key's lease
+// and remembers the key as removed. removed is the key's last value, if it
+// had one, which is kept for the clients waiting on a lease. A nil table
+// just calls remove.
+func (t *Table) Remove(pool, namespace, key string, remove func() (removed *cache.Item)) {
+	if t == nil {
+		remove()
+		return
+	}
+
+	unlock := t.lock(key)
+	defer unlock()
+
+	removed := remove()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.remember(leaseKey{pool, namespace, key}, removed)
+}
+
+// Removed cancels the leases of keys of a namespace that were removed, and
+// remembers them as removed, without their values. A nil table ignores it.
+func (t *Table) Removed(pool, namespace string, keys []string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, key := range keys {
+		t.remember(leaseKey{pool, namespace, key}, nil)
+	}
+}
+
+// remember remembers a key as removed, with mu locked.
+func (t *Table) remember(k leaseKey, stale *cache.Item) {
+	now := t.now()
+	t.leases[k] = &lease{
+		deleted:        true,
+		stale:          stale,
+		staleExpiresAt: now.Add(t.staleTTL),
+	}
+	t.prune(now)
+}
+
+// Clear cancels the leases of a namespace, and drops its stale values. A
+// nil table ignores it.
+func (t *Table) Clear(pool, namespace string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for k := range t.leases {
+		if k.pool == pool && k.namespace == namespace {
+			delete(t.leases, k)
+		}
+	}
+}
+
+func (t *Table) lock(key string) (unlock func()) {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+
+	m := &t.keys[h.Sum32()%keyLockCount]
+	m.Lock()
+	return m.Unlock
+}
+
+func (t *Table) Stats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	leases := 0
+	for _, l := range t.leases {
+		if l.live(now) {
+			leases++
+		}
+	}
+
+	return Stats{
+		Leases:    leases,
+		Granted:   t.granted,
+		Waits:     t.waits,
+		Stale:     t.stale,
+		Refreshes: t.refreshes,
+		Filled:    t.filled,
+		Rejected:  t.rejected,
+	}
+}
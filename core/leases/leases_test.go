@@ -0,0 +1,162 @@
+package leases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/core/cache"
+)
+
+func newTestTable() (*Table, *time.Time) {
+	now := time.Unix(1000, 0)
+	t := New(Config{TTL: time.Second, StaleTTL: 2 * time.Second})
+	t.now = func() time.Time { return now }
+	return t, &now
+}
+
+func TestLeaseFill(t *testing.T) {
+	t.Parallel()
+
+	table, now := newTestTable()
+
+	// the first miss is granted the lease, and the others wait
+	token, wait, _ := table.Miss("pool", "ns", "key")
+	require.NotZero(t, token)
+	require.False(t, wait)
+	_, wait, stale := table.Miss("pool", "ns", "key")
+	require.True(t, wait)
+	require.Nil(t, stale)
+
+	// leases are per namespace
+	other, wait, _ := table.Miss("pool", "other", "key")
+	require.NotZero(t, other)
+	require.False(t, wait)
+
+	// only the lease's token fills the key, once
+	set := 0
+	require.False(t, table.Fill("pool", "ns", "key", token+100, func() { set++ }))
+	require.True(t, table.Fill("pool", "ns", "key", token, func() { set++ }))
+	require.False(t, table.Fill("pool", "ns", "key", token, func() { set++ }))
+	require.Equal(t, 1, set)
+
+	// expired leases are granted again
+	token, _, _ = table.Miss("pool", "ns", "key")
+	*now = now.Add(time.Second)
+	require.False(t, table.Fill("pool", "ns", "key", token, func() { set++ }))
+	token2, wait, _ := table.Miss("pool", "ns", "key")
+	require.False(t, wait)
+	require.NotEqual(t, token, token2)
+
+	require.Equal(t, Stats{Leases: 1, Granted: 4, Waits: 1, Filled: 1, Rejected: 3}, table.Stats())
+}
+
+func TestLeaseRefresh(t *testing.T) {
+	t.Parallel()
+
+	table, now := newTestTable()
+
+	// the first stale read is granted the lease to refresh the key
+	token := table.Refresh("pool", "ns", "key")
+	require.NotZero(t, token)
+	require.Zero(t, table.Refresh("pool", "ns", "key"))
+
+	// which a miss waits for too
+	_, wait, _ := table.Miss("pool", "ns", "key")
+	require.True(t, wait)
+
+	require.True(t, table.Fill("pool", "ns", "key", token, func() {}))
+	require.NotZero(t, table.Refresh("pool", "ns", "key"))
+
+	// and a lease that is never filled expires
+	*now = now.Add(time.Second)
+	require.NotZero(t, table.Refresh("pool", "ns", "key"))
+
+	require.Equal(t, Stats{Leases: 1, Granted: 3, Refreshes: 3, Waits: 1, Filled: 1}, table.Stats())
+}
+
+func TestLeaseWrite(t *testing.T) {
+	t.Parallel()
+
+	table, now := newTestTable()
+
+	// writes cancel the lease
+	token, _, _ := table.Miss("pool", "ns", "key")
+	require.True(t, table.Set("pool", "ns", "key", func() {}))
+	require.False(t, table.Fill("pool", "ns", "key", token, func() {}))
+
+	// removes keep the removed item for the clients waiting on the next lease
+	token, _, _ = table.Miss("pool", "ns", "key")
+	removed := cache.NewItem("key", []byte("old"), 1)
+	table.Remove("pool", "ns", "key", func() *cache.Item { return removed })
+	require.False(t, table.Fill("pool", "ns", "key", token, func() {}))
+
+	token, wait, stale := table.Miss("pool", "ns", "key")
+	require.False(t, wait)
+	require.Nil(t, stale)
+	_, wait, stale = table.Miss("pool", "ns", "key")
+	require.True(t, wait)
+	require.Equal(t, removed, stale)
+
+	// until it is too stale
+	*now = now.Add(2 * time.Second)
+	table.Miss("pool", "ns", "key")
+	_, wait, stale = table.Miss("pool", "ns", "key")
+	require.True(t, wait)
+	require.Nil(t, stale)
+
+	// clears cancel the namespace's leases
+	table.Clear("pool", "ns")
+	require.Equal(t, 0, table.Stats().Leases)
+
+	// a nil table just writes
+	var nilTable *Table
+	written := false
+	require.True(t, nilTable.Set("pool", "ns", "key", func() { written = true }))
+	require.True(t, written)
+	nilTable.Remove("pool", "ns", "key", func() *cache.Item {
+		written = false
+		return nil
+	})
+	require.False(t, written)
+	nilTable.Removed("pool", "ns", []string{"key"})
+}
+
+func TestLeaseStaleSet(t *testing.T) {
+	t.Parallel()
+
+	table, now := newTestTable()
+	set := 0
+	inc := func() { set++ }
+
+	// a slow client cannot set a key without a lease once it is removed,
+	// even if the key was missing
+	require.True(t, table.Set("pool", "ns", "key", inc))
+	table.Remove("pool", "ns", "key", func() *cache.Item { return nil })
+	require.False(t, table.Set("pool", "ns", "key", inc))
+	require.Equal(t, 1, set)
+
+	// until a lease is granted on it, which is then what fills it
+	token, _, _ := table.Miss("pool", "ns", "key")
+	require.True(t, table.Fill("pool", "ns", "key", token, inc))
+	require.True(t, table.Set("pool", "ns", "key", inc))
+	require.Equal(t, 3, set)
+
+	// keys removed by a pattern are rejected the same way, and their leases
+	// are cancelled
+	token, _, _ = table.Miss("pool", "ns", "a")
+	table.Removed("pool", "ns", []string{"a", "b"})
+	require.False(t, table.Fill("pool", "ns", "a", token, inc))
+	require.False(t, table.Set("pool", "ns", "a", inc))
+	require.False(t, table.Set("pool", "ns", "b", inc))
+	require.True(t, table.Set("pool", "ns", "c", inc))
+	require.True(t, table.Set("pool", "other", "a", inc))
+	require.Equal(t, 5, set)
+
+	// and removed keys are forgotten with their stale values
+	*now = now.Add(2 * time.Second)
+	require.True(t, table.Set("pool", "ns", "b", inc))
+	require.Equal(t, 6, set)
+
+	require.Equal(t, uint64(4), table.Stats().Rejected)
+}
@@ -0,0 +1,774 @@
+package membership
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultProbeInterval    = time.Second
+	defaultProbeTimeout     = 500 * time.Millisecond
+	defaultIndirectChecks   = 3
+	defaultSuspicionTimeout = 5 * time.Second
+	defaultRetransmitMult   = 4
+
+	// maxPiggyback is the most updates sent with one message
+	maxPiggyback = 16
+)
+
+var ErrNoSelf = errors.New("membership self address is required")
+
+type State int
+
+const (
+	Alive State = iota
+	Suspect
+	Dead
+	Left
+)
+
+func (s State) String() string {
+	switch s {
+	case Alive:
+		return "alive"
+	case Suspect:
+		return "suspect"
+	case Dead:
+		return "dead"
+	case Left:
+		return "left"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// precedence orders states with the same incarnation; an update only
+// overrides a state of lower precedence.
+func (s State) precedence() int {
+	switch s {
+	case Alive:
+		return 0
+	case Suspect:
+		return 1
+	default:
+		return 2
+	}
+}
+
+type Member struct {
+	// Address is the member's gRPC address, as host:port
+	Address string
+
+	// Weight is the member's relative share of keys
+	Weight int
+
+	State State
+
+	// Incarnation orders updates about the member. Only the member itself
+	// increments it, to refute suspicion of its failure.
+	Incarnation uint64
+}
+
+// Live reports whether the member should own keys. Suspect members are
+// live until they are confirmed dead.
+func (m Member) Live() bool {
+	return m.State == Alive || m.State == Suspect
+}
+
+// supersedes reports whether m is newer information than other.
+func (m Member) supersedes(other Member) bool {
+	if m.Incarnation != other.Incarnation {
+		return m.Incarnation > other.Incarnation
+	}
+	return m.State.precedence() > other.State.precedence()
+}
+
+type Config struct {
+	// Self is this node's address, as the other members know it
+	Self string
+
+	// Weight is this node's relative share of keys; zero means one
+	Weight int
+
+	// Seeds are addresses contacted to join the cluster
+	Seeds []string
+
+	// ProbeInterval is how often a member is probed
+	ProbeInterval time.Duration
+
+	// ProbeTimeout is how long a direct probe waits for a response before
+	// other members are asked to probe indirectly
+	ProbeTimeout time.Duration
+
+	// IndirectChecks is the number of members asked to probe a member that
+	// did not respond
+	IndirectChecks int
+
+	// SuspicionTimeout is how long a member is suspect before it is
+	// declared dead
+	SuspicionTimeout time.Duration
+
+	// RetransmitMult scales the number of times each update is piggybacked,
+	// which is RetransmitMult times the log of the cluster size
+	RetransmitMult int
+
+	// OnChange is called with a member whenever it is added or its state
+	// changes. It is not called for this node. The calls are made one at a
+	// time, in the order the changes were applied.
+	OnChange func(Member)
+
+	Logger logrus.FieldLogger
+}
+
+// Stats is a point in time view of the membership.
+type Stats struct {
+	// Members counts members, including this node, by state
+	Members map[State]int
+
+	Probes       uint64
+	FailedProbes uint64
+	Suspicions   uint64
+	Refutations  uint64
+}
+
+type member struct {
+	Member
+
+	// suspectedAt is when the member became suspect
+	suspectedAt time.Time
+}
+
+type broadcast struct {
+	member    Member
+	remaining int
+}
+
+// Membership is SWIM style gossip membership (Das, Gupta and Motivala).
+// Each probe interval a member pings another, round robin; if it does not
+// respond, other members are asked to ping it indirectly, and if none can it
+// becomes suspect. Suspect members are declared dead after a timeout unless
+// they refute the suspicion with a higher incarnation. Membership updates are
+// piggybacked on probes, so they spread through the cluster epidemically.
+type Membership struct {
+	sync.Mutex
+
+	config Config
+
+	self    *member
+	members map[string]*member
+
+	probeOrder []string
+	probeIndex int
+
+	broadcasts map[string]*broadcast
+	conns      map[string]*grpc.ClientConn
+
+	stats Stats
+
+	// pending are the changes not yet passed to OnChange, and delivering
+	// is held while they are
+	pending    []Member
+	delivering sync.Mutex
+
+	started  bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	logger logrus.FieldLogger
+}
+
+func New(config Config) (*Membership, error) {
+	if config.Self == "" {
+		return nil, ErrNoSelf
+	}
+	if config.Weight <= 0 {
+		config.Weight = 1
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = defaultProbeInterval
+	}
+	if config.ProbeTimeout <= 0 {
+		config.ProbeTimeout = defaultProbeTimeout
+	}
+	if config.ProbeTimeout > config.ProbeInterval {
+		config.ProbeTimeout = config.ProbeInterval
+	}
+	if config.IndirectChecks <= 0 {
+		config.IndirectChecks = defaultIndirectChecks
+	}
+	if config.SuspicionTimeout <= 0 {
+		config.SuspicionTimeout = defaultSuspicionTimeout
+	}
+	if config.RetransmitMult <= 0 {
+		config.RetransmitMult = defaultRetransmitMult
+	}
+
+	self := &member{
+		Member: Member{
+			Address: config.Self,
+			Weight:  config.Weight,
+			State:   Alive,
+		},
+	}
+
+	return &Membership{
+		config:     config,
+		self:       self,
+		members:    make(map[string]*member),
+		broadcasts: make(map[string]*broadcast),
+		conns:      make(map[string]*grpc.ClientConn),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		logger:     config.Logger.WithField("module", "membership"),
+	}, nil
+}
+
+// Start joins the cluster through the seeds and starts probing. Seeds that
+// cannot be reached are retried every probe interval until one joins.
+func (m *Membership) Start() {
+	m.Lock()
+	m.started = true
+	m.Unlock()
+
+	go m.run()
+}
+
+// Stop stops probing and closes the connections to other members.
+func (m *Membership) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+
+		m.Lock()
+		started := m.started
+		m.Unlock()
+		if started {
+			<-m.done
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		for address, conn := range m.conns {
+			conn.Close()
+			delete(m.conns, address)
+		}
+	})
+}
+
+// Leave tells the live members that this node is leaving, then stops.
+func (m *Membership) Leave(ctx context.Context) {
+	m.Lock()
+	m.self.State = Left
+	m.self.Incarnation++
+	m.enqueue(m.self.Member)
+	targets := m.liveAddresses()
+	m.Unlock()
+
+	var wg sync.WaitGroup
+	for _, address := range targets {
+		wg.Add(1)
+		go func(address string) {
+			defer wg.Done()
+			m.ping(ctx, address)
+		}(address)
+	}
+	wg.Wait()
+
+	m.Stop()
+}
+
+// Members returns every known member, including this node, ordered by
+// address.
+func (m *Membership) Members() []Member {
+	m.Lock()
+	defer m.Unlock()
+
+	members := []Member{m.self.Member}
+	for _, mem := range m.members {
+		members = append(members, mem.Member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Address < members[j].Address
+	})
+	return members
+}
+
+func (m *Membership) Stats() Stats {
+	m.Lock()
+	defer m.Unlock()
+
+	stats := m.stats
+	stats.Members = map[State]int{
+		m.self.State: 1,
+	}
+	for _, mem := range m.members {
+		stats.Members[mem.State]++
+	}
+	return stats
+}
+
+func (m *Membership) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.config.ProbeInterval)
+	defer ticker.Stop()
+
+	m.join()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if m.alone() {
+			m.join()
+		}
+		m.probe()
+		m.expireSuspects()
+	}
+}
+
+// alone reports whether no other member is live.
+func (m *Membership) alone() bool {
+	m.Lock()
+	defer m.Unlock()
+
+	return len(m.liveAddresses()) == 0
+}
+
+// join asks each seed for its members, returning the number of seeds that
+// responded.
+func (m *Membership) join() int {
+	m.Lock()
+	self := toProto(m.self.Member)
+	m.Unlock()
+
+	joined := 0
+	for _, seed := range m.config.Seeds {
+		if seed == m.config.Self {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), m.config.ProbeInterval)
+		res, err := m.client(seed).Join(ctx, &memcached.JoinRequest{Member: self})
+		cancel()
+		if err != nil {
+			m.logger.WithError(err).WithField("seed", seed).Debug("join failed")
+			continue
+		}
+
+		joined++
+		m.merge(res.Members)
+	}
+	return joined
+}
+
+// probe pings the next member, directly and then indirectly, and suspects
+// it if it does not respond.
+func (m *Membership) probe() {
+	m.Lock()
+	target, ok := m.nextTarget()
+	if ok {
+		m.stats.Probes++
+	}
+	m.Unlock()
+
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.ProbeTimeout)
+	err := m.ping(ctx, target)
+	cancel()
+	if err == nil {
+		return
+	}
+
+	if m.pingIndirect(target) {
+		return
+	}
+
+	m.logger.WithError(err).WithField("member", target).Debug("probe failed")
+
+	m.Lock()
+	m.stats.FailedProbes++
+	var notify []Member
+	if mem, ok := m.members[target]; ok && mem.State == Alive {
+		notify = m.apply(Member{
+			Address:     target,
+			Weight:      mem.Weight,
+			State:       Suspect,
+			Incarnation: mem.Incarnation,
+		})
+	}
+	m.queue(notify)
+	m.Unlock()
+
+	m.deliver()
+}
+
+// pingIndirect asks other members to ping target, reporting whether any of
+// them could.
+func (m *Membership) pingIndirect(target string) bool {
+	m.Lock()
+	var helpers []string
+	for _, address := range m.liveAddresses() {
+		if address != target {
+			helpers = append(helpers, address)
+		}
+	}
+	m.Unlock()
+
+	rand.Shuffle(len(helpers), func(i, j int) {
+		helpers[i], helpers[j] = helpers[j], helpers[i]
+	})
+	if len(helpers) > m.config.IndirectChecks {
+		helpers = helpers[:m.config.IndirectChecks]
+	}
+	if len(helpers) == 0 {
+		return false
+	}
+
+	timeout := m.config.ProbeInterval - m.config.ProbeTimeout
+	if timeout < m.config.ProbeTimeout {
+		timeout = m.config.ProbeTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	acks := make(chan bool, len(helpers))
+	for _, helper := range helpers {
+		go func(helper string) {
+			res, err := m.client(helper).PingReq(ctx, &memcached.PingReqRequest{
+				From:    m.config.Self,
+				Target:  target,
+				Updates: m.piggyback(),
+			})
+			if err == nil {
+				m.merge(res.Updates)
+			}
+			acks <- err == nil
+		}(helper)
+	}
+
+	for range helpers {
+		if <-acks {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Membership) ping(ctx context.Context, address string) error {
+	res, err := m.client(address).Ping(ctx, &memcached.PingRequest{
+		From:    m.config.Self,
+		Updates: m.piggyback(),
+	})
+	if err != nil {
+		return err
+	}
+	m.merge(res.Updates)
+	return nil
+}
+
+// expireSuspects declares members dead once they have been suspect for the
+// suspicion timeout.
+func (m *Membership) expireSuspects() {
+	m.Lock()
+	var notify []Member
+	now := time.Now()
+	for _, mem := range m.members {
+		if mem.State == Suspect && now.Sub(mem.suspectedAt) >= m.config.SuspicionTimeout {
+			dead := mem.Member
+			dead.State = Dead
+			notify = append(notify, m.apply(dead)...)
+		}
+	}
+	m.queue(notify)
+	m.Unlock()
+
+	m.deliver()
+}
+
+// nextTarget returns the next live member to probe. Members are probed
+// round robin in a random order, which is shuffled on each pass.
+func (m *Membership) nextTarget() (string, bool) {
+	for attempts := 0; attempts < 2; attempts++ {
+		for m.probeIndex < len(m.probeOrder) {
+			address := m.probeOrder[m.probeIndex]
+			m.probeIndex++
+			if mem, ok := m.members[address]; ok && mem.Live() {
+				return address, true
+			}
+		}
+
+		m.probeOrder = m.liveAddresses()
+		m.probeIndex = 0
+		rand.Shuffle(len(m.probeOrder), func(i, j int) {
+			m.probeOrder[i], m.probeOrder[j] = m.probeOrder[j], m.probeOrder[i]
+		})
+	}
+	return "", false
+}
+
+// liveAddresses returns the addresses of the other live members. m must be
+// locked.
+func (m *Membership) liveAddresses() []string {
+	var addresses []string
+	for address, mem := range m.members {
+		if mem.Live() {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// merge applies updates received from another member.
+func (m *Membership) merge(updates []*memcached.Member) {
+	m.Lock()
+	var notify []Member
+	for _, update := range updates {
+		notify = append(notify, m.apply(fromProto(update))...)
+	}
+	m.queue(notify)
+	m.Unlock()
+
+	m.deliver()
+}
+
+// apply applies an update, queueing it for dissemination if it is new, and
+// returns the members to notify of a change. m must be locked.
+func (m *Membership) apply(update Member) []Member {
+	if update.Address == "" {
+		return nil
+	}
+
+	if update.Address == m.self.Address {
+		// refute suspicion, or a stale incarnation from before a restart
+		if m.self.State == Alive && update.supersedes(m.self.Member) {
+			m.self.Incarnation = update.Incarnation + 1
+			m.stats.Refutations++
+			m.enqueue(m.self.Member)
+		}
+		return nil
+	}
+
+	mem, ok := m.members[update.Address]
+	if ok && !update.supersedes(mem.Member) {
+		return nil
+	}
+	if !ok {
+		mem = &member{}
+		m.members[update.Address] = mem
+	}
+
+	changed := !ok || mem.State != update.State || mem.Weight != update.Weight
+	mem.Member = update
+
+	if update.State == Suspect {
+		mem.suspectedAt = time.Now()
+		m.stats.Suspicions++
+	}
+	if !update.Live() {
+		if conn, ok := m.conns[update.Address]; ok {
+			conn.Close()
+			delete(m.conns, update.Address)
+		}
+	}
+
+	m.enqueue(update)
+
+	if !changed {
+		return nil
+	}
+	m.logger.WithFields(logrus.Fields{
+		"member":      update.Address,
+		"state":       update.State,
+		"incarnation": update.Incarnation,
+	}).Info("member changed")
+	return []Member{update}
+}
+
+// queue queues changes for OnChange, in the order they were applied. m must
+// be locked.
+func (m *Membership) queue(members []Member) {
+	if m.config.OnChange == nil {
+		return
+	}
+	m.pending = append(m.pending, members...)
+}
+
+// deliver passes the queued changes to OnChange, one at a time and in
+// order, however many goroutines apply changes concurrently. It is called
+// with m unlocked.
+func (m *Membership) deliver() {
+	m.delivering.Lock()
+	defer m.delivering.Unlock()
+
+	for {
+		m.Lock()
+		if len(m.pending) == 0 {
+			m.Unlock()
+			return
+		}
+		mem := m.pending[0]
+		m.pending = m.pending[1:]
+		m.Unlock()
+
+		m.config.OnChange(mem)
+	}
+}
+
+// enqueue queues an update to be piggybacked on outgoing messages, replacing
+// any older update about the same member. m must be locked.
+func (m *Membership) enqueue(update Member) {
+	size := len(m.liveAddresses()) + 1
+	m.broadcasts[update.Address] = &broadcast{
+		member:    update,
+		remaining: m.config.RetransmitMult * int(math.Ceil(math.Log10(float64(size+1)))),
+	}
+}
+
+// piggyback returns the updates to send with an outgoing message, those
+// sent the fewest times first.
+func (m *Membership) piggyback() []*memcached.Member {
+	m.Lock()
+	defer m.Unlock()
+
+	queued := make([]*broadcast, 0, len(m.broadcasts))
+	for _, b := range m.broadcasts {
+		queued = append(queued, b)
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].remaining > queued[j].remaining
+	})
+	if len(queued) > maxPiggyback {
+		queued = queued[:maxPiggyback]
+	}
+
+	updates := make([]*memcached.Member, len(queued))
+	for i, b := range queued {
+		updates[i] = toProto(b.member)
+
+		b.remaining--
+		if b.remaining <= 0 {
+			delete(m.broadcasts, b.member.Address)
+		}
+	}
+	return updates
+}
+
+func (m *Membership) client(address string) memcached.MembershipClient {
+	m.Lock()
+	defer m.Unlock()
+
+	conn, ok := m.conns[address]
+	if !ok {
+		// dialing without blocking only fails on bad options
+		conn, _ = grpc.Dial(address, grpc.WithInsecure())
+		m.conns[address] = conn
+	}
+	return memcached.NewMembershipClient(conn)
+}
+
+func (m *Membership) Ping(ctx context.Context, req *memcached.PingRequest) (*memcached.PingResponse, error) {
+	m.merge(req.Updates)
+
+	res := &memcached.PingResponse{
+		Updates: m.piggyback(),
+	}
+	return res, nil
+}
+
+func (m *Membership) PingReq(ctx context.Context, req *memcached.PingReqRequest) (*memcached.PingResponse, error) {
+	m.merge(req.Updates)
+
+	pingCtx, cancel := context.WithTimeout(ctx, m.config.ProbeTimeout)
+	defer cancel()
+
+	if err := m.ping(pingCtx, req.Target); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "%s did not respond: %s", req.Target, err)
+	}
+
+	res := &memcached.PingResponse{
+		Updates: m.piggyback(),
+	}
+	return res, nil
+}
+
+func (m *Membership) Join(ctx context.Context, req *memcached.JoinRequest) (*memcached.JoinResponse, error) {
+	if req.Member == nil || req.Member.Address == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "member address is required")
+	}
+
+	joining := fromProto(req.Member)
+	joining.State = Alive
+
+	m.Lock()
+	m.queue(m.apply(joining))
+	m.Unlock()
+	m.deliver()
+
+	res := &memcached.JoinResponse{}
+	for _, mem := range m.Members() {
+		res.Members = append(res.Members, toProto(mem))
+	}
+	return res, nil
+}
+
+func toProto(m Member) *memcached.Member {
+	member := &memcached.Member{
+		Address:     m.Address,
+		Weight:      int32(m.Weight),
+		Incarnation: m.Incarnation,
+	}
+
+	switch m.State {
+	case Suspect:
+		member.State = memcached.Member_SUSPECT
+	case Dead:
+		member.State = memcached.Member_DEAD
+	case Left:
+		member.State = memcached.Member_LEFT
+	default:
+		member.State = memcached.Member_ALIVE
+	}
+	return member
+}
+
+func fromProto(m *memcached.Member) Member {
+	member := Member{
+		Address:     m.Address,
+		Weight:      int(m.Weight),
+		Incarnation: m.Incarnation,
+	}
+	if member.Weight <= 0 {
+		member.Weight = 1
+	}
+
+	switch m.State {
+	case memcached.Member_SUSPECT:
+		member.State = Suspect
+	case memcached.Member_DEAD:
+		member.State = Dead
+	case memcached.Member_LEFT:
+		member.State = Left
+	default:
+		member.State = Alive
+	}
+	return member
+}
@@ -0,0 +1,256 @@
+package membership
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc"
+)
+
+var logger = logrus.NewEntry(logrus.New())
+
+type testNode struct {
+	membership *Membership
+	server     *grpc.Server
+
+	mu      sync.Mutex
+	changes map[string]State
+}
+
+func (n *testNode) stop() {
+	n.membership.Stop()
+	n.server.Stop()
+}
+
+func (n *testNode) state(address string) (State, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	state, ok := n.changes[address]
+	return state, ok
+}
+
+// startMembers starts n members on loopback ports, seeded with the first.
+func startMembers(t *testing.T, n int) []*testNode {
+	listeners := make([]net.Listener, n)
+	for i := range listeners {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		listeners[i] = lis
+	}
+	seed := listeners[0].Addr().String()
+
+	nodes := make([]*testNode, n)
+	for i, lis := range listeners {
+		node := &testNode{
+			changes: make(map[string]State),
+		}
+
+		m, err := New(Config{
+			Self:             lis.Addr().String(),
+			Seeds:            []string{seed},
+			ProbeInterval:    50 * time.Millisecond,
+			ProbeTimeout:     20 * time.Millisecond,
+			SuspicionTimeout: 300 * time.Millisecond,
+			OnChange: func(member Member) {
+				node.mu.Lock()
+				defer node.mu.Unlock()
+				node.changes[member.Address] = member.State
+			},
+			Logger: logger,
+		})
+		require.NoError(t, err)
+		node.membership = m
+
+		node.server = grpc.NewServer()
+		memcached.RegisterMembershipServer(node.server, m)
+		go node.server.Serve(lis)
+
+		m.Start()
+		nodes[i] = node
+	}
+	return nodes
+}
+
+// waitFor waits until every node sees address in state.
+func waitFor(t *testing.T, nodes []*testNode, address string, state State) {
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(10 * time.Millisecond) {
+		done := true
+		for _, node := range nodes {
+			if s, ok := node.state(address); !ok || s != state {
+				done = false
+			}
+		}
+		if done {
+			return
+		}
+	}
+	require.FailNow(t, "members did not converge", "address=%s state=%s", address, state)
+}
+
+func TestMemberSupersedes(t *testing.T) {
+	t.Parallel()
+
+	alive := Member{Address: "a", State: Alive, Incarnation: 1}
+
+	require.True(t, Member{Address: "a", State: Suspect, Incarnation: 1}.supersedes(alive))
+	require.True(t, Member{Address: "a", State: Dead, Incarnation: 1}.supersedes(alive))
+	require.True(t, Member{Address: "a", State: Alive, Incarnation: 2}.supersedes(alive))
+	require.False(t, Member{Address: "a", State: Alive, Incarnation: 1}.supersedes(alive))
+	require.False(t, Member{Address: "a", State: Suspect, Incarnation: 0}.supersedes(alive))
+
+	suspect := Member{Address: "a", State: Suspect, Incarnation: 1}
+	require.False(t, alive.supersedes(suspect))
+	require.True(t, Member{Address: "a", State: Alive, Incarnation: 2}.supersedes(suspect))
+}
+
+func TestMembershipRefute(t *testing.T) {
+	t.Parallel()
+
+	m, err := New(Config{Self: "127.0.0.1:1", Logger: logger})
+	require.NoError(t, err)
+
+	m.merge([]*memcached.Member{
+		{Address: "127.0.0.1:1", State: memcached.Member_SUSPECT, Incarnation: 3},
+		{Address: "127.0.0.1:2", Weight: 2},
+	})
+
+	members := m.Members()
+	require.Len(t, members, 2)
+	require.Equal(t, Member{Address: "127.0.0.1:1", Weight: 1, State: Alive, Incarnation: 4}, members[0])
+	require.Equal(t, Member{Address: "127.0.0.1:2", Weight: 2, State: Alive}, members[1])
+	require.EqualValues(t, 1, m.Stats().Refutations)
+
+	// the refutation is disseminated
+	var refuted bool
+	for _, update := range m.piggyback() {
+		if update.Address == "127.0.0.1:1" && update.Incarnation == 4 && update.State == memcached.Member_ALIVE {
+			refuted = true
+		}
+	}
+	require.True(t, refuted)
+}
+
+func TestMembershipOrder(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var last Member
+	m, err := New(Config{
+		Self: "127.0.0.1:1",
+		OnChange: func(member Member) {
+			mu.Lock()
+			defer mu.Unlock()
+			last = member
+		},
+		Logger: logger,
+	})
+	require.NoError(t, err)
+
+	// the changes are applied concurrently, but the last one delivered is
+	// the member's current state
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(incarnation uint64) {
+			defer wg.Done()
+			m.merge([]*memcached.Member{
+				{Address: "127.0.0.1:2", Weight: int32(incarnation%3 + 1), Incarnation: incarnation},
+			})
+		}(uint64(i))
+	}
+	wg.Wait()
+
+	members := m.Members()
+	require.Len(t, members, 2)
+	require.Equal(t, members[1], last)
+
+	// it was never started
+	m.Stop()
+}
+
+func TestMembershipJoin(t *testing.T) {
+	t.Parallel()
+
+	nodes := startMembers(t, 3)
+	defer func() {
+		for _, node := range nodes {
+			node.stop()
+		}
+	}()
+
+	for _, node := range nodes {
+		waitFor(t, exclude(nodes, node), node.membership.config.Self, Alive)
+	}
+
+	for _, node := range nodes {
+		members := node.membership.Members()
+		require.Len(t, members, 3)
+		for _, member := range members {
+			require.Equal(t, Alive, member.State)
+		}
+		require.Equal(t, 3, node.membership.Stats().Members[Alive])
+	}
+}
+
+func TestMembershipFailure(t *testing.T) {
+	t.Parallel()
+
+	nodes := startMembers(t, 4)
+	defer func() {
+		for _, node := range nodes[:3] {
+			node.stop()
+		}
+	}()
+
+	failed := nodes[3]
+	address := failed.membership.config.Self
+	waitFor(t, nodes[:3], address, Alive)
+
+	failed.stop()
+
+	waitFor(t, nodes[:3], address, Dead)
+	for _, node := range nodes[:3] {
+		stats := node.membership.Stats()
+		require.Equal(t, 3, stats.Members[Alive])
+		require.Equal(t, 1, stats.Members[Dead])
+	}
+}
+
+func TestMembershipLeave(t *testing.T) {
+	t.Parallel()
+
+	nodes := startMembers(t, 3)
+	defer func() {
+		for _, node := range nodes[:2] {
+			node.stop()
+		}
+	}()
+
+	leaving := nodes[2]
+	address := leaving.membership.config.Self
+	waitFor(t, nodes[:2], address, Alive)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	leaving.membership.Leave(ctx)
+	leaving.server.Stop()
+
+	waitFor(t, nodes[:2], address, Left)
+}
+
+func exclude(nodes []*testNode, node *testNode) []*testNode {
+	var others []*testNode
+	for _, n := range nodes {
+		if n != node {
+			others = append(others, n)
+		}
+	}
+	return others
+}
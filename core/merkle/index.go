@@ -0,0 +1,198 @@
+package merkle
+
+import (
+	"sync"
+
+	"github.com/tescherm/mc/core/cache"
+)
+
+// Index keeps the trees of the pools' replicated items as the items change,
+// so that comparing replicas does not scan them. It holds the timestamp of
+// every timestamped item, and, once a namespace is compared with a peer, a
+// tree of the keys the namespace shares with the peer, which each change
+// updates in place. Expired items are held until their cache notices them.
+type Index struct {
+	mu sync.Mutex
+
+	depth      uint
+	namespaces map[namespaceID]*namespaceIndex
+}
+
+type namespaceID struct {
+	pool      string
+	namespace string
+}
+
+type namespaceIndex struct {
+	// leaves holds the timestamp of every key, by the leaf holding it
+	leaves map[uint32]map[string]uint64
+
+	// trees holds the tree of each peer
+	trees map[string]*peerTree
+}
+
+type peerTree struct {
+	*Tree
+
+	// epoch is the topology epoch the tree was built for, and shares
+	// selects the keys it holds
+	epoch  uint64
+	shares func(key string) bool
+}
+
+// NewIndex returns an index of trees of the given depth, or DefaultDepth if
+// depth is zero.
+func NewIndex(depth uint) *Index {
+	if depth == 0 {
+		depth = DefaultDepth
+	}
+	return &Index{
+		depth:      depth,
+		namespaces: make(map[namespaceID]*namespaceIndex),
+	}
+}
+
+// Publish updates the index with a change to the items of a pool's
+// namespace. Items without a timestamp are not indexed.
+func (x *Index) Publish(pool, namespace string, event cache.Event) {
+	if event.Timestamp == 0 && event.Type != cache.EventClear {
+		return
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	id := namespaceID{pool, namespace}
+	n, ok := x.namespaces[id]
+
+	switch event.Type {
+	case cache.EventClear:
+		delete(x.namespaces, id)
+	case cache.EventSet:
+		if !ok {
+			n = &namespaceIndex{
+				leaves: make(map[uint32]map[string]uint64),
+				trees:  make(map[string]*peerTree),
+			}
+			x.namespaces[id] = n
+		}
+		n.remove(x.depth, event.Key, 0)
+		n.add(x.depth, event.Key, event.Timestamp)
+	default:
+		if ok {
+			n.remove(x.depth, event.Key, event.Timestamp)
+		}
+	}
+}
+
+// add indexes a key.
+func (n *namespaceIndex) add(depth uint, key string, timestamp uint64) {
+	l := leaf(depth, key)
+	keys, ok := n.leaves[l]
+	if !ok {
+		keys = make(map[string]uint64)
+		n.leaves[l] = keys
+	}
+	keys[key] = timestamp
+
+	for _, t := range n.trees {
+		if t.shares(key) {
+			t.Add(key, timestamp)
+		}
+	}
+}
+
+// remove stops indexing a key, if it is indexed with timestamp, or with any
+// timestamp if it is zero.
+func (n *namespaceIndex) remove(depth uint, key string, timestamp uint64) {
+	l := leaf(depth, key)
+	indexed, ok := n.leaves[l][key]
+	if !ok || (timestamp != 0 && indexed != timestamp) {
+		return
+	}
+	delete(n.leaves[l], key)
+	if len(n.leaves[l]) == 0 {
+		delete(n.leaves, l)
+	}
+
+	for _, t := range n.trees {
+		if t.shares(key) {
+			t.Remove(key, indexed)
+		}
+	}
+}
+
+// Tree returns a copy of the tree of the keys of a pool's namespace that
+// shares selects, kept for peer. The tree is built the first time, and
+// rebuilt whenever the topology epoch changes, since shares may then select
+// other keys.
+func (x *Index) Tree(pool, namespace, peer string, epoch uint64, shares func(key string) bool) *Tree {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	n, ok := x.namespaces[namespaceID{pool, namespace}]
+	if !ok {
+		return New(x.depth)
+	}
+
+	// trees built for another topology are rebuilt when next compared,
+	// rather than kept up to date until then
+	for p, t := range n.trees {
+		if t.epoch != epoch {
+			delete(n.trees, p)
+		}
+	}
+
+	t, ok := n.trees[peer]
+	if !ok {
+		t = &peerTree{
+			Tree:   New(x.depth),
+			epoch:  epoch,
+			shares: shares,
+		}
+		for _, keys := range n.leaves {
+			for key, timestamp := range keys {
+				if shares(key) {
+					t.Add(key, timestamp)
+				}
+			}
+		}
+		n.trees[peer] = t
+	}
+	return t.Copy()
+}
+
+// Keys returns the timestamps of the keys of a pool's namespace in leaf that
+// shares selects.
+func (x *Index) Keys(pool, namespace string, leaf uint32, shares func(key string) bool) map[string]uint64 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	keys := make(map[string]uint64)
+	n, ok := x.namespaces[namespaceID{pool, namespace}]
+	if !ok {
+		return keys
+	}
+	for key, timestamp := range n.leaves[leaf] {
+		if shares(key) {
+			keys[key] = timestamp
+		}
+	}
+	return keys
+}
+
+// Drop forgets a deleted pool. A nil index ignores it.
+func (x *Index) Drop(pool string) {
+	if x == nil {
+		return
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for id := range x.namespaces {
+		if id.pool == pool {
+			delete(x.namespaces, id)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package merkle
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/core/cache"
+)
+
+func all(key string) bool {
+	return true
+}
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+
+	x := NewIndex(4)
+	set := func(key string, timestamp uint64) {
+		x.Publish("pool", "ns", cache.Event{Type: cache.EventSet, Key: key, Timestamp: timestamp})
+	}
+
+	// a tree kept up to date matches one built from the final entries
+	tree := x.Tree("pool", "ns", "peer", 1, all)
+	require.Equal(t, New(4).Hash(1), tree.Hash(1))
+	for i := 0; i < 100; i++ {
+		set(fmt.Sprintf("key-%d", i), 1)
+	}
+	set("key-1", 2)
+	x.Publish("pool", "ns", cache.Event{Type: cache.EventRemove, Key: "key-2", Timestamp: 1})
+	x.Publish("pool", "ns", cache.Event{Type: cache.EventEvict, Key: "key-3", Timestamp: 1})
+	x.Publish("pool", "ns", cache.Event{Type: cache.EventExpire, Key: "key-4", Timestamp: 1})
+	// removing a write that was overwritten changes nothing
+	x.Publish("pool", "ns", cache.Event{Type: cache.EventRemove, Key: "key-1", Timestamp: 1})
+	// items without a timestamp are not indexed
+	set("untimed", 0)
+
+	expected := New(4)
+	for i := 0; i < 100; i++ {
+		switch i {
+		case 1:
+			expected.Add("key-1", 2)
+		case 2, 3, 4:
+		default:
+			expected.Add(fmt.Sprintf("key-%d", i), 1)
+		}
+	}
+	require.Equal(t, expected.Hash(1), x.Tree("pool", "ns", "peer", 1, all).Hash(1))
+	require.NotEqual(t, expected.Hash(1), tree.Hash(1))
+
+	leaf := expected.Leaf("key-1")
+	require.Equal(t, uint64(2), x.Keys("pool", "ns", leaf, all)["key-1"])
+
+	// another epoch rebuilds the tree with the keys now shared
+	odd := func(key string) bool {
+		return strings.HasSuffix(key, "1") || strings.HasSuffix(key, "3") || strings.HasSuffix(key, "5") ||
+			strings.HasSuffix(key, "7") || strings.HasSuffix(key, "9")
+	}
+	expected = New(4)
+	for i := 1; i < 100; i += 2 {
+		switch i {
+		case 1:
+			expected.Add("key-1", 2)
+		case 3:
+		default:
+			expected.Add(fmt.Sprintf("key-%d", i), 1)
+		}
+	}
+	require.Equal(t, expected.Hash(1), x.Tree("pool", "ns", "peer", 2, odd).Hash(1))
+	require.NotContains(t, x.Keys("pool", "ns", expected.Leaf("key-10"), odd), "key-10")
+
+	// a clear empties the namespace, and a dropped pool is forgotten
+	x.Publish("pool", "ns", cache.Event{Type: cache.EventClear})
+	require.Equal(t, New(4).Hash(1), x.Tree("pool", "ns", "peer", 2, odd).Hash(1))
+
+	set("key", 1)
+	x.Drop("pool")
+	require.Empty(t, x.Keys("pool", "ns", expected.Leaf("key"), all))
+}
@@ -0,0 +1,116 @@
+// Package merkle implements Merkle trees over key hash ranges, for finding
+// the keys two replicas disagree about without exchanging every key.
+package merkle
+
+import (
+	"github.com/tescherm/mc/core/consistenthash"
+)
+
+// DefaultDepth is the default tree depth, giving 1024 leaves.
+const DefaultDepth = 10
+
+// Tree is a complete binary tree whose leaves each cover an equal range of
+// key hashes. A leaf's hash combines the key and timestamp of every entry in
+// its range, independently of the order they were added in, and an inner
+// node's hash combines its children's, so two trees have the same root hash
+// exactly when they hold the same entries, barring collisions. Entries are
+// added and removed in place, rehashing only the nodes above their leaf.
+//
+// Nodes are numbered breadth first from the root, 1, so the children of node
+// i are 2i and 2i+1, and the leaves are numbered from 1<<depth.
+type Tree struct {
+	depth  uint
+	hashes []uint64
+}
+
+func New(depth uint) *Tree {
+	if depth == 0 {
+		depth = DefaultDepth
+	}
+	t := &Tree{
+		depth:  depth,
+		hashes: make([]uint64, 2<<depth),
+	}
+	for node := uint32(1<<depth - 1); node > 0; node-- {
+		t.rehash(node)
+	}
+	return t
+}
+
+func (t *Tree) Depth() uint {
+	return t.depth
+}
+
+// Leaf returns the leaf whose range holds key.
+func (t *Tree) Leaf(key string) uint32 {
+	return leaf(t.depth, key)
+}
+
+func leaf(depth uint, key string) uint32 {
+	return uint32(1<<depth | consistenthash.KeyHash(key)>>(64-depth))
+}
+
+// IsLeaf reports whether node is a leaf.
+func (t *Tree) IsLeaf(node uint32) bool {
+	return node >= 1<<t.depth
+}
+
+// Add adds an entry to the tree.
+func (t *Tree) Add(key string, timestamp uint64) {
+	t.toggle(key, timestamp)
+}
+
+// Remove removes an entry added to the tree.
+func (t *Tree) Remove(key string, timestamp uint64) {
+	t.toggle(key, timestamp)
+}
+
+// toggle adds an entry, or removes it if it was added, since entries are
+// combined by xor.
+func (t *Tree) toggle(key string, timestamp uint64) {
+	node := t.Leaf(key)
+	t.hashes[node] ^= consistenthash.Mix(consistenthash.KeyHash(key) ^ consistenthash.Mix(timestamp))
+	for node > 1 {
+		node >>= 1
+		t.rehash(node)
+	}
+}
+
+// rehash computes an inner node's hash from its children's.
+func (t *Tree) rehash(node uint32) {
+	t.hashes[node] = consistenthash.Mix(t.hashes[2*node]*31 ^ consistenthash.Mix(t.hashes[2*node+1]))
+}
+
+// Hash returns the hash of node, or zero if there is no such node.
+func (t *Tree) Hash(node uint32) uint64 {
+	if node == 0 || int(node) >= len(t.hashes) {
+		return 0
+	}
+	return t.hashes[node]
+}
+
+// Copy returns a copy of the tree.
+func (t *Tree) Copy() *Tree {
+	hashes := make([]uint64, len(t.hashes))
+	copy(hashes, t.hashes)
+	return &Tree{depth: t.depth, hashes: hashes}
+}
+
+// Descendants returns the descendants of node levels below it, or its leaf
+// descendants if the leaves are fewer levels below.
+func (t *Tree) Descendants(node uint32, levels uint) []uint32 {
+	level := uint(0)
+	for n := node; n > 1; n >>= 1 {
+		level++
+	}
+	if level+levels > t.depth {
+		levels = t.depth - level
+	}
+
+	first := node << levels
+	nodes := make([]uint32, 1<<levels)
+	for i := range nodes {
+		nodes[i] = first + uint32(i)
+	}
+	return nodes
+}
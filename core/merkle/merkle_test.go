@@ -0,0 +1,74 @@
+package merkle
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeOrder(t *testing.T) {
+	t.Parallel()
+
+	a, b := New(4), New(4)
+	for i := 0; i < 100; i++ {
+		a.Add(fmt.Sprintf("key-%d", i), uint64(i))
+		b.Add(fmt.Sprintf("key-%d", 99-i), uint64(99-i))
+	}
+	require.Equal(t, a.Hash(1), b.Hash(1))
+	require.NotZero(t, a.Hash(1))
+
+	// removing every entry leaves an empty tree
+	for i := 0; i < 100; i++ {
+		a.Remove(fmt.Sprintf("key-%d", i), uint64(i))
+	}
+	require.Equal(t, New(4).Hash(1), a.Hash(1))
+}
+
+func TestTreeDiff(t *testing.T) {
+	t.Parallel()
+
+	a, b := New(0), New(0)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		a.Add(key, 1)
+		if i != 42 {
+			b.Add(key, 1)
+		}
+	}
+	b.Add("key-42", 2)
+	require.NotEqual(t, a.Hash(1), b.Hash(1))
+
+	// walking down the differing nodes finds the leaf holding the key
+	var leaves []uint32
+	nodes := []uint32{1}
+	for len(nodes) > 0 {
+		var next []uint32
+		for _, node := range nodes {
+			if a.Hash(node) == b.Hash(node) {
+				continue
+			}
+			if a.IsLeaf(node) {
+				leaves = append(leaves, node)
+				continue
+			}
+			next = append(next, a.Descendants(node, 3)...)
+		}
+		nodes = next
+	}
+	require.Equal(t, []uint32{a.Leaf("key-42")}, leaves)
+}
+
+func TestTreeDescendants(t *testing.T) {
+	t.Parallel()
+
+	tree := New(4)
+	require.Equal(t, []uint32{2, 3}, tree.Descendants(1, 1))
+	require.Equal(t, []uint32{12, 13, 14, 15}, tree.Descendants(3, 2))
+
+	// the leaves are two levels below node 4
+	require.Equal(t, []uint32{16, 17, 18, 19}, tree.Descendants(4, 5))
+	require.Equal(t, []uint32{16}, tree.Descendants(16, 1))
+	require.True(t, tree.IsLeaf(16))
+	require.Zero(t, tree.Hash(32))
+}
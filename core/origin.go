@@ -0,0 +1,28 @@
+package core
+
+import (
+	"context"
+
+	"github.com/tescherm/mc/core/cache"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// load loads a key missed by the node holding it from the key's origin, if
+// its pool has one. Loads are made by the node holding the key, so a key's
+// concurrent misses across the cluster share one load.
+func (s *MemcachedService) load(ctx context.Context, pool, namespace, key string) (*cache.Item, error) {
+	ns, err := s.namespace(ctx, pool, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := ns.Load(ctx, key)
+	switch {
+	case err == context.DeadlineExceeded || err == context.Canceled:
+		return nil, status.FromContextError(err).Err()
+	case err != nil:
+		return nil, status.Errorf(codes.Unavailable, "unable to load %s from origin: %s", key, err)
+	}
+	return item, nil
+}
@@ -0,0 +1,142 @@
+// Package origin loads the keys a pool misses from the upstream service the
+// pool caches, over HTTP or gRPC.
+package origin
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/caches"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc"
+)
+
+// TagsHeader is the HTTP response header listing a loaded item's tags,
+// separated by commas.
+const TagsHeader = "Mc-Tags"
+
+// Validate returns an error unless uri names an origin New can load from.
+func Validate(uri string) error {
+	_, err := parse(uri)
+	return err
+}
+
+func parse(uri string) (*url.URL, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid origin %s", uri)
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "grpc":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid origin %s: no address", uri)
+		}
+	default:
+		return nil, fmt.Errorf("invalid origin %s: unsupported scheme %q", uri, u.Scheme)
+	}
+	return u, nil
+}
+
+// New returns a loader for the keys of pool from the origin at uri. An HTTP
+// origin is sent GET <uri>/<namespace>/<key>, and answers 404 for the keys
+// it does not have. A grpc://host:port origin serves the Origin service.
+// The loader must be closed.
+func New(pool, uri string) (caches.Loader, error) {
+	u, err := parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "grpc" {
+		return &httpOrigin{
+			base:   strings.TrimSuffix(uri, "/"),
+			client: &http.Client{},
+		}, nil
+	}
+
+	conn, err := grpc.Dial(u.Host, grpc.WithInsecure())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to dial origin %s", uri)
+	}
+	return &grpcOrigin{
+		pool:   pool,
+		conn:   conn,
+		client: memcached.NewOriginClient(conn),
+	}, nil
+}
+
+type httpOrigin struct {
+	base   string
+	client *http.Client
+}
+
+func (o *httpOrigin) Load(ctx context.Context, namespace, key string) (*cache.Item, error) {
+	req, err := http.NewRequest(http.MethodGet, o.base+"/"+url.PathEscape(namespace)+"/"+url.PathEscape(key), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := o.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("origin responded %s", res.Status)
+	}
+
+	value, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read origin response")
+	}
+	item := cache.NewItem(key, value, 0)
+	if tags := res.Header.Get(TagsHeader); tags != "" {
+		item.Tags = strings.Split(tags, ",")
+	}
+	return item, nil
+}
+
+func (o *httpOrigin) Close() error {
+	o.client.CloseIdleConnections()
+	return nil
+}
+
+type grpcOrigin struct {
+	pool   string
+	conn   *grpc.ClientConn
+	client memcached.OriginClient
+}
+
+func (o *grpcOrigin) Load(ctx context.Context, namespace, key string) (*cache.Item, error) {
+	res, err := o.client.Load(ctx, &memcached.LoadRequest{
+		Pool:      o.pool,
+		Namespace: namespace,
+		Key:       key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !res.Found {
+		return nil, nil
+	}
+
+	item := cache.NewItem(key, res.Value, 0)
+	item.Tags = res.Tags
+	return item, nil
+}
+
+func (o *grpcOrigin) Close() error {
+	return o.conn.Close()
+}
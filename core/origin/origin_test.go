@@ -0,0 +1,102 @@
+package origin
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc"
+)
+
+type testOrigin struct {
+	values map[string]string
+}
+
+func (o *testOrigin) Load(ctx context.Context, req *memcached.LoadRequest) (*memcached.LoadResponse, error) {
+	value, ok := o.values[req.Pool+"/"+req.Namespace+"/"+req.Key]
+	if !ok {
+		return &memcached.LoadResponse{}, nil
+	}
+	return &memcached.LoadResponse{
+		Found: true,
+		Value: []byte(value),
+		Tags:  []string{"tag"},
+	}, nil
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, Validate("http://localhost:8000/items"))
+	require.NoError(t, Validate("https://origin.example.com"))
+	require.NoError(t, Validate("grpc://localhost:9000"))
+
+	require.Error(t, Validate("grpc:///path"))
+	require.Error(t, Validate("ftp://origin.example.com"))
+	require.Error(t, Validate("localhost:8000"))
+}
+
+func TestHTTPOrigin(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/items/ns/a key":
+			w.Header().Set(TagsHeader, "tag1,tag2")
+			w.Write([]byte("value"))
+		case "/items/ns/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	o, err := New("pool", server.URL+"/items/")
+	require.NoError(t, err)
+	defer o.(*httpOrigin).Close()
+
+	item, err := o.Load(context.Background(), "ns", "a key")
+	require.NoError(t, err)
+	require.Equal(t, "a key", item.Key)
+	require.Equal(t, "value", string(item.Value))
+	require.Equal(t, []string{"tag1", "tag2"}, item.Tags)
+
+	item, err = o.Load(context.Background(), "ns", "missing")
+	require.NoError(t, err)
+	require.Nil(t, item)
+
+	_, err = o.Load(context.Background(), "ns", "broken")
+	require.Error(t, err)
+}
+
+func TestGRPCOrigin(t *testing.T) {
+	t.Parallel()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	memcached.RegisterOriginServer(server, &testOrigin{
+		values: map[string]string{"pool/ns/key": "value"},
+	})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	o, err := New("pool", "grpc://"+lis.Addr().String())
+	require.NoError(t, err)
+	defer o.(*grpcOrigin).Close()
+
+	item, err := o.Load(context.Background(), "ns", "key")
+	require.NoError(t, err)
+	require.Equal(t, "value", string(item.Value))
+	require.Equal(t, []string{"tag"}, item.Tags)
+
+	item, err = o.Load(context.Background(), "other", "key")
+	require.NoError(t, err)
+	require.Nil(t, item)
+}
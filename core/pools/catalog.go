@@ -0,0 +1,81 @@
+package pools
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const catalogVersion = 1
+
+type catalogFile struct {
+	Version int          `json:"version"`
+	Pools   []PoolConfig `json:"pools"`
+}
+
+// catalog persists pool definitions as a JSON file.
+type catalog struct {
+	path string
+}
+
+func (c *catalog) load() ([]PoolConfig, error) {
+	b, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read catalog %s", c.path)
+	}
+
+	var f catalogFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse catalog %s", c.path)
+	}
+	if f.Version != catalogVersion {
+		return nil, errors.Errorf("unsupported catalog %s version %d", c.path, f.Version)
+	}
+	return f.Pools, nil
+}
+
+// save atomically replaces the catalog by writing a temporary file and
+// renaming it into place.
+func (c *catalog) save(pools []PoolConfig) error {
+	b, err := json.MarshalIndent(catalogFile{
+		Version: catalogVersion,
+		Pools:   pools,
+	}, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "unable to encode catalog")
+	}
+
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "unable to create catalog directory %s", dir)
+	}
+
+	tmp, err := ioutil.TempFile(dir, filepath.Base(c.path)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "unable to write catalog %s", c.path)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "unable to write catalog %s", c.path)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "unable to write catalog %s", c.path)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "unable to write catalog %s", c.path)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return errors.Wrapf(err, "unable to write catalog %s", c.path)
+	}
+	return nil
+}
@@ -0,0 +1,485 @@
+package pools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/caches"
+	"github.com/tescherm/mc/core/consistenthash"
+	"github.com/tescherm/mc/core/origin"
+)
+
+// DefaultPool is the pool used when a request does not name one.
+const DefaultPool = "default"
+
+var (
+	ErrPoolExists   = errors.New("pool already exists")
+	ErrPoolNotFound = errors.New("pool not found")
+	ErrDefaultPool  = errors.New("the default pool cannot be deleted")
+
+	ErrNamespaceNotFound = errors.New("namespace not found")
+)
+
+var poolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// PoolConfig defines a named cache pool.
+type PoolConfig struct {
+	Name string `json:"name"`
+
+	// Capacity is the default namespace capacity, in bytes
+	Capacity   uint64 `json:"capacity"`
+	CacheCount int    `json:"cacheCount"`
+	Replicas   int    `json:"replicas"`
+
+	Policy cache.Policy  `json:"policy"`
+	TTL    time.Duration `json:"ttl"`
+
+	// SoftTTL, if positive, is how long items are fresh after they are set.
+	// Past it, and until TTL, items are served stale.
+	SoftTTL time.Duration `json:"softTtl,omitempty"`
+
+	// Namespaces maps additional namespace names to their capacity, in bytes
+	Namespaces map[string]uint64 `json:"namespaces,omitempty"`
+
+	// HashStrategy is how keys are mapped to the pool's caches
+	HashStrategy consistenthash.Strategy `json:"hashStrategy"`
+
+	// BoundedLoad, if positive, bounds each cache's load to (1+BoundedLoad)
+	// times the average. In a cluster, reads also spill from peers with more
+	// than (1+BoundedLoad) times the average load.
+	BoundedLoad float64 `json:"boundedLoad,omitempty"`
+
+	// ReplicationFactor is the number of cluster peers holding each key.
+	// Keys are not replicated if it is zero or one.
+	ReplicationFactor int `json:"replicationFactor,omitempty"`
+
+	// ReadQuorum and WriteQuorum are the number of replicas that must
+	// respond to reads and writes. Zero means a majority.
+	ReadQuorum  int `json:"readQuorum,omitempty"`
+	WriteQuorum int `json:"writeQuorum,omitempty"`
+
+	// Origin, if set, is the HTTP URL or grpc://host:port address that
+	// missed keys are loaded from. LoadTimeout bounds each load.
+	Origin      string        `json:"origin,omitempty"`
+	LoadTimeout time.Duration `json:"loadTimeout,omitempty"`
+
+	// NegativeTTL, if positive, is how long keys the origin does not have
+	// are remembered, and not loaded again
+	NegativeTTL time.Duration `json:"negativeTtl,omitempty"`
+}
+
+// Replicated reports whether the pool's keys are replicated across cluster
+// peers.
+func (c PoolConfig) Replicated() bool {
+	return c.ReplicationFactor > 1
+}
+
+// Quorums returns the pool's read and write quorums.
+func (c PoolConfig) Quorums() (read, write int) {
+	majority := c.ReplicationFactor/2 + 1
+
+	read, write = c.ReadQuorum, c.WriteQuorum
+	if read == 0 {
+		read = majority
+	}
+	if write == 0 {
+		write = majority
+	}
+	return read, write
+}
+
+func (c PoolConfig) validate() error {
+	if !poolNamePattern.MatchString(c.Name) {
+		return fmt.Errorf("invalid pool name %q", c.Name)
+	}
+	if c.CacheCount <= 0 {
+		return fmt.Errorf("pool %s: cache count must be positive", c.Name)
+	}
+	if c.Capacity == 0 {
+		return fmt.Errorf("pool %s: capacity must be positive", c.Name)
+	}
+	if c.Replicas < 0 {
+		return fmt.Errorf("pool %s: replicas must not be negative", c.Name)
+	}
+	if c.TTL < 0 {
+		return fmt.Errorf("pool %s: ttl must not be negative", c.Name)
+	}
+	if c.SoftTTL < 0 {
+		return fmt.Errorf("pool %s: soft ttl must not be negative", c.Name)
+	}
+	if c.SoftTTL > 0 && c.TTL > 0 && c.SoftTTL >= c.TTL {
+		return fmt.Errorf("pool %s: soft ttl must be less than the ttl", c.Name)
+	}
+	if c.SoftTTL > 0 && c.Replicated() {
+		return fmt.Errorf("pool %s: soft ttls are not supported by replicated pools", c.Name)
+	}
+	if c.Policy != cache.PolicyLRU && c.Policy != cache.PolicyFIFO {
+		return fmt.Errorf("pool %s: unknown eviction policy %s", c.Name, c.Policy)
+	}
+	if _, err := c.HashStrategy.MarshalText(); err != nil {
+		return fmt.Errorf("pool %s: %s", c.Name, err)
+	}
+	if c.BoundedLoad < 0 {
+		return fmt.Errorf("pool %s: bounded load must not be negative", c.Name)
+	}
+	if c.BoundedLoad > 0 && c.HashStrategy != consistenthash.StrategyRing && c.HashStrategy != consistenthash.StrategyRendezvous {
+		return fmt.Errorf("pool %s: bounded load requires the ring or rendezvous hash strategy", c.Name)
+	}
+	if c.ReplicationFactor < 0 {
+		return fmt.Errorf("pool %s: replication factor must not be negative", c.Name)
+	}
+	if c.ReadQuorum < 0 || c.ReadQuorum > c.ReplicationFactor {
+		return fmt.Errorf("pool %s: read quorum must be between 0 and the replication factor", c.Name)
+	}
+	if c.WriteQuorum < 0 || c.WriteQuorum > c.ReplicationFactor {
+		return fmt.Errorf("pool %s: write quorum must be between 0 and the replication factor", c.Name)
+	}
+	if c.LoadTimeout < 0 {
+		return fmt.Errorf("pool %s: load timeout must not be negative", c.Name)
+	}
+	if c.NegativeTTL < 0 {
+		return fmt.Errorf("pool %s: negative ttl must not be negative", c.Name)
+	}
+	if c.Origin == "" {
+		return nil
+	}
+	if c.Replicated() {
+		return fmt.Errorf("pool %s: origins are not supported by replicated pools", c.Name)
+	}
+	if err := origin.Validate(c.Origin); err != nil {
+		return fmt.Errorf("pool %s: %s", c.Name, err)
+	}
+	return nil
+}
+
+// Pool is a named set of caches with its own configuration.
+type Pool struct {
+	Config PoolConfig
+	Caches *caches.Caches
+}
+
+func newPool(config PoolConfig, listener Listener) (*Pool, error) {
+	var cachesListener caches.Listener
+	if listener != nil {
+		cachesListener = func(namespace string, event cache.Event) {
+			listener(config.Name, namespace, event)
+		}
+	}
+
+	var loader caches.Loader
+	if config.Origin != "" {
+		var err error
+		if loader, err = origin.New(config.Name, config.Origin); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Pool{
+		Config: config,
+		Caches: caches.New(caches.Config{
+			CacheCount:   config.CacheCount,
+			Replicas:     config.Replicas,
+			Capacity:     config.Capacity,
+			Namespaces:   config.Namespaces,
+			Policy:       config.Policy,
+			TTL:          config.TTL,
+			SoftTTL:      config.SoftTTL,
+			HashStrategy: config.HashStrategy,
+			BoundedLoad:  config.BoundedLoad,
+			Listener:     cachesListener,
+			Loader:       loader,
+			LoadTimeout:  config.LoadTimeout,
+			NegativeTTL:  config.NegativeTTL,
+		}),
+	}, nil
+}
+
+type Config struct {
+	// Default configures the default pool, which always exists and is not
+	// stored in the catalog
+	Default PoolConfig
+
+	// CatalogPath is where pool definitions are stored. If empty, pools do
+	// not survive restarts.
+	CatalogPath string
+
+	// Listener, if set, is called with every change to the pools' items
+	Listener Listener
+}
+
+// Listener is called with changes to the items of a pool's namespace, while
+// the cache holding them is locked: it must not block or call the pools.
+type Listener func(pool, namespace string, event cache.Event)
+
+// Listeners returns a listener calling each of listeners in turn, skipping
+// any that are nil.
+func Listeners(listeners ...Listener) Listener {
+	var set []Listener
+	for _, l := range listeners {
+		if l != nil {
+			set = append(set, l)
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	return func(pool, namespace string, event cache.Event) {
+		for _, l := range set {
+			l(pool, namespace, event)
+		}
+	}
+}
+
+// Pools is the registry of named cache pools.
+type Pools struct {
+	sync.RWMutex
+
+	pools    map[string]*Pool
+	catalog  *catalog
+	listener Listener
+}
+
+// New creates the default pool and any pools defined in the catalog.
+func New(config Config) (*Pools, error) {
+	config.Default.Name = DefaultPool
+	if err := config.Default.validate(); err != nil {
+		return nil, err
+	}
+
+	pool, err := newPool(config.Default, config.Listener)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pools{
+		pools: map[string]*Pool{
+			DefaultPool: pool,
+		},
+		listener: config.Listener,
+	}
+
+	if config.CatalogPath == "" {
+		return p, nil
+	}
+
+	p.catalog = &catalog{path: config.CatalogPath}
+	configs, err := p.catalog.load()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range configs {
+		if err := c.validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid catalog %s", config.CatalogPath)
+		}
+		if _, ok := p.pools[c.Name]; ok {
+			return nil, errors.Errorf("invalid catalog %s: duplicate pool %s", config.CatalogPath, c.Name)
+		}
+		if p.pools[c.Name], err = newPool(c, p.listener); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Create creates a pool and records it in the catalog.
+func (p *Pools) Create(config PoolConfig) (*Pool, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if _, ok := p.pools[config.Name]; ok {
+		return nil, ErrPoolExists
+	}
+
+	pool, err := newPool(config, p.listener)
+	if err != nil {
+		return nil, err
+	}
+	p.pools[config.Name] = pool
+
+	if err := p.save(); err != nil {
+		delete(p.pools, config.Name)
+		pool.Caches.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// Delete deletes a pool, discarding its contents.
+func (p *Pools) Delete(name string) error {
+	if name == DefaultPool {
+		return ErrDefaultPool
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	pool, ok := p.pools[name]
+	if !ok {
+		return ErrPoolNotFound
+	}
+	delete(p.pools, name)
+
+	if err := p.save(); err != nil {
+		p.pools[name] = pool
+		return err
+	}
+	pool.Caches.Close()
+	return nil
+}
+
+// Reshard changes the number of caches in a pool and records it in the
+// catalog. It returns the migration that moves existing keys, which the
+// caller must run. The default pool's cache count is not stored, so it
+// reverts to its configured value on restart.
+func (p *Pools) Reshard(name string, cacheCount int) (*caches.Migration, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	pool, ok := p.pools[name]
+	if !ok {
+		return nil, ErrPoolNotFound
+	}
+	if pool.Caches.Migrating() {
+		return nil, caches.ErrMigrating
+	}
+
+	config := pool.Config
+	config.CacheCount = cacheCount
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	// pools are replaced rather than modified, so callers holding the old
+	// pool see a consistent config
+	p.pools[name] = &Pool{
+		Config: config,
+		Caches: pool.Caches,
+	}
+	if err := p.save(); err != nil {
+		p.pools[name] = pool
+		return nil, err
+	}
+
+	return pool.Caches.Reshard(cacheCount)
+}
+
+// Resize changes the capacity of a pool namespace and records it in the
+// catalog. Like Reshard, resizing the default pool lasts until restart.
+func (p *Pools) Resize(name, namespace string, capacity uint64) (*Pool, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	pool, ok := p.pools[name]
+	if !ok {
+		return nil, ErrPoolNotFound
+	}
+	ns, ok := pool.Caches.Namespace(namespace)
+	if !ok {
+		return nil, ErrNamespaceNotFound
+	}
+	if pool.Caches.Migrating() {
+		return nil, caches.ErrMigrating
+	}
+	if capacity == 0 {
+		return nil, fmt.Errorf("pool %s: capacity must be positive", name)
+	}
+
+	config := pool.Config
+	if namespace == caches.DefaultNamespace {
+		config.Capacity = capacity
+	} else {
+		config.Namespaces = make(map[string]uint64, len(pool.Config.Namespaces))
+		for k, v := range pool.Config.Namespaces {
+			config.Namespaces[k] = v
+		}
+		config.Namespaces[namespace] = capacity
+	}
+
+	resized := &Pool{
+		Config: config,
+		Caches: pool.Caches,
+	}
+	p.pools[name] = resized
+	if err := p.save(); err != nil {
+		p.pools[name] = pool
+		return nil, err
+	}
+
+	if err := ns.SetCapacity(capacity); err != nil {
+		return nil, err
+	}
+	return resized, nil
+}
+
+// Rebalance rebalances the capacity of every pool's caches, returning the
+// number of bytes moved.
+func (p *Pools) Rebalance(config caches.RebalanceConfig) uint64 {
+	var moved uint64
+	for _, pool := range p.List() {
+		moved += pool.Caches.Rebalance(config)
+	}
+	return moved
+}
+
+// Trim evicts the given fraction of the contents of every pool, returning
+// the number of bytes freed.
+func (p *Pools) Trim(fraction float64) uint64 {
+	var freed uint64
+	for _, pool := range p.List() {
+		freed += pool.Caches.Trim(fraction)
+	}
+	return freed
+}
+
+func (p *Pools) Get(name string) (*Pool, bool) {
+	p.RLock()
+	defer p.RUnlock()
+
+	pool, ok := p.pools[name]
+	return pool, ok
+}
+
+// List returns every pool, sorted by name.
+func (p *Pools) List() []*Pool {
+	p.RLock()
+	defer p.RUnlock()
+
+	pools := make([]*Pool, 0, len(p.pools))
+	for _, pool := range p.pools {
+		pools = append(pools, pool)
+	}
+	sort.Slice(pools, func(i, j int) bool {
+		return pools[i].Config.Name < pools[j].Config.Name
+	})
+	return pools
+}
+
+// save writes every pool except the default to the catalog.
+func (p *Pools) save() error {
+	if p.catalog == nil {
+		return nil
+	}
+
+	configs := make([]PoolConfig, 0, len(p.pools))
+	for name, pool := range p.pools {
+		if name == DefaultPool {
+			continue
+		}
+		configs = append(configs, pool.Config)
+	}
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Name < configs[j].Name
+	})
+
+	return p.catalog.save(configs)
+}
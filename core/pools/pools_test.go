@@ -0,0 +1,259 @@
+package pools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/caches"
+	"github.com/tescherm/mc/core/consistenthash"
+)
+
+var defaultConfig = PoolConfig{
+	Capacity:   100000,
+	CacheCount: 5,
+	Replicas:   160,
+}
+
+func tempCatalog(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "pools")
+	require.NoError(t, err)
+
+	return filepath.Join(dir, "catalog.json"), func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPoolsDefault(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(Config{Default: defaultConfig})
+	require.NoError(t, err)
+
+	pool, ok := p.Get(DefaultPool)
+	require.True(t, ok)
+	require.Equal(t, DefaultPool, pool.Config.Name)
+	require.NotNil(t, pool.Caches.CacheForKey("key"))
+
+	require.Equal(t, ErrDefaultPool, p.Delete(DefaultPool))
+}
+
+func TestPoolsCreateDelete(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(Config{Default: defaultConfig})
+	require.NoError(t, err)
+
+	sessions, err := p.Create(PoolConfig{
+		Name:       "sessions",
+		Capacity:   1000,
+		CacheCount: 2,
+		Policy:     cache.PolicyFIFO,
+		TTL:        time.Minute,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "sessions", sessions.Config.Name)
+
+	_, err = p.Create(PoolConfig{
+		Name:       "sessions",
+		Capacity:   1000,
+		CacheCount: 2,
+	})
+	require.Equal(t, ErrPoolExists, err)
+
+	pools := p.List()
+	require.Len(t, pools, 2)
+	require.Equal(t, DefaultPool, pools[0].Config.Name)
+	require.Equal(t, "sessions", pools[1].Config.Name)
+
+	require.NoError(t, p.Delete("sessions"))
+	require.Equal(t, ErrPoolNotFound, p.Delete("sessions"))
+
+	_, ok := p.Get("sessions")
+	require.False(t, ok)
+}
+
+func TestPoolsValidation(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(Config{Default: defaultConfig})
+	require.NoError(t, err)
+
+	invalid := []PoolConfig{
+		{Name: "", Capacity: 1000, CacheCount: 1},
+		{Name: "bad name", Capacity: 1000, CacheCount: 1},
+		{Name: "pool", Capacity: 0, CacheCount: 1},
+		{Name: "pool", Capacity: 1000, CacheCount: 0},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, Replicas: -1},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, TTL: -time.Second},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, SoftTTL: -time.Second},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, TTL: time.Second, SoftTTL: time.Second},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, SoftTTL: time.Second, ReplicationFactor: 3},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, Policy: cache.Policy(7)},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, ReplicationFactor: -1},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, ReplicationFactor: 3, ReadQuorum: 4},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, ReplicationFactor: 3, WriteQuorum: -1},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, Origin: "ftp://origin"},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, Origin: "grpc://origin:9000", ReplicationFactor: 3},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, Origin: "http://origin", LoadTimeout: -time.Second},
+		{Name: "pool", Capacity: 1000, CacheCount: 1, Origin: "http://origin", NegativeTTL: -time.Second},
+	}
+
+	for _, config := range invalid {
+		_, err := p.Create(config)
+		require.Error(t, err, "config=%+v", config)
+	}
+}
+
+func TestPoolsCatalog(t *testing.T) {
+	t.Parallel()
+
+	path, cleanup := tempCatalog(t)
+	defer cleanup()
+
+	p, err := New(Config{Default: defaultConfig, CatalogPath: path})
+	require.NoError(t, err)
+
+	fragments := PoolConfig{
+		Name:       "fragments",
+		Capacity:   1 << 20,
+		CacheCount: 4,
+		Replicas:   50,
+		Namespaces: map[string]uint64{"team": 1 << 10},
+
+		HashStrategy: consistenthash.StrategyMaglev,
+	}
+	sessions := PoolConfig{
+		Name:       "sessions",
+		Capacity:   1000,
+		CacheCount: 2,
+		Replicas:   160,
+		Policy:     cache.PolicyFIFO,
+		TTL:        time.Minute,
+	}
+
+	_, err = p.Create(fragments)
+	require.NoError(t, err)
+	_, err = p.Create(sessions)
+	require.NoError(t, err)
+	_, err = p.Create(PoolConfig{Name: "scratch", Capacity: 1000, CacheCount: 1})
+	require.NoError(t, err)
+	require.NoError(t, p.Delete("scratch"))
+
+	// a restart recreates the catalog pools
+	restarted, err := New(Config{Default: defaultConfig, CatalogPath: path})
+	require.NoError(t, err)
+
+	pools := restarted.List()
+	require.Len(t, pools, 3)
+	require.Equal(t, fragments, pools[1].Config)
+	require.Equal(t, sessions, pools[2].Config)
+
+	_, ok := pools[1].Caches.Namespace("team")
+	require.True(t, ok)
+}
+
+func TestPoolsReshard(t *testing.T) {
+	t.Parallel()
+
+	path, cleanup := tempCatalog(t)
+	defer cleanup()
+
+	p, err := New(Config{Default: defaultConfig, CatalogPath: path})
+	require.NoError(t, err)
+
+	_, err = p.Create(PoolConfig{Name: "sessions", Capacity: 1000, CacheCount: 2})
+	require.NoError(t, err)
+
+	_, err = p.Reshard("other", 4)
+	require.Equal(t, ErrPoolNotFound, err)
+	_, err = p.Reshard("sessions", 0)
+	require.Error(t, err)
+
+	m, err := p.Reshard("sessions", 4)
+	require.NoError(t, err)
+
+	_, err = p.Reshard("sessions", 3)
+	require.Equal(t, caches.ErrMigrating, err)
+
+	m.Run(nil)
+
+	sessions, ok := p.Get("sessions")
+	require.True(t, ok)
+	require.Equal(t, 4, sessions.Config.CacheCount)
+
+	restarted, err := New(Config{Default: defaultConfig, CatalogPath: path})
+	require.NoError(t, err)
+
+	sessions, ok = restarted.Get("sessions")
+	require.True(t, ok)
+	require.Equal(t, 4, sessions.Config.CacheCount)
+}
+
+func TestPoolsResize(t *testing.T) {
+	t.Parallel()
+
+	path, cleanup := tempCatalog(t)
+	defer cleanup()
+
+	p, err := New(Config{Default: defaultConfig, CatalogPath: path})
+	require.NoError(t, err)
+
+	_, err = p.Create(PoolConfig{
+		Name:       "sessions",
+		Capacity:   1000,
+		CacheCount: 2,
+		Namespaces: map[string]uint64{"team": 500},
+	})
+	require.NoError(t, err)
+
+	_, err = p.Resize("other", caches.DefaultNamespace, 2000)
+	require.Equal(t, ErrPoolNotFound, err)
+	_, err = p.Resize("sessions", "other", 2000)
+	require.Equal(t, ErrNamespaceNotFound, err)
+	_, err = p.Resize("sessions", caches.DefaultNamespace, 0)
+	require.Error(t, err)
+
+	sessions, err := p.Resize("sessions", caches.DefaultNamespace, 2000)
+	require.NoError(t, err)
+	require.EqualValues(t, 2000, sessions.Config.Capacity)
+
+	sessions, err = p.Resize("sessions", "team", 800)
+	require.NoError(t, err)
+	require.EqualValues(t, 800, sessions.Config.Namespaces["team"])
+
+	stats := sessions.Caches.Stats()
+	require.EqualValues(t, 2000, stats.Namespaces[caches.DefaultNamespace].Capacity)
+	require.EqualValues(t, 800, stats.Namespaces["team"].Capacity)
+
+	restarted, err := New(Config{Default: defaultConfig, CatalogPath: path})
+	require.NoError(t, err)
+
+	sessions, ok := restarted.Get("sessions")
+	require.True(t, ok)
+	require.EqualValues(t, 2000, sessions.Config.Capacity)
+	require.EqualValues(t, 800, sessions.Config.Namespaces["team"])
+}
+
+func TestPoolsCatalogInvalid(t *testing.T) {
+	t.Parallel()
+
+	path, cleanup := tempCatalog(t)
+	defer cleanup()
+
+	err := ioutil.WriteFile(path, []byte(`{"version": 1, "pools": [{"name": "default", "capacity": 1, "cacheCount": 1}]}`), 0644)
+	require.NoError(t, err)
+
+	_, err = New(Config{Default: defaultConfig, CatalogPath: path})
+	require.Error(t, err)
+
+	err = ioutil.WriteFile(path, []byte(`{"version": 2}`), 0644)
+	require.NoError(t, err)
+
+	_, err = New(Config{Default: defaultConfig, CatalogPath: path})
+	require.Error(t, err)
+}
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/caches"
+	"github.com/tescherm/mc/core/changes"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A primary publishes every mutation it applies to a feed, and replicas
+// follow it with Replicate: the stream starts with a snapshot of the
+// primary's pools, generations and items, taken after subscribing to the
+// feed, and then sends the mutations published since subscribing. Replaying
+// mutations the snapshot already reflects leaves the replica as the primary
+// is, since mutations to a key are published in the order they are applied.
+// Replicas that fall too far behind are dropped, and start over with a new
+// snapshot.
+
+const (
+	// heartbeatInterval is how often an idle stream tells the replica the
+	// primary's latest sequence, so that it can tell how far behind it is
+	heartbeatInterval = time.Second
+
+	// replicateBatch bounds the mutations sent in one message
+	replicateBatch = 500
+)
+
+// writable returns an error if the service is a replica, which only its
+// primary writes to.
+func (s *MemcachedService) writable() error {
+	if s.Replica {
+		return status.Errorf(codes.FailedPrecondition, "read-only replica; write to the primary")
+	}
+	return nil
+}
+
+// mutation returns a mutation of the namespace named by a request.
+func mutation(ctx context.Context, op memcached.Mutation_Op, pool, namespace string) *memcached.Mutation {
+	pool, namespace = requestNames(ctx, pool, namespace)
+	return &memcached.Mutation{
+		Op:        op,
+		Pool:      pool,
+		Namespace: namespace,
+	}
+}
+
+// setMutation returns the mutation setting item, with its version.
+func setMutation(ctx context.Context, pool, namespace string, item *cache.Item) *memcached.Mutation {
+	m := mutation(ctx, memcached.Mutation_SET, pool, namespace)
+	m.Item = fromCacheItem(item)
+	return m
+}
+
+// generationMutation returns the mutation setting a namespace's generation.
+func generationMutation(ctx context.Context, pool, namespace string, generation uint64) *memcached.Mutation {
+	m := mutation(ctx, memcached.Mutation_SET_GENERATION, pool, namespace)
+	m.Generation = generation
+	return m
+}
+
+func (s *MemcachedService) Replicate(req *memcached.ReplicateRequest, stream memcached.Memcached_ReplicateServer) error {
+	logger := s.Logger.WithField("replica", req.Replica)
+	logger.Info("Replicate")
+
+	if s.Feed == nil {
+		return status.Errorf(codes.FailedPrecondition, "not a primary")
+	}
+
+	sub := s.Feed.Subscribe()
+	defer sub.Close()
+
+	if err := s.sendSnapshot(stream, sub.Start); err != nil {
+		return err
+	}
+	logger.WithField("sequence", sub.Start).Info("sent snapshot")
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	sequence := sub.Start
+	for {
+		select {
+		case change, ok := <-sub.Changes():
+			if !ok {
+				return status.Errorf(codes.ResourceExhausted, "%s", sub.Err())
+			}
+
+			res := &memcached.ReplicateResponse{}
+			add := func(change changes.Change) {
+				res.Mutations = append(res.Mutations, change.Mutation)
+				res.Sequence = change.Sequence
+				res.Timestamp = change.Time.UnixNano()
+			}
+
+			// send what else is waiting along with it
+			add(change)
+		drain:
+			for len(res.Mutations) < replicateBatch {
+				select {
+				case change, ok := <-sub.Changes():
+					if !ok {
+						break drain
+					}
+					add(change)
+				default:
+					break drain
+				}
+			}
+
+			res.Latest = s.Feed.Sequence()
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+			sequence = res.Sequence
+
+		case <-heartbeat.C:
+			res := &memcached.ReplicateResponse{
+				Sequence:  sequence,
+				Latest:    s.Feed.Sequence(),
+				Timestamp: time.Now().UnixNano(),
+			}
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// sendSnapshot sends the pools, their namespaces' generations and their
+// items as of sequence.
+func (s *MemcachedService) sendSnapshot(stream memcached.Memcached_ReplicateServer, sequence uint64) error {
+	res := &memcached.ReplicateResponse{
+		Sequence: sequence,
+		Snapshot: true,
+	}
+	send := func() error {
+		res.Latest = s.Feed.Sequence()
+		res.Timestamp = time.Now().UnixNano()
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+		res.Mutations = nil
+		return nil
+	}
+
+	pools := s.Pools.List()
+	for _, pool := range pools {
+		res.Mutations = append(res.Mutations, &memcached.Mutation{
+			Op:         memcached.Mutation_CREATE_POOL,
+			Pool:       pool.Config.Name,
+			PoolConfig: fromPoolConfig(pool.Config),
+		})
+		for _, name := range pool.Caches.NamespaceNames() {
+			ns, ok := pool.Caches.Namespace(name)
+			if !ok {
+				continue
+			}
+			res.Mutations = append(res.Mutations, &memcached.Mutation{
+				Op:         memcached.Mutation_SET_GENERATION,
+				Pool:       pool.Config.Name,
+				Namespace:  name,
+				Generation: ns.Generation(),
+			})
+		}
+	}
+	if err := send(); err != nil {
+		return err
+	}
+
+	for _, pool := range pools {
+		for _, name := range pool.Caches.NamespaceNames() {
+			ns, ok := pool.Caches.Namespace(name)
+			if !ok {
+				continue
+			}
+			if err := s.sendNamespace(ns, pool.Config.Name, &res.Mutations, send); err != nil {
+				return err
+			}
+		}
+	}
+
+	res.SnapshotDone = true
+	return send()
+}
+
+// sendNamespace adds the namespace's items to mutations, sending them in
+// batches. The items are gathered first so that sending does not hold up
+// writes to the caches; they share their values with the caches.
+func (s *MemcachedService) sendNamespace(ns *caches.Namespace, pool string, mutations *[]*memcached.Mutation, send func() error) error {
+	var items []*cache.Item
+	err := ns.Scan(func(item *cache.Item) {
+		if !item.Deleted {
+			items = append(items, item)
+		}
+	})
+	if err == caches.ErrMigrating {
+		return status.Errorf(codes.Unavailable, "pool %s is resharding", pool)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		*mutations = append(*mutations, &memcached.Mutation{
+			Op:        memcached.Mutation_SET,
+			Pool:      pool,
+			Namespace: ns.Name(),
+			Item:      fromCacheItem(item),
+		})
+		if len(*mutations) == replicateBatch {
+			if err := send(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
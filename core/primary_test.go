@@ -0,0 +1,228 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/changes"
+	"github.com/tescherm/mc/core/jobs"
+	"github.com/tescherm/mc/core/pools"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newStandalone returns an unclustered service.
+func newStandalone(t *testing.T, feed *changes.Feed, replica bool) *MemcachedService {
+	p, err := pools.New(pools.Config{
+		Default: pools.PoolConfig{
+			Capacity:   1 << 20,
+			CacheCount: 4,
+			Replicas:   50,
+		},
+	})
+	require.NoError(t, err)
+
+	return New(Config{
+		Pools:   p,
+		Jobs:    jobs.NewManager(jobs.Config{}),
+		Feed:    feed,
+		Replica: replica,
+		Logger:  testLogger,
+	})
+}
+
+// waitFor polls until cond holds.
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		require.True(t, time.Now().Before(deadline), "timed out")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReplication(t *testing.T) {
+	t.Parallel()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	primary := newStandalone(t, changes.New(changes.Config{}), false)
+	server := grpc.NewServer()
+	memcached.RegisterMemcachedServer(server, primary)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := memcached.NewMemcachedClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// set returns the casID of the item it sets
+	set := func(key string) int64 {
+		_, err := client.Set(ctx, &memcached.SetRequest{
+			Item: &memcached.Item{Key: key, Value: []byte("value-" + key)},
+		})
+		require.NoError(t, err)
+		res, err := client.Get(ctx, &memcached.GetRequest{Key: key})
+		require.NoError(t, err)
+		return res.Item.CasID
+	}
+
+	// written before the replica follows, and sent in its snapshot
+	_, err = client.SetGeneration(ctx, &memcached.SetGenerationRequest{Generation: 7})
+	require.NoError(t, err)
+	before := make(map[string]int64)
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("before-%d", i)
+		before[key] = set(key)
+	}
+
+	replica := newStandalone(t, nil, true)
+	followCtx, stopFollowing := context.WithCancel(ctx)
+	followed := make(chan error, 1)
+	go func() {
+		followed <- replica.Follow(followCtx, client, "replica")
+	}()
+
+	get := func(key string) *memcached.Item {
+		res, err := replica.Get(ctx, &memcached.GetRequest{Key: key})
+		require.NoError(t, err)
+		return res.Item
+	}
+
+	waitFor(t, func() bool { return replica.ReplicaStats().Snapshots == 1 })
+	for key, casID := range before {
+		item := get(key)
+		require.NotNil(t, item, key)
+		require.Equal(t, []byte("value-"+key), item.Value)
+		require.Equal(t, casID, item.CasID, key)
+	}
+	generation, err := replica.GetGeneration(ctx, &memcached.GetGenerationRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), generation.Generation)
+
+	caughtUp := func() bool {
+		return replica.ReplicaStats().Sequence == primary.Feed.Sequence()
+	}
+
+	// live mutations follow the snapshot
+	after := set("after")
+	_, err = client.Remove(ctx, &memcached.RemoveRequest{Key: "before-0"})
+	require.NoError(t, err)
+	waitFor(t, caughtUp)
+	item := get("after")
+	require.NotNil(t, item)
+	require.Equal(t, after, item.CasID)
+	require.Nil(t, get("before-0"))
+	require.NotNil(t, get("before-1"))
+
+	_, err = client.ClearNamespace(ctx, &memcached.ClearNamespaceRequest{})
+	require.NoError(t, err)
+	waitFor(t, caughtUp)
+	require.Nil(t, get("before-1"))
+	require.Nil(t, get("after"))
+
+	set("bumped")
+	_, err = client.BumpGeneration(ctx, &memcached.BumpGenerationRequest{})
+	require.NoError(t, err)
+	waitFor(t, caughtUp)
+	require.Nil(t, get("bumped"))
+	generation, err = replica.GetGeneration(ctx, &memcached.GetGenerationRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), generation.Generation)
+
+	// replicas only take writes from their primary
+	_, err = replica.Set(ctx, &memcached.SetRequest{
+		Item: &memcached.Item{Key: "key", Value: []byte("value")},
+	})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	stats := replica.ReplicaStats()
+	require.True(t, stats.Connected)
+	require.Equal(t, stats.Latest, stats.Sequence)
+	require.Zero(t, stats.Errors)
+
+	stopFollowing()
+	require.NoError(t, <-followed)
+	require.False(t, replica.ReplicaStats().Connected)
+}
+
+func TestReplicationConcurrentClear(t *testing.T) {
+	t.Parallel()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	primary := newStandalone(t, changes.New(changes.Config{}), false)
+	server := grpc.NewServer()
+	memcached.RegisterMemcachedServer(server, primary)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := memcached.NewMemcachedClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	replica := newStandalone(t, nil, true)
+	followCtx, stopFollowing := context.WithCancel(ctx)
+	defer stopFollowing()
+	go replica.Follow(followCtx, client, "replica")
+	waitFor(t, func() bool { return replica.ReplicaStats().Snapshots == 1 })
+
+	caughtUp := func() bool {
+		return replica.ReplicaStats().Sequence == primary.Feed.Sequence()
+	}
+
+	// sets racing a clear are published on the side of the clear they were
+	// applied on
+	for round := 0; round < 20; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					_, err := primary.Set(ctx, &memcached.SetRequest{
+						Item: &memcached.Item{Key: fmt.Sprintf("key-%d-%d-%d", round, i, j), Value: []byte("value")},
+					})
+					require.NoError(t, err)
+				}
+			}(i)
+		}
+		_, err := primary.ClearNamespace(ctx, &memcached.ClearNamespaceRequest{})
+		require.NoError(t, err)
+		wg.Wait()
+
+		waitFor(t, caughtUp)
+		require.Equal(t, localKeys(t, primary), localKeys(t, replica), "round=%d", round)
+	}
+}
+
+// localKeys returns the keys in a service's default namespace.
+func localKeys(t *testing.T, s *MemcachedService) []string {
+	ns, err := s.namespace(context.Background(), "", "")
+	require.NoError(t, err)
+
+	var keys []string
+	require.NoError(t, ns.Scan(func(item *cache.Item) {
+		keys = append(keys, item.Key)
+	}))
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,338 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/cluster"
+	"github.com/tescherm/mc/core/hints"
+	"github.com/tescherm/mc/core/pools"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Replicated pools keep each key on the replication factor (N) peers
+// following its owner on the ring. Whichever node receives a request
+// coordinates it: writes are timestamped with the node's hybrid logical
+// clock and sent to every replica, succeeding once the write quorum (W)
+// acknowledge, and reads succeed once the read quorum (R) respond, returning
+// the latest write. Replicas keep the latest write they see, so conflicting
+// writes resolve to the same value everywhere, and reads repair replicas
+// that are behind. Removes are kept as deleted items, so that older writes
+// cannot resurrect a key.
+
+// replicated returns the configuration of the request's pool if this node
+// should coordinate the request as a quorum operation: the service is
+// clustered, the pool is replicated, and the request was not sent by
+// another coordinating node.
+func (s *MemcachedService) replicated(ctx context.Context, pool string) (pools.PoolConfig, bool) {
+	if s.Cluster == nil {
+		return pools.PoolConfig{}, false
+	}
+	if _, ok := cluster.Forwarded(ctx); ok {
+		return pools.PoolConfig{}, false
+	}
+
+	pool, _ = requestNames(ctx, pool, "")
+	p, ok := s.Pools.Get(pool)
+	if !ok || !p.Config.Replicated() {
+		return pools.PoolConfig{}, false
+	}
+	return p.Config, true
+}
+
+// quorums returns the read and write quorums for a request, which override
+// the pool's when positive.
+func quorums(config pools.PoolConfig, read, write int32) (int, int, error) {
+	r, w := config.Quorums()
+	if read > 0 {
+		r = int(read)
+	}
+	if write > 0 {
+		w = int(write)
+	}
+
+	if r > config.ReplicationFactor || w > config.ReplicationFactor {
+		return 0, 0, status.Errorf(codes.InvalidArgument, "quorums must not exceed the replication factor %d", config.ReplicationFactor)
+	}
+	return r, w, nil
+}
+
+// merge merges a replicated write into this node's replica of its key,
+// returning the item it replaces.
+func (s *MemcachedService) merge(ctx context.Context, pool, namespace string, item *memcached.Item) (*memcached.Item, error) {
+	c, err := s.pick(ctx, pool, namespace, item.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Cluster.Clock().Observe(cluster.Timestamp(item.Timestamp))
+
+	var previous *cache.Item
+	s.Feed.Apply(item.Key, func() *memcached.Mutation {
+		previous = c.Fetch(item.Key)
+		merged := toCacheItem(item)
+		if !c.Merge(merged) {
+			return nil
+		}
+		if merged.Deleted {
+			m := mutation(ctx, memcached.Mutation_REMOVE, pool, namespace)
+			m.Item = &memcached.Item{Key: item.Key}
+			return m
+		}
+		return setMutation(ctx, pool, namespace, merged)
+	})
+	return fromCacheItem(previous), nil
+}
+
+// readQuorum reads key from its replicas, returning the latest write, which
+// may be a remove. For a positive beta, recompute is set if any replica read
+// says to recompute the item early.
+func (s *MemcachedService) readQuorum(ctx context.Context, config pools.PoolConfig, pool, namespace, key string, r int, beta float64) (item *memcached.Item, recompute bool, err error) {
+	req := &memcached.GetRequest{
+		Key:       key,
+		Pool:      pool,
+		Namespace: namespace,
+		Beta:      beta,
+	}
+	var recomputes int32
+
+	// tracked reads are tracked by each replica
+	tracked := ctx
+	id := trackingID(ctx)
+
+	read := func(ctx context.Context, address string, peer memcached.MemcachedClient) (*memcached.Item, error) {
+		if peer == nil {
+			c, err := s.pick(ctx, pool, namespace, key)
+			if err != nil {
+				return nil, err
+			}
+			s.trackRead(tracked, pool, namespace, key)
+			item := c.Fetch(key)
+			if item != nil && !item.Deleted && item.Recompute(time.Now(), beta) {
+				atomic.StoreInt32(&recomputes, 1)
+			}
+			return fromCacheItem(item), nil
+		}
+
+		res, err := peer.Get(withTracking(ctx, id), req)
+		if err != nil {
+			return nil, err
+		}
+		if res.Recompute {
+			atomic.StoreInt32(&recomputes, 1)
+		}
+		return res.Item, nil
+	}
+
+	repair := func(ctx context.Context, peer memcached.MemcachedClient, item *memcached.Item) error {
+		if peer == nil {
+			_, err := s.merge(ctx, pool, namespace, item)
+			return err
+		}
+
+		_, err := peer.Set(ctx, &memcached.SetRequest{
+			Item:      item,
+			Pool:      pool,
+			Namespace: namespace,
+		})
+		return err
+	}
+
+	item, err = s.Cluster.Read(ctx, key, config.ReplicationFactor, r, read, repair)
+	return item, atomic.LoadInt32(&recomputes) != 0, err
+}
+
+// writeQuorum writes a timestamped item, or a remove, to its key's replicas.
+// It returns the latest item the write replaced. Writes that cannot reach a
+// replica are kept as hints, if the service keeps them.
+func (s *MemcachedService) writeQuorum(ctx context.Context, config pools.PoolConfig, pool, namespace string, item *memcached.Item, w int) (*memcached.Item, error) {
+	write := func(ctx context.Context, address string, peer memcached.MemcachedClient) (*memcached.Item, error) {
+		if peer == nil {
+			return s.merge(ctx, pool, namespace, item)
+		}
+
+		previous, err := replicaWrite(ctx, peer, pool, namespace, item)
+		if err != nil && s.Hints != nil && unreachable(err) {
+			s.Hints.Add(address, hints.Hint{
+				Pool:      pool,
+				Namespace: namespace,
+				Item:      item,
+			})
+		}
+		return previous, err
+	}
+
+	return s.Cluster.Write(ctx, item.Key, config.ReplicationFactor, w, write)
+}
+
+// replicaWrite merges a timestamped item, or a remove, into a peer's replica
+// of its key. It returns the item a remove replaced.
+func replicaWrite(ctx context.Context, peer memcached.MemcachedClient, pool, namespace string, item *memcached.Item) (*memcached.Item, error) {
+	if item.Deleted {
+		res, err := peer.Remove(ctx, &memcached.RemoveRequest{
+			Key:       item.Key,
+			Pool:      pool,
+			Namespace: namespace,
+			Timestamp: item.Timestamp,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return res.Item, nil
+	}
+
+	_, err := peer.Set(ctx, &memcached.SetRequest{
+		Item:      item,
+		Pool:      pool,
+		Namespace: namespace,
+	})
+	return nil, err
+}
+
+// unreachable reports whether a request to a peer failed because the peer
+// could not be reached, rather than rejected it.
+func unreachable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// ReplayHints sends the peer at address the writes held for it as hints,
+// returning the number sent.
+func (s *MemcachedService) ReplayHints(ctx context.Context, address string) (uint64, error) {
+	if s.Hints == nil || s.Cluster == nil {
+		return 0, nil
+	}
+	conn := s.Cluster.Conn(address)
+	if conn == nil {
+		return 0, nil
+	}
+	peer := memcached.NewMemcachedClient(conn)
+
+	return s.Hints.Replay(ctx, address, func(ctx context.Context, hint hints.Hint) error {
+		_, err := replicaWrite(ctx, peer, hint.Pool, hint.Namespace, hint.Item)
+		return err
+	})
+}
+
+// timestamped returns a copy of item for a new replicated write. Its CAS
+// version is its timestamp, so that every replica agrees on it.
+func (s *MemcachedService) timestamped(item *memcached.Item) *memcached.Item {
+	ts := uint64(s.Cluster.Clock().Now())
+
+	return &memcached.Item{
+		Key:       item.Key,
+		Value:     item.Value,
+		Tags:      item.Tags,
+		CasID:     int64(ts),
+		Timestamp: ts,
+		Deleted:   item.Deleted,
+		Delta:     item.Delta,
+	}
+}
+
+func (s *MemcachedService) replicatedGet(ctx context.Context, config pools.PoolConfig, req *memcached.GetRequest) (*memcached.GetResponse, error) {
+	r, _, err := quorums(config, req.ReadQuorum, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, namespace := requestNames(ctx, req.Pool, req.Namespace)
+	item, recompute, err := s.readQuorum(ctx, config, pool, namespace, req.Key, r, req.Beta)
+	if err != nil {
+		return nil, err
+	}
+	if item != nil && item.Deleted {
+		item = nil
+	}
+
+	res := &memcached.GetResponse{
+		Item:      item,
+		Recompute: item != nil && recompute,
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) replicatedSet(ctx context.Context, config pools.PoolConfig, req *memcached.SetRequest) (*memcached.SetResponse, error) {
+	_, w, err := quorums(config, 0, req.WriteQuorum)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, namespace := requestNames(ctx, req.Pool, req.Namespace)
+	item := s.timestamped(req.Item)
+	if _, err := s.writeQuorum(ctx, config, pool, namespace, item, w); err != nil {
+		return nil, err
+	}
+
+	res := &memcached.SetResponse{
+		Item: &memcached.Item{
+			Key:   item.Key,
+			Value: item.Value,
+			CasID: item.CasID,
+		},
+	}
+	return res, nil
+}
+
+// replicatedCompareAndSwap reads the key's latest version from a read
+// quorum, and writes the item if it matches. Unlike an unreplicated swap it
+// is not atomic: a concurrent write to another replica may win instead.
+func (s *MemcachedService) replicatedCompareAndSwap(ctx context.Context, config pools.PoolConfig, req *memcached.CompareAndSwapRequest) (*memcached.CompareAndSwapResponse, error) {
+	r, w, err := quorums(config, req.ReadQuorum, req.WriteQuorum)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, namespace := requestNames(ctx, req.Pool, req.Namespace)
+	current, _, err := s.readQuorum(ctx, config, pool, namespace, req.Item.Key, r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && !current.Deleted && current.CasID != req.Item.CasID {
+		return nil, status.Errorf(codes.Aborted, "compare-and-swap conflict")
+	}
+
+	item := s.timestamped(req.Item)
+	if _, err := s.writeQuorum(ctx, config, pool, namespace, item, w); err != nil {
+		return nil, err
+	}
+
+	res := &memcached.CompareAndSwapResponse{
+		Item: &memcached.Item{
+			Key:   item.Key,
+			Value: item.Value,
+			CasID: item.CasID,
+		},
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) replicatedRemove(ctx context.Context, config pools.PoolConfig, req *memcached.RemoveRequest) (*memcached.RemoveResponse, error) {
+	_, w, err := quorums(config, 0, req.WriteQuorum)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, namespace := requestNames(ctx, req.Pool, req.Namespace)
+	item := s.timestamped(&memcached.Item{Key: req.Key, Deleted: true})
+	previous, err := s.writeQuorum(ctx, config, pool, namespace, item, w)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.Deleted {
+		previous = nil
+	}
+
+	res := &memcached.RemoveResponse{
+		Item: previous,
+	}
+	return res, nil
+}
@@ -3,23 +3,141 @@ package core
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/tescherm/mc/core/cache"
 	"github.com/tescherm/mc/core/caches"
+	"github.com/tescherm/mc/core/changes"
+	"github.com/tescherm/mc/core/cluster"
+	"github.com/tescherm/mc/core/hints"
+	"github.com/tescherm/mc/core/hotcache"
+	"github.com/tescherm/mc/core/jobs"
+	"github.com/tescherm/mc/core/leases"
+	"github.com/tescherm/mc/core/membership"
+	"github.com/tescherm/mc/core/merkle"
+	"github.com/tescherm/mc/core/pools"
+	"github.com/tescherm/mc/core/tracking"
+	"github.com/tescherm/mc/core/watch"
 	"github.com/tescherm/mc/pb"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+const (
+	// NamespaceMetadataKey is the request metadata key that selects a
+	// namespace when the request itself does not name one.
+	NamespaceMetadataKey = "mc-namespace"
+
+	// PoolMetadataKey is the request metadata key that selects a pool when
+	// the request itself does not name one.
+	PoolMetadataKey = "mc-pool"
+)
+
+const (
+	// peerJobPollInterval is how often a job waiting on jobs it started on
+	// other nodes checks on them
+	peerJobPollInterval = 100 * time.Millisecond
+
+	// peerJobCancelTimeout bounds the requests cancelling jobs on other
+	// nodes
+	peerJobCancelTimeout = 5 * time.Second
+)
+
 type MemcachedService struct {
-	Caches *caches.Caches
+	Pools *pools.Pools
+	Jobs  *jobs.Manager
+
+	// Cluster is nil unless the service is one node of a cluster
+	Cluster *cluster.Cluster
+
+	// Membership is nil unless cluster members are discovered by gossip
+	Membership *membership.Membership
+
+	// Trees is nil unless replicated pools are compared by anti-entropy;
+	// the pools must publish their events to it
+	Trees *merkle.Index
+
+	// Hints is nil unless writes that cannot reach a replica are kept
+	Hints *hints.Store
+
+	// Hot is nil unless copies of hot keys owned by other nodes are kept
+	Hot *hotcache.HotCache
+
+	// Feed is nil unless replicas may follow the service
+	Feed *changes.Feed
+
+	// Replica is true if the service is a replica, which rejects writes
+	// other than its primary's
+	Replica bool
+
+	// Watches is nil unless clients may watch keys for changes; the pools
+	// must publish their events to it
+	Watches *watch.Hub
+
+	// Tracking is nil unless clients may cache the keys they read; the
+	// pools must publish their events to it
+	Tracking *tracking.Table
+
+	// Leases is nil unless clients may take leases on the keys they miss
+	Leases *leases.Table
+
 	Logger logrus.FieldLogger
+
+	antiEntropy *antiEntropy
+	follower    follower
+
+	// refreshes grants the leases to refresh stale keys when Leases is nil
+	refreshes *leases.Table
 }
 
 type Config struct {
-	Caches *caches.Caches
+	Pools *pools.Pools
+	Jobs  *jobs.Manager
+
+	// Cluster, if set, forwards key requests to the peer owning the key
+	Cluster *cluster.Cluster
+
+	// Membership, if set, is reported by ListMembers
+	Membership *membership.Membership
+
+	// Trees, if set, keeps the Merkle trees anti-entropy compares replicated
+	// pools with
+	Trees *merkle.Index
+
+	// AntiEntropyRate bounds the keys per second anti-entropy repairs; zero
+	// means unlimited
+	AntiEntropyRate int
+
+	// Hints, if set, keeps replicated writes that could not reach a replica
+	// until they can be replayed to it
+	Hints *hints.Store
+
+	// Hot, if set, keeps copies of the keys owned by other nodes that are
+	// read most often
+	Hot *hotcache.HotCache
+
+	// Feed, if set, publishes the service's mutations to its replicas
+	Feed *changes.Feed
+
+	// Replica, if set, makes the service a read-only replica, written only
+	// by following a primary
+	Replica bool
+
+	// Watches, if set, serves Watch requests with the pools' events
+	Watches *watch.Hub
+
+	// Tracking, if set, tracks the keys clients read, and serves Track
+	// requests with the pools' events
+	Tracking *tracking.Table
+
+	// Leases, if set, grants leases on missed keys to the clients asking
+	Leases *leases.Table
+
 	Logger logrus.FieldLogger
 }
 
@@ -27,26 +145,103 @@ func New(config Config) *MemcachedService {
 	logger := config.Logger.WithField("module", "service")
 
 	return &MemcachedService{
-		Caches: config.Caches,
-		Logger: logger,
+		Pools:      config.Pools,
+		Jobs:       config.Jobs,
+		Cluster:    config.Cluster,
+		Membership: config.Membership,
+		Trees:      config.Trees,
+		Hints:      config.Hints,
+		Hot:        config.Hot,
+		Feed:       config.Feed,
+		Replica:    config.Replica,
+		Watches:    config.Watches,
+		Tracking:   config.Tracking,
+		Leases:     config.Leases,
+		Logger:     logger,
+
+		antiEntropy: newAntiEntropy(config.AntiEntropyRate),
+		refreshes:   leases.New(leases.Config{}),
 	}
 }
 
 func (s *MemcachedService) Get(ctx context.Context, req *memcached.GetRequest) (*memcached.GetResponse, error) {
 	key := req.Key
 
-	s.Logger.WithField("key", key).Info("Get")
+	s.Logger.WithFields(logrus.Fields{
+		"key":       key,
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Info("Get")
+
+	if req.Lease {
+		if s.Leases == nil {
+			return nil, status.Errorf(codes.Unimplemented, "leases are disabled")
+		}
+		if err := s.leasable(ctx, req.Pool); err != nil {
+			return nil, err
+		}
+	}
+	if config, ok := s.replicated(ctx, req.Pool); ok {
+		return s.replicatedGet(ctx, config, req)
+	}
+	// leases, tracked reads and early recomputes are the owner's, not
+	// the hot cache's
+	if id := trackingID(ctx); id != "" || req.Lease || req.Beta > 0 {
+		if peer := s.route(ctx, key); peer != nil {
+			req.Pool, req.Namespace = requestNames(ctx, req.Pool, req.Namespace)
+			return peer.Get(withTracking(ctx, id), req)
+		}
+	} else if peer, spilled := s.routeRead(ctx, req.Pool, key); peer != nil {
+		req.Pool, req.Namespace = requestNames(ctx, req.Pool, req.Namespace)
+		// the peer keeps the copy, which the owner tracks
+		if spilled {
+			return peer.Get(metadata.AppendToOutgoingContext(ctx, cluster.SpilledMetadataKey, "1"), req)
+		}
+		if s.Hot != nil {
+			return s.hotGet(ctx, peer, req)
+		}
+		return peer.Get(ctx, req)
+	}
 
-	c, err := s.pick(key)
+	c, err := s.pick(ctx, req.Pool, req.Namespace, key)
 	if err != nil {
 		return nil, err
 	}
 
-	item := c.Get(key)
+	// tracked before the read, so that a write racing it invalidates the copy
+	s.trackCopy(ctx, req.Pool, req.Namespace, key)
+	s.trackRead(ctx, req.Pool, req.Namespace, key)
+
+	// coordinating nodes see removes, to order them against other writes
+	var item *cache.Item
+	if s.forwarded(ctx) {
+		item = c.Fetch(key)
+	} else {
+		item = c.Get(key)
+	}
+
+	if item == nil {
+		if item, err = s.load(ctx, req.Pool, req.Namespace, key); err != nil {
+			return nil, err
+		}
+	}
 
 	res := &memcached.GetResponse{
 		Item: fromCacheItem(item),
 	}
+	if item == nil || item.Deleted {
+		if req.Lease {
+			s.leaseMiss(ctx, req, res)
+		}
+		return res, nil
+	}
+
+	now := time.Now()
+	if item.Stale(now) {
+		res.Stale = true
+		s.leaseRefresh(ctx, req, res)
+	}
+	res.Recompute = item.Recompute(now, req.Beta)
 	return res, nil
 }
 
@@ -54,15 +249,56 @@ func (s *MemcachedService) Set(ctx context.Context, req *memcached.SetRequest) (
 	key := req.Item.Key
 	value := req.Item.Value
 
-	s.Logger.WithField("key", key).Info("Set")
+	s.Logger.WithFields(logrus.Fields{
+		"key":       key,
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Info("Set")
+
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	if req.Lease != 0 {
+		if err := s.leasable(ctx, req.Pool); err != nil {
+			return nil, err
+		}
+	}
+	if config, ok := s.replicated(ctx, req.Pool); ok {
+		return s.replicatedSet(ctx, config, req)
+	}
+	if peer := s.route(ctx, key); peer != nil {
+		req.Pool, req.Namespace = requestNames(ctx, req.Pool, req.Namespace)
+		s.invalidateOwnCopy(ctx, req.Pool, req.Namespace, key)
+		return peer.Set(ctx, req)
+	}
+
+	if req.Item.Timestamp != 0 && s.forwarded(ctx) {
+		if _, err := s.merge(ctx, req.Pool, req.Namespace, req.Item); err != nil {
+			return nil, err
+		}
+		return &memcached.SetResponse{Item: req.Item}, nil
+	}
 
-	c, err := s.pick(key)
+	c, err := s.pick(ctx, req.Pool, req.Namespace, key)
 	if err != nil {
 		return nil, err
 	}
 
 	item := toCacheItem(req.Item)
-	c.Set(item)
+	set := func() {
+		s.Feed.Apply(key, func() *memcached.Mutation {
+			c.Set(item)
+			return setMutation(ctx, req.Pool, req.Namespace, item)
+		})
+	}
+	if req.Lease != 0 {
+		if err := s.fill(ctx, req.Pool, req.Namespace, key, req.Lease, set); err != nil {
+			return nil, err
+		}
+	} else if err := s.leaseSet(ctx, req.Pool, req.Namespace, key, set); err != nil {
+		return nil, err
+	}
+	s.invalidateCopies(ctx, req.Pool, req.Namespace, key)
 
 	res := &memcached.SetResponse{
 		Item: &memcached.Item{
@@ -77,18 +313,46 @@ func (s *MemcachedService) CompareAndSwap(ctx context.Context, req *memcached.Co
 	key := req.Item.Key
 	value := req.Item.Value
 
-	s.Logger.WithField("key", key).Info("Set")
+	s.Logger.WithFields(logrus.Fields{
+		"key":       key,
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Info("Set")
+
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	if config, ok := s.replicated(ctx, req.Pool); ok {
+		return s.replicatedCompareAndSwap(ctx, config, req)
+	}
+	if peer := s.route(ctx, key); peer != nil {
+		req.Pool, req.Namespace = requestNames(ctx, req.Pool, req.Namespace)
+		s.invalidateOwnCopy(ctx, req.Pool, req.Namespace, key)
+		return peer.CompareAndSwap(ctx, req)
+	}
 
-	c, err := s.pick(key)
+	c, err := s.pick(ctx, req.Pool, req.Namespace, key)
 	if err != nil {
 		return nil, err
 	}
 
 	item := toCacheItem(req.Item)
-	set := c.CompareAndSwap(item)
+	var set bool
+	err = s.leaseSet(ctx, req.Pool, req.Namespace, key, func() {
+		s.Feed.Apply(key, func() *memcached.Mutation {
+			if set = c.CompareAndSwap(item); !set {
+				return nil
+			}
+			return setMutation(ctx, req.Pool, req.Namespace, item)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 	if !set {
 		return nil, status.Errorf(codes.Aborted, "compare-and-swap conflict")
 	}
+	s.invalidateCopies(ctx, req.Pool, req.Namespace, key)
 
 	res := &memcached.CompareAndSwapResponse{
 		Item: &memcached.Item{
@@ -102,39 +366,528 @@ func (s *MemcachedService) CompareAndSwap(ctx context.Context, req *memcached.Co
 func (s *MemcachedService) Remove(ctx context.Context, req *memcached.RemoveRequest) (*memcached.RemoveResponse, error) {
 	key := req.Key
 
-	s.Logger.WithField("key", key).Info("Remove")
+	s.Logger.WithFields(logrus.Fields{
+		"key":       key,
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Info("Remove")
 
-	c, err := s.pick(key)
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	if config, ok := s.replicated(ctx, req.Pool); ok {
+		return s.replicatedRemove(ctx, config, req)
+	}
+	if peer := s.route(ctx, key); peer != nil {
+		req.Pool, req.Namespace = requestNames(ctx, req.Pool, req.Namespace)
+		s.invalidateOwnCopy(ctx, req.Pool, req.Namespace, key)
+		return peer.Remove(ctx, req)
+	}
+
+	if req.Timestamp != 0 && s.forwarded(ctx) {
+		previous, err := s.merge(ctx, req.Pool, req.Namespace, &memcached.Item{
+			Key:       key,
+			CasID:     int64(req.Timestamp),
+			Timestamp: req.Timestamp,
+			Deleted:   true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &memcached.RemoveResponse{Item: previous}, nil
+	}
+
+	c, err := s.pick(ctx, req.Pool, req.Namespace, key)
 	if err != nil {
 		return nil, err
 	}
 
-	item := c.Remove(key)
+	var item *cache.Item
+	s.leaseRemove(ctx, req.Pool, req.Namespace, key, func() *cache.Item {
+		s.Feed.Apply(key, func() *memcached.Mutation {
+			if item = c.Remove(key); item == nil {
+				return nil
+			}
+			m := mutation(ctx, memcached.Mutation_REMOVE, req.Pool, req.Namespace)
+			m.Item = &memcached.Item{Key: key}
+			return m
+		})
+		return item
+	})
+	s.invalidateCopies(ctx, req.Pool, req.Namespace, key)
+
 	res := &memcached.RemoveResponse{
 		Item: fromCacheItem(item),
 	}
 	return res, nil
 }
 
-func (s *MemcachedService) Clear(ctx context.Context, req *memcached.ClearRequest) (*memcached.ClearResponse, error) {
-	s.Logger.Info("Clear")
+func (s *MemcachedService) ClearNamespace(ctx context.Context, req *memcached.ClearNamespaceRequest) (*memcached.ClearNamespaceResponse, error) {
+	s.Logger.WithFields(logrus.Fields{
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Info("ClearNamespace")
+
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	ns, err := s.namespace(ctx, req.Pool, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Feed.ApplyAll(func() *memcached.Mutation {
+		ns.Clear()
+		return mutation(ctx, memcached.Mutation_CLEAR_NAMESPACE, req.Pool, req.Namespace)
+	})
+	s.invalidateNamespaceCopies(ctx, req.Pool, req.Namespace)
+	s.clearLeases(ctx, req.Pool, req.Namespace)
+
+	req.Pool, req.Namespace = requestNames(ctx, req.Pool, req.Namespace)
+	err = s.others(ctx, func(peer memcached.MemcachedClient) error {
+		_, err := peer.ClearNamespace(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &memcached.ClearNamespaceResponse{}, nil
+}
+
+func (s *MemcachedService) InvalidateTags(ctx context.Context, req *memcached.InvalidateTagsRequest) (*memcached.InvalidateTagsResponse, error) {
+	s.Logger.WithFields(logrus.Fields{
+		"tags":      req.Tags,
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Info("InvalidateTags")
+
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	ns, err := s.namespace(ctx, req.Pool, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var count uint64
+	s.Feed.ApplyAll(func() *memcached.Mutation {
+		count = ns.InvalidateTags(req.Tags)
+		m := mutation(ctx, memcached.Mutation_INVALIDATE_TAGS, req.Pool, req.Namespace)
+		m.Tags = req.Tags
+		return m
+	})
+	if s.Hot != nil {
+		s.Hot.InvalidateTags(req.Tags)
+	}
+	s.clearLeases(ctx, req.Pool, req.Namespace)
+
+	req.Pool, req.Namespace = requestNames(ctx, req.Pool, req.Namespace)
+	err = s.others(ctx, func(peer memcached.MemcachedClient) error {
+		res, err := peer.InvalidateTags(ctx, req)
+		if err != nil {
+			return err
+		}
+		atomic.AddUint64(&count, res.Count)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &memcached.InvalidateTagsResponse{
+		Count: count,
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) GetGeneration(ctx context.Context, req *memcached.GetGenerationRequest) (*memcached.GetGenerationResponse, error) {
+	s.Logger.WithFields(logrus.Fields{
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Info("GetGeneration")
+
+	ns, err := s.namespace(ctx, req.Pool, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &memcached.GetGenerationResponse{
+		Generation: ns.Generation(),
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) SetGeneration(ctx context.Context, req *memcached.SetGenerationRequest) (*memcached.SetGenerationResponse, error) {
+	s.Logger.WithFields(logrus.Fields{
+		"namespace":  req.Namespace,
+		"pool":       req.Pool,
+		"generation": req.Generation,
+	}).Info("SetGeneration")
+
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	ns, err := s.namespace(ctx, req.Pool, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Feed.ApplyAll(func() *memcached.Mutation {
+		ns.SetGeneration(req.Generation)
+		return generationMutation(ctx, req.Pool, req.Namespace, req.Generation)
+	})
+	s.invalidateNamespaceCopies(ctx, req.Pool, req.Namespace)
+	s.clearLeases(ctx, req.Pool, req.Namespace)
+	if err := s.setPeerGenerations(ctx, req.Pool, req.Namespace, req.Generation); err != nil {
+		return nil, err
+	}
+
+	res := &memcached.SetGenerationResponse{
+		Generation: req.Generation,
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) BumpGeneration(ctx context.Context, req *memcached.BumpGenerationRequest) (*memcached.BumpGenerationResponse, error) {
+	s.Logger.WithFields(logrus.Fields{
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Info("BumpGeneration")
 
-	s.Caches.Clear()
-	return &memcached.ClearResponse{}, nil
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	ns, err := s.namespace(ctx, req.Pool, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var generation uint64
+	s.Feed.ApplyAll(func() *memcached.Mutation {
+		generation = ns.BumpGeneration()
+		return generationMutation(ctx, req.Pool, req.Namespace, generation)
+	})
+	s.invalidateNamespaceCopies(ctx, req.Pool, req.Namespace)
+	s.clearLeases(ctx, req.Pool, req.Namespace)
+	if err := s.setPeerGenerations(ctx, req.Pool, req.Namespace, generation); err != nil {
+		return nil, err
+	}
+
+	res := &memcached.BumpGenerationResponse{
+		Generation: generation,
+	}
+	return res, nil
 }
 
 func (s *MemcachedService) Size(ctx context.Context, req *memcached.SizeRequest) (*memcached.SizeResponse, error) {
-	s.Logger.Info("Size")
+	s.Logger.WithFields(logrus.Fields{
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Info("Size")
+
+	ns, err := s.namespace(ctx, req.Pool, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
 
-	size := s.Caches.Size()
+	size := ns.Size()
 	res := &memcached.SizeResponse{
 		Size: size,
 	}
 	return res, nil
 }
 
-func (s *MemcachedService) pick(key string) (cache.Cache, error) {
-	c := s.Caches.CacheForKey(key)
+func (s *MemcachedService) DeleteMatching(ctx context.Context, req *memcached.DeleteMatchingRequest) (*memcached.DeleteMatchingResponse, error) {
+	s.Logger.WithFields(logrus.Fields{
+		"prefix":    req.Prefix,
+		"pattern":   req.Pattern,
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Info("DeleteMatching")
+
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	ns, err := s.namespace(ctx, req.Pool, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var match caches.Matcher
+	switch {
+	case req.Prefix != "" && req.Pattern != "":
+		return nil, status.Errorf(codes.InvalidArgument, "only one of prefix or pattern may be set")
+	case req.Prefix != "":
+		match = caches.MatchPrefix(req.Prefix)
+	case req.Pattern != "":
+		m, err := caches.MatchGlob(req.Pattern)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+		}
+		match = m
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "one of prefix or pattern must be set")
+	}
+
+	s.invalidateNamespaceCopies(ctx, req.Pool, req.Namespace)
+	m := mutation(ctx, memcached.Mutation_DELETE_MATCHING, req.Pool, req.Namespace)
+	m.Prefix = req.Prefix
+	m.Pattern = req.Pattern
+
+	// every other node removes its own matching keys, in a job the local
+	// job waits on
+	var mu sync.Mutex
+	var peerJobs []peerJob
+	req.Pool, req.Namespace = requestNames(ctx, req.Pool, req.Namespace)
+	err = s.others(ctx, func(peer memcached.MemcachedClient) error {
+		res, err := peer.DeleteMatching(ctx, req)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		peerJobs = append(peerJobs, peerJob{peer: peer, id: res.JobID})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		cancelPeerJobs(peerJobs)
+		return nil, err
+	}
+
+	job := s.Jobs.Start("delete-matching", func(ctx context.Context, job *jobs.Job) error {
+		// writes wait for the removal, so that replicas remove the keys this
+		// node does. It is published even if cancelled part way, since
+		// replicas removing more keys is harmless.
+		var local jobs.Status
+		var err error
+		var keys []string
+		s.Feed.ApplyAll(func() *memcached.Mutation {
+			_, err = ns.RemoveMatching(ctx, func(key string) bool {
+				if !match(key) {
+					return false
+				}
+				keys = append(keys, key)
+				return true
+			}, func(done, total int, removed uint64) {
+				local.Done, local.Total, local.Count = uint64(done), uint64(total), removed
+				job.Progress(done, total, removed)
+			})
+			return m
+		})
+		// the keys removed may only be set with a new lease
+		s.clearLeases(ctx, req.Pool, req.Namespace)
+		s.leaseRemoved(ctx, req.Pool, req.Namespace, keys)
+		if err != nil {
+			cancelPeerJobs(peerJobs)
+			return err
+		}
+		return waitPeerJobs(ctx, job, local, peerJobs)
+	})
+
+	res := &memcached.DeleteMatchingResponse{
+		JobID: job.Status().ID,
+	}
+	return res, nil
+}
+
+// peerJob is a job started on another node by a request broadcast to it.
+type peerJob struct {
+	peer memcached.MemcachedClient
+	id   string
+}
+
+// waitPeerJobs waits for jobs started on other nodes, reporting their
+// progress summed with local's as job's. A peer job that fails or is
+// cancelled fails job, and job being cancelled cancels the peer jobs.
+func waitPeerJobs(ctx context.Context, job *jobs.Job, local jobs.Status, peerJobs []peerJob) error {
+	for {
+		done, total, count := local.Done, local.Total, local.Count
+		running := false
+		for _, p := range peerJobs {
+			res, err := p.peer.GetJob(ctx, &memcached.GetJobRequest{JobID: p.id})
+			if err != nil {
+				if ctx.Err() != nil {
+					cancelPeerJobs(peerJobs)
+					return ctx.Err()
+				}
+				return errors.Wrapf(err, "unable to get peer job %s", p.id)
+			}
+
+			switch res.Job.State {
+			case memcached.Job_RUNNING:
+				running = true
+			case memcached.Job_FAILED:
+				return fmt.Errorf("peer job %s failed: %s", p.id, res.Job.Error)
+			case memcached.Job_CANCELLED:
+				return fmt.Errorf("peer job %s was cancelled", p.id)
+			}
+			done += res.Job.Done
+			total += res.Job.Total
+			count += res.Job.Count
+		}
+		job.Progress(int(done), int(total), count)
+
+		if !running {
+			return nil
+		}
+		select {
+		case <-time.After(peerJobPollInterval):
+		case <-ctx.Done():
+			cancelPeerJobs(peerJobs)
+			return ctx.Err()
+		}
+	}
+}
+
+// cancelPeerJobs cancels jobs started on other nodes, ignoring failures.
+func cancelPeerJobs(peerJobs []peerJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), peerJobCancelTimeout)
+	defer cancel()
+
+	for _, p := range peerJobs {
+		p.peer.CancelJob(ctx, &memcached.CancelJobRequest{JobID: p.id})
+	}
+}
+
+func (s *MemcachedService) GetJob(ctx context.Context, req *memcached.GetJobRequest) (*memcached.GetJobResponse, error) {
+	s.Logger.WithField("jobID", req.JobID).Info("GetJob")
+
+	job, ok := s.Jobs.Get(req.JobID)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", req.JobID)
+	}
+
+	res := &memcached.GetJobResponse{
+		Job: fromJobStatus(job.Status()),
+	}
+	return res, nil
+}
+
+func (s *MemcachedService) CancelJob(ctx context.Context, req *memcached.CancelJobRequest) (*memcached.CancelJobResponse, error) {
+	s.Logger.WithField("jobID", req.JobID).Info("CancelJob")
+
+	job, ok := s.Jobs.Get(req.JobID)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", req.JobID)
+	}
+	job.Cancel()
+
+	res := &memcached.CancelJobResponse{
+		Job: fromJobStatus(job.Status()),
+	}
+	return res, nil
+}
+
+// requestValue returns value if set, otherwise the request metadata value
+// for key, otherwise def.
+func requestValue(ctx context.Context, value, key, def string) string {
+	if value != "" {
+		return value
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(key); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return def
+}
+
+// requestNames resolves the pool and namespace named by a request, falling
+// back to the request metadata and then the defaults.
+func requestNames(ctx context.Context, pool, namespace string) (string, string) {
+	pool = requestValue(ctx, pool, PoolMetadataKey, pools.DefaultPool)
+	namespace = requestValue(ctx, namespace, NamespaceMetadataKey, caches.DefaultNamespace)
+	return pool, namespace
+}
+
+// namespace returns the namespace named by a request.
+func (s *MemcachedService) namespace(ctx context.Context, pool, namespace string) (*caches.Namespace, error) {
+	pool, namespace = requestNames(ctx, pool, namespace)
+
+	p, ok := s.Pools.Get(pool)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "pool %s not found", pool)
+	}
+
+	ns, ok := p.Caches.Namespace(namespace)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "namespace %s not found", namespace)
+	}
+	return ns, nil
+}
+
+// forwarded reports whether the request was sent by another node of the
+// cluster.
+func (s *MemcachedService) forwarded(ctx context.Context) bool {
+	if s.Cluster == nil {
+		return false
+	}
+	_, ok := cluster.Forwarded(ctx)
+	return ok
+}
+
+// route returns a client for the peer owning key, or nil if this node
+// should serve the request.
+func (s *MemcachedService) route(ctx context.Context, key string) memcached.MemcachedClient {
+	if s.Cluster == nil {
+		return nil
+	}
+	return s.Cluster.Route(ctx, key)
+}
+
+// routeRead returns a client for the peer to read key from, or nil if this
+// node should serve the read. Reads in pools with bounded loads spill from
+// owners over their load bound, and spilled reports it.
+func (s *MemcachedService) routeRead(ctx context.Context, pool, key string) (peer memcached.MemcachedClient, spilled bool) {
+	if s.Cluster == nil {
+		return nil, false
+	}
+
+	var boundedLoad float64
+	pool, _ = requestNames(ctx, pool, "")
+	if p, ok := s.Pools.Get(pool); ok {
+		boundedLoad = p.Config.BoundedLoad
+	}
+	return s.Cluster.RouteRead(ctx, key, boundedLoad)
+}
+
+// others calls fn for every other peer in the cluster, for requests that
+// apply to every node. Requests are forwarded with their pool and namespace
+// resolved, since metadata is not.
+func (s *MemcachedService) others(ctx context.Context, fn func(peer memcached.MemcachedClient) error) error {
+	if s.Cluster == nil {
+		return nil
+	}
+	return s.Cluster.Others(ctx, func(address string, peer memcached.MemcachedClient) error {
+		if err := fn(peer); err != nil {
+			s.Logger.WithError(err).WithField("peer", address).Warn("peer request failed")
+			return err
+		}
+		return nil
+	})
+}
+
+// setPeerGenerations sets a namespace's generation on every other peer, so
+// that bumping it on any node invalidates the namespace cluster wide.
+func (s *MemcachedService) setPeerGenerations(ctx context.Context, pool, namespace string, generation uint64) error {
+	pool, namespace = requestNames(ctx, pool, namespace)
+	return s.others(ctx, func(peer memcached.MemcachedClient) error {
+		_, err := peer.SetGeneration(ctx, &memcached.SetGenerationRequest{
+			Pool:       pool,
+			Namespace:  namespace,
+			Generation: generation,
+		})
+		return err
+	})
+}
+
+func (s *MemcachedService) pick(ctx context.Context, pool, namespace, key string) (cache.Cache, error) {
+	ns, err := s.namespace(ctx, pool, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	c := ns.CacheForKey(key)
 	if c == nil {
 		err := fmt.Errorf("unable to get cache for %s", key)
 		return nil, errors.WithStack(err)
@@ -146,7 +899,13 @@ func toCacheItem(item *memcached.Item) *cache.Item {
 	if item == nil {
 		return nil
 	}
-	return cache.NewItem(item.Key, item.Value, item.CasID)
+	i := cache.NewItem(item.Key, item.Value, item.CasID)
+	i.Tags = item.Tags
+	i.Timestamp = item.Timestamp
+	i.Deleted = item.Deleted
+	i.Delta = time.Duration(item.Delta)
+	i.SetExpiry(unixTime(item.ExpiresAt), unixTime(item.SoftExpiresAt))
+	return i
 }
 
 func fromCacheItem(item *cache.Item) *memcached.Item {
@@ -157,5 +916,59 @@ func fromCacheItem(item *cache.Item) *memcached.Item {
 		Key:   item.Key,
 		Value: item.Value,
 		CasID: item.VersionID(),
+		Tags:  item.Tags,
+
+		Timestamp: item.Timestamp,
+		Deleted:   item.Deleted,
+		Delta:     int64(item.Delta),
+
+		ExpiresAt:     unixNano(item.ExpiresAt()),
+		SoftExpiresAt: unixNano(item.SoftExpiresAt()),
+	}
+}
+
+// unixNano and unixTime convert between times and unix nanoseconds, with
+// the zero time as zero.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func unixTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+func fromJobStatus(st jobs.Status) *memcached.Job {
+	job := &memcached.Job{
+		JobID:     st.ID,
+		Kind:      st.Kind,
+		Done:      st.Done,
+		Total:     st.Total,
+		Count:     st.Count,
+		StartedAt: st.StartedAt.UnixNano(),
+	}
+
+	switch st.State {
+	case jobs.Done:
+		job.State = memcached.Job_DONE
+	case jobs.Cancelled:
+		job.State = memcached.Job_CANCELLED
+	case jobs.Failed:
+		job.State = memcached.Job_FAILED
+	default:
+		job.State = memcached.Job_RUNNING
+	}
+
+	if st.Err != nil {
+		job.Error = st.Err.Error()
+	}
+	if !st.FinishedAt.IsZero() {
+		job.FinishedAt = st.FinishedAt.UnixNano()
 	}
+	return job
 }
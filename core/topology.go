@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+
+	"github.com/tescherm/mc/core/cluster"
+	"github.com/tescherm/mc/core/consistenthash"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *MemcachedService) GetTopology(ctx context.Context, req *memcached.GetTopologyRequest) (*memcached.GetTopologyResponse, error) {
+	s.Logger.Info("GetTopology")
+
+	if s.Cluster == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "not clustered")
+	}
+
+	topology, _ := s.Cluster.Topology()
+	res := &memcached.GetTopologyResponse{
+		Topology: fromTopology(topology),
+	}
+	return res, nil
+}
+
+// WatchTopology sends the topology, unless the watcher already has it, and
+// then every change to it until the watcher goes away. Changes made in quick
+// succession may be sent as one.
+func (s *MemcachedService) WatchTopology(req *memcached.WatchTopologyRequest, stream memcached.Memcached_WatchTopologyServer) error {
+	s.Logger.WithField("epoch", req.Epoch).Info("WatchTopology")
+
+	if s.Cluster == nil {
+		return status.Errorf(codes.FailedPrecondition, "not clustered")
+	}
+
+	epoch := req.Epoch
+	for {
+		topology, changed := s.Cluster.Topology()
+		if topology.Epoch != epoch {
+			res := &memcached.WatchTopologyResponse{
+				Topology: fromTopology(topology),
+			}
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+			epoch = topology.Epoch
+		}
+
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func fromTopology(topology cluster.Topology) *memcached.Topology {
+	t := &memcached.Topology{
+		Epoch:    topology.Epoch,
+		Strategy: consistenthash.StrategyRing.String(),
+		Replicas: int32(topology.Replicas),
+		Peers:    make([]*memcached.Member, len(topology.Peers)),
+	}
+	for i, peer := range topology.Peers {
+		t.Peers[i] = &memcached.Member{
+			Address: peer.Address,
+			Weight:  int32(peer.Weight),
+		}
+	}
+	return t
+}
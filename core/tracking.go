@@ -0,0 +1,177 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tescherm/mc/core/tracking"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Clients that cache what they read open a Track stream, and tag their reads
+// with its client ID. Reads are tracked on the nodes that serve them: the
+// owner of a forwarded key, or each replica read for a replicated pool. So
+// the node a client tracks with opens a Track stream to every other node
+// and relays their invalidations, and reads that forward keep their tag.
+// Reads of tracked keys bypass the hot cache, whose copies are not tracked.
+
+// trackingID returns the client a request is tracked for, if any.
+func trackingID(ctx context.Context) string {
+	return requestValue(ctx, "", tracking.MetadataKey, "")
+}
+
+// withTracking tags the requests sent with ctx with a tracked client.
+func withTracking(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, tracking.MetadataKey, id)
+}
+
+// trackRead tracks a read of key, if the request is tracked. It is called
+// before the read, so that a write racing it invalidates the key.
+func (s *MemcachedService) trackRead(ctx context.Context, pool, namespace, key string) {
+	if s.Tracking == nil {
+		return
+	}
+	id := trackingID(ctx)
+	if id == "" {
+		return
+	}
+	pool, namespace = requestNames(ctx, pool, namespace)
+	s.Tracking.Track(id, pool, namespace, key)
+}
+
+// Track streams invalidations of the keys the client reads, until the client
+// disconnects. In a cluster, the stream ends if any other node's does, and
+// the client must drop every key it read.
+func (s *MemcachedService) Track(req *memcached.TrackRequest, stream memcached.Memcached_TrackServer) error {
+	ctx := stream.Context()
+
+	s.Logger.WithFields(logrus.Fields{
+		"client": req.Client,
+	}).Info("Track")
+
+	if s.Tracking == nil {
+		return status.Errorf(codes.Unimplemented, "client tracking is disabled")
+	}
+	if req.Client == "" {
+		return status.Errorf(codes.InvalidArgument, "client is required")
+	}
+
+	client := s.Tracking.Connect(req.Client)
+	defer client.Disconnect()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	peerResponses := make(chan *memcached.TrackResponse)
+	peersReady := make(chan struct{})
+	peerErr := make(chan error, 1)
+	go s.trackOthers(ctx, req, peerResponses, peersReady, peerErr)
+
+	// the client is ready once every node tracks its reads
+	select {
+	case <-peersReady:
+	case err := <-peerErr:
+		return status.Errorf(codes.Unavailable, "unable to track with peer: %s", err)
+	case <-ctx.Done():
+		return nil
+	}
+	if err := stream.Send(&memcached.TrackResponse{Ready: true}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-client.Ready():
+			invalidations, flush := client.Take()
+			res := &memcached.TrackResponse{
+				Invalidations: make([]*memcached.Invalidation, len(invalidations)),
+				Flush:         flush,
+			}
+			for i, invalidation := range invalidations {
+				res.Invalidations[i] = fromInvalidation(invalidation)
+			}
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+
+		case res := <-peerResponses:
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+
+		case err := <-peerErr:
+			return status.Errorf(codes.Unavailable, "unable to track with peer: %s", err)
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// trackOthers opens a Track stream to every other node, unless the request
+// was forwarded itself, sending their invalidations to responses. ready is
+// closed once every stream is ready, and the first error is sent to errs.
+func (s *MemcachedService) trackOthers(ctx context.Context, req *memcached.TrackRequest, responses chan<- *memcached.TrackResponse, ready chan<- struct{}, errs chan<- error) {
+	var mu sync.Mutex
+	var streams []memcached.Memcached_TrackClient
+	err := s.others(ctx, func(peer memcached.MemcachedClient) error {
+		stream, err := peer.Track(ctx, req)
+		if err != nil {
+			return err
+		}
+		res, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if !res.Ready {
+			return status.Errorf(codes.Internal, "peer track stream did not start ready")
+		}
+
+		mu.Lock()
+		streams = append(streams, stream)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		errs <- err
+		return
+	}
+	close(ready)
+
+	for _, stream := range streams {
+		go func(stream memcached.Memcached_TrackClient) {
+			for {
+				res, err := stream.Recv()
+				if err != nil {
+					if ctx.Err() == nil {
+						select {
+						case errs <- err:
+						default:
+						}
+					}
+					return
+				}
+				select {
+				case responses <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(stream)
+	}
+}
+
+func fromInvalidation(invalidation tracking.Invalidation) *memcached.Invalidation {
+	return &memcached.Invalidation{
+		Pool:      invalidation.Pool,
+		Namespace: invalidation.Namespace,
+		Key:       invalidation.Key,
+	}
+}
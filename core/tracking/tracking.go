@@ -0,0 +1,317 @@
+// Package tracking remembers which clients have read which keys, and tells
+// them when those keys change, so that the clients can cache what they read.
+package tracking
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/tescherm/mc/core/cache"
+)
+
+// MetadataKey is the request metadata key that names the client a Get is
+// tracked for, so that the client hears when the key changes.
+const MetadataKey = "mc-tracking"
+
+const (
+	defaultMaxKeys = 1000000
+	defaultBuffer  = 10000
+)
+
+type Config struct {
+	// MaxKeys bounds the keys tracked. Past it, a tracked key is dropped,
+	// and invalidated, for each new one.
+	MaxKeys int
+
+	// Buffer is the number of invalidations a client may fall behind by
+	// before it is told to flush everything instead
+	Buffer int
+}
+
+// Invalidation tells a client to drop a key it read, or every key of the
+// namespace if Key is empty.
+type Invalidation struct {
+	Pool      string
+	Namespace string
+	Key       string
+}
+
+// Stats is a point in time view of a table.
+type Stats struct {
+	Clients int
+	Keys    int
+
+	Invalidations uint64
+
+	// Flushes counts the times a client fell behind and was told to flush
+	Flushes uint64
+
+	// Dropped counts the keys dropped to keep within MaxKeys
+	Dropped uint64
+}
+
+type namespaceKey struct {
+	pool      string
+	namespace string
+}
+
+type trackedKey struct {
+	namespaceKey
+	key string
+}
+
+// Table tracks the keys each connected client has read.
+type Table struct {
+	mu sync.Mutex
+
+	maxKeys int
+	buffer  int
+
+	clients map[string]*Client
+
+	// connected is the number of clients, so that publishing with none is
+	// cheap
+	connected int32
+
+	// keys maps each namespace's tracked keys to the clients that read them
+	keys  map[namespaceKey]map[string]map[*Client]bool
+	count int
+
+	invalidations uint64
+	flushes       uint64
+	dropped       uint64
+}
+
+func New(config Config) *Table {
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = defaultMaxKeys
+	}
+	if config.Buffer <= 0 {
+		config.Buffer = defaultBuffer
+	}
+
+	return &Table{
+		maxKeys: config.MaxKeys,
+		buffer:  config.Buffer,
+		clients: make(map[string]*Client),
+		keys:    make(map[namespaceKey]map[string]map[*Client]bool),
+	}
+}
+
+// Connect registers a client, replacing any client connected with the same
+// ID. Reads are only tracked for connected clients.
+func (t *Table) Connect(id string) *Client {
+	c := &Client{
+		id:    id,
+		table: t,
+		keys:  make(map[trackedKey]bool),
+		ready: make(chan struct{}, 1),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if old, ok := t.clients[id]; ok {
+		t.disconnect(old)
+	}
+	t.clients[id] = c
+	atomic.StoreInt32(&t.connected, int32(len(t.clients)))
+	return c
+}
+
+func (t *Table) disconnect(c *Client) {
+	if t.clients[c.id] != c {
+		return
+	}
+	delete(t.clients, c.id)
+	atomic.StoreInt32(&t.connected, int32(len(t.clients)))
+
+	for k := range c.keys {
+		keys := t.keys[k.namespaceKey]
+		clients := keys[k.key]
+		delete(clients, c)
+		if len(clients) > 0 {
+			continue
+		}
+		delete(keys, k.key)
+		t.count--
+		if len(keys) == 0 {
+			delete(t.keys, k.namespaceKey)
+		}
+	}
+	c.keys = nil
+}
+
+// Track records that the client with id read key, if it is connected. Keys
+// are tracked until they change; a client must read a key again to hear of
+// its next change.
+func (t *Table) Track(id, pool, namespace, key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.clients[id]
+	if !ok {
+		return
+	}
+
+	ns := namespaceKey{pool, namespace}
+	keys, ok := t.keys[ns]
+	if !ok {
+		keys = make(map[string]map[*Client]bool)
+		t.keys[ns] = keys
+	}
+	clients, ok := keys[key]
+	if !ok {
+		if t.count >= t.maxKeys {
+			t.dropOne()
+		}
+		clients = make(map[*Client]bool)
+		keys[key] = clients
+		t.count++
+	}
+	clients[c] = true
+	c.keys[trackedKey{ns, key}] = true
+}
+
+// dropOne stops tracking an arbitrary key, invalidating it first.
+func (t *Table) dropOne() {
+	for ns, keys := range t.keys {
+		for key := range keys {
+			t.invalidate(ns, key)
+			t.dropped++
+			return
+		}
+	}
+}
+
+// invalidate tells the clients that read a key that it changed, and stops
+// tracking it.
+func (t *Table) invalidate(ns namespaceKey, key string) {
+	keys := t.keys[ns]
+	clients, ok := keys[key]
+	if !ok {
+		return
+	}
+	for c := range clients {
+		delete(c.keys, trackedKey{ns, key})
+		c.deliver(Invalidation{Pool: ns.pool, Namespace: ns.namespace, Key: key})
+	}
+	delete(keys, key)
+	t.count--
+	if len(keys) == 0 {
+		delete(t.keys, ns)
+	}
+}
+
+// Publish invalidates the keys a cache event changes. It has the signature
+// of a pools.Listener and does not block.
+func (t *Table) Publish(pool, namespace string, event cache.Event) {
+	if atomic.LoadInt32(&t.connected) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ns := namespaceKey{pool, namespace}
+	if event.Type != cache.EventClear {
+		t.invalidate(ns, event.Key)
+		return
+	}
+
+	keys, ok := t.keys[ns]
+	if !ok {
+		return
+	}
+	notified := make(map[*Client]bool)
+	for key, clients := range keys {
+		for c := range clients {
+			delete(c.keys, trackedKey{ns, key})
+			if !notified[c] {
+				c.deliver(Invalidation{Pool: pool, Namespace: namespace})
+				notified[c] = true
+			}
+		}
+	}
+	t.count -= len(keys)
+	delete(t.keys, ns)
+}
+
+func (t *Table) Stats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return Stats{
+		Clients:       len(t.clients),
+		Keys:          t.count,
+		Invalidations: t.invalidations,
+		Flushes:       t.flushes,
+		Dropped:       t.dropped,
+	}
+}
+
+// Client buffers the invalidations for a connected client until they are
+// taken.
+type Client struct {
+	id    string
+	table *Table
+
+	// keys are the keys the client is tracked for, guarded by the table
+	keys map[trackedKey]bool
+
+	mu            sync.Mutex
+	invalidations []Invalidation
+	flush         bool
+
+	// ready is signalled when there are invalidations to take
+	ready chan struct{}
+}
+
+// deliver queues an invalidation, with the table locked.
+func (c *Client) deliver(invalidation Invalidation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.table.invalidations++
+	if c.flush {
+		return
+	}
+	if len(c.invalidations) >= c.table.buffer {
+		c.table.flushes++
+		c.invalidations = nil
+		c.flush = true
+	} else {
+		c.invalidations = append(c.invalidations, invalidation)
+	}
+
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Ready returns a channel that receives when there are invalidations to
+// take.
+func (c *Client) Ready() <-chan struct{} {
+	return c.ready
+}
+
+// Take returns the invalidations since the last call, in order, or flush if
+// the client fell behind and must drop every key it read.
+func (c *Client) Take() (invalidations []Invalidation, flush bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	invalidations, flush = c.invalidations, c.flush
+	c.invalidations = nil
+	c.flush = false
+	return invalidations, flush
+}
+
+// Disconnect stops tracking the client's reads.
+func (c *Client) Disconnect() {
+	c.table.mu.Lock()
+	defer c.table.mu.Unlock()
+
+	c.table.disconnect(c)
+}
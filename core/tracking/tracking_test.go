@@ -0,0 +1,117 @@
+package tracking
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/core/cache"
+)
+
+func set(key string) cache.Event {
+	return cache.Event{Type: cache.EventSet, Key: key, VersionID: 1}
+}
+
+func keys(invalidations []Invalidation) []string {
+	var keys []string
+	for _, invalidation := range invalidations {
+		keys = append(keys, invalidation.Key)
+	}
+	return keys
+}
+
+func TestTrackingInvalidate(t *testing.T) {
+	t.Parallel()
+
+	table := New(Config{})
+	a := table.Connect("a")
+	b := table.Connect("b")
+
+	table.Track("a", "pool", "ns", "key")
+	table.Track("a", "pool", "ns", "key2")
+	table.Track("b", "pool", "ns", "key")
+	// unknown clients are not tracked
+	table.Track("c", "pool", "ns", "key3")
+	require.Equal(t, Stats{Clients: 2, Keys: 2}, table.Stats())
+
+	table.Publish("pool", "ns", set("key"))
+	table.Publish("pool", "other", set("key2"))
+	table.Publish("pool", "ns", set("key3"))
+
+	<-a.Ready()
+	invalidations, flush := a.Take()
+	require.Equal(t, []Invalidation{{Pool: "pool", Namespace: "ns", Key: "key"}}, invalidations)
+	require.False(t, flush)
+	invalidations, _ = b.Take()
+	require.Equal(t, []string{"key"}, keys(invalidations))
+
+	// keys are invalidated once, until they are read again
+	table.Publish("pool", "ns", set("key"))
+	invalidations, _ = a.Take()
+	require.Empty(t, invalidations)
+
+	// clears invalidate the namespace
+	table.Publish("pool", "ns", cache.Event{Type: cache.EventClear})
+	invalidations, _ = a.Take()
+	require.Equal(t, []Invalidation{{Pool: "pool", Namespace: "ns"}}, invalidations)
+	invalidations, _ = b.Take()
+	require.Empty(t, invalidations)
+
+	require.Equal(t, Stats{Clients: 2, Invalidations: 3}, table.Stats())
+}
+
+func TestTrackingDisconnect(t *testing.T) {
+	t.Parallel()
+
+	table := New(Config{})
+	a := table.Connect("a")
+	table.Track("a", "pool", "ns", "key")
+
+	// reconnecting replaces the client, forgetting its keys
+	a2 := table.Connect("a")
+	require.Equal(t, Stats{Clients: 1}, table.Stats())
+	table.Track("a", "pool", "ns", "key")
+
+	// disconnecting an old client does not disconnect the new one
+	a.Disconnect()
+	require.Equal(t, Stats{Clients: 1, Keys: 1}, table.Stats())
+
+	a2.Disconnect()
+	require.Equal(t, Stats{}, table.Stats())
+	table.Publish("pool", "ns", set("key"))
+	invalidations, _ := a2.Take()
+	require.Empty(t, invalidations)
+}
+
+func TestTrackingLimits(t *testing.T) {
+	t.Parallel()
+
+	table := New(Config{MaxKeys: 2, Buffer: 2})
+	a := table.Connect("a")
+
+	// past MaxKeys, tracked keys are dropped and invalidated
+	for _, key := range []string{"a", "b", "c"} {
+		table.Track("a", "pool", "ns", key)
+	}
+	invalidations, flush := a.Take()
+	require.Len(t, invalidations, 1)
+	require.False(t, flush)
+	require.Equal(t, Stats{Clients: 1, Keys: 2, Invalidations: 1, Dropped: 1}, table.Stats())
+
+	// clients that fall behind are told to flush
+	for _, key := range []string{"a", "b", "c"} {
+		table.Track("a", "pool", "ns", key)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		table.Publish("pool", "ns", set(key))
+	}
+	invalidations, flush = a.Take()
+	require.Empty(t, invalidations)
+	require.True(t, flush)
+
+	// and start over once they do
+	table.Track("a", "pool", "ns", "a")
+	table.Publish("pool", "ns", set("a"))
+	invalidations, flush = a.Take()
+	require.Equal(t, []string{"a"}, keys(invalidations))
+	require.False(t, flush)
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tescherm/mc/core/cache"
+	"github.com/tescherm/mc/core/watch"
+	"github.com/tescherm/mc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Watch streams the changes to the watched keys. In a cluster, the node
+// watches the other nodes' keys too, as of when the watch starts; if any of
+// them cannot be watched, the stream ends and the client should watch again.
+func (s *MemcachedService) Watch(req *memcached.WatchRequest, stream memcached.Memcached_WatchServer) error {
+	ctx := stream.Context()
+
+	s.Logger.WithFields(logrus.Fields{
+		"keys":      len(req.Keys),
+		"prefixes":  len(req.Prefixes),
+		"namespace": req.Namespace,
+		"pool":      req.Pool,
+	}).Info("Watch")
+
+	if s.Watches == nil {
+		return status.Errorf(codes.Unimplemented, "watches are disabled")
+	}
+	if _, err := s.namespace(ctx, req.Pool, req.Namespace); err != nil {
+		return err
+	}
+
+	req.Pool, req.Namespace = requestNames(ctx, req.Pool, req.Namespace)
+	w := s.Watches.Watch(watch.Filter{
+		Pool:      req.Pool,
+		Namespace: req.Namespace,
+		Keys:      req.Keys,
+		Prefixes:  req.Prefixes,
+	})
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	peerEvents := make(chan *memcached.WatchResponse)
+	peerErr := make(chan error, 1)
+	go s.watchOthers(ctx, req, peerEvents, peerErr)
+
+	var node string
+	if s.Cluster != nil {
+		node = s.Cluster.Self()
+	}
+
+	for {
+		select {
+		case <-w.Ready():
+			events, missed := w.Take()
+			res := &memcached.WatchResponse{
+				Events: make([]*memcached.WatchEvent, 0, len(events)+1),
+			}
+			for _, event := range events {
+				res.Events = append(res.Events, fromWatchEvent(event, node))
+			}
+			if missed > 0 {
+				res.Events = append(res.Events, &memcached.WatchEvent{
+					Type:   memcached.WatchEvent_OVERFLOW,
+					Missed: missed,
+					Node:   node,
+				})
+			}
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+
+		case res := <-peerEvents:
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+
+		case err := <-peerErr:
+			return status.Errorf(codes.Unavailable, "unable to watch peer: %s", err)
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// watchOthers forwards a watch to every other node, unless it was forwarded
+// itself, sending their events to events. The first error is sent to errs.
+func (s *MemcachedService) watchOthers(ctx context.Context, req *memcached.WatchRequest, events chan<- *memcached.WatchResponse, errs chan<- error) {
+	s.others(ctx, func(peer memcached.MemcachedClient) error {
+		err := watchPeer(ctx, peer, req, events)
+		if err != nil && ctx.Err() == nil {
+			select {
+			case errs <- err:
+			default:
+			}
+		}
+		return err
+	})
+}
+
+func watchPeer(ctx context.Context, peer memcached.MemcachedClient, req *memcached.WatchRequest, events chan<- *memcached.WatchResponse) error {
+	stream, err := peer.Watch(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		select {
+		case events <- res:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func fromWatchEvent(event watch.Event, node string) *memcached.WatchEvent {
+	return &memcached.WatchEvent{
+		Type:  fromEventType(event.Type),
+		Key:   event.Key,
+		CasID: event.VersionID,
+		Node:  node,
+	}
+}
+
+func fromEventType(t cache.EventType) memcached.WatchEvent_Type {
+	switch t {
+	case cache.EventRemove:
+		return memcached.WatchEvent_REMOVE
+	case cache.EventEvict:
+		return memcached.WatchEvent_EVICT
+	case cache.EventExpire:
+		return memcached.WatchEvent_EXPIRE
+	case cache.EventClear:
+		return memcached.WatchEvent_CLEAR
+	}
+	return memcached.WatchEvent_SET
+}
@@ -0,0 +1,213 @@
+// Package watch delivers changes to cached items to the clients watching
+// them.
+package watch
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tescherm/mc/core/cache"
+)
+
+const defaultBuffer = 1000
+
+type Config struct {
+	// Buffer is the number of events a watcher may fall behind by before
+	// further events are dropped
+	Buffer int
+}
+
+// Event is a change to an item in a pool's namespace.
+type Event struct {
+	Pool      string
+	Namespace string
+
+	cache.Event
+}
+
+// Filter selects events in a pool's namespace: those for one of Keys, or for
+// a key starting with one of Prefixes, or for any key if neither is set.
+// Clears are always selected.
+type Filter struct {
+	Pool      string
+	Namespace string
+
+	Keys     []string
+	Prefixes []string
+}
+
+// Stats is a point in time view of a hub.
+type Stats struct {
+	Watchers int
+
+	// Events counts the events delivered to watchers
+	Events uint64
+
+	// Overflows counts the times a watcher fell behind and dropped events
+	Overflows uint64
+}
+
+// Hub fans out cache events to the watchers whose filters select them.
+type Hub struct {
+	mu sync.RWMutex
+
+	buffer   int
+	watchers map[*Watcher]bool
+
+	// count is the number of watchers, so that publishing with none is cheap
+	count int32
+
+	events    uint64
+	overflows uint64
+}
+
+func New(config Config) *Hub {
+	if config.Buffer <= 0 {
+		config.Buffer = defaultBuffer
+	}
+
+	return &Hub{
+		buffer:   config.Buffer,
+		watchers: make(map[*Watcher]bool),
+	}
+}
+
+// Publish delivers an event to the watchers selecting it. It has the
+// signature of a pools.Listener and does not block.
+func (h *Hub) Publish(pool, namespace string, event cache.Event) {
+	if atomic.LoadInt32(&h.count) == 0 {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for w := range h.watchers {
+		if w.selects(pool, namespace, event) {
+			w.deliver(Event{
+				Pool:      pool,
+				Namespace: namespace,
+				Event:     event,
+			})
+		}
+	}
+}
+
+// Watch returns a watcher receiving the events filter selects from now on.
+func (h *Hub) Watch(filter Filter) *Watcher {
+	w := &Watcher{
+		hub:    h,
+		filter: filter,
+		keys:   make(map[string]bool, len(filter.Keys)),
+		ready:  make(chan struct{}, 1),
+	}
+	for _, key := range filter.Keys {
+		w.keys[key] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.watchers[w] = true
+	atomic.StoreInt32(&h.count, int32(len(h.watchers)))
+	return w
+}
+
+func (h *Hub) remove(w *Watcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.watchers, w)
+	atomic.StoreInt32(&h.count, int32(len(h.watchers)))
+}
+
+func (h *Hub) Stats() Stats {
+	h.mu.RLock()
+	watchers := len(h.watchers)
+	h.mu.RUnlock()
+
+	return Stats{
+		Watchers:  watchers,
+		Events:    atomic.LoadUint64(&h.events),
+		Overflows: atomic.LoadUint64(&h.overflows),
+	}
+}
+
+// Watcher buffers the events selected by its filter until they are taken.
+type Watcher struct {
+	hub    *Hub
+	filter Filter
+	keys   map[string]bool
+
+	mu     sync.Mutex
+	events []Event
+	missed uint64
+
+	// ready is signalled when there are events to take
+	ready chan struct{}
+}
+
+func (w *Watcher) selects(pool, namespace string, event cache.Event) bool {
+	if pool != w.filter.Pool || namespace != w.filter.Namespace {
+		return false
+	}
+	if event.Type == cache.EventClear {
+		return true
+	}
+	if len(w.filter.Keys) == 0 && len(w.filter.Prefixes) == 0 {
+		return true
+	}
+	if w.keys[event.Key] {
+		return true
+	}
+	for _, prefix := range w.filter.Prefixes {
+		if strings.HasPrefix(event.Key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) deliver(event Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.events) >= w.hub.buffer {
+		if w.missed == 0 {
+			atomic.AddUint64(&w.hub.overflows, 1)
+		}
+		w.missed++
+	} else {
+		w.events = append(w.events, event)
+		atomic.AddUint64(&w.hub.events, 1)
+	}
+
+	select {
+	case w.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Ready returns a channel that receives when there are events to take.
+func (w *Watcher) Ready() <-chan struct{} {
+	return w.ready
+}
+
+// Take returns the events received since the last call, in order, and the
+// number of events dropped after them because the watcher fell behind. The
+// watcher's view is only complete again after re-reading its keys.
+func (w *Watcher) Take() ([]Event, uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	events, missed := w.events, w.missed
+	w.events = nil
+	w.missed = 0
+	return events, missed
+}
+
+// Close stops the watcher receiving events.
+func (w *Watcher) Close() {
+	w.hub.remove(w)
+}
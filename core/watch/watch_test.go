@@ -0,0 +1,82 @@
+package watch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tescherm/mc/core/cache"
+)
+
+func set(key string) cache.Event {
+	return cache.Event{Type: cache.EventSet, Key: key, VersionID: 1}
+}
+
+func keys(events []Event) []string {
+	var keys []string
+	for _, event := range events {
+		keys = append(keys, event.Key)
+	}
+	return keys
+}
+
+func TestWatchFilter(t *testing.T) {
+	t.Parallel()
+
+	h := New(Config{})
+	w := h.Watch(Filter{
+		Pool:      "pool",
+		Namespace: "ns",
+		Keys:      []string{"key"},
+		Prefixes:  []string{"config/"},
+	})
+	all := h.Watch(Filter{Pool: "pool", Namespace: "ns"})
+
+	h.Publish("pool", "ns", set("key"))
+	h.Publish("pool", "ns", set("key2"))
+	h.Publish("pool", "ns", set("config/a"))
+	h.Publish("pool", "other", set("key"))
+	h.Publish("other", "ns", set("key"))
+	h.Publish("pool", "ns", cache.Event{Type: cache.EventClear})
+
+	<-w.Ready()
+	events, missed := w.Take()
+	require.Equal(t, []string{"key", "config/a", ""}, keys(events))
+	require.Zero(t, missed)
+	require.Equal(t, Event{Pool: "pool", Namespace: "ns", Event: set("key")}, events[0])
+	require.Equal(t, cache.EventClear, events[2].Type)
+
+	events, _ = all.Take()
+	require.Equal(t, []string{"key", "key2", "config/a", ""}, keys(events))
+
+	// closed watchers receive nothing
+	w.Close()
+	h.Publish("pool", "ns", set("key"))
+	events, _ = w.Take()
+	require.Empty(t, events)
+	require.Equal(t, Stats{Watchers: 1, Events: 8}, h.Stats())
+}
+
+func TestWatchOverflow(t *testing.T) {
+	t.Parallel()
+
+	h := New(Config{Buffer: 2})
+	w := h.Watch(Filter{Pool: "pool", Namespace: "ns"})
+	defer w.Close()
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		h.Publish("pool", "ns", set(key))
+	}
+
+	// the events that fit are kept, and the rest counted
+	events, missed := w.Take()
+	require.Equal(t, []string{"a", "b"}, keys(events))
+	require.Equal(t, uint64(2), missed)
+
+	// and the watcher starts over once it catches up
+	h.Publish("pool", "ns", set("e"))
+	events, missed = w.Take()
+	require.Equal(t, []string{"e"}, keys(events))
+	require.Zero(t, missed)
+
+	require.Equal(t, Stats{Watchers: 1, Events: 3, Overflows: 1}, h.Stats())
+}
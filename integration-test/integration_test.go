@@ -4,7 +4,11 @@ package integration_test
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -31,7 +35,7 @@ func TestCacheLifecycle(t *testing.T) {
 	ctx := context.Background()
 
 	defer func() {
-		err := mc.Clear(ctx)
+		err := mc.ClearNamespace(ctx, "")
 		require.NoError(t, err)
 	}()
 
@@ -72,7 +76,7 @@ func TestCompareAndSwap(t *testing.T) {
 	ctx := context.Background()
 
 	defer func() {
-		err := mc.Clear(ctx)
+		err := mc.ClearNamespace(ctx, "")
 		require.NoError(t, err)
 	}()
 
@@ -121,7 +125,7 @@ func TestCacheConcurrency(t *testing.T) {
 	ctx := context.Background()
 
 	defer func() {
-		err := mc.Clear(ctx)
+		err := mc.ClearNamespace(ctx, "")
 		require.NoError(t, err)
 	}()
 
@@ -179,3 +183,489 @@ func cacheWorker(t *testing.T, jobs <-chan int, results chan<- string) {
 		results <- key
 	}
 }
+
+func TestDeleteMatching(t *testing.T) {
+	ctx := context.Background()
+
+	defer func() {
+		err := mc.ClearNamespace(ctx, "")
+		require.NoError(t, err)
+	}()
+
+	prefix := randAlphaNumericString(10) + ":"
+	for i := 0; i < 10; i++ {
+		err := mc.Set(ctx, &client.Item{
+			Key:   prefix + randAlphaNumericString(10),
+			Value: []byte(randAlphaNumericString(20)),
+		})
+		require.NoError(t, err)
+	}
+
+	other := randAlphaNumericString(10)
+	err := mc.Set(ctx, &client.Item{
+		Key:   other,
+		Value: []byte(randAlphaNumericString(20)),
+	})
+	require.NoError(t, err)
+
+	jobID, err := mc.DeleteMatching(ctx, prefix, "")
+	require.NoError(t, err)
+
+	var job *client.Job
+	for i := 0; i < 100; i++ {
+		job, err = mc.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		if job.State != client.JobRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, client.JobDone, job.State)
+	require.EqualValues(t, 10, job.Count)
+
+	size, err := mc.Size(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, size)
+}
+
+func TestInvalidateTags(t *testing.T) {
+	ctx := context.Background()
+
+	defer func() {
+		err := mc.ClearNamespace(ctx, "")
+		require.NoError(t, err)
+	}()
+
+	tag := randAlphaNumericString(10)
+
+	tagged := &client.Item{
+		Key:   randAlphaNumericString(10),
+		Value: []byte(randAlphaNumericString(20)),
+		Tags:  []string{tag},
+	}
+	err := mc.Set(ctx, tagged)
+	require.NoError(t, err)
+
+	untagged := &client.Item{
+		Key:   randAlphaNumericString(10),
+		Value: []byte(randAlphaNumericString(20)),
+	}
+	err = mc.Set(ctx, untagged)
+	require.NoError(t, err)
+
+	item, err := mc.Get(ctx, tagged.Key)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	require.Equal(t, []string{tag}, item.Tags)
+
+	count, err := mc.InvalidateTags(ctx, tag)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, count)
+
+	item, err = mc.Get(ctx, tagged.Key)
+	require.NoError(t, err)
+	require.Nil(t, item)
+
+	item, err = mc.Get(ctx, untagged.Key)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+}
+
+func TestNamespaces(t *testing.T) {
+	ctx := context.Background()
+
+	nsc, err := client.New(client.Config{
+		ServiceURI: "localhost:8080",
+		Namespace:  "integration",
+	})
+	require.NoError(t, err)
+
+	defer func() {
+		err := mc.ClearNamespace(ctx, "")
+		require.NoError(t, err)
+		err = nsc.ClearNamespace(ctx, "")
+		require.NoError(t, err)
+	}()
+
+	key := randAlphaNumericString(10)
+
+	err = nsc.Set(ctx, &client.Item{
+		Key:   key,
+		Value: []byte("namespaced"),
+	})
+	require.NoError(t, err)
+
+	item, err := mc.Get(ctx, key)
+	require.NoError(t, err)
+	require.Nil(t, item)
+
+	item, err = nsc.Get(ctx, key)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	require.Equal(t, "namespaced", string(item.Value))
+
+	err = nsc.ClearNamespace(ctx, "")
+	require.NoError(t, err)
+
+	size, err := nsc.Size(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, size)
+
+	missing, err := client.New(client.Config{
+		ServiceURI: "localhost:8080",
+		Namespace:  "missing",
+	})
+	require.NoError(t, err)
+
+	_, err = missing.Get(ctx, key)
+	require.Error(t, err)
+}
+
+func TestGeneration(t *testing.T) {
+	ctx := context.Background()
+
+	defer func() {
+		err := mc.ClearNamespace(ctx, "")
+		require.NoError(t, err)
+	}()
+
+	key := randAlphaNumericString(10)
+	err := mc.Set(ctx, &client.Item{
+		Key:   key,
+		Value: []byte(randAlphaNumericString(20)),
+	})
+	require.NoError(t, err)
+
+	generation, err := mc.Generation(ctx)
+	require.NoError(t, err)
+
+	bumped, err := mc.BumpGeneration(ctx)
+	require.NoError(t, err)
+	require.Equal(t, generation+1, bumped)
+
+	item, err := mc.Get(ctx, key)
+	require.NoError(t, err)
+	require.Nil(t, item)
+
+	err = mc.SetGeneration(ctx, generation)
+	require.NoError(t, err)
+
+	item, err = mc.Get(ctx, key)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+}
+
+func TestPools(t *testing.T) {
+	ctx := context.Background()
+
+	name := randAlphaNumericString(10)
+	pool, err := mc.CreatePool(ctx, client.PoolConfig{
+		Name:       name,
+		Capacity:   1 << 20,
+		CacheCount: 4,
+		Replicas:   50,
+		Policy:     client.PolicyFIFO,
+		TTL:        time.Minute,
+
+		HashStrategy: client.HashJump,
+	})
+	require.NoError(t, err)
+	require.Equal(t, name, pool.Config.Name)
+	require.Equal(t, client.HashJump, pool.Config.HashStrategy)
+	require.Equal(t, client.PolicyFIFO, pool.Config.Policy)
+	require.Equal(t, time.Minute, pool.Config.TTL)
+
+	defer func() {
+		err := mc.DeletePool(ctx, name)
+		require.NoError(t, err)
+
+		_, err = mc.DescribePool(ctx, name)
+		require.Error(t, err)
+	}()
+
+	pools, err := mc.ListPools(ctx)
+	require.NoError(t, err)
+
+	var names []string
+	for _, p := range pools {
+		names = append(names, p.Config.Name)
+	}
+	require.Contains(t, names, "default")
+	require.Contains(t, names, name)
+
+	pc, err := client.New(client.Config{
+		ServiceURI: "localhost:8080",
+		Pool:       name,
+	})
+	require.NoError(t, err)
+
+	key := randAlphaNumericString(10)
+	err = pc.Set(ctx, &client.Item{
+		Key:   key,
+		Value: []byte("pooled"),
+	})
+	require.NoError(t, err)
+
+	item, err := mc.Get(ctx, key)
+	require.NoError(t, err)
+	require.Nil(t, item)
+
+	item, err = pc.Get(ctx, key)
+	require.NoError(t, err)
+	require.NotNil(t, item)
+
+	pool, err = mc.DescribePool(ctx, name)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, pool.Size)
+}
+
+func TestReshard(t *testing.T) {
+	ctx := context.Background()
+
+	name := randAlphaNumericString(10)
+	_, err := mc.CreatePool(ctx, client.PoolConfig{
+		Name:       name,
+		Capacity:   1 << 20,
+		CacheCount: 2,
+	})
+	require.NoError(t, err)
+	defer mc.DeletePool(ctx, name)
+
+	pc, err := client.New(client.Config{
+		ServiceURI: "localhost:8080",
+		Pool:       name,
+	})
+	require.NoError(t, err)
+
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = randAlphaNumericString(10)
+		err := pc.Set(ctx, &client.Item{
+			Key:   keys[i],
+			Value: []byte("value"),
+		})
+		require.NoError(t, err)
+	}
+
+	jobID, err := mc.Reshard(ctx, name, 5)
+	require.NoError(t, err)
+
+	var job *client.Job
+	for i := 0; i < 100; i++ {
+		job, err = mc.GetJob(ctx, jobID)
+		require.NoError(t, err)
+		if job.State != client.JobRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, client.JobDone, job.State)
+
+	pool, err := mc.DescribePool(ctx, name)
+	require.NoError(t, err)
+	require.Equal(t, 5, pool.Config.CacheCount)
+	require.EqualValues(t, len(keys), pool.Size)
+
+	for _, key := range keys {
+		item, err := pc.Get(ctx, key)
+		require.NoError(t, err)
+		require.NotNil(t, item)
+	}
+}
+
+func TestResize(t *testing.T) {
+	ctx := context.Background()
+
+	name := randAlphaNumericString(10)
+	_, err := mc.CreatePool(ctx, client.PoolConfig{
+		Name:       name,
+		Capacity:   1 << 20,
+		CacheCount: 2,
+	})
+	require.NoError(t, err)
+	defer mc.DeletePool(ctx, name)
+
+	pool, err := mc.Resize(ctx, name, "", 2<<20)
+	require.NoError(t, err)
+	require.EqualValues(t, 2<<20, pool.Config.Capacity)
+
+	_, err = mc.Resize(ctx, name, "other", 2<<20)
+	require.Error(t, err)
+
+	_, err = mc.Resize(ctx, name, "", 0)
+	require.Error(t, err)
+}
+
+func TestSoftTTL(t *testing.T) {
+	ctx := context.Background()
+
+	name := randAlphaNumericString(10)
+	pool, err := mc.CreatePool(ctx, client.PoolConfig{
+		Name:       name,
+		Capacity:   1 << 20,
+		CacheCount: 2,
+		TTL:        time.Minute,
+		SoftTTL:    100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 100*time.Millisecond, pool.Config.SoftTTL)
+	defer mc.DeletePool(ctx, name)
+
+	pc, err := client.New(client.Config{
+		ServiceURI: "localhost:8080",
+		Pool:       name,
+	})
+	require.NoError(t, err)
+	defer pc.Close()
+
+	key := randAlphaNumericString(10)
+	err = pc.Set(ctx, &client.Item{Key: key, Value: []byte("old")})
+	require.NoError(t, err)
+
+	item, err := pc.Get(ctx, key)
+	require.NoError(t, err)
+	require.False(t, item.Stale)
+
+	time.Sleep(100 * time.Millisecond)
+
+	// the first stale reader is granted the refresh, and the others are
+	// served stale
+	refresh, err := pc.LeaseGet(ctx, key)
+	require.NoError(t, err)
+	require.True(t, refresh.Item.Stale)
+	require.NotZero(t, refresh.Token)
+
+	fill := func(ctx context.Context) (*client.Item, error) {
+		return nil, errors.New("fill while another client refreshes")
+	}
+	item, err = client.GetWithLease(ctx, pc, key, client.LeaseOptions{}, fill)
+	require.NoError(t, err)
+	require.True(t, item.Stale)
+	require.Equal(t, "old", string(item.Value))
+
+	err = pc.LeaseSet(ctx, &client.Item{Key: key, Value: []byte("new")}, refresh.Token)
+	require.NoError(t, err)
+
+	item, err = pc.Get(ctx, key)
+	require.NoError(t, err)
+	require.False(t, item.Stale)
+	require.Equal(t, "new", string(item.Value))
+}
+
+func TestGetOrCompute(t *testing.T) {
+	ctx := context.Background()
+
+	name := randAlphaNumericString(10)
+	_, err := mc.CreatePool(ctx, client.PoolConfig{
+		Name:       name,
+		Capacity:   1 << 20,
+		CacheCount: 2,
+		TTL:        time.Second,
+	})
+	require.NoError(t, err)
+	defer mc.DeletePool(ctx, name)
+
+	pc, err := client.New(client.Config{
+		ServiceURI: "localhost:8080",
+		Pool:       name,
+	})
+	require.NoError(t, err)
+	defer pc.Close()
+
+	computes := 0
+	compute := func(ctx context.Context) (*client.Item, error) {
+		computes++
+		time.Sleep(50 * time.Millisecond)
+		return &client.Item{Value: []byte("computed")}, nil
+	}
+
+	key := randAlphaNumericString(10)
+	item, err := client.GetOrCompute(ctx, pc, key, 0, compute)
+	require.NoError(t, err)
+	require.Equal(t, "computed", string(item.Value))
+	require.Equal(t, 1, computes)
+
+	// the compute time is stored with the item
+	item, recompute, err := pc.XFetch(ctx, key, 1)
+	require.NoError(t, err)
+	require.True(t, item.Delta >= 50*time.Millisecond)
+	require.False(t, recompute)
+
+	// and an item about to expire is almost always recomputed early
+	time.Sleep(900 * time.Millisecond)
+	_, recompute, err = pc.XFetch(ctx, key, 100)
+	require.NoError(t, err)
+	require.True(t, recompute)
+}
+
+func TestOriginLoad(t *testing.T) {
+	ctx := context.Background()
+
+	var loads int32
+	release := make(chan struct{})
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		if r.URL.Path != "/default/key" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("loaded"))
+	}))
+	defer origin.Close()
+
+	name := randAlphaNumericString(10)
+	pool, err := mc.CreatePool(ctx, client.PoolConfig{
+		Name:        name,
+		Capacity:    1 << 20,
+		CacheCount:  2,
+		Origin:      origin.URL,
+		NegativeTTL: time.Minute,
+	})
+	require.NoError(t, err)
+	require.Equal(t, origin.URL, pool.Config.Origin)
+	defer mc.DeletePool(ctx, name)
+
+	pc, err := client.New(client.Config{
+		ServiceURI: "localhost:8080",
+		Pool:       name,
+	})
+	require.NoError(t, err)
+	defer pc.Close()
+
+	// concurrent misses are loaded once
+	var wg sync.WaitGroup
+	values := make([]string, 10)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			item, err := pc.Get(ctx, "key")
+			if err == nil && item != nil {
+				values[i] = string(item.Value)
+			}
+		}(i)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, value := range values {
+		require.Equal(t, "loaded", value)
+	}
+	require.EqualValues(t, 1, atomic.LoadInt32(&loads))
+
+	// and then cached
+	item, err := pc.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, "loaded", string(item.Value))
+	require.EqualValues(t, 1, atomic.LoadInt32(&loads))
+
+	// keys the origin does not have are remembered
+	for i := 0; i < 2; i++ {
+		item, err = pc.Get(ctx, "missing")
+		require.NoError(t, err)
+		require.Nil(t, item)
+	}
+	require.EqualValues(t, 2, atomic.LoadInt32(&loads))
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -17,28 +20,86 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/tescherm/mc/core"
 	"github.com/tescherm/mc/core/caches"
+	"github.com/tescherm/mc/core/changes"
+	"github.com/tescherm/mc/core/cluster"
+	"github.com/tescherm/mc/core/consistenthash"
+	"github.com/tescherm/mc/core/governor"
+	"github.com/tescherm/mc/core/hints"
+	"github.com/tescherm/mc/core/hotcache"
+	"github.com/tescherm/mc/core/jobs"
+	"github.com/tescherm/mc/core/leases"
+	"github.com/tescherm/mc/core/membership"
+	"github.com/tescherm/mc/core/merkle"
+	"github.com/tescherm/mc/core/pools"
+	"github.com/tescherm/mc/core/tracking"
+	"github.com/tescherm/mc/core/watch"
 	"github.com/tescherm/mc/metrics"
 	pb "github.com/tescherm/mc/pb"
 	"google.golang.org/grpc"
 )
 
 var (
-	apiPort      = envflag.Int("API_PORT", 8080, "service API listen port")
-	cacheCount   = envflag.Int("NUM_CACHES", 20, "Number of caches")
-	capacityFlag = envflag.String("CAPACITY", "128m", "cache size")
-	metricsPort  = envflag.Int("METRICS_PORT", 9090, "service metrics listen port")
-	loglevel     = envflag.String("LOG_LEVEL", "info", "log level")
-	replicas     = envflag.Int("NUM_REPLICAS", 160, "number of cache node replicas")
+	antiEntropy     = envflag.Duration("ANTI_ENTROPY_INTERVAL", time.Minute, "how often replicated pools are compared with the other cluster peers; 0 to disable")
+	antiEntropyRate = envflag.Int("ANTI_ENTROPY_RATE", 1000, "the most keys per second anti-entropy repairs; 0 for no limit")
+	apiPort         = envflag.Int("API_PORT", 8080, "service API listen port")
+	cacheCount      = envflag.Int("NUM_CACHES", 20, "Number of caches")
+	boundedLoad     = envflag.Float64("BOUNDED_LOAD", 0, "if positive, spill keys from caches with more than (1+BOUNDED_LOAD) times the average load")
+	capacityFlag    = envflag.String("CAPACITY", "128m", "cache size")
+	catalogPath     = envflag.String("CATALOG_PATH", "catalog.json", "cache pool catalog path")
+	clusterPeers    = envflag.String("CLUSTER_PEERS", "", "comma separated cluster peer addresses with optional weights, e.g. 10.0.0.1:8080,10.0.0.2:8080=2; the gossip seeds if CLUSTER_GOSSIP is set")
+	clusterSelf     = envflag.String("CLUSTER_SELF", "", "this node's address as its cluster peers know it; clustering is disabled if empty")
+	clusterGossip   = envflag.Bool("CLUSTER_GOSSIP", false, "discover cluster peers by gossip rather than from a static list")
+	clusterWeight   = envflag.Int("CLUSTER_WEIGHT", 1, "this node's relative share of keys, unless CLUSTER_PEERS lists it with a weight")
+	gossipProbe     = envflag.Duration("GOSSIP_PROBE_INTERVAL", time.Second, "how often a gossip member probes another")
+	gossipSuspicion = envflag.Duration("GOSSIP_SUSPICION_TIMEOUT", 5*time.Second, "how long a member that failed probes is suspect before it is declared dead")
+	hintsDir        = envflag.String("HINTS_DIR", "", "directory replicated writes for unreachable peers are persisted in; kept in memory only if empty")
+	hintsMax        = envflag.Int("HINTS_MAX", 100000, "the most replicated writes kept for unreachable peers")
+	hintsReplay     = envflag.Duration("HINTS_REPLAY_INTERVAL", 10*time.Second, "how often writes kept for unreachable peers are expired and retried")
+	hintsTTL        = envflag.Duration("HINTS_TTL", 3*time.Hour, "how long writes are kept for an unreachable peer")
+	hotCacheSize    = envflag.String("HOT_CACHE_SIZE", "8m", "size of the copies kept of hot keys owned by other cluster peers; 0 to disable")
+	hotThreshold    = envflag.Int("HOT_CACHE_THRESHOLD", 8, "how many times a key owned by another peer is read within HOT_CACHE_WINDOW before a copy is kept")
+	hotWindow       = envflag.Duration("HOT_CACHE_WINDOW", 10*time.Second, "the period hot key reads are counted over, and the longest a copy is kept")
+	hashStrategy    = envflag.String("HASH_STRATEGY", "ring", "how keys are mapped to caches: ring, jump, rendezvous or maglev")
+	metricsPort     = envflag.Int("METRICS_PORT", 9090, "service metrics listen port")
+	leaseTTL        = envflag.Duration("LEASE_TTL", 10*time.Second, "how long a client's lease on a missed key is valid; 0 to disable leases")
+	leaseStaleTTL   = envflag.Duration("LEASE_STALE_TTL", 10*time.Second, "how long the value of a removed key is served stale to clients waiting on its lease")
+	loglevel        = envflag.String("LOG_LEVEL", "info", "log level")
+	memoryCheck     = envflag.Duration("MEMORY_CHECK_INTERVAL", time.Second, "how often memory usage is checked; 0 to disable")
+	memoryLimit     = envflag.String("MEMORY_LIMIT", "", "process memory limit; the cgroup memory limit if empty")
+	memoryTarget    = envflag.Float64("MEMORY_THRESHOLD", 0.8, "fraction of the memory limit at which caches are trimmed")
+	namespaces      = envflag.String("NAMESPACES", "", "comma separated namespace quotas, e.g. teamA=64m,teamB=32m")
+	rebalance       = envflag.Duration("REBALANCE_INTERVAL", 10*time.Second, "how often capacity is rebalanced between caches; 0 to disable")
+	replicaOf       = envflag.String("REPLICA_OF", "", "address of the primary to follow as a read-only replica; this server is a primary if empty")
+	replicaBuffer   = envflag.Int("REPLICATION_BUFFER", 100000, "the most mutations a replica may fall behind by before it is dropped and resynced")
+	replicas        = envflag.Int("NUM_REPLICAS", 160, "number of cache node replicas")
+	trackingMaxKeys = envflag.Int("TRACKING_MAX_KEYS", 1000000, "the most keys tracked for clients that cache what they read; 0 to disable client tracking")
+	watchBuffer     = envflag.Int("WATCH_BUFFER", 1000, "the most events a watch may fall behind by before it drops events; 0 to disable watches")
 )
 
 var (
 	logger = logrus.NewEntry(logrus.New())
 )
 
-func newServer(c *caches.Caches) *core.MemcachedService {
+// replicaRetryDelay is how long a replica waits before reconnecting to its
+// primary.
+const replicaRetryDelay = time.Second
+
+func newServer(p *pools.Pools, c *cluster.Cluster, m *membership.Membership, trees *merkle.Index, h *hints.Store, hot *hotcache.HotCache, feed *changes.Feed, w *watch.Hub, t *tracking.Table, l *leases.Table) *core.MemcachedService {
 	s := core.New(core.Config{
-		Caches: c,
-		Logger: logger,
+		Pools:           p,
+		Jobs:            jobs.NewManager(jobs.Config{}),
+		Cluster:         c,
+		Membership:      m,
+		Trees:           trees,
+		AntiEntropyRate: *antiEntropyRate,
+		Hints:           h,
+		Hot:             hot,
+		Feed:            feed,
+		Replica:         *replicaOf != "",
+		Watches:         w,
+		Tracking:        t,
+		Leases:          l,
+		Logger:          logger,
 	})
 	return s
 }
@@ -58,6 +119,166 @@ func initLogging() {
 	})
 }
 
+// parseNamespaces parses a comma separated list of name=capacity pairs.
+func parseNamespaces(s string) (map[string]uint64, error) {
+	quotas := make(map[string]uint64)
+	if s == "" {
+		return quotas, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid namespace quota %q", pair)
+		}
+		capacity, err := humanize.ParseBytes(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace quota %q: %s", pair, err)
+		}
+		quotas[parts[0]] = capacity
+	}
+	return quotas, nil
+}
+
+// parsePeers parses a comma separated list of peer addresses, each
+// optionally followed by =weight.
+func parsePeers(s string) ([]cluster.Peer, error) {
+	var peers []cluster.Peer
+	if s == "" {
+		return peers, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if parts[0] == "" {
+			return nil, fmt.Errorf("invalid peer %q", entry)
+		}
+		peer := cluster.Peer{Address: parts[0]}
+		if len(parts) == 2 {
+			weight, err := strconv.Atoi(parts[1])
+			if err != nil || weight <= 0 {
+				return nil, fmt.Errorf("invalid peer weight %q", entry)
+			}
+			peer.Weight = weight
+		}
+		peers = append(peers, peer)
+	}
+	return peers, nil
+}
+
+// updatePeer returns a membership callback that keeps the cluster ring in
+// step with the live members, and calls joined when a member is live.
+func updatePeer(c *cluster.Cluster, joined func(address string)) func(membership.Member) {
+	return func(member membership.Member) {
+		if !member.Live() {
+			c.Remove(member.Address)
+			return
+		}
+
+		err := c.Add(cluster.Peer{
+			Address: member.Address,
+			Weight:  member.Weight,
+		})
+		if err != nil {
+			logger.WithError(err).WithField("member", member.Address).Error("unable to add cluster peer")
+			return
+		}
+		joined(member.Address)
+	}
+}
+
+// replayHints sends a peer the writes kept for it while it was unreachable.
+func replayHints(s *core.MemcachedService, address string) {
+	replayed, err := s.ReplayHints(context.Background(), address)
+	if err != nil {
+		logger.WithError(err).WithField("peer", address).Warn("unable to replay hints")
+	}
+	if replayed > 0 {
+		logger.WithFields(logrus.Fields{
+			"peer":  address,
+			"hints": replayed,
+		}).Info("replayed hints")
+	}
+}
+
+// hintsLoop periodically expires hints, and retries them for peers that may
+// be back without the membership noticing they were away.
+func hintsLoop(s *core.MemcachedService, h *hints.Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if expired := h.Expire(); expired > 0 {
+			logger.WithField("hints", expired).Warn("expired hints")
+		}
+		for _, address := range h.Peers() {
+			replayHints(s, address)
+		}
+	}
+}
+
+// rebalanceLoop periodically moves capacity towards the caches that miss
+// the most for lack of it.
+func rebalanceLoop(p *pools.Pools, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if moved := p.Rebalance(caches.DefaultRebalanceConfig); moved > 0 {
+			logger.WithField("bytes", moved).Debug("rebalanced cache capacity")
+		}
+	}
+}
+
+// antiEntropyLoop periodically repairs the replicated pools' keys that this
+// node and its peers disagree about.
+func antiEntropyLoop(s *core.MemcachedService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		repaired, err := s.AntiEntropy(context.Background(), "", nil)
+		if err != nil {
+			logger.WithError(err).Warn("anti-entropy failed")
+			continue
+		}
+		if repaired > 0 {
+			logger.WithField("keys", repaired).Info("anti-entropy repaired keys")
+		}
+	}
+}
+
+// followPrimary keeps the service replicating the primary at address,
+// reconnecting whenever the stream fails.
+func followPrimary(s *core.MemcachedService, address string) {
+	conn, err := grpc.Dial(address,
+		grpc.WithInsecure(),
+		grpc.WithBackoffMaxDelay(3*time.Second),
+	)
+	if err != nil {
+		logger.WithError(err).Fatalf("unable to dial primary %s", address)
+	}
+	defer conn.Close()
+
+	name, _ := os.Hostname()
+	primary := pb.NewMemcachedClient(conn)
+	for {
+		err := s.Follow(context.Background(), primary, name)
+		logger.WithError(err).WithField("primary", address).Warn("replication stream ended")
+		time.Sleep(replicaRetryDelay)
+	}
+}
+
+// governLoop periodically trims caches if memory is running out.
+func governLoop(g *governor.Governor, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		g.Check()
+	}
+}
+
 func main() {
 	envflag.Parse()
 	initLogging()
@@ -67,13 +288,70 @@ func main() {
 		logger.WithError(err).Fatalf("invalid CAPACITY: %s", *capacityFlag)
 	}
 
+	hotCapacity, err := humanize.ParseBytes(*hotCacheSize)
+	if err != nil {
+		logger.WithError(err).Fatalf("invalid HOT_CACHE_SIZE: %s", *hotCacheSize)
+	}
+
+	namespaceQuotas, err := parseNamespaces(*namespaces)
+	if err != nil {
+		logger.WithError(err).Fatalf("invalid NAMESPACES: %s", *namespaces)
+	}
+
+	strategy, err := consistenthash.ParseStrategy(*hashStrategy)
+	if err != nil {
+		logger.WithError(err).Fatalf("invalid HASH_STRATEGY: %s", *hashStrategy)
+	}
+
+	peers, err := parsePeers(*clusterPeers)
+	if err != nil {
+		logger.WithError(err).Fatalf("invalid CLUSTER_PEERS: %s", *clusterPeers)
+	}
+
+	var memLimit uint64
+	if *memoryLimit != "" {
+		memLimit, err = humanize.ParseBytes(*memoryLimit)
+		if err != nil {
+			logger.WithError(err).Fatalf("invalid MEMORY_LIMIT: %s", *memoryLimit)
+		}
+	}
+
 	logger.WithFields(logrus.Fields{
-		"API_PORT":     *apiPort,
-		"CAPACITY":     *capacityFlag,
-		"LOG_LEVEL":    *loglevel,
-		"METRICS_PORT": *metricsPort,
-		"NUM_CACHES":   *cacheCount,
-		"NUM_REPLICAS": *replicas,
+		"ANTI_ENTROPY_INTERVAL":    *antiEntropy,
+		"ANTI_ENTROPY_RATE":        *antiEntropyRate,
+		"API_PORT":                 *apiPort,
+		"BOUNDED_LOAD":             *boundedLoad,
+		"CAPACITY":                 *capacityFlag,
+		"CATALOG_PATH":             *catalogPath,
+		"CLUSTER_GOSSIP":           *clusterGossip,
+		"CLUSTER_PEERS":            *clusterPeers,
+		"CLUSTER_SELF":             *clusterSelf,
+		"CLUSTER_WEIGHT":           *clusterWeight,
+		"GOSSIP_PROBE_INTERVAL":    *gossipProbe,
+		"GOSSIP_SUSPICION_TIMEOUT": *gossipSuspicion,
+		"HASH_STRATEGY":            *hashStrategy,
+		"HINTS_DIR":                *hintsDir,
+		"HINTS_MAX":                *hintsMax,
+		"HINTS_REPLAY_INTERVAL":    *hintsReplay,
+		"HINTS_TTL":                *hintsTTL,
+		"HOT_CACHE_SIZE":           *hotCacheSize,
+		"HOT_CACHE_THRESHOLD":      *hotThreshold,
+		"HOT_CACHE_WINDOW":         *hotWindow,
+		"LEASE_STALE_TTL":          *leaseStaleTTL,
+		"LEASE_TTL":                *leaseTTL,
+		"LOG_LEVEL":                *loglevel,
+		"MEMORY_CHECK_INTERVAL":    *memoryCheck,
+		"MEMORY_LIMIT":             *memoryLimit,
+		"MEMORY_THRESHOLD":         *memoryTarget,
+		"METRICS_PORT":             *metricsPort,
+		"NAMESPACES":               *namespaces,
+		"NUM_CACHES":               *cacheCount,
+		"NUM_REPLICAS":             *replicas,
+		"REBALANCE_INTERVAL":       *rebalance,
+		"REPLICA_OF":               *replicaOf,
+		"REPLICATION_BUFFER":       *replicaBuffer,
+		"TRACKING_MAX_KEYS":        *trackingMaxKeys,
+		"WATCH_BUFFER":             *watchBuffer,
 	}).Info("starting service")
 
 	apiAddr := net.JoinHostPort("0.0.0.0", strconv.Itoa(*apiPort))
@@ -82,11 +360,137 @@ func main() {
 		logger.WithError(err).Fatal("tcp Listen failed")
 	}
 
-	c := caches.New(caches.Config{
-		Capacity:   capacity,
-		CacheCount: *cacheCount,
-		Replicas:   *replicas,
+	var w *watch.Hub
+	var watchListener pools.Listener
+	if *watchBuffer > 0 {
+		w = watch.New(watch.Config{Buffer: *watchBuffer})
+		watchListener = w.Publish
+		prometheus.MustRegister(metrics.NewWatchCollector(w))
+	}
+
+	var t *tracking.Table
+	var trackingListener pools.Listener
+	if *trackingMaxKeys > 0 {
+		t = tracking.New(tracking.Config{MaxKeys: *trackingMaxKeys})
+		trackingListener = t.Publish
+		prometheus.MustRegister(metrics.NewTrackingCollector(t))
+	}
+
+	// the trees anti-entropy compares are kept up to date from the first
+	// write
+	var trees *merkle.Index
+	var treesListener pools.Listener
+	if *clusterSelf != "" {
+		trees = merkle.NewIndex(0)
+		treesListener = trees.Publish
+	}
+
+	p, err := pools.New(pools.Config{
+		Default: pools.PoolConfig{
+			Capacity:   capacity,
+			CacheCount: *cacheCount,
+			Replicas:   *replicas,
+			Namespaces: namespaceQuotas,
+
+			HashStrategy: strategy,
+			BoundedLoad:  *boundedLoad,
+		},
+		CatalogPath: *catalogPath,
+		Listener:    pools.Listeners(watchListener, trackingListener, treesListener),
+	})
+	if err != nil {
+		logger.WithError(err).Fatal("unable to create cache pools")
+	}
+
+	if *rebalance > 0 {
+		go rebalanceLoop(p, *rebalance)
+	}
+
+	g := governor.New(governor.Config{
+		Pools:     p,
+		Limit:     memLimit,
+		Threshold: *memoryTarget,
+		Logger:    logger,
 	})
+	if *memoryCheck > 0 {
+		go governLoop(g, *memoryCheck)
+	}
+
+	var c *cluster.Cluster
+	var m *membership.Membership
+	var h *hints.Store
+	var hot *hotcache.HotCache
+	var s *core.MemcachedService
+	if *clusterSelf != "" && *replicaOf != "" {
+		logger.Fatal("REPLICA_OF cannot be combined with CLUSTER_SELF")
+	}
+	if *clusterSelf != "" {
+		config := cluster.Config{
+			Self:     *clusterSelf,
+			Weight:   *clusterWeight,
+			Replicas: *replicas,
+			Logger:   logger,
+		}
+		if !*clusterGossip {
+			config.Peers = peers
+		}
+
+		c, err = cluster.New(config)
+		if err != nil {
+			logger.WithError(err).Fatal("unable to create cluster")
+		}
+		defer c.Close()
+
+		prometheus.MustRegister(metrics.NewClusterCollector(c))
+
+		h, err = hints.New(hints.Config{
+			MaxHints: *hintsMax,
+			TTL:      *hintsTTL,
+			Dir:      *hintsDir,
+			Logger:   logger,
+		})
+		if err != nil {
+			logger.WithError(err).Fatal("unable to create hint store")
+		}
+
+		prometheus.MustRegister(metrics.NewHintsCollector(h))
+
+		if hotCapacity > 0 {
+			hot = hotcache.New(hotcache.Config{
+				Capacity:  hotCapacity,
+				Threshold: *hotThreshold,
+				Window:    *hotWindow,
+			})
+			prometheus.MustRegister(metrics.NewHotCacheCollector(hot))
+		}
+	}
+
+	if c != nil && *clusterGossip {
+		seeds := make([]string, len(peers))
+		for i, peer := range peers {
+			seeds[i] = peer.Address
+		}
+
+		// members only change once started, after s is set
+		joined := func(address string) {
+			go replayHints(s, address)
+		}
+
+		m, err = membership.New(membership.Config{
+			Self:             *clusterSelf,
+			Weight:           *clusterWeight,
+			Seeds:            seeds,
+			ProbeInterval:    *gossipProbe,
+			SuspicionTimeout: *gossipSuspicion,
+			OnChange:         updatePeer(c, joined),
+			Logger:           logger,
+		})
+		if err != nil {
+			logger.WithError(err).Fatal("unable to create cluster membership")
+		}
+
+		prometheus.MustRegister(metrics.NewMembershipCollector(m))
+	}
 
 	grpc_prometheus.EnableHandlingTimeHistogram()
 
@@ -94,10 +498,45 @@ func main() {
 		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
 		grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
 	)
-	pb.RegisterMemcachedServer(grpcServer, newServer(c))
+	var feed *changes.Feed
+	if *replicaOf == "" {
+		feed = changes.New(changes.Config{Buffer: *replicaBuffer})
+		prometheus.MustRegister(metrics.NewPrimaryCollector(feed))
+	}
+
+	var l *leases.Table
+	if *leaseTTL > 0 {
+		l = leases.New(leases.Config{
+			TTL:      *leaseTTL,
+			StaleTTL: *leaseStaleTTL,
+		})
+		prometheus.MustRegister(metrics.NewLeaseCollector(l))
+	}
+
+	s = newServer(p, c, m, trees, h, hot, feed, w, t, l)
+	pb.RegisterMemcachedServer(grpcServer, s)
+	if *replicaOf != "" {
+		prometheus.MustRegister(metrics.NewReplicaCollector(s))
+		go followPrimary(s, *replicaOf)
+	}
+	if c != nil {
+		if *hintsReplay > 0 {
+			go hintsLoop(s, h, *hintsReplay)
+		}
+		pb.RegisterAntiEntropyServer(grpcServer, s)
+		prometheus.MustRegister(metrics.NewAntiEntropyCollector(s))
+		if *antiEntropy > 0 {
+			go antiEntropyLoop(s, *antiEntropy)
+		}
+	}
+	if m != nil {
+		pb.RegisterMembershipServer(grpcServer, m)
+	}
 	grpc_prometheus.Register(grpcServer)
 
-	prometheus.MustRegister(metrics.NewCacheCollector(c))
+	prometheus.MustRegister(metrics.NewCacheCollector(p))
+	prometheus.MustRegister(metrics.NewLoadCollector(p))
+	prometheus.MustRegister(metrics.NewMemoryCollector(g))
 	http.Handle("/metrics", promhttp.Handler())
 
 	// start http (metrics) server
@@ -127,12 +566,22 @@ func main() {
 		"address": apiAddr,
 	}).Info("started API server")
 
+	if m != nil {
+		m.Start()
+	}
+
 	// Wait for signal
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-sigCh
 	logger.WithField("signal", sig).Info("caught signal")
 
+	if m != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), *gossipProbe)
+		m.Leave(ctx)
+		cancel()
+	}
+
 	grpcServer.GracefulStop()
 
 	logger.Info("server exit")
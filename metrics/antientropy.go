@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core"
+)
+
+type AntiEntropyCollector struct {
+	roundsDesc          *prometheus.Desc
+	comparisonsDesc     *prometheus.Desc
+	divergentRangesDesc *prometheus.Desc
+	repairedDesc        *prometheus.Desc
+	errorsDesc          *prometheus.Desc
+
+	service *core.MemcachedService
+}
+
+func (c *AntiEntropyCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *AntiEntropyCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.service.AntiEntropyStats()
+
+	ch <- prometheus.MustNewConstMetric(c.roundsDesc, prometheus.CounterValue, float64(stats.Rounds))
+	ch <- prometheus.MustNewConstMetric(c.comparisonsDesc, prometheus.CounterValue, float64(stats.Comparisons))
+	ch <- prometheus.MustNewConstMetric(c.divergentRangesDesc, prometheus.CounterValue, float64(stats.DivergentRanges))
+	ch <- prometheus.MustNewConstMetric(c.repairedDesc, prometheus.CounterValue, float64(stats.Pulled), "pulled")
+	ch <- prometheus.MustNewConstMetric(c.repairedDesc, prometheus.CounterValue, float64(stats.Pushed), "pushed")
+	ch <- prometheus.MustNewConstMetric(c.errorsDesc, prometheus.CounterValue, float64(stats.Errors))
+}
+
+func antiEntropyStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"anti_entropy",
+		shortName,
+	)
+}
+
+func NewAntiEntropyCollector(service *core.MemcachedService) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+
+	return &AntiEntropyCollector{
+		roundsDesc: prometheus.NewDesc(
+			antiEntropyStatName("rounds_total"),
+			"Number of completed anti-entropy rounds",
+			nil,
+			constLabels,
+		),
+		comparisonsDesc: prometheus.NewDesc(
+			antiEntropyStatName("comparisons_total"),
+			"Number of namespaces compared with a peer",
+			nil,
+			constLabels,
+		),
+		divergentRangesDesc: prometheus.NewDesc(
+			antiEntropyStatName("divergent_ranges_total"),
+			"Number of key hash ranges found to differ from a peer's",
+			nil,
+			constLabels,
+		),
+		repairedDesc: prometheus.NewDesc(
+			antiEntropyStatName("keys_repaired_total"),
+			"Number of keys repaired, pulled from peers or pushed to them",
+			[]string{"direction"},
+			constLabels,
+		),
+		errorsDesc: prometheus.NewDesc(
+			antiEntropyStatName("errors_total"),
+			"Number of comparisons with a peer that failed",
+			nil,
+			constLabels,
+		),
+
+		service: service,
+	}
+}
@@ -4,20 +4,31 @@ import (
 	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core/cache"
 	"github.com/tescherm/mc/core/caches"
+	"github.com/tescherm/mc/core/pools"
 )
 
 type CacheCollector struct {
-	numEvictsDesc  *prometheus.Desc
-	numRemovesDesc *prometheus.Desc
-	numClearsDesc  *prometheus.Desc
-	numSetsDesc    *prometheus.Desc
-	numHitsDesc    *prometheus.Desc
-	numMissesDesc  *prometheus.Desc
+	numEvictsDesc         *prometheus.Desc
+	numRemovesDesc        *prometheus.Desc
+	numPatternRemovesDesc *prometheus.Desc
+	numTagRemovesDesc     *prometheus.Desc
+	numExpiresDesc        *prometheus.Desc
+	numClearsDesc         *prometheus.Desc
+	numSetsDesc           *prometheus.Desc
+	numHitsDesc           *prometheus.Desc
+	numMissesDesc         *prometheus.Desc
+	numStaleHitsDesc      *prometheus.Desc
 
-	currentCapacityDesc *prometheus.Desc
+	currentCapacityDesc   *prometheus.Desc
+	maxCapacityDesc       *prometheus.Desc
+	namespaceCapacityDesc *prometheus.Desc
+	generationDesc        *prometheus.Desc
+	rebalancedDesc        *prometheus.Desc
+	spillsDesc            *prometheus.Desc
 
-	caches *caches.Caches
+	pools *pools.Pools
 }
 
 func (c *CacheCollector) Describe(ch chan<- *prometheus.Desc) {
@@ -25,60 +36,159 @@ func (c *CacheCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *CacheCollector) Collect(ch chan<- prometheus.Metric) {
-	stats := c.caches.Stats()
+	for _, pool := range c.pools.List() {
+		c.collectPool(ch, pool.Config.Name, pool.Caches.Stats())
+	}
+}
 
-	for i, stats := range stats.Caches {
-		cacheID := strconv.Itoa(i)
+func (c *CacheCollector) collectPool(ch chan<- prometheus.Metric, pool string, stats caches.Stats) {
+	ch <- prometheus.MustNewConstMetric(
+		c.spillsDesc,
+		prometheus.CounterValue,
+		float64(stats.Spills),
+		pool,
+	)
 
+	for namespace, nsStats := range stats.Namespaces {
 		ch <- prometheus.MustNewConstMetric(
-			c.numEvictsDesc,
-			prometheus.CounterValue,
-			float64(stats.Evicts),
-			cacheID,
+			c.namespaceCapacityDesc,
+			prometheus.GaugeValue,
+			float64(nsStats.Capacity),
+			namespace,
+			pool,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			c.numRemovesDesc,
-			prometheus.CounterValue,
-			float64(stats.Removes),
-			cacheID,
+			c.generationDesc,
+			prometheus.GaugeValue,
+			float64(nsStats.Generation),
+			namespace,
+			pool,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			c.numClearsDesc,
+			c.rebalancedDesc,
 			prometheus.CounterValue,
-			float64(stats.Clears),
-			cacheID,
+			float64(nsStats.Rebalanced),
+			namespace,
+			pool,
 		)
 
-		ch <- prometheus.MustNewConstMetric(
-			c.numSetsDesc,
-			prometheus.CounterValue,
-			float64(stats.Sets),
-			cacheID,
-		)
+		for i, stats := range nsStats.Caches {
+			cacheID := strconv.Itoa(i)
+			c.collectCache(ch, stats, pool, cacheID, namespace)
+		}
+	}
+}
 
-		ch <- prometheus.MustNewConstMetric(
-			c.numHitsDesc,
-			prometheus.CounterValue,
-			float64(stats.Hits),
-			cacheID,
-		)
+func (c *CacheCollector) collectCache(ch chan<- prometheus.Metric, stats cache.Stats, pool, cacheID, namespace string) {
+	ch <- prometheus.MustNewConstMetric(
+		c.numEvictsDesc,
+		prometheus.CounterValue,
+		float64(stats.Evicts),
+		cacheID,
+		namespace,
+		pool,
+	)
 
-		ch <- prometheus.MustNewConstMetric(
-			c.numMissesDesc,
-			prometheus.CounterValue,
-			float64(stats.Misses),
-			cacheID,
-		)
+	ch <- prometheus.MustNewConstMetric(
+		c.numRemovesDesc,
+		prometheus.CounterValue,
+		float64(stats.Removes),
+		cacheID,
+		namespace,
+		pool,
+	)
 
-		ch <- prometheus.MustNewConstMetric(
-			c.currentCapacityDesc,
-			prometheus.GaugeValue,
-			float64(stats.CurrentCapacity),
-			cacheID,
-		)
-	}
+	ch <- prometheus.MustNewConstMetric(
+		c.numPatternRemovesDesc,
+		prometheus.CounterValue,
+		float64(stats.PatternRemoves),
+		cacheID,
+		namespace,
+		pool,
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		c.numTagRemovesDesc,
+		prometheus.CounterValue,
+		float64(stats.TagRemoves),
+		cacheID,
+		namespace,
+		pool,
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		c.numExpiresDesc,
+		prometheus.CounterValue,
+		float64(stats.Expires),
+		cacheID,
+		namespace,
+		pool,
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		c.numClearsDesc,
+		prometheus.CounterValue,
+		float64(stats.Clears),
+		cacheID,
+		namespace,
+		pool,
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		c.numSetsDesc,
+		prometheus.CounterValue,
+		float64(stats.Sets),
+		cacheID,
+		namespace,
+		pool,
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		c.numHitsDesc,
+		prometheus.CounterValue,
+		float64(stats.Hits),
+		cacheID,
+		namespace,
+		pool,
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		c.numMissesDesc,
+		prometheus.CounterValue,
+		float64(stats.Misses),
+		cacheID,
+		namespace,
+		pool,
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		c.numStaleHitsDesc,
+		prometheus.CounterValue,
+		float64(stats.StaleHits),
+		cacheID,
+		namespace,
+		pool,
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		c.currentCapacityDesc,
+		prometheus.GaugeValue,
+		float64(stats.CurrentCapacity),
+		cacheID,
+		namespace,
+		pool,
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		c.maxCapacityDesc,
+		prometheus.GaugeValue,
+		float64(stats.MaxCapacity),
+		cacheID,
+		namespace,
+		pool,
+	)
 }
 
 func cacheStatName(shortName string) string {
@@ -89,68 +199,140 @@ func cacheStatName(shortName string) string {
 	)
 }
 
-func NewCacheCollector(caches *caches.Caches) prometheus.Collector {
+func NewCacheCollector(pools *pools.Pools) prometheus.Collector {
 	constLabels := prometheus.Labels{}
 
 	numEvictsDesc := prometheus.NewDesc(
 		cacheStatName("evicts_total"),
 		"Number of cache evictions",
-		[]string{"cache"},
+		[]string{"cache", "namespace", "pool"},
 		constLabels,
 	)
 
 	numRemovesDesc := prometheus.NewDesc(
 		cacheStatName("removes_total"),
 		"Number of cache remove operations",
-		[]string{"cache"},
+		[]string{"cache", "namespace", "pool"},
+		constLabels,
+	)
+
+	numPatternRemovesDesc := prometheus.NewDesc(
+		cacheStatName("pattern_removes_total"),
+		"Number of keys removed by prefix or pattern deletes",
+		[]string{"cache", "namespace", "pool"},
+		constLabels,
+	)
+
+	numTagRemovesDesc := prometheus.NewDesc(
+		cacheStatName("tag_removes_total"),
+		"Number of keys removed by tag invalidations",
+		[]string{"cache", "namespace", "pool"},
+		constLabels,
+	)
+
+	numExpiresDesc := prometheus.NewDesc(
+		cacheStatName("expires_total"),
+		"Number of expired items removed",
+		[]string{"cache", "namespace", "pool"},
 		constLabels,
 	)
 
 	numClearsDesc := prometheus.NewDesc(
 		cacheStatName("clears_total"),
 		"Number of cache clear operations",
-		[]string{"cache"},
+		[]string{"cache", "namespace", "pool"},
 		constLabels,
 	)
 
 	numSetsDesc := prometheus.NewDesc(
 		cacheStatName("set_total"),
 		"Number of cache set operations",
-		[]string{"cache"},
+		[]string{"cache", "namespace", "pool"},
 		constLabels,
 	)
 
 	numHitsDesc := prometheus.NewDesc(
 		cacheStatName("hits_total"),
 		"Number of cache hits",
-		[]string{"cache"},
+		[]string{"cache", "namespace", "pool"},
 		constLabels,
 	)
 
 	numMissesDesc := prometheus.NewDesc(
 		cacheStatName("misses_total"),
 		"Number of cache misses",
-		[]string{"cache"},
+		[]string{"cache", "namespace", "pool"},
+		constLabels,
+	)
+
+	numStaleHitsDesc := prometheus.NewDesc(
+		cacheStatName("stale_hits_total"),
+		"Number of cache hits on items past their soft TTL, served stale",
+		[]string{"cache", "namespace", "pool"},
 		constLabels,
 	)
 
 	currentCapacity := prometheus.NewDesc(
 		cacheStatName("current_capacity"),
 		"The current cache capacity, in bytes",
-		[]string{"cache"},
+		[]string{"cache", "namespace", "pool"},
+		constLabels,
+	)
+
+	maxCapacity := prometheus.NewDesc(
+		cacheStatName("max_capacity"),
+		"The capacity the cache evicts down to, in bytes",
+		[]string{"cache", "namespace", "pool"},
+		constLabels,
+	)
+
+	namespaceCapacity := prometheus.NewDesc(
+		cacheStatName("namespace_capacity"),
+		"The namespace capacity quota, in bytes",
+		[]string{"namespace", "pool"},
+		constLabels,
+	)
+
+	generation := prometheus.NewDesc(
+		cacheStatName("namespace_generation"),
+		"The current namespace generation",
+		[]string{"namespace", "pool"},
+		constLabels,
+	)
+
+	rebalanced := prometheus.NewDesc(
+		cacheStatName("namespace_rebalanced_bytes_total"),
+		"Capacity moved between the namespace's caches by rebalancing, in bytes",
+		[]string{"namespace", "pool"},
+		constLabels,
+	)
+
+	spills := prometheus.NewDesc(
+		cacheStatName("bounded_load_spills_total"),
+		"Number of operations sent to a cache other than the key's owner because of bounded loads",
+		[]string{"pool"},
 		constLabels,
 	)
 
 	return &CacheCollector{
-		numEvictsDesc:  numEvictsDesc,
-		numClearsDesc:  numClearsDesc,
-		numSetsDesc:    numSetsDesc,
-		numRemovesDesc: numRemovesDesc,
-		numHitsDesc:    numHitsDesc,
-		numMissesDesc:  numMissesDesc,
+		numEvictsDesc:         numEvictsDesc,
+		numClearsDesc:         numClearsDesc,
+		numSetsDesc:           numSetsDesc,
+		numRemovesDesc:        numRemovesDesc,
+		numPatternRemovesDesc: numPatternRemovesDesc,
+		numTagRemovesDesc:     numTagRemovesDesc,
+		numExpiresDesc:        numExpiresDesc,
+		numHitsDesc:           numHitsDesc,
+		numMissesDesc:         numMissesDesc,
+		numStaleHitsDesc:      numStaleHitsDesc,
 
-		currentCapacityDesc: currentCapacity,
+		currentCapacityDesc:   currentCapacity,
+		maxCapacityDesc:       maxCapacity,
+		namespaceCapacityDesc: namespaceCapacity,
+		generationDesc:        generation,
+		rebalancedDesc:        rebalanced,
+		spillsDesc:            spills,
 
-		caches: caches,
+		pools: pools,
 	}
 }
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core/cluster"
+)
+
+type ClusterCollector struct {
+	peerWeightDesc    *prometheus.Desc
+	forwardedDesc     *prometheus.Desc
+	forwardErrorsDesc *prometheus.Desc
+	spilledDesc       *prometheus.Desc
+	readRepairsDesc   *prometheus.Desc
+	failedReadsDesc   *prometheus.Desc
+	failedWritesDesc  *prometheus.Desc
+
+	cluster *cluster.Cluster
+}
+
+func (c *ClusterCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *ClusterCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, stats := range c.cluster.Stats() {
+		ch <- prometheus.MustNewConstMetric(c.peerWeightDesc, prometheus.GaugeValue, float64(stats.Weight), stats.Address)
+		if stats.Self {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.forwardedDesc, prometheus.CounterValue, float64(stats.Forwarded), stats.Address)
+		ch <- prometheus.MustNewConstMetric(c.forwardErrorsDesc, prometheus.CounterValue, float64(stats.Errors), stats.Address)
+		ch <- prometheus.MustNewConstMetric(c.spilledDesc, prometheus.CounterValue, float64(stats.Spilled), stats.Address)
+	}
+
+	replication := c.cluster.ReplicationStats()
+	ch <- prometheus.MustNewConstMetric(c.readRepairsDesc, prometheus.CounterValue, float64(replication.ReadRepairs))
+	ch <- prometheus.MustNewConstMetric(c.failedReadsDesc, prometheus.CounterValue, float64(replication.FailedReads))
+	ch <- prometheus.MustNewConstMetric(c.failedWritesDesc, prometheus.CounterValue, float64(replication.FailedWrites))
+}
+
+func clusterStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"cluster",
+		shortName,
+	)
+}
+
+func NewClusterCollector(cluster *cluster.Cluster) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+
+	return &ClusterCollector{
+		peerWeightDesc: prometheus.NewDesc(
+			clusterStatName("peer_weight"),
+			"Relative share of keys owned by each cluster peer",
+			[]string{"peer"},
+			constLabels,
+		),
+		forwardedDesc: prometheus.NewDesc(
+			clusterStatName("forwarded_total"),
+			"Number of requests forwarded to the peer owning their key",
+			[]string{"peer"},
+			constLabels,
+		),
+		forwardErrorsDesc: prometheus.NewDesc(
+			clusterStatName("forward_errors_total"),
+			"Number of forwarded requests that failed",
+			[]string{"peer"},
+			constLabels,
+		),
+		spilledDesc: prometheus.NewDesc(
+			clusterStatName("spilled_total"),
+			"Number of reads sent to the peer because their key's owner was over its load bound",
+			[]string{"peer"},
+			constLabels,
+		),
+		readRepairsDesc: prometheus.NewDesc(
+			clusterStatName("read_repairs_total"),
+			"Number of replicas repaired after a quorum read found them behind",
+			nil,
+			constLabels,
+		),
+		failedReadsDesc: prometheus.NewDesc(
+			clusterStatName("read_quorum_failures_total"),
+			"Number of replicated reads that did not reach their read quorum",
+			nil,
+			constLabels,
+		),
+		failedWritesDesc: prometheus.NewDesc(
+			clusterStatName("write_quorum_failures_total"),
+			"Number of replicated writes that did not reach their write quorum",
+			nil,
+			constLabels,
+		),
+
+		cluster: cluster,
+	}
+}
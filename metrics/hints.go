@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core/hints"
+)
+
+type HintsCollector struct {
+	queuedDesc   *prometheus.Desc
+	storedDesc   *prometheus.Desc
+	replayedDesc *prometheus.Desc
+	expiredDesc  *prometheus.Desc
+	droppedDesc  *prometheus.Desc
+
+	store *hints.Store
+}
+
+func (c *HintsCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *HintsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.store.Stats()
+
+	for peer, queued := range stats.Queued {
+		ch <- prometheus.MustNewConstMetric(c.queuedDesc, prometheus.GaugeValue, float64(queued), peer)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.storedDesc, prometheus.CounterValue, float64(stats.Stored))
+	ch <- prometheus.MustNewConstMetric(c.replayedDesc, prometheus.CounterValue, float64(stats.Replayed))
+	ch <- prometheus.MustNewConstMetric(c.expiredDesc, prometheus.CounterValue, float64(stats.Expired))
+	ch <- prometheus.MustNewConstMetric(c.droppedDesc, prometheus.CounterValue, float64(stats.Dropped))
+}
+
+func hintsStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"hints",
+		shortName,
+	)
+}
+
+func NewHintsCollector(store *hints.Store) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+
+	return &HintsCollector{
+		queuedDesc: prometheus.NewDesc(
+			hintsStatName("queued"),
+			"Number of hints waiting to be replayed to each peer",
+			[]string{"peer"},
+			constLabels,
+		),
+		storedDesc: prometheus.NewDesc(
+			hintsStatName("stored_total"),
+			"Number of writes stored as hints for a peer that could not be reached",
+			nil,
+			constLabels,
+		),
+		replayedDesc: prometheus.NewDesc(
+			hintsStatName("replayed_total"),
+			"Number of hints replayed to their peer",
+			nil,
+			constLabels,
+		),
+		expiredDesc: prometheus.NewDesc(
+			hintsStatName("expired_total"),
+			"Number of hints dropped after their peer stayed away too long",
+			nil,
+			constLabels,
+		),
+		droppedDesc: prometheus.NewDesc(
+			hintsStatName("dropped_total"),
+			"Number of hints dropped because the hint store was full",
+			nil,
+			constLabels,
+		),
+
+		store: store,
+	}
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core/hotcache"
+)
+
+type HotCacheCollector struct {
+	hitsDesc            *prometheus.Desc
+	missesDesc          *prometheus.Desc
+	hitRatioDesc        *prometheus.Desc
+	promotionsDesc      *prometheus.Desc
+	invalidationsDesc   *prometheus.Desc
+	itemsDesc           *prometheus.Desc
+	currentCapacityDesc *prometheus.Desc
+	maxCapacityDesc     *prometheus.Desc
+
+	hot *hotcache.HotCache
+}
+
+func (c *HotCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *HotCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.hot.Stats()
+
+	var ratio float64
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		ratio = float64(stats.Hits) / float64(lookups)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.hitsDesc, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.missesDesc, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.hitRatioDesc, prometheus.GaugeValue, ratio)
+	ch <- prometheus.MustNewConstMetric(c.promotionsDesc, prometheus.CounterValue, float64(stats.Promotions))
+	ch <- prometheus.MustNewConstMetric(c.invalidationsDesc, prometheus.CounterValue, float64(stats.Invalidations))
+	ch <- prometheus.MustNewConstMetric(c.itemsDesc, prometheus.GaugeValue, float64(stats.Items))
+	ch <- prometheus.MustNewConstMetric(c.currentCapacityDesc, prometheus.GaugeValue, float64(stats.CurrentCapacity))
+	ch <- prometheus.MustNewConstMetric(c.maxCapacityDesc, prometheus.GaugeValue, float64(stats.MaxCapacity))
+}
+
+func hotCacheStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"hot_cache",
+		shortName,
+	)
+}
+
+func NewHotCacheCollector(hot *hotcache.HotCache) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+
+	return &HotCacheCollector{
+		hitsDesc: prometheus.NewDesc(
+			hotCacheStatName("hits_total"),
+			"Number of reads of keys owned by other peers served from a local copy",
+			nil,
+			constLabels,
+		),
+		missesDesc: prometheus.NewDesc(
+			hotCacheStatName("misses_total"),
+			"Number of reads of keys owned by other peers forwarded to the owner",
+			nil,
+			constLabels,
+		),
+		hitRatioDesc: prometheus.NewDesc(
+			hotCacheStatName("hit_ratio"),
+			"Fraction of reads of keys owned by other peers served from a local copy",
+			nil,
+			constLabels,
+		),
+		promotionsDesc: prometheus.NewDesc(
+			hotCacheStatName("promotions_total"),
+			"Number of copies kept of hot keys",
+			nil,
+			constLabels,
+		),
+		invalidationsDesc: prometheus.NewDesc(
+			hotCacheStatName("invalidations_total"),
+			"Number of copies dropped because their key changed",
+			nil,
+			constLabels,
+		),
+		itemsDesc: prometheus.NewDesc(
+			hotCacheStatName("items"),
+			"Number of copies of hot keys held",
+			nil,
+			constLabels,
+		),
+		currentCapacityDesc: prometheus.NewDesc(
+			hotCacheStatName("current_capacity"),
+			"Size of the copies of hot keys held, in bytes",
+			nil,
+			constLabels,
+		),
+		maxCapacityDesc: prometheus.NewDesc(
+			hotCacheStatName("max_capacity"),
+			"Most the copies of hot keys may take, in bytes",
+			nil,
+			constLabels,
+		),
+
+		hot: hot,
+	}
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core/leases"
+)
+
+type LeaseCollector struct {
+	leasesDesc   *prometheus.Desc
+	grantedDesc  *prometheus.Desc
+	waitsDesc    *prometheus.Desc
+	staleDesc    *prometheus.Desc
+	refreshDesc  *prometheus.Desc
+	filledDesc   *prometheus.Desc
+	rejectedDesc *prometheus.Desc
+
+	table *leases.Table
+}
+
+func (c *LeaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *LeaseCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.table.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.leasesDesc, prometheus.GaugeValue, float64(stats.Leases))
+	ch <- prometheus.MustNewConstMetric(c.grantedDesc, prometheus.CounterValue, float64(stats.Granted))
+	ch <- prometheus.MustNewConstMetric(c.waitsDesc, prometheus.CounterValue, float64(stats.Waits))
+	ch <- prometheus.MustNewConstMetric(c.staleDesc, prometheus.CounterValue, float64(stats.Stale))
+	ch <- prometheus.MustNewConstMetric(c.refreshDesc, prometheus.CounterValue, float64(stats.Refreshes))
+	ch <- prometheus.MustNewConstMetric(c.filledDesc, prometheus.CounterValue, float64(stats.Filled))
+	ch <- prometheus.MustNewConstMetric(c.rejectedDesc, prometheus.CounterValue, float64(stats.Rejected))
+}
+
+func leaseStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"leases",
+		shortName,
+	)
+}
+
+func NewLeaseCollector(table *leases.Table) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+
+	return &LeaseCollector{
+		leasesDesc: prometheus.NewDesc(
+			leaseStatName("active"),
+			"Number of keys with a live lease",
+			nil,
+			constLabels,
+		),
+		grantedDesc: prometheus.NewDesc(
+			leaseStatName("granted_total"),
+			"Number of leases granted on missed keys",
+			nil,
+			constLabels,
+		),
+		waitsDesc: prometheus.NewDesc(
+			leaseStatName("waits_total"),
+			"Number of misses told to wait for another client's lease",
+			nil,
+			constLabels,
+		),
+		staleDesc: prometheus.NewDesc(
+			leaseStatName("stale_total"),
+			"Number of waits given the stale value of a removed key",
+			nil,
+			constLabels,
+		),
+		refreshDesc: prometheus.NewDesc(
+			leaseStatName("refreshes_total"),
+			"Number of leases granted to refresh stale keys",
+			nil,
+			constLabels,
+		),
+		filledDesc: prometheus.NewDesc(
+			leaseStatName("filled_total"),
+			"Number of keys set with a valid lease",
+			nil,
+			constLabels,
+		),
+		rejectedDesc: prometheus.NewDesc(
+			leaseStatName("rejected_total"),
+			"Number of sets rejected because their lease was no longer valid",
+			nil,
+			constLabels,
+		),
+		table: table,
+	}
+}
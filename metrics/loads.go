@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core/caches"
+	"github.com/tescherm/mc/core/pools"
+)
+
+type LoadCollector struct {
+	loadsDesc        *prometheus.Desc
+	coalescedDesc    *prometheus.Desc
+	notFoundDesc     *prometheus.Desc
+	negativeHitsDesc *prometheus.Desc
+	errorsDesc       *prometheus.Desc
+	discardedDesc    *prometheus.Desc
+	durationDesc     *prometheus.Desc
+
+	pools *pools.Pools
+}
+
+func (c *LoadCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *LoadCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, pool := range c.pools.List() {
+		if !pool.Caches.Loads() {
+			continue
+		}
+		c.collectPool(ch, pool.Config.Name, pool.Caches.Stats().Loads)
+	}
+}
+
+func (c *LoadCollector) collectPool(ch chan<- prometheus.Metric, pool string, stats caches.LoadStats) {
+	ch <- prometheus.MustNewConstMetric(c.loadsDesc, prometheus.CounterValue, float64(stats.Loads), pool)
+	ch <- prometheus.MustNewConstMetric(c.coalescedDesc, prometheus.CounterValue, float64(stats.Coalesced), pool)
+	ch <- prometheus.MustNewConstMetric(c.notFoundDesc, prometheus.CounterValue, float64(stats.NotFound), pool)
+	ch <- prometheus.MustNewConstMetric(c.negativeHitsDesc, prometheus.CounterValue, float64(stats.NegativeHits), pool)
+	ch <- prometheus.MustNewConstMetric(c.errorsDesc, prometheus.CounterValue, float64(stats.Errors), pool)
+	ch <- prometheus.MustNewConstMetric(c.discardedDesc, prometheus.CounterValue, float64(stats.Discarded), pool)
+
+	buckets := make(map[float64]uint64, len(caches.LoadBuckets))
+	for i, bound := range caches.LoadBuckets {
+		buckets[bound] = stats.Latency[i]
+	}
+	ch <- prometheus.MustNewConstHistogram(c.durationDesc, stats.Loads, stats.LatencySum, buckets, pool)
+}
+
+func loadStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"loads",
+		shortName,
+	)
+}
+
+func NewLoadCollector(pools *pools.Pools) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+	labels := []string{"pool"}
+
+	return &LoadCollector{
+		loadsDesc: prometheus.NewDesc(
+			loadStatName("total"),
+			"Number of missed keys loaded from the pool's origin",
+			labels,
+			constLabels,
+		),
+		coalescedDesc: prometheus.NewDesc(
+			loadStatName("coalesced_total"),
+			"Number of misses that waited on another miss's load of the key",
+			labels,
+			constLabels,
+		),
+		notFoundDesc: prometheus.NewDesc(
+			loadStatName("not_found_total"),
+			"Number of loads of keys the origin does not have",
+			labels,
+			constLabels,
+		),
+		negativeHitsDesc: prometheus.NewDesc(
+			loadStatName("negative_hits_total"),
+			"Number of misses of keys remembered as not in the origin",
+			labels,
+			constLabels,
+		),
+		errorsDesc: prometheus.NewDesc(
+			loadStatName("errors_total"),
+			"Number of loads that failed or timed out",
+			labels,
+			constLabels,
+		),
+		discardedDesc: prometheus.NewDesc(
+			loadStatName("discarded_total"),
+			"Number of loaded items not cached because the key was written during the load",
+			labels,
+			constLabels,
+		),
+		durationDesc: prometheus.NewDesc(
+			loadStatName("duration_seconds"),
+			"Latency of the loads from the pool's origin",
+			labels,
+			constLabels,
+		),
+		pools: pools,
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core/membership"
+)
+
+var memberStates = []membership.State{
+	membership.Alive,
+	membership.Suspect,
+	membership.Dead,
+	membership.Left,
+}
+
+type MembershipCollector struct {
+	membersDesc      *prometheus.Desc
+	memberStateDesc  *prometheus.Desc
+	probesDesc       *prometheus.Desc
+	failedProbesDesc *prometheus.Desc
+	suspicionsDesc   *prometheus.Desc
+	refutationsDesc  *prometheus.Desc
+
+	membership *membership.Membership
+}
+
+func (c *MembershipCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *MembershipCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.membership.Stats()
+
+	for _, state := range memberStates {
+		ch <- prometheus.MustNewConstMetric(c.membersDesc, prometheus.GaugeValue, float64(stats.Members[state]), state.String())
+	}
+
+	for _, member := range c.membership.Members() {
+		ch <- prometheus.MustNewConstMetric(c.memberStateDesc, prometheus.GaugeValue, float64(member.State), member.Address)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.probesDesc, prometheus.CounterValue, float64(stats.Probes))
+	ch <- prometheus.MustNewConstMetric(c.failedProbesDesc, prometheus.CounterValue, float64(stats.FailedProbes))
+	ch <- prometheus.MustNewConstMetric(c.suspicionsDesc, prometheus.CounterValue, float64(stats.Suspicions))
+	ch <- prometheus.MustNewConstMetric(c.refutationsDesc, prometheus.CounterValue, float64(stats.Refutations))
+}
+
+func membershipStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"membership",
+		shortName,
+	)
+}
+
+func NewMembershipCollector(membership *membership.Membership) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+
+	return &MembershipCollector{
+		membersDesc: prometheus.NewDesc(
+			membershipStatName("members"),
+			"Number of cluster members, including this node, in each state",
+			[]string{"state"},
+			constLabels,
+		),
+		memberStateDesc: prometheus.NewDesc(
+			membershipStatName("member_state"),
+			"State of each cluster member: 0 alive, 1 suspect, 2 dead, 3 left",
+			[]string{"member"},
+			constLabels,
+		),
+		probesDesc: prometheus.NewDesc(
+			membershipStatName("probes_total"),
+			"Number of failure detection probes sent",
+			nil,
+			constLabels,
+		),
+		failedProbesDesc: prometheus.NewDesc(
+			membershipStatName("failed_probes_total"),
+			"Number of probes that got no direct or indirect response",
+			nil,
+			constLabels,
+		),
+		suspicionsDesc: prometheus.NewDesc(
+			membershipStatName("suspicions_total"),
+			"Number of times a member became suspect",
+			nil,
+			constLabels,
+		),
+		refutationsDesc: prometheus.NewDesc(
+			membershipStatName("refutations_total"),
+			"Number of times this node refuted suspicion of its own failure",
+			nil,
+			constLabels,
+		),
+
+		membership: membership,
+	}
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core/governor"
+)
+
+type MemoryCollector struct {
+	limitDesc        *prometheus.Desc
+	usageDesc        *prometheus.Desc
+	heapInuseDesc    *prometheus.Desc
+	cgroupUsageDesc  *prometheus.Desc
+	cacheSizeDesc    *prometheus.Desc
+	trimsDesc        *prometheus.Desc
+	trimmedBytesDesc *prometheus.Desc
+
+	governor *governor.Governor
+}
+
+func (c *MemoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *MemoryCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.governor.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.limitDesc, prometheus.GaugeValue, float64(stats.Limit))
+	ch <- prometheus.MustNewConstMetric(c.usageDesc, prometheus.GaugeValue, float64(stats.Usage))
+	ch <- prometheus.MustNewConstMetric(c.heapInuseDesc, prometheus.GaugeValue, float64(stats.HeapInuse))
+	ch <- prometheus.MustNewConstMetric(c.cgroupUsageDesc, prometheus.GaugeValue, float64(stats.CgroupUsage))
+	ch <- prometheus.MustNewConstMetric(c.cacheSizeDesc, prometheus.GaugeValue, float64(stats.CacheSize))
+	ch <- prometheus.MustNewConstMetric(c.trimsDesc, prometheus.CounterValue, float64(stats.Trims))
+	ch <- prometheus.MustNewConstMetric(c.trimmedBytesDesc, prometheus.CounterValue, float64(stats.TrimmedBytes))
+}
+
+func memoryStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"memory",
+		shortName,
+	)
+}
+
+func NewMemoryCollector(governor *governor.Governor) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+
+	return &MemoryCollector{
+		limitDesc: prometheus.NewDesc(
+			memoryStatName("limit_bytes"),
+			"The memory limit the governor enforces, in bytes; zero if none",
+			nil,
+			constLabels,
+		),
+		usageDesc: prometheus.NewDesc(
+			memoryStatName("usage_bytes"),
+			"Memory held by the process, in bytes",
+			nil,
+			constLabels,
+		),
+		heapInuseDesc: prometheus.NewDesc(
+			memoryStatName("heap_inuse_bytes"),
+			"Bytes in in-use heap spans",
+			nil,
+			constLabels,
+		),
+		cgroupUsageDesc: prometheus.NewDesc(
+			memoryStatName("cgroup_usage_bytes"),
+			"Memory usage reported by the process cgroup, in bytes",
+			nil,
+			constLabels,
+		),
+		cacheSizeDesc: prometheus.NewDesc(
+			memoryStatName("cache_bytes"),
+			"The logical size of every cache, in bytes",
+			nil,
+			constLabels,
+		),
+		trimsDesc: prometheus.NewDesc(
+			memoryStatName("trims_total"),
+			"Number of times caches were trimmed to relieve memory pressure",
+			nil,
+			constLabels,
+		),
+		trimmedBytesDesc: prometheus.NewDesc(
+			memoryStatName("trimmed_bytes_total"),
+			"Bytes evicted to relieve memory pressure",
+			nil,
+			constLabels,
+		),
+
+		governor: governor,
+	}
+}
@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core"
+	"github.com/tescherm/mc/core/changes"
+)
+
+type ReplicaCollector struct {
+	connectedDesc    *prometheus.Desc
+	lagDesc          *prometheus.Desc
+	lagMutationsDesc *prometheus.Desc
+	appliedDesc      *prometheus.Desc
+	snapshotsDesc    *prometheus.Desc
+	errorsDesc       *prometheus.Desc
+
+	service *core.MemcachedService
+}
+
+func (c *ReplicaCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *ReplicaCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.service.ReplicaStats()
+
+	var connected float64
+	if stats.Connected {
+		connected = 1
+	}
+	var behind uint64
+	if stats.Latest > stats.Sequence {
+		behind = stats.Latest - stats.Sequence
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.connectedDesc, prometheus.GaugeValue, connected)
+	ch <- prometheus.MustNewConstMetric(c.lagDesc, prometheus.GaugeValue, stats.Lag.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.lagMutationsDesc, prometheus.GaugeValue, float64(behind))
+	ch <- prometheus.MustNewConstMetric(c.appliedDesc, prometheus.CounterValue, float64(stats.Applied))
+	ch <- prometheus.MustNewConstMetric(c.snapshotsDesc, prometheus.CounterValue, float64(stats.Snapshots))
+	ch <- prometheus.MustNewConstMetric(c.errorsDesc, prometheus.CounterValue, float64(stats.Errors))
+}
+
+func replicaStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"replica",
+		shortName,
+	)
+}
+
+func NewReplicaCollector(service *core.MemcachedService) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+
+	return &ReplicaCollector{
+		connectedDesc: prometheus.NewDesc(
+			replicaStatName("connected"),
+			"Whether the replica is following its primary",
+			nil,
+			constLabels,
+		),
+		lagDesc: prometheus.NewDesc(
+			replicaStatName("lag_seconds"),
+			"How long before it was received the primary applied the latest replicated mutation, or sent the latest heartbeat",
+			nil,
+			constLabels,
+		),
+		lagMutationsDesc: prometheus.NewDesc(
+			replicaStatName("lag_mutations"),
+			"Number of mutations the primary has applied that the replica has not",
+			nil,
+			constLabels,
+		),
+		appliedDesc: prometheus.NewDesc(
+			replicaStatName("applied_total"),
+			"Number of replicated mutations applied, including snapshot items",
+			nil,
+			constLabels,
+		),
+		snapshotsDesc: prometheus.NewDesc(
+			replicaStatName("snapshots_total"),
+			"Number of snapshots of the primary loaded",
+			nil,
+			constLabels,
+		),
+		errorsDesc: prometheus.NewDesc(
+			replicaStatName("errors_total"),
+			"Number of replicated mutations that could not be applied",
+			nil,
+			constLabels,
+		),
+
+		service: service,
+	}
+}
+
+type PrimaryCollector struct {
+	sequenceDesc *prometheus.Desc
+	replicasDesc *prometheus.Desc
+	droppedDesc  *prometheus.Desc
+
+	feed *changes.Feed
+}
+
+func (c *PrimaryCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *PrimaryCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.feed.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.sequenceDesc, prometheus.CounterValue, float64(stats.Sequence))
+	ch <- prometheus.MustNewConstMetric(c.replicasDesc, prometheus.GaugeValue, float64(stats.Subscribers))
+	ch <- prometheus.MustNewConstMetric(c.droppedDesc, prometheus.CounterValue, float64(stats.Dropped))
+}
+
+func primaryStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"primary",
+		shortName,
+	)
+}
+
+func NewPrimaryCollector(feed *changes.Feed) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+
+	return &PrimaryCollector{
+		sequenceDesc: prometheus.NewDesc(
+			primaryStatName("mutations_total"),
+			"Number of mutations published to replicas",
+			nil,
+			constLabels,
+		),
+		replicasDesc: prometheus.NewDesc(
+			primaryStatName("replicas"),
+			"Number of replicas following the primary",
+			nil,
+			constLabels,
+		),
+		droppedDesc: prometheus.NewDesc(
+			primaryStatName("replicas_dropped_total"),
+			"Number of replicas dropped for falling too far behind",
+			nil,
+			constLabels,
+		),
+
+		feed: feed,
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core/tracking"
+)
+
+type TrackingCollector struct {
+	clientsDesc       *prometheus.Desc
+	keysDesc          *prometheus.Desc
+	invalidationsDesc *prometheus.Desc
+	flushesDesc       *prometheus.Desc
+	droppedDesc       *prometheus.Desc
+
+	table *tracking.Table
+}
+
+func (c *TrackingCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *TrackingCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.table.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.clientsDesc, prometheus.GaugeValue, float64(stats.Clients))
+	ch <- prometheus.MustNewConstMetric(c.keysDesc, prometheus.GaugeValue, float64(stats.Keys))
+	ch <- prometheus.MustNewConstMetric(c.invalidationsDesc, prometheus.CounterValue, float64(stats.Invalidations))
+	ch <- prometheus.MustNewConstMetric(c.flushesDesc, prometheus.CounterValue, float64(stats.Flushes))
+	ch <- prometheus.MustNewConstMetric(c.droppedDesc, prometheus.CounterValue, float64(stats.Dropped))
+}
+
+func trackingStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"tracking",
+		shortName,
+	)
+}
+
+func NewTrackingCollector(table *tracking.Table) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+
+	return &TrackingCollector{
+		clientsDesc: prometheus.NewDesc(
+			trackingStatName("clients"),
+			"Number of clients whose reads are tracked",
+			nil,
+			constLabels,
+		),
+		keysDesc: prometheus.NewDesc(
+			trackingStatName("keys"),
+			"Number of keys tracked for clients",
+			nil,
+			constLabels,
+		),
+		invalidationsDesc: prometheus.NewDesc(
+			trackingStatName("invalidations_total"),
+			"Number of invalidations sent to clients",
+			nil,
+			constLabels,
+		),
+		flushesDesc: prometheus.NewDesc(
+			trackingStatName("flushes_total"),
+			"Number of times a client fell behind and was told to drop every key",
+			nil,
+			constLabels,
+		),
+		droppedDesc: prometheus.NewDesc(
+			trackingStatName("dropped_total"),
+			"Number of keys invalidated to keep within the tracking table size",
+			nil,
+			constLabels,
+		),
+		table: table,
+	}
+}
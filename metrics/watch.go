@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tescherm/mc/core/watch"
+)
+
+type WatchCollector struct {
+	watchersDesc  *prometheus.Desc
+	eventsDesc    *prometheus.Desc
+	overflowsDesc *prometheus.Desc
+
+	hub *watch.Hub
+}
+
+func (c *WatchCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *WatchCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.hub.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.watchersDesc, prometheus.GaugeValue, float64(stats.Watchers))
+	ch <- prometheus.MustNewConstMetric(c.eventsDesc, prometheus.CounterValue, float64(stats.Events))
+	ch <- prometheus.MustNewConstMetric(c.overflowsDesc, prometheus.CounterValue, float64(stats.Overflows))
+}
+
+func watchStatName(shortName string) string {
+	return prometheus.BuildFQName(
+		"mc",
+		"watch",
+		shortName,
+	)
+}
+
+func NewWatchCollector(hub *watch.Hub) prometheus.Collector {
+	constLabels := prometheus.Labels{}
+
+	return &WatchCollector{
+		watchersDesc: prometheus.NewDesc(
+			watchStatName("watchers"),
+			"Number of open watches",
+			nil,
+			constLabels,
+		),
+		eventsDesc: prometheus.NewDesc(
+			watchStatName("events_total"),
+			"Number of events delivered to watches",
+			nil,
+			constLabels,
+		),
+		overflowsDesc: prometheus.NewDesc(
+			watchStatName("overflows_total"),
+			"Number of times a watch fell behind and dropped events",
+			nil,
+			constLabels,
+		),
+		hub: hub,
+	}
+}
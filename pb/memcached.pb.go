@@ -25,10 +25,226 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type Job_State int32
+
+const (
+	Job_RUNNING   Job_State = 0
+	Job_DONE      Job_State = 1
+	Job_CANCELLED Job_State = 2
+	Job_FAILED    Job_State = 3
+)
+
+var Job_State_name = map[int32]string{
+	0: "RUNNING",
+	1: "DONE",
+	2: "CANCELLED",
+	3: "FAILED",
+}
+
+var Job_State_value = map[string]int32{
+	"RUNNING":   0,
+	"DONE":      1,
+	"CANCELLED": 2,
+	"FAILED":    3,
+}
+
+func (x Job_State) String() string {
+	return proto.EnumName(Job_State_name, int32(x))
+}
+
+func (Job_State) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{15, 0}
+}
+
+type PoolConfig_EvictionPolicy int32
+
+const (
+	PoolConfig_LRU  PoolConfig_EvictionPolicy = 0
+	PoolConfig_FIFO PoolConfig_EvictionPolicy = 1
+)
+
+var PoolConfig_EvictionPolicy_name = map[int32]string{
+	0: "LRU",
+	1: "FIFO",
+}
+
+var PoolConfig_EvictionPolicy_value = map[string]int32{
+	"LRU":  0,
+	"FIFO": 1,
+}
+
+func (x PoolConfig_EvictionPolicy) String() string {
+	return proto.EnumName(PoolConfig_EvictionPolicy_name, int32(x))
+}
+
+func (PoolConfig_EvictionPolicy) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{28, 0}
+}
+
+type PoolConfig_HashStrategy int32
+
+const (
+	PoolConfig_RING       PoolConfig_HashStrategy = 0
+	PoolConfig_JUMP       PoolConfig_HashStrategy = 1
+	PoolConfig_RENDEZVOUS PoolConfig_HashStrategy = 2
+	PoolConfig_MAGLEV     PoolConfig_HashStrategy = 3
+)
+
+var PoolConfig_HashStrategy_name = map[int32]string{
+	0: "RING",
+	1: "JUMP",
+	2: "RENDEZVOUS",
+	3: "MAGLEV",
+}
+
+var PoolConfig_HashStrategy_value = map[string]int32{
+	"RING":       0,
+	"JUMP":       1,
+	"RENDEZVOUS": 2,
+	"MAGLEV":     3,
+}
+
+func (x PoolConfig_HashStrategy) String() string {
+	return proto.EnumName(PoolConfig_HashStrategy_name, int32(x))
+}
+
+func (PoolConfig_HashStrategy) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{28, 1}
+}
+
+type Member_State int32
+
+const (
+	Member_ALIVE   Member_State = 0
+	Member_SUSPECT Member_State = 1
+	Member_DEAD    Member_State = 2
+	Member_LEFT    Member_State = 3
+)
+
+var Member_State_name = map[int32]string{
+	0: "ALIVE",
+	1: "SUSPECT",
+	2: "DEAD",
+	3: "LEFT",
+}
+
+var Member_State_value = map[string]int32{
+	"ALIVE":   0,
+	"SUSPECT": 1,
+	"DEAD":    2,
+	"LEFT":    3,
+}
+
+func (x Member_State) String() string {
+	return proto.EnumName(Member_State_name, int32(x))
+}
+
+func (Member_State) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{42, 0}
+}
+
+type Mutation_Op int32
+
+const (
+	Mutation_SET             Mutation_Op = 0
+	Mutation_REMOVE          Mutation_Op = 1
+	Mutation_CLEAR_NAMESPACE Mutation_Op = 2
+	Mutation_INVALIDATE_TAGS Mutation_Op = 3
+	Mutation_SET_GENERATION  Mutation_Op = 4
+	Mutation_DELETE_MATCHING Mutation_Op = 5
+	Mutation_CREATE_POOL     Mutation_Op = 6
+	Mutation_DELETE_POOL     Mutation_Op = 7
+)
+
+var Mutation_Op_name = map[int32]string{
+	0: "SET",
+	1: "REMOVE",
+	2: "CLEAR_NAMESPACE",
+	3: "INVALIDATE_TAGS",
+	4: "SET_GENERATION",
+	5: "DELETE_MATCHING",
+	6: "CREATE_POOL",
+	7: "DELETE_POOL",
+}
+
+var Mutation_Op_value = map[string]int32{
+	"SET":             0,
+	"REMOVE":          1,
+	"CLEAR_NAMESPACE": 2,
+	"INVALIDATE_TAGS": 3,
+	"SET_GENERATION":  4,
+	"DELETE_MATCHING": 5,
+	"CREATE_POOL":     6,
+	"DELETE_POOL":     7,
+}
+
+func (x Mutation_Op) String() string {
+	return proto.EnumName(Mutation_Op_name, int32(x))
+}
+
+func (Mutation_Op) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{64, 0}
+}
+
+type WatchEvent_Type int32
+
+const (
+	WatchEvent_SET    WatchEvent_Type = 0
+	WatchEvent_REMOVE WatchEvent_Type = 1
+	WatchEvent_EVICT  WatchEvent_Type = 2
+	WatchEvent_EXPIRE WatchEvent_Type = 3
+	// CLEAR reports that every key in the namespace was removed, by a
+	// clear or a generation change
+	WatchEvent_CLEAR WatchEvent_Type = 4
+	// OVERFLOW reports that the watcher fell behind and missed events
+	WatchEvent_OVERFLOW WatchEvent_Type = 5
+)
+
+var WatchEvent_Type_name = map[int32]string{
+	0: "SET",
+	1: "REMOVE",
+	2: "EVICT",
+	3: "EXPIRE",
+	4: "CLEAR",
+	5: "OVERFLOW",
+}
+
+var WatchEvent_Type_value = map[string]int32{
+	"SET":      0,
+	"REMOVE":   1,
+	"EVICT":    2,
+	"EXPIRE":   3,
+	"CLEAR":    4,
+	"OVERFLOW": 5,
+}
+
+func (x WatchEvent_Type) String() string {
+	return proto.EnumName(WatchEvent_Type_name, int32(x))
+}
+
+func (WatchEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{68, 0}
+}
+
 type Item struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value                []byte   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
-	CasID                int64    `protobuf:"varint,3,opt,name=casID,proto3" json:"casID,omitempty"`
+	Key   string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value []byte   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	CasID int64    `protobuf:"varint,3,opt,name=casID,proto3" json:"casID,omitempty"`
+	Tags  []string `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	// timestamp is the hybrid logical clock time of a replicated write; the
+	// latest write to a key wins
+	Timestamp uint64 `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// deleted marks a replicated remove. Deleted items are only returned to
+	// coordinating nodes, never to clients.
+	Deleted bool `protobuf:"varint,6,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// delta is how long the item took to compute, in nanoseconds, which
+	// weighs the chance of recomputing it early; zero if unknown
+	Delta int64 `protobuf:"varint,7,opt,name=delta,proto3" json:"delta,omitempty"`
+	// expiresAt and softExpiresAt are when a read item expires and becomes
+	// stale, in unix nanoseconds; zero if never. They are set by the pool's
+	// ttls, and ignored on writes.
+	ExpiresAt            int64    `protobuf:"varint,8,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
+	SoftExpiresAt        int64    `protobuf:"varint,9,opt,name=softExpiresAt,proto3" json:"softExpiresAt,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -80,8 +296,66 @@ func (m *Item) GetCasID() int64 {
 	return 0
 }
 
+func (m *Item) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Item) GetTimestamp() uint64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *Item) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
+func (m *Item) GetDelta() int64 {
+	if m != nil {
+		return m.Delta
+	}
+	return 0
+}
+
+func (m *Item) GetExpiresAt() int64 {
+	if m != nil {
+		return m.ExpiresAt
+	}
+	return 0
+}
+
+func (m *Item) GetSoftExpiresAt() int64 {
+	if m != nil {
+		return m.SoftExpiresAt
+	}
+	return 0
+}
+
 type GetRequest struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// namespace selects the key space. When empty, the mc-namespace request
+	// metadata is used, falling back to the default namespace.
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// pool selects the cache pool. When empty, the mc-pool request metadata
+	// is used, falling back to the default pool.
+	Pool string `protobuf:"bytes,3,opt,name=pool,proto3" json:"pool,omitempty"`
+	// readQuorum is the number of replicas that must respond to a read of a
+	// replicated pool. When zero, the pool's read quorum is used.
+	ReadQuorum int32 `protobuf:"varint,4,opt,name=readQuorum,proto3" json:"readQuorum,omitempty"`
+	// lease asks for a lease token on a miss, so that only one client fills
+	// the key
+	Lease bool `protobuf:"varint,5,opt,name=lease,proto3" json:"lease,omitempty"`
+	// beta, if positive, asks whether to recompute the item before it
+	// expires, by the XFetch algorithm. It scales the chance of doing so;
+	// 1 is the optimum.
+	Beta                 float64  `protobuf:"fixed64,6,opt,name=beta,proto3" json:"beta,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -119,8 +393,57 @@ func (m *GetRequest) GetKey() string {
 	return ""
 }
 
+func (m *GetRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *GetRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *GetRequest) GetReadQuorum() int32 {
+	if m != nil {
+		return m.ReadQuorum
+	}
+	return 0
+}
+
+func (m *GetRequest) GetLease() bool {
+	if m != nil {
+		return m.Lease
+	}
+	return false
+}
+
+func (m *GetRequest) GetBeta() float64 {
+	if m != nil {
+		return m.Beta
+	}
+	return 0
+}
+
 type GetResponse struct {
-	Item                 *Item    `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	Item *Item `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	// lease is the token to set the missed key with, if the client was
+	// granted its lease, or to refresh the stale item with
+	Lease uint64 `protobuf:"varint,2,opt,name=lease,proto3" json:"lease,omitempty"`
+	// wait is set if another client holds the missed key's lease. staleItem
+	// is the key's value before it was last removed, if recent.
+	Wait      bool  `protobuf:"varint,3,opt,name=wait,proto3" json:"wait,omitempty"`
+	StaleItem *Item `protobuf:"bytes,4,opt,name=staleItem,proto3" json:"staleItem,omitempty"`
+	// stale is set if the item is past its pool's soft ttl. The first reader
+	// is granted the lease to refresh it, even if leases are disabled, and
+	// the others are served the stale item until it is refreshed.
+	Stale bool `protobuf:"varint,5,opt,name=stale,proto3" json:"stale,omitempty"`
+	// recompute is set, for a request with a beta, if the item should be
+	// recomputed now, before it expires
+	Recompute            bool     `protobuf:"varint,6,opt,name=recompute,proto3" json:"recompute,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -158,8 +481,51 @@ func (m *GetResponse) GetItem() *Item {
 	return nil
 }
 
+func (m *GetResponse) GetLease() uint64 {
+	if m != nil {
+		return m.Lease
+	}
+	return 0
+}
+
+func (m *GetResponse) GetWait() bool {
+	if m != nil {
+		return m.Wait
+	}
+	return false
+}
+
+func (m *GetResponse) GetStaleItem() *Item {
+	if m != nil {
+		return m.StaleItem
+	}
+	return nil
+}
+
+func (m *GetResponse) GetStale() bool {
+	if m != nil {
+		return m.Stale
+	}
+	return false
+}
+
+func (m *GetResponse) GetRecompute() bool {
+	if m != nil {
+		return m.Recompute
+	}
+	return false
+}
+
 type SetRequest struct {
-	Item                 *Item    `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	Item      *Item  `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pool      string `protobuf:"bytes,3,opt,name=pool,proto3" json:"pool,omitempty"`
+	// writeQuorum is the number of replicas that must acknowledge a write to
+	// a replicated pool. When zero, the pool's write quorum is used.
+	WriteQuorum int32 `protobuf:"varint,4,opt,name=writeQuorum,proto3" json:"writeQuorum,omitempty"`
+	// lease, if set, is the lease token granted by a Get. The set is
+	// aborted unless it is still the key's lease.
+	Lease                uint64   `protobuf:"varint,5,opt,name=lease,proto3" json:"lease,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -197,6 +563,34 @@ func (m *SetRequest) GetItem() *Item {
 	return nil
 }
 
+func (m *SetRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *SetRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *SetRequest) GetWriteQuorum() int32 {
+	if m != nil {
+		return m.WriteQuorum
+	}
+	return 0
+}
+
+func (m *SetRequest) GetLease() uint64 {
+	if m != nil {
+		return m.Lease
+	}
+	return 0
+}
+
 type SetResponse struct {
 	Item                 *Item    `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -238,6 +632,10 @@ func (m *SetResponse) GetItem() *Item {
 
 type CompareAndSwapRequest struct {
 	Item                 *Item    `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	Namespace            string   `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pool                 string   `protobuf:"bytes,3,opt,name=pool,proto3" json:"pool,omitempty"`
+	ReadQuorum           int32    `protobuf:"varint,4,opt,name=readQuorum,proto3" json:"readQuorum,omitempty"`
+	WriteQuorum          int32    `protobuf:"varint,5,opt,name=writeQuorum,proto3" json:"writeQuorum,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -275,6 +673,34 @@ func (m *CompareAndSwapRequest) GetItem() *Item {
 	return nil
 }
 
+func (m *CompareAndSwapRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *CompareAndSwapRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *CompareAndSwapRequest) GetReadQuorum() int32 {
+	if m != nil {
+		return m.ReadQuorum
+	}
+	return 0
+}
+
+func (m *CompareAndSwapRequest) GetWriteQuorum() int32 {
+	if m != nil {
+		return m.WriteQuorum
+	}
+	return 0
+}
+
 type CompareAndSwapResponse struct {
 	Item                 *Item    `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -315,7 +741,12 @@ func (m *CompareAndSwapResponse) GetItem() *Item {
 }
 
 type RemoveRequest struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Key         string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Namespace   string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pool        string `protobuf:"bytes,3,opt,name=pool,proto3" json:"pool,omitempty"`
+	WriteQuorum int32  `protobuf:"varint,4,opt,name=writeQuorum,proto3" json:"writeQuorum,omitempty"`
+	// timestamp is set by the node coordinating a replicated remove
+	Timestamp            uint64   `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -353,6 +784,34 @@ func (m *RemoveRequest) GetKey() string {
 	return ""
 }
 
+func (m *RemoveRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *RemoveRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *RemoveRequest) GetWriteQuorum() int32 {
+	if m != nil {
+		return m.WriteQuorum
+	}
+	return 0
+}
+
+func (m *RemoveRequest) GetTimestamp() uint64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
 type RemoveResponse struct {
 	Item                 *Item    `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -392,69 +851,87 @@ func (m *RemoveResponse) GetItem() *Item {
 	return nil
 }
 
-type ClearRequest struct {
+type ClearNamespaceRequest struct {
+	Namespace            string   `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pool                 string   `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ClearRequest) Reset()         { *m = ClearRequest{} }
-func (m *ClearRequest) String() string { return proto.CompactTextString(m) }
-func (*ClearRequest) ProtoMessage()    {}
-func (*ClearRequest) Descriptor() ([]byte, []int) {
+func (m *ClearNamespaceRequest) Reset()         { *m = ClearNamespaceRequest{} }
+func (m *ClearNamespaceRequest) String() string { return proto.CompactTextString(m) }
+func (*ClearNamespaceRequest) ProtoMessage()    {}
+func (*ClearNamespaceRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor_8892273135fec606, []int{9}
 }
 
-func (m *ClearRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ClearRequest.Unmarshal(m, b)
+func (m *ClearNamespaceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClearNamespaceRequest.Unmarshal(m, b)
+}
+func (m *ClearNamespaceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClearNamespaceRequest.Marshal(b, m, deterministic)
 }
-func (m *ClearRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ClearRequest.Marshal(b, m, deterministic)
+func (m *ClearNamespaceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClearNamespaceRequest.Merge(m, src)
 }
-func (m *ClearRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ClearRequest.Merge(m, src)
+func (m *ClearNamespaceRequest) XXX_Size() int {
+	return xxx_messageInfo_ClearNamespaceRequest.Size(m)
 }
-func (m *ClearRequest) XXX_Size() int {
-	return xxx_messageInfo_ClearRequest.Size(m)
+func (m *ClearNamespaceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClearNamespaceRequest.DiscardUnknown(m)
 }
-func (m *ClearRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_ClearRequest.DiscardUnknown(m)
+
+var xxx_messageInfo_ClearNamespaceRequest proto.InternalMessageInfo
+
+func (m *ClearNamespaceRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
 }
 
-var xxx_messageInfo_ClearRequest proto.InternalMessageInfo
+func (m *ClearNamespaceRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
 
-type ClearResponse struct {
+type ClearNamespaceResponse struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ClearResponse) Reset()         { *m = ClearResponse{} }
-func (m *ClearResponse) String() string { return proto.CompactTextString(m) }
-func (*ClearResponse) ProtoMessage()    {}
-func (*ClearResponse) Descriptor() ([]byte, []int) {
+func (m *ClearNamespaceResponse) Reset()         { *m = ClearNamespaceResponse{} }
+func (m *ClearNamespaceResponse) String() string { return proto.CompactTextString(m) }
+func (*ClearNamespaceResponse) ProtoMessage()    {}
+func (*ClearNamespaceResponse) Descriptor() ([]byte, []int) {
 	return fileDescriptor_8892273135fec606, []int{10}
 }
 
-func (m *ClearResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ClearResponse.Unmarshal(m, b)
+func (m *ClearNamespaceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClearNamespaceResponse.Unmarshal(m, b)
 }
-func (m *ClearResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ClearResponse.Marshal(b, m, deterministic)
+func (m *ClearNamespaceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClearNamespaceResponse.Marshal(b, m, deterministic)
 }
-func (m *ClearResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ClearResponse.Merge(m, src)
+func (m *ClearNamespaceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClearNamespaceResponse.Merge(m, src)
 }
-func (m *ClearResponse) XXX_Size() int {
-	return xxx_messageInfo_ClearResponse.Size(m)
+func (m *ClearNamespaceResponse) XXX_Size() int {
+	return xxx_messageInfo_ClearNamespaceResponse.Size(m)
 }
-func (m *ClearResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_ClearResponse.DiscardUnknown(m)
+func (m *ClearNamespaceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClearNamespaceResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ClearResponse proto.InternalMessageInfo
+var xxx_messageInfo_ClearNamespaceResponse proto.InternalMessageInfo
 
 type SizeRequest struct {
+	Namespace            string   `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pool                 string   `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -485,6 +962,20 @@ func (m *SizeRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_SizeRequest proto.InternalMessageInfo
 
+func (m *SizeRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *SizeRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
 type SizeResponse struct {
 	Size                 uint64   `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -524,306 +1015,4895 @@ func (m *SizeResponse) GetSize() uint64 {
 	return 0
 }
 
-func init() {
-	proto.RegisterType((*Item)(nil), "Item")
-	proto.RegisterType((*GetRequest)(nil), "GetRequest")
-	proto.RegisterType((*GetResponse)(nil), "GetResponse")
-	proto.RegisterType((*SetRequest)(nil), "SetRequest")
-	proto.RegisterType((*SetResponse)(nil), "SetResponse")
-	proto.RegisterType((*CompareAndSwapRequest)(nil), "CompareAndSwapRequest")
-	proto.RegisterType((*CompareAndSwapResponse)(nil), "CompareAndSwapResponse")
-	proto.RegisterType((*RemoveRequest)(nil), "RemoveRequest")
-	proto.RegisterType((*RemoveResponse)(nil), "RemoveResponse")
-	proto.RegisterType((*ClearRequest)(nil), "ClearRequest")
-	proto.RegisterType((*ClearResponse)(nil), "ClearResponse")
-	proto.RegisterType((*SizeRequest)(nil), "SizeRequest")
-	proto.RegisterType((*SizeResponse)(nil), "SizeResponse")
+type DeleteMatchingRequest struct {
+	// exactly one of prefix or pattern must be set
+	Prefix               string   `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Pattern              string   `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Namespace            string   `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pool                 string   `protobuf:"bytes,4,opt,name=pool,proto3" json:"pool,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func init() { proto.RegisterFile("memcached.proto", fileDescriptor_8892273135fec606) }
-
-var fileDescriptor_8892273135fec606 = []byte{
-	// 352 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x93, 0xdf, 0x6a, 0xe2, 0x40,
-	0x18, 0xc5, 0x13, 0x13, 0x05, 0x4f, 0xfe, 0xb8, 0x0c, 0xbb, 0xae, 0x9b, 0x8b, 0xc5, 0x0e, 0x94,
-	0x06, 0x84, 0xb9, 0xd0, 0x27, 0x28, 0x0a, 0xe2, 0x45, 0x6f, 0x66, 0x9e, 0x20, 0xd5, 0x0f, 0x2a,
-	0x35, 0x26, 0x35, 0xd1, 0x52, 0x1f, 0xa4, 0xcf, 0x5b, 0x32, 0x51, 0x4c, 0x5a, 0x5b, 0xbd, 0x9b,
-	0x33, 0x39, 0xf3, 0x9b, 0xf0, 0xfb, 0x12, 0x74, 0x62, 0x8a, 0xe7, 0xd1, 0xfc, 0x89, 0x16, 0x22,
-	0xdd, 0x24, 0x79, 0xc2, 0x27, 0xb0, 0x67, 0x39, 0xc5, 0xec, 0x17, 0xac, 0x67, 0x7a, 0xeb, 0x99,
-	0x7d, 0x33, 0x6c, 0xcb, 0x62, 0xc9, 0x7e, 0xa3, 0xb9, 0x8b, 0x56, 0x5b, 0xea, 0x35, 0xfa, 0x66,
-	0xe8, 0xca, 0x32, 0x14, 0xbb, 0xf3, 0x28, 0x9b, 0x4d, 0x7a, 0x56, 0xdf, 0x0c, 0x2d, 0x59, 0x06,
-	0xfe, 0x1f, 0x98, 0x52, 0x2e, 0xe9, 0x65, 0x4b, 0x59, 0xfe, 0x95, 0xc5, 0x43, 0x38, 0xfa, 0x79,
-	0x96, 0x26, 0xeb, 0x8c, 0xd8, 0x3f, 0xd8, 0xcb, 0x9c, 0x62, 0xdd, 0x70, 0x86, 0x4d, 0x51, 0xbc,
-	0x81, 0xd4, 0x5b, 0xfc, 0x0e, 0x50, 0x27, 0xd2, 0x0f, 0xc5, 0x10, 0x8e, 0xba, 0x0e, 0x39, 0xc4,
-	0x9f, 0x71, 0x12, 0xa7, 0xd1, 0x86, 0xee, 0xd7, 0x0b, 0xf5, 0x1a, 0xa5, 0x57, 0xd0, 0x47, 0xe8,
-	0x7e, 0x3e, 0x73, 0xf9, 0xa2, 0x1b, 0x78, 0x92, 0xe2, 0x64, 0x47, 0xdf, 0x8b, 0x18, 0xc0, 0x3f,
-	0x56, 0x2e, 0xf3, 0x7c, 0xb8, 0xe3, 0x15, 0x45, 0x9b, 0x03, 0x8e, 0x77, 0xe0, 0x1d, 0x72, 0x79,
-	0x96, 0x7b, 0x70, 0xd4, 0x72, 0x7f, 0xbc, 0x8e, 0x73, 0xb8, 0x65, 0x3c, 0xa0, 0x19, 0xec, 0x6c,
-	0xb9, 0x27, 0x8d, 0xb6, 0xa5, 0x5e, 0x0f, 0xdf, 0x1b, 0x68, 0x3f, 0x1c, 0xbf, 0x01, 0xc6, 0x61,
-	0x4d, 0x29, 0x67, 0x8e, 0x38, 0x4d, 0x2f, 0x70, 0x45, 0x65, 0x54, 0xdc, 0x28, 0x3a, 0x4a, 0x77,
-	0x54, 0xb5, 0xa3, 0x6a, 0x9d, 0x31, 0xfc, 0xba, 0x2e, 0xd6, 0x15, 0x67, 0x9d, 0x07, 0x7f, 0xc5,
-	0x79, 0xaf, 0xdc, 0x60, 0x03, 0xb4, 0x4a, 0x37, 0xcc, 0x17, 0x35, 0x8f, 0x41, 0x47, 0xd4, 0xa5,
-	0x71, 0x83, 0x85, 0x68, 0x6a, 0x17, 0xcc, 0x13, 0x55, 0x47, 0x81, 0x2f, 0xea, 0x8a, 0x0c, 0x76,
-	0x0b, 0xbb, 0xb0, 0xc2, 0x5c, 0x51, 0x71, 0x15, 0x78, 0xa2, 0xaa, 0x8a, 0x1b, 0x8f, 0x2d, 0xfd,
-	0x3f, 0x8c, 0x3e, 0x02, 0x00, 0x00, 0xff, 0xff, 0xef, 0xee, 0x45, 0x73, 0x22, 0x03, 0x00, 0x00,
+func (m *DeleteMatchingRequest) Reset()         { *m = DeleteMatchingRequest{} }
+func (m *DeleteMatchingRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteMatchingRequest) ProtoMessage()    {}
+func (*DeleteMatchingRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{13}
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func (m *DeleteMatchingRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteMatchingRequest.Unmarshal(m, b)
+}
+func (m *DeleteMatchingRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteMatchingRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteMatchingRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteMatchingRequest.Merge(m, src)
+}
+func (m *DeleteMatchingRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteMatchingRequest.Size(m)
+}
+func (m *DeleteMatchingRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteMatchingRequest.DiscardUnknown(m)
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+var xxx_messageInfo_DeleteMatchingRequest proto.InternalMessageInfo
+
+func (m *DeleteMatchingRequest) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *DeleteMatchingRequest) GetPattern() string {
+	if m != nil {
+		return m.Pattern
+	}
+	return ""
+}
+
+func (m *DeleteMatchingRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *DeleteMatchingRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+type DeleteMatchingResponse struct {
+	JobID                string   `protobuf:"bytes,1,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteMatchingResponse) Reset()         { *m = DeleteMatchingResponse{} }
+func (m *DeleteMatchingResponse) String() string { return proto.CompactTextString(m) }
+func (*DeleteMatchingResponse) ProtoMessage()    {}
+func (*DeleteMatchingResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{14}
+}
+
+func (m *DeleteMatchingResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteMatchingResponse.Unmarshal(m, b)
+}
+func (m *DeleteMatchingResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteMatchingResponse.Marshal(b, m, deterministic)
+}
+func (m *DeleteMatchingResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteMatchingResponse.Merge(m, src)
+}
+func (m *DeleteMatchingResponse) XXX_Size() int {
+	return xxx_messageInfo_DeleteMatchingResponse.Size(m)
+}
+func (m *DeleteMatchingResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteMatchingResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteMatchingResponse proto.InternalMessageInfo
+
+func (m *DeleteMatchingResponse) GetJobID() string {
+	if m != nil {
+		return m.JobID
+	}
+	return ""
+}
+
+type Job struct {
+	JobID                string    `protobuf:"bytes,1,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	Kind                 string    `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	State                Job_State `protobuf:"varint,3,opt,name=state,proto3,enum=Job_State" json:"state,omitempty"`
+	Error                string    `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	Done                 uint64    `protobuf:"varint,5,opt,name=done,proto3" json:"done,omitempty"`
+	Total                uint64    `protobuf:"varint,6,opt,name=total,proto3" json:"total,omitempty"`
+	Count                uint64    `protobuf:"varint,7,opt,name=count,proto3" json:"count,omitempty"`
+	StartedAt            int64     `protobuf:"varint,8,opt,name=startedAt,proto3" json:"startedAt,omitempty"`
+	FinishedAt           int64     `protobuf:"varint,9,opt,name=finishedAt,proto3" json:"finishedAt,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *Job) Reset()         { *m = Job{} }
+func (m *Job) String() string { return proto.CompactTextString(m) }
+func (*Job) ProtoMessage()    {}
+func (*Job) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{15}
+}
+
+func (m *Job) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Job.Unmarshal(m, b)
+}
+func (m *Job) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Job.Marshal(b, m, deterministic)
+}
+func (m *Job) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Job.Merge(m, src)
+}
+func (m *Job) XXX_Size() int {
+	return xxx_messageInfo_Job.Size(m)
+}
+func (m *Job) XXX_DiscardUnknown() {
+	xxx_messageInfo_Job.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Job proto.InternalMessageInfo
+
+func (m *Job) GetJobID() string {
+	if m != nil {
+		return m.JobID
+	}
+	return ""
+}
+
+func (m *Job) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *Job) GetState() Job_State {
+	if m != nil {
+		return m.State
+	}
+	return Job_RUNNING
+}
+
+func (m *Job) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *Job) GetDone() uint64 {
+	if m != nil {
+		return m.Done
+	}
+	return 0
+}
+
+func (m *Job) GetTotal() uint64 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
+func (m *Job) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *Job) GetStartedAt() int64 {
+	if m != nil {
+		return m.StartedAt
+	}
+	return 0
+}
+
+func (m *Job) GetFinishedAt() int64 {
+	if m != nil {
+		return m.FinishedAt
+	}
+	return 0
+}
+
+type GetJobRequest struct {
+	JobID                string   `protobuf:"bytes,1,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetJobRequest) Reset()         { *m = GetJobRequest{} }
+func (m *GetJobRequest) String() string { return proto.CompactTextString(m) }
+func (*GetJobRequest) ProtoMessage()    {}
+func (*GetJobRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{16}
+}
+
+func (m *GetJobRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetJobRequest.Unmarshal(m, b)
+}
+func (m *GetJobRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetJobRequest.Marshal(b, m, deterministic)
+}
+func (m *GetJobRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetJobRequest.Merge(m, src)
+}
+func (m *GetJobRequest) XXX_Size() int {
+	return xxx_messageInfo_GetJobRequest.Size(m)
+}
+func (m *GetJobRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetJobRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetJobRequest proto.InternalMessageInfo
+
+func (m *GetJobRequest) GetJobID() string {
+	if m != nil {
+		return m.JobID
+	}
+	return ""
+}
+
+type GetJobResponse struct {
+	Job                  *Job     `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetJobResponse) Reset()         { *m = GetJobResponse{} }
+func (m *GetJobResponse) String() string { return proto.CompactTextString(m) }
+func (*GetJobResponse) ProtoMessage()    {}
+func (*GetJobResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{17}
+}
+
+func (m *GetJobResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetJobResponse.Unmarshal(m, b)
+}
+func (m *GetJobResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetJobResponse.Marshal(b, m, deterministic)
+}
+func (m *GetJobResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetJobResponse.Merge(m, src)
+}
+func (m *GetJobResponse) XXX_Size() int {
+	return xxx_messageInfo_GetJobResponse.Size(m)
+}
+func (m *GetJobResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetJobResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetJobResponse proto.InternalMessageInfo
+
+func (m *GetJobResponse) GetJob() *Job {
+	if m != nil {
+		return m.Job
+	}
+	return nil
+}
+
+type CancelJobRequest struct {
+	JobID                string   `protobuf:"bytes,1,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelJobRequest) Reset()         { *m = CancelJobRequest{} }
+func (m *CancelJobRequest) String() string { return proto.CompactTextString(m) }
+func (*CancelJobRequest) ProtoMessage()    {}
+func (*CancelJobRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{18}
+}
+
+func (m *CancelJobRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelJobRequest.Unmarshal(m, b)
+}
+func (m *CancelJobRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelJobRequest.Marshal(b, m, deterministic)
+}
+func (m *CancelJobRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelJobRequest.Merge(m, src)
+}
+func (m *CancelJobRequest) XXX_Size() int {
+	return xxx_messageInfo_CancelJobRequest.Size(m)
+}
+func (m *CancelJobRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelJobRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelJobRequest proto.InternalMessageInfo
+
+func (m *CancelJobRequest) GetJobID() string {
+	if m != nil {
+		return m.JobID
+	}
+	return ""
+}
+
+type CancelJobResponse struct {
+	Job                  *Job     `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelJobResponse) Reset()         { *m = CancelJobResponse{} }
+func (m *CancelJobResponse) String() string { return proto.CompactTextString(m) }
+func (*CancelJobResponse) ProtoMessage()    {}
+func (*CancelJobResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{19}
+}
+
+func (m *CancelJobResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelJobResponse.Unmarshal(m, b)
+}
+func (m *CancelJobResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelJobResponse.Marshal(b, m, deterministic)
+}
+func (m *CancelJobResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelJobResponse.Merge(m, src)
+}
+func (m *CancelJobResponse) XXX_Size() int {
+	return xxx_messageInfo_CancelJobResponse.Size(m)
+}
+func (m *CancelJobResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelJobResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelJobResponse proto.InternalMessageInfo
+
+func (m *CancelJobResponse) GetJob() *Job {
+	if m != nil {
+		return m.Job
+	}
+	return nil
+}
+
+type InvalidateTagsRequest struct {
+	Tags                 []string `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	Namespace            string   `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pool                 string   `protobuf:"bytes,3,opt,name=pool,proto3" json:"pool,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InvalidateTagsRequest) Reset()         { *m = InvalidateTagsRequest{} }
+func (m *InvalidateTagsRequest) String() string { return proto.CompactTextString(m) }
+func (*InvalidateTagsRequest) ProtoMessage()    {}
+func (*InvalidateTagsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{20}
+}
+
+func (m *InvalidateTagsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InvalidateTagsRequest.Unmarshal(m, b)
+}
+func (m *InvalidateTagsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InvalidateTagsRequest.Marshal(b, m, deterministic)
+}
+func (m *InvalidateTagsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InvalidateTagsRequest.Merge(m, src)
+}
+func (m *InvalidateTagsRequest) XXX_Size() int {
+	return xxx_messageInfo_InvalidateTagsRequest.Size(m)
+}
+func (m *InvalidateTagsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InvalidateTagsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InvalidateTagsRequest proto.InternalMessageInfo
+
+func (m *InvalidateTagsRequest) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *InvalidateTagsRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *InvalidateTagsRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+type InvalidateTagsResponse struct {
+	Count                uint64   `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InvalidateTagsResponse) Reset()         { *m = InvalidateTagsResponse{} }
+func (m *InvalidateTagsResponse) String() string { return proto.CompactTextString(m) }
+func (*InvalidateTagsResponse) ProtoMessage()    {}
+func (*InvalidateTagsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{21}
+}
+
+func (m *InvalidateTagsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InvalidateTagsResponse.Unmarshal(m, b)
+}
+func (m *InvalidateTagsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InvalidateTagsResponse.Marshal(b, m, deterministic)
+}
+func (m *InvalidateTagsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InvalidateTagsResponse.Merge(m, src)
+}
+func (m *InvalidateTagsResponse) XXX_Size() int {
+	return xxx_messageInfo_InvalidateTagsResponse.Size(m)
+}
+func (m *InvalidateTagsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_InvalidateTagsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InvalidateTagsResponse proto.InternalMessageInfo
+
+func (m *InvalidateTagsResponse) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type GetGenerationRequest struct {
+	Namespace            string   `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pool                 string   `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetGenerationRequest) Reset()         { *m = GetGenerationRequest{} }
+func (m *GetGenerationRequest) String() string { return proto.CompactTextString(m) }
+func (*GetGenerationRequest) ProtoMessage()    {}
+func (*GetGenerationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{22}
+}
+
+func (m *GetGenerationRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetGenerationRequest.Unmarshal(m, b)
+}
+func (m *GetGenerationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetGenerationRequest.Marshal(b, m, deterministic)
+}
+func (m *GetGenerationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetGenerationRequest.Merge(m, src)
+}
+func (m *GetGenerationRequest) XXX_Size() int {
+	return xxx_messageInfo_GetGenerationRequest.Size(m)
+}
+func (m *GetGenerationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetGenerationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetGenerationRequest proto.InternalMessageInfo
+
+func (m *GetGenerationRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *GetGenerationRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+type GetGenerationResponse struct {
+	Generation           uint64   `protobuf:"varint,1,opt,name=generation,proto3" json:"generation,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetGenerationResponse) Reset()         { *m = GetGenerationResponse{} }
+func (m *GetGenerationResponse) String() string { return proto.CompactTextString(m) }
+func (*GetGenerationResponse) ProtoMessage()    {}
+func (*GetGenerationResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{23}
+}
+
+func (m *GetGenerationResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetGenerationResponse.Unmarshal(m, b)
+}
+func (m *GetGenerationResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetGenerationResponse.Marshal(b, m, deterministic)
+}
+func (m *GetGenerationResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetGenerationResponse.Merge(m, src)
+}
+func (m *GetGenerationResponse) XXX_Size() int {
+	return xxx_messageInfo_GetGenerationResponse.Size(m)
+}
+func (m *GetGenerationResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetGenerationResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetGenerationResponse proto.InternalMessageInfo
+
+func (m *GetGenerationResponse) GetGeneration() uint64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
+type SetGenerationRequest struct {
+	Namespace            string   `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Generation           uint64   `protobuf:"varint,2,opt,name=generation,proto3" json:"generation,omitempty"`
+	Pool                 string   `protobuf:"bytes,3,opt,name=pool,proto3" json:"pool,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetGenerationRequest) Reset()         { *m = SetGenerationRequest{} }
+func (m *SetGenerationRequest) String() string { return proto.CompactTextString(m) }
+func (*SetGenerationRequest) ProtoMessage()    {}
+func (*SetGenerationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{24}
+}
+
+func (m *SetGenerationRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetGenerationRequest.Unmarshal(m, b)
+}
+func (m *SetGenerationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetGenerationRequest.Marshal(b, m, deterministic)
+}
+func (m *SetGenerationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetGenerationRequest.Merge(m, src)
+}
+func (m *SetGenerationRequest) XXX_Size() int {
+	return xxx_messageInfo_SetGenerationRequest.Size(m)
+}
+func (m *SetGenerationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetGenerationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetGenerationRequest proto.InternalMessageInfo
+
+func (m *SetGenerationRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *SetGenerationRequest) GetGeneration() uint64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
+func (m *SetGenerationRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+type SetGenerationResponse struct {
+	Generation           uint64   `protobuf:"varint,1,opt,name=generation,proto3" json:"generation,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetGenerationResponse) Reset()         { *m = SetGenerationResponse{} }
+func (m *SetGenerationResponse) String() string { return proto.CompactTextString(m) }
+func (*SetGenerationResponse) ProtoMessage()    {}
+func (*SetGenerationResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{25}
+}
+
+func (m *SetGenerationResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetGenerationResponse.Unmarshal(m, b)
+}
+func (m *SetGenerationResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetGenerationResponse.Marshal(b, m, deterministic)
+}
+func (m *SetGenerationResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetGenerationResponse.Merge(m, src)
+}
+func (m *SetGenerationResponse) XXX_Size() int {
+	return xxx_messageInfo_SetGenerationResponse.Size(m)
+}
+func (m *SetGenerationResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetGenerationResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetGenerationResponse proto.InternalMessageInfo
+
+func (m *SetGenerationResponse) GetGeneration() uint64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
+type BumpGenerationRequest struct {
+	Namespace            string   `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pool                 string   `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BumpGenerationRequest) Reset()         { *m = BumpGenerationRequest{} }
+func (m *BumpGenerationRequest) String() string { return proto.CompactTextString(m) }
+func (*BumpGenerationRequest) ProtoMessage()    {}
+func (*BumpGenerationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{26}
+}
+
+func (m *BumpGenerationRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BumpGenerationRequest.Unmarshal(m, b)
+}
+func (m *BumpGenerationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BumpGenerationRequest.Marshal(b, m, deterministic)
+}
+func (m *BumpGenerationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BumpGenerationRequest.Merge(m, src)
+}
+func (m *BumpGenerationRequest) XXX_Size() int {
+	return xxx_messageInfo_BumpGenerationRequest.Size(m)
+}
+func (m *BumpGenerationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BumpGenerationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BumpGenerationRequest proto.InternalMessageInfo
+
+func (m *BumpGenerationRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *BumpGenerationRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+type BumpGenerationResponse struct {
+	Generation           uint64   `protobuf:"varint,1,opt,name=generation,proto3" json:"generation,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BumpGenerationResponse) Reset()         { *m = BumpGenerationResponse{} }
+func (m *BumpGenerationResponse) String() string { return proto.CompactTextString(m) }
+func (*BumpGenerationResponse) ProtoMessage()    {}
+func (*BumpGenerationResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{27}
+}
+
+func (m *BumpGenerationResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BumpGenerationResponse.Unmarshal(m, b)
+}
+func (m *BumpGenerationResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BumpGenerationResponse.Marshal(b, m, deterministic)
+}
+func (m *BumpGenerationResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BumpGenerationResponse.Merge(m, src)
+}
+func (m *BumpGenerationResponse) XXX_Size() int {
+	return xxx_messageInfo_BumpGenerationResponse.Size(m)
+}
+func (m *BumpGenerationResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BumpGenerationResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BumpGenerationResponse proto.InternalMessageInfo
+
+func (m *BumpGenerationResponse) GetGeneration() uint64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
+type PoolConfig struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// capacity of the default namespace, in bytes
+	Capacity   uint64                    `protobuf:"varint,2,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	CacheCount int32                     `protobuf:"varint,3,opt,name=cacheCount,proto3" json:"cacheCount,omitempty"`
+	Replicas   int32                     `protobuf:"varint,4,opt,name=replicas,proto3" json:"replicas,omitempty"`
+	Policy     PoolConfig_EvictionPolicy `protobuf:"varint,5,opt,name=policy,proto3,enum=PoolConfig_EvictionPolicy" json:"policy,omitempty"`
+	// item time to live, in milliseconds; zero if items do not expire
+	TtlMillis int64 `protobuf:"varint,6,opt,name=ttlMillis,proto3" json:"ttlMillis,omitempty"`
+	// additional namespace capacities, in bytes
+	Namespaces map[string]uint64 `protobuf:"bytes,7,rep,name=namespaces,proto3" json:"namespaces,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// how keys are mapped to the pool's caches
+	HashStrategy PoolConfig_HashStrategy `protobuf:"varint,8,opt,name=hashStrategy,proto3,enum=PoolConfig_HashStrategy" json:"hashStrategy,omitempty"`
+	// if positive, keys spill from caches with more than (1+boundedLoad)
+	// times the average load
+	BoundedLoad float64 `protobuf:"fixed64,9,opt,name=boundedLoad,proto3" json:"boundedLoad,omitempty"`
+	// replicationFactor is the number of cluster peers holding each key;
+	// keys are not replicated if it is zero or one
+	ReplicationFactor int32 `protobuf:"varint,10,opt,name=replicationFactor,proto3" json:"replicationFactor,omitempty"`
+	// readQuorum and writeQuorum are the number of replicas that must
+	// respond to reads and writes; a majority if zero
+	ReadQuorum  int32 `protobuf:"varint,11,opt,name=readQuorum,proto3" json:"readQuorum,omitempty"`
+	WriteQuorum int32 `protobuf:"varint,12,opt,name=writeQuorum,proto3" json:"writeQuorum,omitempty"`
+	// if positive, how long items are fresh after they are set, in
+	// milliseconds. Past it, and until the ttl, items are served stale.
+	SoftTtlMillis int64 `protobuf:"varint,13,opt,name=softTtlMillis,proto3" json:"softTtlMillis,omitempty"`
+	// origin, if set, is where missed keys are loaded from: an http or https
+	// URL, or a grpc://host:port address serving the Origin service
+	Origin string `protobuf:"bytes,14,opt,name=origin,proto3" json:"origin,omitempty"`
+	// loadTimeoutMillis bounds each load from the origin; 5s if zero
+	LoadTimeoutMillis int64 `protobuf:"varint,15,opt,name=loadTimeoutMillis,proto3" json:"loadTimeoutMillis,omitempty"`
+	// if positive, how long keys the origin does not have are remembered,
+	// in milliseconds, before they are loaded again
+	NegativeTtlMillis    int64    `protobuf:"varint,16,opt,name=negativeTtlMillis,proto3" json:"negativeTtlMillis,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PoolConfig) Reset()         { *m = PoolConfig{} }
+func (m *PoolConfig) String() string { return proto.CompactTextString(m) }
+func (*PoolConfig) ProtoMessage()    {}
+func (*PoolConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{28}
+}
+
+func (m *PoolConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PoolConfig.Unmarshal(m, b)
+}
+func (m *PoolConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PoolConfig.Marshal(b, m, deterministic)
+}
+func (m *PoolConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PoolConfig.Merge(m, src)
+}
+func (m *PoolConfig) XXX_Size() int {
+	return xxx_messageInfo_PoolConfig.Size(m)
+}
+func (m *PoolConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_PoolConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PoolConfig proto.InternalMessageInfo
+
+func (m *PoolConfig) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *PoolConfig) GetCapacity() uint64 {
+	if m != nil {
+		return m.Capacity
+	}
+	return 0
+}
+
+func (m *PoolConfig) GetCacheCount() int32 {
+	if m != nil {
+		return m.CacheCount
+	}
+	return 0
+}
+
+func (m *PoolConfig) GetReplicas() int32 {
+	if m != nil {
+		return m.Replicas
+	}
+	return 0
+}
+
+func (m *PoolConfig) GetPolicy() PoolConfig_EvictionPolicy {
+	if m != nil {
+		return m.Policy
+	}
+	return PoolConfig_LRU
+}
+
+func (m *PoolConfig) GetTtlMillis() int64 {
+	if m != nil {
+		return m.TtlMillis
+	}
+	return 0
+}
+
+func (m *PoolConfig) GetNamespaces() map[string]uint64 {
+	if m != nil {
+		return m.Namespaces
+	}
+	return nil
+}
+
+func (m *PoolConfig) GetHashStrategy() PoolConfig_HashStrategy {
+	if m != nil {
+		return m.HashStrategy
+	}
+	return PoolConfig_RING
+}
+
+func (m *PoolConfig) GetBoundedLoad() float64 {
+	if m != nil {
+		return m.BoundedLoad
+	}
+	return 0
+}
+
+func (m *PoolConfig) GetReplicationFactor() int32 {
+	if m != nil {
+		return m.ReplicationFactor
+	}
+	return 0
+}
+
+func (m *PoolConfig) GetReadQuorum() int32 {
+	if m != nil {
+		return m.ReadQuorum
+	}
+	return 0
+}
+
+func (m *PoolConfig) GetWriteQuorum() int32 {
+	if m != nil {
+		return m.WriteQuorum
+	}
+	return 0
+}
+
+func (m *PoolConfig) GetSoftTtlMillis() int64 {
+	if m != nil {
+		return m.SoftTtlMillis
+	}
+	return 0
+}
+
+func (m *PoolConfig) GetOrigin() string {
+	if m != nil {
+		return m.Origin
+	}
+	return ""
+}
+
+func (m *PoolConfig) GetLoadTimeoutMillis() int64 {
+	if m != nil {
+		return m.LoadTimeoutMillis
+	}
+	return 0
+}
+
+func (m *PoolConfig) GetNegativeTtlMillis() int64 {
+	if m != nil {
+		return m.NegativeTtlMillis
+	}
+	return 0
+}
+
+type Pool struct {
+	Config               *PoolConfig `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	Size                 uint64      `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	CurrentCapacity      uint64      `protobuf:"varint,3,opt,name=currentCapacity,proto3" json:"currentCapacity,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *Pool) Reset()         { *m = Pool{} }
+func (m *Pool) String() string { return proto.CompactTextString(m) }
+func (*Pool) ProtoMessage()    {}
+func (*Pool) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{29}
+}
+
+func (m *Pool) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Pool.Unmarshal(m, b)
+}
+func (m *Pool) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Pool.Marshal(b, m, deterministic)
+}
+func (m *Pool) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Pool.Merge(m, src)
+}
+func (m *Pool) XXX_Size() int {
+	return xxx_messageInfo_Pool.Size(m)
+}
+func (m *Pool) XXX_DiscardUnknown() {
+	xxx_messageInfo_Pool.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Pool proto.InternalMessageInfo
+
+func (m *Pool) GetConfig() *PoolConfig {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+func (m *Pool) GetSize() uint64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *Pool) GetCurrentCapacity() uint64 {
+	if m != nil {
+		return m.CurrentCapacity
+	}
+	return 0
+}
+
+type CreatePoolRequest struct {
+	Config               *PoolConfig `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *CreatePoolRequest) Reset()         { *m = CreatePoolRequest{} }
+func (m *CreatePoolRequest) String() string { return proto.CompactTextString(m) }
+func (*CreatePoolRequest) ProtoMessage()    {}
+func (*CreatePoolRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{30}
+}
+
+func (m *CreatePoolRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreatePoolRequest.Unmarshal(m, b)
+}
+func (m *CreatePoolRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreatePoolRequest.Marshal(b, m, deterministic)
+}
+func (m *CreatePoolRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreatePoolRequest.Merge(m, src)
+}
+func (m *CreatePoolRequest) XXX_Size() int {
+	return xxx_messageInfo_CreatePoolRequest.Size(m)
+}
+func (m *CreatePoolRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreatePoolRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreatePoolRequest proto.InternalMessageInfo
+
+func (m *CreatePoolRequest) GetConfig() *PoolConfig {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+type CreatePoolResponse struct {
+	Pool                 *Pool    `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CreatePoolResponse) Reset()         { *m = CreatePoolResponse{} }
+func (m *CreatePoolResponse) String() string { return proto.CompactTextString(m) }
+func (*CreatePoolResponse) ProtoMessage()    {}
+func (*CreatePoolResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{31}
+}
+
+func (m *CreatePoolResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreatePoolResponse.Unmarshal(m, b)
+}
+func (m *CreatePoolResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreatePoolResponse.Marshal(b, m, deterministic)
+}
+func (m *CreatePoolResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreatePoolResponse.Merge(m, src)
+}
+func (m *CreatePoolResponse) XXX_Size() int {
+	return xxx_messageInfo_CreatePoolResponse.Size(m)
+}
+func (m *CreatePoolResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreatePoolResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreatePoolResponse proto.InternalMessageInfo
+
+func (m *CreatePoolResponse) GetPool() *Pool {
+	if m != nil {
+		return m.Pool
+	}
+	return nil
+}
+
+type ListPoolsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListPoolsRequest) Reset()         { *m = ListPoolsRequest{} }
+func (m *ListPoolsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListPoolsRequest) ProtoMessage()    {}
+func (*ListPoolsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{32}
+}
+
+func (m *ListPoolsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPoolsRequest.Unmarshal(m, b)
+}
+func (m *ListPoolsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPoolsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListPoolsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPoolsRequest.Merge(m, src)
+}
+func (m *ListPoolsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListPoolsRequest.Size(m)
+}
+func (m *ListPoolsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPoolsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPoolsRequest proto.InternalMessageInfo
+
+type ListPoolsResponse struct {
+	Pools                []*Pool  `protobuf:"bytes,1,rep,name=pools,proto3" json:"pools,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListPoolsResponse) Reset()         { *m = ListPoolsResponse{} }
+func (m *ListPoolsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListPoolsResponse) ProtoMessage()    {}
+func (*ListPoolsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{33}
+}
+
+func (m *ListPoolsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPoolsResponse.Unmarshal(m, b)
+}
+func (m *ListPoolsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPoolsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListPoolsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPoolsResponse.Merge(m, src)
+}
+func (m *ListPoolsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListPoolsResponse.Size(m)
+}
+func (m *ListPoolsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPoolsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPoolsResponse proto.InternalMessageInfo
+
+func (m *ListPoolsResponse) GetPools() []*Pool {
+	if m != nil {
+		return m.Pools
+	}
+	return nil
+}
+
+type DescribePoolRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DescribePoolRequest) Reset()         { *m = DescribePoolRequest{} }
+func (m *DescribePoolRequest) String() string { return proto.CompactTextString(m) }
+func (*DescribePoolRequest) ProtoMessage()    {}
+func (*DescribePoolRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{34}
+}
+
+func (m *DescribePoolRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DescribePoolRequest.Unmarshal(m, b)
+}
+func (m *DescribePoolRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DescribePoolRequest.Marshal(b, m, deterministic)
+}
+func (m *DescribePoolRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DescribePoolRequest.Merge(m, src)
+}
+func (m *DescribePoolRequest) XXX_Size() int {
+	return xxx_messageInfo_DescribePoolRequest.Size(m)
+}
+func (m *DescribePoolRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DescribePoolRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DescribePoolRequest proto.InternalMessageInfo
+
+func (m *DescribePoolRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type DescribePoolResponse struct {
+	Pool                 *Pool    `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DescribePoolResponse) Reset()         { *m = DescribePoolResponse{} }
+func (m *DescribePoolResponse) String() string { return proto.CompactTextString(m) }
+func (*DescribePoolResponse) ProtoMessage()    {}
+func (*DescribePoolResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{35}
+}
+
+func (m *DescribePoolResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DescribePoolResponse.Unmarshal(m, b)
+}
+func (m *DescribePoolResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DescribePoolResponse.Marshal(b, m, deterministic)
+}
+func (m *DescribePoolResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DescribePoolResponse.Merge(m, src)
+}
+func (m *DescribePoolResponse) XXX_Size() int {
+	return xxx_messageInfo_DescribePoolResponse.Size(m)
+}
+func (m *DescribePoolResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DescribePoolResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DescribePoolResponse proto.InternalMessageInfo
+
+func (m *DescribePoolResponse) GetPool() *Pool {
+	if m != nil {
+		return m.Pool
+	}
+	return nil
+}
+
+type DeletePoolRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeletePoolRequest) Reset()         { *m = DeletePoolRequest{} }
+func (m *DeletePoolRequest) String() string { return proto.CompactTextString(m) }
+func (*DeletePoolRequest) ProtoMessage()    {}
+func (*DeletePoolRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{36}
+}
+
+func (m *DeletePoolRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeletePoolRequest.Unmarshal(m, b)
+}
+func (m *DeletePoolRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeletePoolRequest.Marshal(b, m, deterministic)
+}
+func (m *DeletePoolRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeletePoolRequest.Merge(m, src)
+}
+func (m *DeletePoolRequest) XXX_Size() int {
+	return xxx_messageInfo_DeletePoolRequest.Size(m)
+}
+func (m *DeletePoolRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeletePoolRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeletePoolRequest proto.InternalMessageInfo
+
+func (m *DeletePoolRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type DeletePoolResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeletePoolResponse) Reset()         { *m = DeletePoolResponse{} }
+func (m *DeletePoolResponse) String() string { return proto.CompactTextString(m) }
+func (*DeletePoolResponse) ProtoMessage()    {}
+func (*DeletePoolResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{37}
+}
+
+func (m *DeletePoolResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeletePoolResponse.Unmarshal(m, b)
+}
+func (m *DeletePoolResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeletePoolResponse.Marshal(b, m, deterministic)
+}
+func (m *DeletePoolResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeletePoolResponse.Merge(m, src)
+}
+func (m *DeletePoolResponse) XXX_Size() int {
+	return xxx_messageInfo_DeletePoolResponse.Size(m)
+}
+func (m *DeletePoolResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeletePoolResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeletePoolResponse proto.InternalMessageInfo
+
+type ReshardRequest struct {
+	// name is the pool to reshard
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	CacheCount           int32    `protobuf:"varint,2,opt,name=cacheCount,proto3" json:"cacheCount,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReshardRequest) Reset()         { *m = ReshardRequest{} }
+func (m *ReshardRequest) String() string { return proto.CompactTextString(m) }
+func (*ReshardRequest) ProtoMessage()    {}
+func (*ReshardRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{38}
+}
+
+func (m *ReshardRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReshardRequest.Unmarshal(m, b)
+}
+func (m *ReshardRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReshardRequest.Marshal(b, m, deterministic)
+}
+func (m *ReshardRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReshardRequest.Merge(m, src)
+}
+func (m *ReshardRequest) XXX_Size() int {
+	return xxx_messageInfo_ReshardRequest.Size(m)
+}
+func (m *ReshardRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReshardRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReshardRequest proto.InternalMessageInfo
+
+func (m *ReshardRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ReshardRequest) GetCacheCount() int32 {
+	if m != nil {
+		return m.CacheCount
+	}
+	return 0
+}
+
+type ReshardResponse struct {
+	// jobID identifies the job moving keys to their new caches
+	JobID                string   `protobuf:"bytes,1,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReshardResponse) Reset()         { *m = ReshardResponse{} }
+func (m *ReshardResponse) String() string { return proto.CompactTextString(m) }
+func (*ReshardResponse) ProtoMessage()    {}
+func (*ReshardResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{39}
+}
+
+func (m *ReshardResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReshardResponse.Unmarshal(m, b)
+}
+func (m *ReshardResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReshardResponse.Marshal(b, m, deterministic)
+}
+func (m *ReshardResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReshardResponse.Merge(m, src)
+}
+func (m *ReshardResponse) XXX_Size() int {
+	return xxx_messageInfo_ReshardResponse.Size(m)
+}
+func (m *ReshardResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReshardResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReshardResponse proto.InternalMessageInfo
+
+func (m *ReshardResponse) GetJobID() string {
+	if m != nil {
+		return m.JobID
+	}
+	return ""
+}
+
+type ResizeRequest struct {
+	// name is the pool to resize; the default pool if empty
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// namespace is the namespace to resize; the default namespace if empty
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// capacity is the new namespace capacity, in bytes
+	Capacity             uint64   `protobuf:"varint,3,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResizeRequest) Reset()         { *m = ResizeRequest{} }
+func (m *ResizeRequest) String() string { return proto.CompactTextString(m) }
+func (*ResizeRequest) ProtoMessage()    {}
+func (*ResizeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{40}
+}
+
+func (m *ResizeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResizeRequest.Unmarshal(m, b)
+}
+func (m *ResizeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResizeRequest.Marshal(b, m, deterministic)
+}
+func (m *ResizeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResizeRequest.Merge(m, src)
+}
+func (m *ResizeRequest) XXX_Size() int {
+	return xxx_messageInfo_ResizeRequest.Size(m)
+}
+func (m *ResizeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResizeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResizeRequest proto.InternalMessageInfo
+
+func (m *ResizeRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ResizeRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *ResizeRequest) GetCapacity() uint64 {
+	if m != nil {
+		return m.Capacity
+	}
+	return 0
+}
+
+type ResizeResponse struct {
+	Pool                 *Pool    `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResizeResponse) Reset()         { *m = ResizeResponse{} }
+func (m *ResizeResponse) String() string { return proto.CompactTextString(m) }
+func (*ResizeResponse) ProtoMessage()    {}
+func (*ResizeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{41}
+}
+
+func (m *ResizeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResizeResponse.Unmarshal(m, b)
+}
+func (m *ResizeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResizeResponse.Marshal(b, m, deterministic)
+}
+func (m *ResizeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResizeResponse.Merge(m, src)
+}
+func (m *ResizeResponse) XXX_Size() int {
+	return xxx_messageInfo_ResizeResponse.Size(m)
+}
+func (m *ResizeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResizeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResizeResponse proto.InternalMessageInfo
+
+func (m *ResizeResponse) GetPool() *Pool {
+	if m != nil {
+		return m.Pool
+	}
+	return nil
+}
+
+type Member struct {
+	// address is the member's gRPC address, as host:port
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// weight is the member's relative share of keys
+	Weight int32        `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	State  Member_State `protobuf:"varint,3,opt,name=state,proto3,enum=Member_State" json:"state,omitempty"`
+	// incarnation orders updates about the member; only the member itself
+	// increments it, to refute suspicion
+	Incarnation          uint64   `protobuf:"varint,4,opt,name=incarnation,proto3" json:"incarnation,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Member) Reset()         { *m = Member{} }
+func (m *Member) String() string { return proto.CompactTextString(m) }
+func (*Member) ProtoMessage()    {}
+func (*Member) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{42}
+}
+
+func (m *Member) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Member.Unmarshal(m, b)
+}
+func (m *Member) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Member.Marshal(b, m, deterministic)
+}
+func (m *Member) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Member.Merge(m, src)
+}
+func (m *Member) XXX_Size() int {
+	return xxx_messageInfo_Member.Size(m)
+}
+func (m *Member) XXX_DiscardUnknown() {
+	xxx_messageInfo_Member.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Member proto.InternalMessageInfo
+
+func (m *Member) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *Member) GetWeight() int32 {
+	if m != nil {
+		return m.Weight
+	}
+	return 0
+}
+
+func (m *Member) GetState() Member_State {
+	if m != nil {
+		return m.State
+	}
+	return Member_ALIVE
+}
+
+func (m *Member) GetIncarnation() uint64 {
+	if m != nil {
+		return m.Incarnation
+	}
+	return 0
+}
+
+type ListMembersRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListMembersRequest) Reset()         { *m = ListMembersRequest{} }
+func (m *ListMembersRequest) String() string { return proto.CompactTextString(m) }
+func (*ListMembersRequest) ProtoMessage()    {}
+func (*ListMembersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{43}
+}
+
+func (m *ListMembersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListMembersRequest.Unmarshal(m, b)
+}
+func (m *ListMembersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListMembersRequest.Marshal(b, m, deterministic)
+}
+func (m *ListMembersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListMembersRequest.Merge(m, src)
+}
+func (m *ListMembersRequest) XXX_Size() int {
+	return xxx_messageInfo_ListMembersRequest.Size(m)
+}
+func (m *ListMembersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListMembersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListMembersRequest proto.InternalMessageInfo
+
+type ListMembersResponse struct {
+	Members              []*Member `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ListMembersResponse) Reset()         { *m = ListMembersResponse{} }
+func (m *ListMembersResponse) String() string { return proto.CompactTextString(m) }
+func (*ListMembersResponse) ProtoMessage()    {}
+func (*ListMembersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{44}
+}
+
+func (m *ListMembersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListMembersResponse.Unmarshal(m, b)
+}
+func (m *ListMembersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListMembersResponse.Marshal(b, m, deterministic)
+}
+func (m *ListMembersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListMembersResponse.Merge(m, src)
+}
+func (m *ListMembersResponse) XXX_Size() int {
+	return xxx_messageInfo_ListMembersResponse.Size(m)
+}
+func (m *ListMembersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListMembersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListMembersResponse proto.InternalMessageInfo
+
+func (m *ListMembersResponse) GetMembers() []*Member {
+	if m != nil {
+		return m.Members
+	}
+	return nil
+}
+
+type PingRequest struct {
+	From string `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	// membership updates piggybacked on the probe
+	Updates              []*Member `protobuf:"bytes,2,rep,name=updates,proto3" json:"updates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *PingRequest) Reset()         { *m = PingRequest{} }
+func (m *PingRequest) String() string { return proto.CompactTextString(m) }
+func (*PingRequest) ProtoMessage()    {}
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{45}
+}
+
+func (m *PingRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PingRequest.Unmarshal(m, b)
+}
+func (m *PingRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PingRequest.Marshal(b, m, deterministic)
+}
+func (m *PingRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PingRequest.Merge(m, src)
+}
+func (m *PingRequest) XXX_Size() int {
+	return xxx_messageInfo_PingRequest.Size(m)
+}
+func (m *PingRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PingRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PingRequest proto.InternalMessageInfo
+
+func (m *PingRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *PingRequest) GetUpdates() []*Member {
+	if m != nil {
+		return m.Updates
+	}
+	return nil
+}
+
+type PingResponse struct {
+	Updates              []*Member `protobuf:"bytes,1,rep,name=updates,proto3" json:"updates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *PingResponse) Reset()         { *m = PingResponse{} }
+func (m *PingResponse) String() string { return proto.CompactTextString(m) }
+func (*PingResponse) ProtoMessage()    {}
+func (*PingResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{46}
+}
+
+func (m *PingResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PingResponse.Unmarshal(m, b)
+}
+func (m *PingResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PingResponse.Marshal(b, m, deterministic)
+}
+func (m *PingResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PingResponse.Merge(m, src)
+}
+func (m *PingResponse) XXX_Size() int {
+	return xxx_messageInfo_PingResponse.Size(m)
+}
+func (m *PingResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PingResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PingResponse proto.InternalMessageInfo
+
+func (m *PingResponse) GetUpdates() []*Member {
+	if m != nil {
+		return m.Updates
+	}
+	return nil
+}
+
+type PingReqRequest struct {
+	From string `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	// target is the member to probe on the sender's behalf
+	Target               string    `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Updates              []*Member `protobuf:"bytes,3,rep,name=updates,proto3" json:"updates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *PingReqRequest) Reset()         { *m = PingReqRequest{} }
+func (m *PingReqRequest) String() string { return proto.CompactTextString(m) }
+func (*PingReqRequest) ProtoMessage()    {}
+func (*PingReqRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{47}
+}
+
+func (m *PingReqRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PingReqRequest.Unmarshal(m, b)
+}
+func (m *PingReqRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PingReqRequest.Marshal(b, m, deterministic)
+}
+func (m *PingReqRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PingReqRequest.Merge(m, src)
+}
+func (m *PingReqRequest) XXX_Size() int {
+	return xxx_messageInfo_PingReqRequest.Size(m)
+}
+func (m *PingReqRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PingReqRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PingReqRequest proto.InternalMessageInfo
+
+func (m *PingReqRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *PingReqRequest) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *PingReqRequest) GetUpdates() []*Member {
+	if m != nil {
+		return m.Updates
+	}
+	return nil
+}
+
+type JoinRequest struct {
+	Member               *Member  `protobuf:"bytes,1,opt,name=member,proto3" json:"member,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *JoinRequest) Reset()         { *m = JoinRequest{} }
+func (m *JoinRequest) String() string { return proto.CompactTextString(m) }
+func (*JoinRequest) ProtoMessage()    {}
+func (*JoinRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{48}
+}
+
+func (m *JoinRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_JoinRequest.Unmarshal(m, b)
+}
+func (m *JoinRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_JoinRequest.Marshal(b, m, deterministic)
+}
+func (m *JoinRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JoinRequest.Merge(m, src)
+}
+func (m *JoinRequest) XXX_Size() int {
+	return xxx_messageInfo_JoinRequest.Size(m)
+}
+func (m *JoinRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_JoinRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JoinRequest proto.InternalMessageInfo
+
+func (m *JoinRequest) GetMember() *Member {
+	if m != nil {
+		return m.Member
+	}
+	return nil
+}
+
+type JoinResponse struct {
+	// members is every member the receiver knows of
+	Members              []*Member `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *JoinResponse) Reset()         { *m = JoinResponse{} }
+func (m *JoinResponse) String() string { return proto.CompactTextString(m) }
+func (*JoinResponse) ProtoMessage()    {}
+func (*JoinResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{49}
+}
+
+func (m *JoinResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_JoinResponse.Unmarshal(m, b)
+}
+func (m *JoinResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_JoinResponse.Marshal(b, m, deterministic)
+}
+func (m *JoinResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JoinResponse.Merge(m, src)
+}
+func (m *JoinResponse) XXX_Size() int {
+	return xxx_messageInfo_JoinResponse.Size(m)
+}
+func (m *JoinResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_JoinResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JoinResponse proto.InternalMessageInfo
+
+func (m *JoinResponse) GetMembers() []*Member {
+	if m != nil {
+		return m.Members
+	}
+	return nil
+}
+
+type RepairReplicasRequest struct {
+	// pool is the replicated pool to repair; every replicated pool if empty
+	Pool                 string   `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RepairReplicasRequest) Reset()         { *m = RepairReplicasRequest{} }
+func (m *RepairReplicasRequest) String() string { return proto.CompactTextString(m) }
+func (*RepairReplicasRequest) ProtoMessage()    {}
+func (*RepairReplicasRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{50}
+}
+
+func (m *RepairReplicasRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RepairReplicasRequest.Unmarshal(m, b)
+}
+func (m *RepairReplicasRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RepairReplicasRequest.Marshal(b, m, deterministic)
+}
+func (m *RepairReplicasRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RepairReplicasRequest.Merge(m, src)
+}
+func (m *RepairReplicasRequest) XXX_Size() int {
+	return xxx_messageInfo_RepairReplicasRequest.Size(m)
+}
+func (m *RepairReplicasRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RepairReplicasRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RepairReplicasRequest proto.InternalMessageInfo
+
+func (m *RepairReplicasRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+type RepairReplicasResponse struct {
+	// jobID identifies the job comparing replicas with the other peers
+	JobID                string   `protobuf:"bytes,1,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RepairReplicasResponse) Reset()         { *m = RepairReplicasResponse{} }
+func (m *RepairReplicasResponse) String() string { return proto.CompactTextString(m) }
+func (*RepairReplicasResponse) ProtoMessage()    {}
+func (*RepairReplicasResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{51}
+}
+
+func (m *RepairReplicasResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RepairReplicasResponse.Unmarshal(m, b)
+}
+func (m *RepairReplicasResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RepairReplicasResponse.Marshal(b, m, deterministic)
+}
+func (m *RepairReplicasResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RepairReplicasResponse.Merge(m, src)
+}
+func (m *RepairReplicasResponse) XXX_Size() int {
+	return xxx_messageInfo_RepairReplicasResponse.Size(m)
+}
+func (m *RepairReplicasResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RepairReplicasResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RepairReplicasResponse proto.InternalMessageInfo
+
+func (m *RepairReplicasResponse) GetJobID() string {
+	if m != nil {
+		return m.JobID
+	}
+	return ""
+}
+
+type MerkleTreeRequest struct {
+	Pool      string `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// from is the requesting peer; the tree covers only the keys both peers
+	// replicate
+	From string `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	// nodes are the tree nodes to return the hashes of
+	Nodes                []uint32 `protobuf:"varint,4,rep,packed,name=nodes,proto3" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MerkleTreeRequest) Reset()         { *m = MerkleTreeRequest{} }
+func (m *MerkleTreeRequest) String() string { return proto.CompactTextString(m) }
+func (*MerkleTreeRequest) ProtoMessage()    {}
+func (*MerkleTreeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{52}
+}
+
+func (m *MerkleTreeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MerkleTreeRequest.Unmarshal(m, b)
+}
+func (m *MerkleTreeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MerkleTreeRequest.Marshal(b, m, deterministic)
+}
+func (m *MerkleTreeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MerkleTreeRequest.Merge(m, src)
+}
+func (m *MerkleTreeRequest) XXX_Size() int {
+	return xxx_messageInfo_MerkleTreeRequest.Size(m)
+}
+func (m *MerkleTreeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MerkleTreeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MerkleTreeRequest proto.InternalMessageInfo
+
+func (m *MerkleTreeRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *MerkleTreeRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *MerkleTreeRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *MerkleTreeRequest) GetNodes() []uint32 {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+type MerkleTreeResponse struct {
+	// hashes are the requested node hashes, in order
+	Hashes               []uint64 `protobuf:"varint,1,rep,packed,name=hashes,proto3" json:"hashes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MerkleTreeResponse) Reset()         { *m = MerkleTreeResponse{} }
+func (m *MerkleTreeResponse) String() string { return proto.CompactTextString(m) }
+func (*MerkleTreeResponse) ProtoMessage()    {}
+func (*MerkleTreeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{53}
+}
+
+func (m *MerkleTreeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MerkleTreeResponse.Unmarshal(m, b)
+}
+func (m *MerkleTreeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MerkleTreeResponse.Marshal(b, m, deterministic)
+}
+func (m *MerkleTreeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MerkleTreeResponse.Merge(m, src)
+}
+func (m *MerkleTreeResponse) XXX_Size() int {
+	return xxx_messageInfo_MerkleTreeResponse.Size(m)
+}
+func (m *MerkleTreeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MerkleTreeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MerkleTreeResponse proto.InternalMessageInfo
+
+func (m *MerkleTreeResponse) GetHashes() []uint64 {
+	if m != nil {
+		return m.Hashes
+	}
+	return nil
+}
+
+type KeyDigest struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Timestamp            uint64   `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeyDigest) Reset()         { *m = KeyDigest{} }
+func (m *KeyDigest) String() string { return proto.CompactTextString(m) }
+func (*KeyDigest) ProtoMessage()    {}
+func (*KeyDigest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{54}
+}
+
+func (m *KeyDigest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyDigest.Unmarshal(m, b)
+}
+func (m *KeyDigest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyDigest.Marshal(b, m, deterministic)
+}
+func (m *KeyDigest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyDigest.Merge(m, src)
+}
+func (m *KeyDigest) XXX_Size() int {
+	return xxx_messageInfo_KeyDigest.Size(m)
+}
+func (m *KeyDigest) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyDigest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyDigest proto.InternalMessageInfo
+
+func (m *KeyDigest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *KeyDigest) GetTimestamp() uint64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+type DiffRequest struct {
+	Pool      string `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	From      string `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	// leaves are the tree leaves the peers disagree about
+	Leaves []uint32 `protobuf:"varint,4,rep,packed,name=leaves,proto3" json:"leaves,omitempty"`
+	// digests are the requesting peer's keys in leaves
+	Digests              []*KeyDigest `protobuf:"bytes,5,rep,name=digests,proto3" json:"digests,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *DiffRequest) Reset()         { *m = DiffRequest{} }
+func (m *DiffRequest) String() string { return proto.CompactTextString(m) }
+func (*DiffRequest) ProtoMessage()    {}
+func (*DiffRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{55}
+}
+
+func (m *DiffRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DiffRequest.Unmarshal(m, b)
+}
+func (m *DiffRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DiffRequest.Marshal(b, m, deterministic)
+}
+func (m *DiffRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DiffRequest.Merge(m, src)
+}
+func (m *DiffRequest) XXX_Size() int {
+	return xxx_messageInfo_DiffRequest.Size(m)
+}
+func (m *DiffRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DiffRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DiffRequest proto.InternalMessageInfo
+
+func (m *DiffRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *DiffRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *DiffRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *DiffRequest) GetLeaves() []uint32 {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
+}
+
+func (m *DiffRequest) GetDigests() []*KeyDigest {
+	if m != nil {
+		return m.Digests
+	}
+	return nil
+}
+
+type DiffResponse struct {
+	// item is newer than the requesting peer's, or missing there
+	Item *Item `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	// wanted are keys the requesting peer has newer writes for
+	Wanted               []string `protobuf:"bytes,2,rep,name=wanted,proto3" json:"wanted,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DiffResponse) Reset()         { *m = DiffResponse{} }
+func (m *DiffResponse) String() string { return proto.CompactTextString(m) }
+func (*DiffResponse) ProtoMessage()    {}
+func (*DiffResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{56}
+}
+
+func (m *DiffResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DiffResponse.Unmarshal(m, b)
+}
+func (m *DiffResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DiffResponse.Marshal(b, m, deterministic)
+}
+func (m *DiffResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DiffResponse.Merge(m, src)
+}
+func (m *DiffResponse) XXX_Size() int {
+	return xxx_messageInfo_DiffResponse.Size(m)
+}
+func (m *DiffResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DiffResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DiffResponse proto.InternalMessageInfo
+
+func (m *DiffResponse) GetItem() *Item {
+	if m != nil {
+		return m.Item
+	}
+	return nil
+}
+
+func (m *DiffResponse) GetWanted() []string {
+	if m != nil {
+		return m.Wanted
+	}
+	return nil
+}
+
+type InvalidateHotRequest struct {
+	Pool      string `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// keys are the changed keys whose copies are dropped
+	Keys                 []string `protobuf:"bytes,3,rep,name=keys,proto3" json:"keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InvalidateHotRequest) Reset()         { *m = InvalidateHotRequest{} }
+func (m *InvalidateHotRequest) String() string { return proto.CompactTextString(m) }
+func (*InvalidateHotRequest) ProtoMessage()    {}
+func (*InvalidateHotRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{57}
+}
+
+func (m *InvalidateHotRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InvalidateHotRequest.Unmarshal(m, b)
+}
+func (m *InvalidateHotRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InvalidateHotRequest.Marshal(b, m, deterministic)
+}
+func (m *InvalidateHotRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InvalidateHotRequest.Merge(m, src)
+}
+func (m *InvalidateHotRequest) XXX_Size() int {
+	return xxx_messageInfo_InvalidateHotRequest.Size(m)
+}
+func (m *InvalidateHotRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InvalidateHotRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InvalidateHotRequest proto.InternalMessageInfo
+
+func (m *InvalidateHotRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *InvalidateHotRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *InvalidateHotRequest) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type InvalidateHotResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InvalidateHotResponse) Reset()         { *m = InvalidateHotResponse{} }
+func (m *InvalidateHotResponse) String() string { return proto.CompactTextString(m) }
+func (*InvalidateHotResponse) ProtoMessage()    {}
+func (*InvalidateHotResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{58}
+}
+
+func (m *InvalidateHotResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InvalidateHotResponse.Unmarshal(m, b)
+}
+func (m *InvalidateHotResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InvalidateHotResponse.Marshal(b, m, deterministic)
+}
+func (m *InvalidateHotResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InvalidateHotResponse.Merge(m, src)
+}
+func (m *InvalidateHotResponse) XXX_Size() int {
+	return xxx_messageInfo_InvalidateHotResponse.Size(m)
+}
+func (m *InvalidateHotResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_InvalidateHotResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InvalidateHotResponse proto.InternalMessageInfo
+
+type Topology struct {
+	// epoch advances each time the node's ring changes. It is the node's
+	// own count, not comparable between nodes.
+	Epoch uint64 `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	// strategy is the consistenthash strategy keys are placed by, and
+	// replicas the ring points per unit of peer weight
+	Strategy string `protobuf:"bytes,2,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	Replicas int32  `protobuf:"varint,3,opt,name=replicas,proto3" json:"replicas,omitempty"`
+	// peers are the ring's members, ordered by address; weight is always
+	// set
+	Peers                []*Member `protobuf:"bytes,4,rep,name=peers,proto3" json:"peers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *Topology) Reset()         { *m = Topology{} }
+func (m *Topology) String() string { return proto.CompactTextString(m) }
+func (*Topology) ProtoMessage()    {}
+func (*Topology) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{59}
+}
+
+func (m *Topology) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Topology.Unmarshal(m, b)
+}
+func (m *Topology) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Topology.Marshal(b, m, deterministic)
+}
+func (m *Topology) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Topology.Merge(m, src)
+}
+func (m *Topology) XXX_Size() int {
+	return xxx_messageInfo_Topology.Size(m)
+}
+func (m *Topology) XXX_DiscardUnknown() {
+	xxx_messageInfo_Topology.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Topology proto.InternalMessageInfo
+
+func (m *Topology) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *Topology) GetStrategy() string {
+	if m != nil {
+		return m.Strategy
+	}
+	return ""
+}
+
+func (m *Topology) GetReplicas() int32 {
+	if m != nil {
+		return m.Replicas
+	}
+	return 0
+}
+
+func (m *Topology) GetPeers() []*Member {
+	if m != nil {
+		return m.Peers
+	}
+	return nil
+}
+
+type GetTopologyRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTopologyRequest) Reset()         { *m = GetTopologyRequest{} }
+func (m *GetTopologyRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTopologyRequest) ProtoMessage()    {}
+func (*GetTopologyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{60}
+}
+
+func (m *GetTopologyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTopologyRequest.Unmarshal(m, b)
+}
+func (m *GetTopologyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTopologyRequest.Marshal(b, m, deterministic)
+}
+func (m *GetTopologyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTopologyRequest.Merge(m, src)
+}
+func (m *GetTopologyRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTopologyRequest.Size(m)
+}
+func (m *GetTopologyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTopologyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTopologyRequest proto.InternalMessageInfo
+
+type GetTopologyResponse struct {
+	Topology             *Topology `protobuf:"bytes,1,opt,name=topology,proto3" json:"topology,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *GetTopologyResponse) Reset()         { *m = GetTopologyResponse{} }
+func (m *GetTopologyResponse) String() string { return proto.CompactTextString(m) }
+func (*GetTopologyResponse) ProtoMessage()    {}
+func (*GetTopologyResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{61}
+}
+
+func (m *GetTopologyResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTopologyResponse.Unmarshal(m, b)
+}
+func (m *GetTopologyResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTopologyResponse.Marshal(b, m, deterministic)
+}
+func (m *GetTopologyResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTopologyResponse.Merge(m, src)
+}
+func (m *GetTopologyResponse) XXX_Size() int {
+	return xxx_messageInfo_GetTopologyResponse.Size(m)
+}
+func (m *GetTopologyResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTopologyResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTopologyResponse proto.InternalMessageInfo
+
+func (m *GetTopologyResponse) GetTopology() *Topology {
+	if m != nil {
+		return m.Topology
+	}
+	return nil
+}
+
+type WatchTopologyRequest struct {
+	// epoch is the epoch of the topology the watcher has; the current
+	// topology is sent first unless it is the same
+	Epoch                uint64   `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchTopologyRequest) Reset()         { *m = WatchTopologyRequest{} }
+func (m *WatchTopologyRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchTopologyRequest) ProtoMessage()    {}
+func (*WatchTopologyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{62}
+}
+
+func (m *WatchTopologyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchTopologyRequest.Unmarshal(m, b)
+}
+func (m *WatchTopologyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchTopologyRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchTopologyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchTopologyRequest.Merge(m, src)
+}
+func (m *WatchTopologyRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchTopologyRequest.Size(m)
+}
+func (m *WatchTopologyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchTopologyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchTopologyRequest proto.InternalMessageInfo
+
+func (m *WatchTopologyRequest) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+type WatchTopologyResponse struct {
+	Topology             *Topology `protobuf:"bytes,1,opt,name=topology,proto3" json:"topology,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *WatchTopologyResponse) Reset()         { *m = WatchTopologyResponse{} }
+func (m *WatchTopologyResponse) String() string { return proto.CompactTextString(m) }
+func (*WatchTopologyResponse) ProtoMessage()    {}
+func (*WatchTopologyResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{63}
+}
+
+func (m *WatchTopologyResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchTopologyResponse.Unmarshal(m, b)
+}
+func (m *WatchTopologyResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchTopologyResponse.Marshal(b, m, deterministic)
+}
+func (m *WatchTopologyResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchTopologyResponse.Merge(m, src)
+}
+func (m *WatchTopologyResponse) XXX_Size() int {
+	return xxx_messageInfo_WatchTopologyResponse.Size(m)
+}
+func (m *WatchTopologyResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchTopologyResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchTopologyResponse proto.InternalMessageInfo
+
+func (m *WatchTopologyResponse) GetTopology() *Topology {
+	if m != nil {
+		return m.Topology
+	}
+	return nil
+}
+
+type Mutation struct {
+	Op        Mutation_Op `protobuf:"varint,1,opt,name=op,proto3,enum=Mutation_Op" json:"op,omitempty"`
+	Pool      string      `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
+	Namespace string      `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// item is the item set, with its version, or just the key removed
+	Item       *Item       `protobuf:"bytes,4,opt,name=item,proto3" json:"item,omitempty"`
+	Tags       []string    `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	Generation uint64      `protobuf:"varint,6,opt,name=generation,proto3" json:"generation,omitempty"`
+	Prefix     string      `protobuf:"bytes,7,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Pattern    string      `protobuf:"bytes,8,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	PoolConfig *PoolConfig `protobuf:"bytes,9,opt,name=poolConfig,proto3" json:"poolConfig,omitempty"`
+	// sequence is the primary's sequence number of the mutation; zero in a
+	// snapshot
+	Sequence             uint64   `protobuf:"varint,10,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Mutation) Reset()         { *m = Mutation{} }
+func (m *Mutation) String() string { return proto.CompactTextString(m) }
+func (*Mutation) ProtoMessage()    {}
+func (*Mutation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{64}
+}
+
+func (m *Mutation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Mutation.Unmarshal(m, b)
+}
+func (m *Mutation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Mutation.Marshal(b, m, deterministic)
+}
+func (m *Mutation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Mutation.Merge(m, src)
+}
+func (m *Mutation) XXX_Size() int {
+	return xxx_messageInfo_Mutation.Size(m)
+}
+func (m *Mutation) XXX_DiscardUnknown() {
+	xxx_messageInfo_Mutation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Mutation proto.InternalMessageInfo
+
+func (m *Mutation) GetOp() Mutation_Op {
+	if m != nil {
+		return m.Op
+	}
+	return Mutation_SET
+}
+
+func (m *Mutation) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *Mutation) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *Mutation) GetItem() *Item {
+	if m != nil {
+		return m.Item
+	}
+	return nil
+}
+
+func (m *Mutation) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Mutation) GetGeneration() uint64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
+func (m *Mutation) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *Mutation) GetPattern() string {
+	if m != nil {
+		return m.Pattern
+	}
+	return ""
+}
+
+func (m *Mutation) GetPoolConfig() *PoolConfig {
+	if m != nil {
+		return m.PoolConfig
+	}
+	return nil
+}
+
+func (m *Mutation) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+type ReplicateRequest struct {
+	// replica names the replica, for the primary's logs
+	Replica              string   `protobuf:"bytes,1,opt,name=replica,proto3" json:"replica,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplicateRequest) Reset()         { *m = ReplicateRequest{} }
+func (m *ReplicateRequest) String() string { return proto.CompactTextString(m) }
+func (*ReplicateRequest) ProtoMessage()    {}
+func (*ReplicateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{65}
+}
+
+func (m *ReplicateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicateRequest.Unmarshal(m, b)
+}
+func (m *ReplicateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicateRequest.Marshal(b, m, deterministic)
+}
+func (m *ReplicateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicateRequest.Merge(m, src)
+}
+func (m *ReplicateRequest) XXX_Size() int {
+	return xxx_messageInfo_ReplicateRequest.Size(m)
+}
+func (m *ReplicateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicateRequest proto.InternalMessageInfo
+
+func (m *ReplicateRequest) GetReplica() string {
+	if m != nil {
+		return m.Replica
+	}
+	return ""
+}
+
+type ReplicateResponse struct {
+	// sequence is the primary's sequence number of the last mutation the
+	// message brings the replica up to, and latest the primary's latest
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Latest   uint64 `protobuf:"varint,2,opt,name=latest,proto3" json:"latest,omitempty"`
+	// timestamp is when the primary applied the message's mutation, or
+	// sent it if it has none, in unix nanoseconds
+	Timestamp int64       `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Mutations []*Mutation `protobuf:"bytes,4,rep,name=mutations,proto3" json:"mutations,omitempty"`
+	// snapshot marks the messages of the snapshot the stream starts with,
+	// and snapshotDone the last of them
+	Snapshot             bool     `protobuf:"varint,5,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	SnapshotDone         bool     `protobuf:"varint,6,opt,name=snapshotDone,proto3" json:"snapshotDone,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplicateResponse) Reset()         { *m = ReplicateResponse{} }
+func (m *ReplicateResponse) String() string { return proto.CompactTextString(m) }
+func (*ReplicateResponse) ProtoMessage()    {}
+func (*ReplicateResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{66}
+}
+
+func (m *ReplicateResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicateResponse.Unmarshal(m, b)
+}
+func (m *ReplicateResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicateResponse.Marshal(b, m, deterministic)
+}
+func (m *ReplicateResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicateResponse.Merge(m, src)
+}
+func (m *ReplicateResponse) XXX_Size() int {
+	return xxx_messageInfo_ReplicateResponse.Size(m)
+}
+func (m *ReplicateResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicateResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicateResponse proto.InternalMessageInfo
+
+func (m *ReplicateResponse) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *ReplicateResponse) GetLatest() uint64 {
+	if m != nil {
+		return m.Latest
+	}
+	return 0
+}
+
+func (m *ReplicateResponse) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *ReplicateResponse) GetMutations() []*Mutation {
+	if m != nil {
+		return m.Mutations
+	}
+	return nil
+}
+
+func (m *ReplicateResponse) GetSnapshot() bool {
+	if m != nil {
+		return m.Snapshot
+	}
+	return false
+}
+
+func (m *ReplicateResponse) GetSnapshotDone() bool {
+	if m != nil {
+		return m.SnapshotDone
+	}
+	return false
+}
+
+type WatchRequest struct {
+	// keys and prefixes select the keys watched; every key in the
+	// namespace if neither is set
+	Keys                 []string `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	Prefixes             []string `protobuf:"bytes,2,rep,name=prefixes,proto3" json:"prefixes,omitempty"`
+	Namespace            string   `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pool                 string   `protobuf:"bytes,4,opt,name=pool,proto3" json:"pool,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchRequest) Reset()         { *m = WatchRequest{} }
+func (m *WatchRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()    {}
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{67}
+}
+
+func (m *WatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchRequest.Unmarshal(m, b)
+}
+func (m *WatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchRequest.Merge(m, src)
+}
+func (m *WatchRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchRequest.Size(m)
+}
+func (m *WatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchRequest proto.InternalMessageInfo
+
+func (m *WatchRequest) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func (m *WatchRequest) GetPrefixes() []string {
+	if m != nil {
+		return m.Prefixes
+	}
+	return nil
+}
+
+func (m *WatchRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *WatchRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+type WatchEvent struct {
+	Type WatchEvent_Type `protobuf:"varint,1,opt,name=type,proto3,enum=WatchEvent_Type" json:"type,omitempty"`
+	Key  string          `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// casID is the item's version after a set, and the version removed
+	// otherwise
+	CasID int64 `protobuf:"varint,3,opt,name=casID,proto3" json:"casID,omitempty"`
+	// missed is the number of events an overflow dropped
+	Missed uint64 `protobuf:"varint,4,opt,name=missed,proto3" json:"missed,omitempty"`
+	// node is the cluster node the event happened on, if clustered
+	Node                 string   `protobuf:"bytes,5,opt,name=node,proto3" json:"node,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchEvent) Reset()         { *m = WatchEvent{} }
+func (m *WatchEvent) String() string { return proto.CompactTextString(m) }
+func (*WatchEvent) ProtoMessage()    {}
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{68}
+}
+
+func (m *WatchEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchEvent.Unmarshal(m, b)
+}
+func (m *WatchEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchEvent.Marshal(b, m, deterministic)
+}
+func (m *WatchEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchEvent.Merge(m, src)
+}
+func (m *WatchEvent) XXX_Size() int {
+	return xxx_messageInfo_WatchEvent.Size(m)
+}
+func (m *WatchEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchEvent proto.InternalMessageInfo
+
+func (m *WatchEvent) GetType() WatchEvent_Type {
+	if m != nil {
+		return m.Type
+	}
+	return WatchEvent_SET
+}
+
+func (m *WatchEvent) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *WatchEvent) GetCasID() int64 {
+	if m != nil {
+		return m.CasID
+	}
+	return 0
+}
+
+func (m *WatchEvent) GetMissed() uint64 {
+	if m != nil {
+		return m.Missed
+	}
+	return 0
+}
+
+func (m *WatchEvent) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+type WatchResponse struct {
+	Events               []*WatchEvent `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *WatchResponse) Reset()         { *m = WatchResponse{} }
+func (m *WatchResponse) String() string { return proto.CompactTextString(m) }
+func (*WatchResponse) ProtoMessage()    {}
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{69}
+}
+
+func (m *WatchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchResponse.Unmarshal(m, b)
+}
+func (m *WatchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchResponse.Marshal(b, m, deterministic)
+}
+func (m *WatchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchResponse.Merge(m, src)
+}
+func (m *WatchResponse) XXX_Size() int {
+	return xxx_messageInfo_WatchResponse.Size(m)
+}
+func (m *WatchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchResponse proto.InternalMessageInfo
+
+func (m *WatchResponse) GetEvents() []*WatchEvent {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+type TrackRequest struct {
+	// client identifies the client the reads tagged with the mc-tracking
+	// request metadata are tracked for. It must be unique.
+	Client               string   `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TrackRequest) Reset()         { *m = TrackRequest{} }
+func (m *TrackRequest) String() string { return proto.CompactTextString(m) }
+func (*TrackRequest) ProtoMessage()    {}
+func (*TrackRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{70}
+}
+
+func (m *TrackRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TrackRequest.Unmarshal(m, b)
+}
+func (m *TrackRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TrackRequest.Marshal(b, m, deterministic)
+}
+func (m *TrackRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TrackRequest.Merge(m, src)
+}
+func (m *TrackRequest) XXX_Size() int {
+	return xxx_messageInfo_TrackRequest.Size(m)
+}
+func (m *TrackRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TrackRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TrackRequest proto.InternalMessageInfo
+
+func (m *TrackRequest) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+type Invalidation struct {
+	Pool      string `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// key is empty if every key of the namespace changed
+	Key                  string   `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Invalidation) Reset()         { *m = Invalidation{} }
+func (m *Invalidation) String() string { return proto.CompactTextString(m) }
+func (*Invalidation) ProtoMessage()    {}
+func (*Invalidation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{71}
+}
+
+func (m *Invalidation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Invalidation.Unmarshal(m, b)
+}
+func (m *Invalidation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Invalidation.Marshal(b, m, deterministic)
+}
+func (m *Invalidation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Invalidation.Merge(m, src)
+}
+func (m *Invalidation) XXX_Size() int {
+	return xxx_messageInfo_Invalidation.Size(m)
+}
+func (m *Invalidation) XXX_DiscardUnknown() {
+	xxx_messageInfo_Invalidation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Invalidation proto.InternalMessageInfo
+
+func (m *Invalidation) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *Invalidation) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *Invalidation) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type TrackResponse struct {
+	// ready is set on the first message, once reads are tracked on every
+	// node; keys read before it must not be cached
+	Ready         bool            `protobuf:"varint,1,opt,name=ready,proto3" json:"ready,omitempty"`
+	Invalidations []*Invalidation `protobuf:"bytes,2,rep,name=invalidations,proto3" json:"invalidations,omitempty"`
+	// flush tells the client it fell behind, and must drop every key
+	Flush                bool     `protobuf:"varint,3,opt,name=flush,proto3" json:"flush,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TrackResponse) Reset()         { *m = TrackResponse{} }
+func (m *TrackResponse) String() string { return proto.CompactTextString(m) }
+func (*TrackResponse) ProtoMessage()    {}
+func (*TrackResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{72}
+}
+
+func (m *TrackResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TrackResponse.Unmarshal(m, b)
+}
+func (m *TrackResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TrackResponse.Marshal(b, m, deterministic)
+}
+func (m *TrackResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TrackResponse.Merge(m, src)
+}
+func (m *TrackResponse) XXX_Size() int {
+	return xxx_messageInfo_TrackResponse.Size(m)
+}
+func (m *TrackResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TrackResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TrackResponse proto.InternalMessageInfo
+
+func (m *TrackResponse) GetReady() bool {
+	if m != nil {
+		return m.Ready
+	}
+	return false
+}
+
+func (m *TrackResponse) GetInvalidations() []*Invalidation {
+	if m != nil {
+		return m.Invalidations
+	}
+	return nil
+}
+
+func (m *TrackResponse) GetFlush() bool {
+	if m != nil {
+		return m.Flush
+	}
+	return false
+}
+
+// AntiEntropy is the protocol replicas use to find and repair the keys they
+// disagree about.
+type LoadRequest struct {
+	Pool                 string   `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	Namespace            string   `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Key                  string   `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LoadRequest) Reset()         { *m = LoadRequest{} }
+func (m *LoadRequest) String() string { return proto.CompactTextString(m) }
+func (*LoadRequest) ProtoMessage()    {}
+func (*LoadRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{73}
+}
+
+func (m *LoadRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LoadRequest.Unmarshal(m, b)
+}
+func (m *LoadRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LoadRequest.Marshal(b, m, deterministic)
+}
+func (m *LoadRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LoadRequest.Merge(m, src)
+}
+func (m *LoadRequest) XXX_Size() int {
+	return xxx_messageInfo_LoadRequest.Size(m)
+}
+func (m *LoadRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LoadRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LoadRequest proto.InternalMessageInfo
+
+func (m *LoadRequest) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *LoadRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *LoadRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type LoadResponse struct {
+	// found is false if the origin does not have the key
+	Found                bool     `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	Value                []byte   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Tags                 []string `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LoadResponse) Reset()         { *m = LoadResponse{} }
+func (m *LoadResponse) String() string { return proto.CompactTextString(m) }
+func (*LoadResponse) ProtoMessage()    {}
+func (*LoadResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8892273135fec606, []int{74}
+}
+
+func (m *LoadResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LoadResponse.Unmarshal(m, b)
+}
+func (m *LoadResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LoadResponse.Marshal(b, m, deterministic)
+}
+func (m *LoadResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LoadResponse.Merge(m, src)
+}
+func (m *LoadResponse) XXX_Size() int {
+	return xxx_messageInfo_LoadResponse.Size(m)
+}
+func (m *LoadResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_LoadResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LoadResponse proto.InternalMessageInfo
+
+func (m *LoadResponse) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+func (m *LoadResponse) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *LoadResponse) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("Job_State", Job_State_name, Job_State_value)
+	proto.RegisterEnum("PoolConfig_EvictionPolicy", PoolConfig_EvictionPolicy_name, PoolConfig_EvictionPolicy_value)
+	proto.RegisterEnum("PoolConfig_HashStrategy", PoolConfig_HashStrategy_name, PoolConfig_HashStrategy_value)
+	proto.RegisterEnum("Member_State", Member_State_name, Member_State_value)
+	proto.RegisterEnum("Mutation_Op", Mutation_Op_name, Mutation_Op_value)
+	proto.RegisterEnum("WatchEvent_Type", WatchEvent_Type_name, WatchEvent_Type_value)
+	proto.RegisterType((*Item)(nil), "Item")
+	proto.RegisterType((*GetRequest)(nil), "GetRequest")
+	proto.RegisterType((*GetResponse)(nil), "GetResponse")
+	proto.RegisterType((*SetRequest)(nil), "SetRequest")
+	proto.RegisterType((*SetResponse)(nil), "SetResponse")
+	proto.RegisterType((*CompareAndSwapRequest)(nil), "CompareAndSwapRequest")
+	proto.RegisterType((*CompareAndSwapResponse)(nil), "CompareAndSwapResponse")
+	proto.RegisterType((*RemoveRequest)(nil), "RemoveRequest")
+	proto.RegisterType((*RemoveResponse)(nil), "RemoveResponse")
+	proto.RegisterType((*ClearNamespaceRequest)(nil), "ClearNamespaceRequest")
+	proto.RegisterType((*ClearNamespaceResponse)(nil), "ClearNamespaceResponse")
+	proto.RegisterType((*SizeRequest)(nil), "SizeRequest")
+	proto.RegisterType((*SizeResponse)(nil), "SizeResponse")
+	proto.RegisterType((*DeleteMatchingRequest)(nil), "DeleteMatchingRequest")
+	proto.RegisterType((*DeleteMatchingResponse)(nil), "DeleteMatchingResponse")
+	proto.RegisterType((*Job)(nil), "Job")
+	proto.RegisterType((*GetJobRequest)(nil), "GetJobRequest")
+	proto.RegisterType((*GetJobResponse)(nil), "GetJobResponse")
+	proto.RegisterType((*CancelJobRequest)(nil), "CancelJobRequest")
+	proto.RegisterType((*CancelJobResponse)(nil), "CancelJobResponse")
+	proto.RegisterType((*InvalidateTagsRequest)(nil), "InvalidateTagsRequest")
+	proto.RegisterType((*InvalidateTagsResponse)(nil), "InvalidateTagsResponse")
+	proto.RegisterType((*GetGenerationRequest)(nil), "GetGenerationRequest")
+	proto.RegisterType((*GetGenerationResponse)(nil), "GetGenerationResponse")
+	proto.RegisterType((*SetGenerationRequest)(nil), "SetGenerationRequest")
+	proto.RegisterType((*SetGenerationResponse)(nil), "SetGenerationResponse")
+	proto.RegisterType((*BumpGenerationRequest)(nil), "BumpGenerationRequest")
+	proto.RegisterType((*BumpGenerationResponse)(nil), "BumpGenerationResponse")
+	proto.RegisterType((*PoolConfig)(nil), "PoolConfig")
+	proto.RegisterMapType((map[string]uint64)(nil), "PoolConfig.NamespacesEntry")
+	proto.RegisterType((*Pool)(nil), "Pool")
+	proto.RegisterType((*CreatePoolRequest)(nil), "CreatePoolRequest")
+	proto.RegisterType((*CreatePoolResponse)(nil), "CreatePoolResponse")
+	proto.RegisterType((*ListPoolsRequest)(nil), "ListPoolsRequest")
+	proto.RegisterType((*ListPoolsResponse)(nil), "ListPoolsResponse")
+	proto.RegisterType((*DescribePoolRequest)(nil), "DescribePoolRequest")
+	proto.RegisterType((*DescribePoolResponse)(nil), "DescribePoolResponse")
+	proto.RegisterType((*DeletePoolRequest)(nil), "DeletePoolRequest")
+	proto.RegisterType((*DeletePoolResponse)(nil), "DeletePoolResponse")
+	proto.RegisterType((*ReshardRequest)(nil), "ReshardRequest")
+	proto.RegisterType((*ReshardResponse)(nil), "ReshardResponse")
+	proto.RegisterType((*ResizeRequest)(nil), "ResizeRequest")
+	proto.RegisterType((*ResizeResponse)(nil), "ResizeResponse")
+	proto.RegisterType((*Member)(nil), "Member")
+	proto.RegisterType((*ListMembersRequest)(nil), "ListMembersRequest")
+	proto.RegisterType((*ListMembersResponse)(nil), "ListMembersResponse")
+	proto.RegisterType((*PingRequest)(nil), "PingRequest")
+	proto.RegisterType((*PingResponse)(nil), "PingResponse")
+	proto.RegisterType((*PingReqRequest)(nil), "PingReqRequest")
+	proto.RegisterType((*JoinRequest)(nil), "JoinRequest")
+	proto.RegisterType((*JoinResponse)(nil), "JoinResponse")
+	proto.RegisterType((*RepairReplicasRequest)(nil), "RepairReplicasRequest")
+	proto.RegisterType((*RepairReplicasResponse)(nil), "RepairReplicasResponse")
+	proto.RegisterType((*MerkleTreeRequest)(nil), "MerkleTreeRequest")
+	proto.RegisterType((*MerkleTreeResponse)(nil), "MerkleTreeResponse")
+	proto.RegisterType((*KeyDigest)(nil), "KeyDigest")
+	proto.RegisterType((*DiffRequest)(nil), "DiffRequest")
+	proto.RegisterType((*DiffResponse)(nil), "DiffResponse")
+	proto.RegisterType((*InvalidateHotRequest)(nil), "InvalidateHotRequest")
+	proto.RegisterType((*InvalidateHotResponse)(nil), "InvalidateHotResponse")
+	proto.RegisterType((*Topology)(nil), "Topology")
+	proto.RegisterType((*GetTopologyRequest)(nil), "GetTopologyRequest")
+	proto.RegisterType((*GetTopologyResponse)(nil), "GetTopologyResponse")
+	proto.RegisterType((*WatchTopologyRequest)(nil), "WatchTopologyRequest")
+	proto.RegisterType((*WatchTopologyResponse)(nil), "WatchTopologyResponse")
+	proto.RegisterType((*Mutation)(nil), "Mutation")
+	proto.RegisterType((*ReplicateRequest)(nil), "ReplicateRequest")
+	proto.RegisterType((*ReplicateResponse)(nil), "ReplicateResponse")
+	proto.RegisterType((*WatchRequest)(nil), "WatchRequest")
+	proto.RegisterType((*WatchEvent)(nil), "WatchEvent")
+	proto.RegisterType((*WatchResponse)(nil), "WatchResponse")
+	proto.RegisterType((*TrackRequest)(nil), "TrackRequest")
+	proto.RegisterType((*Invalidation)(nil), "Invalidation")
+	proto.RegisterType((*TrackResponse)(nil), "TrackResponse")
+	proto.RegisterType((*LoadRequest)(nil), "LoadRequest")
+	proto.RegisterType((*LoadResponse)(nil), "LoadResponse")
+}
+
+func init() { proto.RegisterFile("memcached.proto", fileDescriptor_8892273135fec606) }
+
+var fileDescriptor_8892273135fec606 = []byte{
+	// 2957 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x5a, 0x5b, 0x73, 0xdb, 0xc6,
+	0xf5, 0x27, 0x48, 0x90, 0x12, 0x0f, 0x2f, 0xa2, 0x56, 0x22, 0xcd, 0x3f, 0x92, 0x7f, 0xfe, 0xfa,
+	0xaf, 0xe3, 0x5a, 0xad, 0x3d, 0x9b, 0x44, 0xce, 0x34, 0x6e, 0x6e, 0x2d, 0x43, 0xc2, 0x32, 0x1d,
+	0xdd, 0x02, 0xd2, 0x4e, 0xa7, 0xd3, 0x8c, 0x07, 0x22, 0x57, 0x12, 0x62, 0x12, 0x40, 0x01, 0xc8,
+	0x8e, 0xd2, 0x0f, 0xd0, 0xb7, 0xce, 0x64, 0x3a, 0x7d, 0xe9, 0x53, 0x9f, 0xfa, 0xd2, 0xe7, 0xf6,
+	0x63, 0xf4, 0xa5, 0xdf, 0xa2, 0x1f, 0xa2, 0xd3, 0xd9, 0x0b, 0x80, 0x05, 0x09, 0x49, 0xb6, 0x93,
+	0xbe, 0xe1, 0x9c, 0x5d, 0x9c, 0xdb, 0x2e, 0xce, 0xe5, 0x47, 0xc2, 0xda, 0x9c, 0xce, 0x27, 0xf6,
+	0xe4, 0x8c, 0x4e, 0x89, 0x1f, 0x78, 0x91, 0x87, 0xff, 0xa5, 0x81, 0x3e, 0x8c, 0xe8, 0x1c, 0xb5,
+	0xa0, 0xf4, 0x8c, 0x5e, 0x74, 0xb5, 0x2d, 0x6d, 0xbb, 0x6a, 0xb1, 0x47, 0xb4, 0x09, 0xe5, 0xe7,
+	0xf6, 0xec, 0x9c, 0x76, 0x8b, 0x5b, 0xda, 0x76, 0xdd, 0x12, 0x04, 0xe3, 0x4e, 0xec, 0x70, 0x38,
+	0xe8, 0x96, 0xb6, 0xb4, 0xed, 0x92, 0x25, 0x08, 0x84, 0x40, 0x8f, 0xec, 0xd3, 0xb0, 0xab, 0x6f,
+	0x95, 0xb6, 0xab, 0x16, 0x7f, 0x46, 0x6f, 0x42, 0x35, 0x72, 0xe6, 0x34, 0x8c, 0xec, 0xb9, 0xdf,
+	0x2d, 0x6f, 0x69, 0xdb, 0xba, 0x95, 0x32, 0x50, 0x17, 0x56, 0xa6, 0x74, 0x46, 0x23, 0x3a, 0xed,
+	0x56, 0xb6, 0xb4, 0xed, 0x55, 0x2b, 0x26, 0x99, 0x86, 0x29, 0x9d, 0x45, 0x76, 0x77, 0x45, 0x68,
+	0xe0, 0x04, 0x93, 0x46, 0xbf, 0xf1, 0x9d, 0x80, 0x86, 0xbd, 0xa8, 0xbb, 0xca, 0x57, 0x52, 0x06,
+	0x7a, 0x1b, 0x1a, 0xa1, 0x77, 0x12, 0x99, 0xc9, 0x8e, 0x2a, 0xdf, 0x91, 0x65, 0xe2, 0x3f, 0x69,
+	0x00, 0xbb, 0x34, 0xb2, 0xe8, 0x6f, 0xce, 0x69, 0x18, 0xe5, 0xb8, 0xfc, 0x26, 0x54, 0x5d, 0x7b,
+	0x4e, 0x43, 0xdf, 0x9e, 0x08, 0xb7, 0xab, 0x56, 0xca, 0x60, 0x4e, 0xfa, 0x9e, 0x37, 0xe3, 0x9e,
+	0x57, 0x2d, 0xfe, 0x8c, 0xde, 0x02, 0x08, 0xa8, 0x3d, 0xfd, 0xe2, 0xdc, 0x0b, 0xce, 0xe7, 0x5d,
+	0x7d, 0x4b, 0xdb, 0x2e, 0x5b, 0x0a, 0x87, 0x39, 0x33, 0xa3, 0x76, 0x48, 0x79, 0x00, 0x56, 0x2d,
+	0x41, 0x30, 0x49, 0xc7, 0x34, 0xb2, 0xb9, 0xe7, 0x9a, 0xc5, 0x9f, 0xf1, 0x5f, 0x35, 0xa8, 0x71,
+	0xe3, 0x42, 0xdf, 0x73, 0x43, 0x8a, 0xfe, 0x07, 0x74, 0x27, 0xa2, 0x73, 0x6e, 0x5e, 0x6d, 0xa7,
+	0x4c, 0xd8, 0x29, 0x59, 0x9c, 0x95, 0x0a, 0x2d, 0xf2, 0xa8, 0xa6, 0x42, 0x5f, 0xd8, 0x4e, 0xc4,
+	0xcd, 0x5b, 0xb5, 0xf8, 0x33, 0xba, 0x09, 0xd5, 0x30, 0xb2, 0x67, 0x94, 0xbd, 0xdc, 0xd5, 0x55,
+	0x49, 0x29, 0x9f, 0x89, 0xe3, 0x44, 0x6c, 0x23, 0x27, 0x58, 0x2c, 0x02, 0x3a, 0xf1, 0xe6, 0xfe,
+	0x79, 0x44, 0xe5, 0x11, 0xa5, 0x0c, 0xfc, 0x9d, 0x06, 0x30, 0x4a, 0x43, 0x79, 0x85, 0xb1, 0xaf,
+	0x1e, 0xd3, 0x2d, 0xa8, 0xbd, 0x08, 0x9c, 0x88, 0x66, 0x82, 0xaa, 0xb2, 0xb2, 0x51, 0x8d, 0x03,
+	0x80, 0xb7, 0xa1, 0x36, 0x7a, 0xa9, 0x00, 0xe2, 0xbf, 0x68, 0xd0, 0xee, 0x7b, 0x73, 0xdf, 0x0e,
+	0x68, 0xcf, 0x9d, 0x8e, 0x5e, 0xd8, 0xfe, 0x7f, 0xc5, 0x91, 0xeb, 0x2e, 0xc7, 0x82, 0xa3, 0xe5,
+	0x25, 0x47, 0xf1, 0x3d, 0xe8, 0x2c, 0xda, 0x79, 0xbd, 0x77, 0x7f, 0xd0, 0xa0, 0x61, 0xd1, 0xb9,
+	0xf7, 0x9c, 0xfe, 0x90, 0x37, 0xfd, 0xfa, 0x53, 0xb9, 0xf2, 0x83, 0xc7, 0x77, 0xa0, 0x19, 0x1b,
+	0x75, 0xbd, 0x0b, 0x43, 0x68, 0xf7, 0x67, 0xd4, 0x0e, 0x0e, 0x62, 0x93, 0x62, 0x4f, 0x32, 0x76,
+	0x6b, 0x97, 0xd9, 0x5d, 0x4c, 0xed, 0xc6, 0x5d, 0xe8, 0x2c, 0x8a, 0x12, 0xfa, 0xf1, 0xcf, 0xa1,
+	0x36, 0x72, 0xbe, 0xfd, 0x1e, 0xa2, 0x31, 0xd4, 0x85, 0x00, 0xe9, 0x10, 0x02, 0x3d, 0x74, 0xbe,
+	0x15, 0x2f, 0xeb, 0x16, 0x7f, 0xc6, 0xbf, 0x85, 0xf6, 0x80, 0x27, 0xb6, 0x7d, 0x3b, 0x9a, 0x9c,
+	0x39, 0xee, 0x69, 0xac, 0xae, 0x03, 0x15, 0x3f, 0xa0, 0x27, 0xce, 0x37, 0x52, 0x97, 0xa4, 0x58,
+	0x62, 0xf4, 0xed, 0x28, 0xa2, 0x81, 0x2b, 0x75, 0xc5, 0x64, 0xd6, 0xc0, 0xd2, 0x65, 0x06, 0xea,
+	0x8a, 0x81, 0x04, 0x3a, 0x8b, 0xca, 0xa5, 0xa9, 0x9b, 0x50, 0xfe, 0xda, 0x3b, 0x1e, 0x0e, 0xa4,
+	0x72, 0x41, 0xe0, 0x3f, 0x17, 0xa1, 0xf4, 0xc8, 0x3b, 0xce, 0x5f, 0x65, 0x1a, 0x9e, 0x39, 0xee,
+	0x34, 0x0e, 0x01, 0x7b, 0x46, 0x5b, 0x3c, 0x77, 0x44, 0xc2, 0x9e, 0xe6, 0x0e, 0x90, 0x47, 0xde,
+	0x31, 0x19, 0x31, 0x8e, 0x25, 0x16, 0x98, 0x2c, 0x1a, 0x04, 0x5e, 0x20, 0x0d, 0x13, 0x04, 0x93,
+	0x35, 0xf5, 0xdc, 0xf8, 0x03, 0xe6, 0xcf, 0x6c, 0x67, 0xe4, 0x45, 0xf6, 0x8c, 0x67, 0x1b, 0xdd,
+	0x12, 0x04, 0xe3, 0x4e, 0xbc, 0x73, 0x37, 0xe2, 0xe5, 0x40, 0xb7, 0x04, 0xc1, 0x62, 0x11, 0x46,
+	0x76, 0x10, 0xd1, 0x69, 0x5a, 0x0e, 0x12, 0x06, 0xfb, 0xf0, 0x4e, 0x1c, 0xd7, 0x09, 0xcf, 0xe8,
+	0x34, 0xa9, 0x05, 0x0a, 0x07, 0xff, 0x0c, 0xca, 0xdc, 0x46, 0x54, 0x83, 0x15, 0xeb, 0xf1, 0xc1,
+	0xc1, 0xf0, 0x60, 0xb7, 0x55, 0x40, 0xab, 0xa0, 0x0f, 0x0e, 0x0f, 0xcc, 0x96, 0x86, 0x1a, 0x50,
+	0xed, 0xf7, 0x0e, 0xfa, 0xe6, 0xde, 0x9e, 0x39, 0x68, 0x15, 0x11, 0x40, 0xe5, 0x41, 0x6f, 0xc8,
+	0x9e, 0x4b, 0xf8, 0x16, 0x34, 0x76, 0x69, 0xf4, 0xc8, 0x3b, 0x8e, 0xcf, 0x31, 0x3f, 0x92, 0xdb,
+	0xd0, 0x8c, 0xb7, 0xc9, 0x88, 0x77, 0xa0, 0xf4, 0xb5, 0x77, 0x2c, 0x2f, 0xbb, 0xce, 0xe2, 0x64,
+	0x31, 0x06, 0xde, 0x86, 0x56, 0xdf, 0x76, 0x27, 0x74, 0x76, 0xad, 0xcc, 0x3b, 0xb0, 0xae, 0xec,
+	0xbc, 0x46, 0xec, 0x57, 0xd0, 0x1e, 0xba, 0xcf, 0xed, 0x99, 0x33, 0xb5, 0x23, 0x3a, 0xb6, 0x4f,
+	0xc3, 0x58, 0x76, 0x5c, 0xaa, 0xb5, 0x6c, 0xa9, 0x7e, 0xb5, 0x6c, 0xc0, 0x6e, 0xd6, 0xa2, 0xf8,
+	0xf4, 0x66, 0x89, 0xf3, 0xd2, 0x94, 0xf3, 0xc2, 0x0f, 0x61, 0x73, 0x97, 0x46, 0xbb, 0xd4, 0xa5,
+	0x81, 0x1d, 0x39, 0x9e, 0xfb, 0xfa, 0x1f, 0xdd, 0x07, 0xd0, 0x5e, 0x90, 0x24, 0x15, 0xbf, 0x05,
+	0x70, 0x9a, 0x70, 0xa5, 0x76, 0x85, 0x83, 0xcf, 0x60, 0x73, 0xf4, 0xea, 0x26, 0x64, 0xa5, 0x16,
+	0x17, 0xa5, 0xe6, 0x06, 0xe7, 0x03, 0x68, 0x8f, 0x5e, 0xcb, 0xc4, 0x21, 0xb4, 0x3f, 0x3b, 0x9f,
+	0xfb, 0x3f, 0x44, 0x98, 0xee, 0x43, 0x67, 0x51, 0xd4, 0x4b, 0x1a, 0xf1, 0xc7, 0x0a, 0xc0, 0x91,
+	0xe7, 0xcd, 0xfa, 0x9e, 0x7b, 0xe2, 0x9c, 0x32, 0xe1, 0x4c, 0x93, 0xd4, 0xca, 0x9f, 0x91, 0x01,
+	0xab, 0x13, 0xdb, 0xb7, 0x27, 0x4e, 0x74, 0x21, 0x43, 0x92, 0xd0, 0x4c, 0x3c, 0xef, 0x30, 0xfb,
+	0xfc, 0x12, 0x94, 0x44, 0xd1, 0x4b, 0x39, 0xec, 0xdd, 0x80, 0xfa, 0x33, 0x67, 0x62, 0x87, 0xb2,
+	0x88, 0x24, 0x34, 0xda, 0x81, 0x8a, 0xef, 0xcd, 0x9c, 0xc9, 0x05, 0xcf, 0x0b, 0xcd, 0x1d, 0x83,
+	0xa4, 0x86, 0x10, 0xf3, 0xb9, 0x33, 0x61, 0x06, 0x1e, 0xf1, 0x1d, 0x96, 0xdc, 0xc9, 0xab, 0x4e,
+	0x34, 0xdb, 0x77, 0x66, 0x33, 0x27, 0xe4, 0x99, 0xa3, 0x64, 0xa5, 0x0c, 0xf4, 0x11, 0x40, 0x12,
+	0xa7, 0xb0, 0xbb, 0xb2, 0x55, 0xda, 0xae, 0xed, 0xbc, 0xa1, 0x4a, 0x4d, 0xca, 0x42, 0x68, 0xba,
+	0x51, 0x70, 0x61, 0x29, 0xdb, 0xd1, 0xc7, 0x50, 0x3f, 0xb3, 0xc3, 0xb3, 0x51, 0x14, 0xd8, 0x11,
+	0x3d, 0xbd, 0xe0, 0x79, 0xa6, 0xb9, 0xd3, 0x55, 0x5f, 0x7f, 0xa8, 0xac, 0x5b, 0x99, 0xdd, 0xac,
+	0x60, 0x1e, 0x7b, 0xe7, 0xee, 0x94, 0x4e, 0xf7, 0x3c, 0x7b, 0xca, 0xb3, 0x90, 0x66, 0xa9, 0x2c,
+	0x74, 0x17, 0xd6, 0xa5, 0xeb, 0xcc, 0xaf, 0x07, 0xf6, 0x24, 0xf2, 0x82, 0x2e, 0xf0, 0x98, 0x2c,
+	0x2f, 0x2c, 0x74, 0x13, 0xb5, 0xeb, 0xba, 0x89, 0xfa, 0x72, 0x81, 0x96, 0x5d, 0xf2, 0x38, 0x09,
+	0x57, 0x23, 0xed, 0x92, 0x13, 0x26, 0x2b, 0x4c, 0x5e, 0xe0, 0x9c, 0x3a, 0x6e, 0xb7, 0x29, 0x0a,
+	0x93, 0xa0, 0x98, 0xb5, 0x33, 0xcf, 0x9e, 0x8e, 0x9d, 0x39, 0xf5, 0xce, 0x23, 0x29, 0x61, 0x8d,
+	0x4b, 0x58, 0x5e, 0x60, 0xbb, 0x5d, 0x7a, 0x6a, 0x47, 0xce, 0x73, 0x9a, 0xea, 0x6b, 0x89, 0xdd,
+	0x4b, 0x0b, 0xc6, 0x27, 0xb0, 0xb6, 0x70, 0x10, 0xd7, 0x0d, 0x24, 0xba, 0x1c, 0x48, 0x3e, 0x2c,
+	0xde, 0xd7, 0xf0, 0x4d, 0x68, 0x66, 0x6f, 0x07, 0x5a, 0x81, 0xd2, 0x9e, 0xf5, 0x58, 0x24, 0xf5,
+	0x07, 0xc3, 0x07, 0x87, 0x2d, 0x0d, 0x7f, 0x0a, 0x75, 0xf5, 0xb4, 0xd8, 0x8a, 0x95, 0x24, 0xfe,
+	0x47, 0x8f, 0xf7, 0x8f, 0x5a, 0x1a, 0x6a, 0x02, 0x58, 0xe6, 0xc1, 0xc0, 0xfc, 0xd5, 0x93, 0xc3,
+	0xc7, 0x23, 0x91, 0xf9, 0xf7, 0x7b, 0xbb, 0x7b, 0xe6, 0x93, 0x56, 0x09, 0x3b, 0xa0, 0xb3, 0x83,
+	0x47, 0x37, 0xa1, 0x32, 0xe1, 0x87, 0x2f, 0x93, 0x6e, 0x4d, 0xb9, 0x0f, 0x56, 0x65, 0x92, 0x7c,
+	0x35, 0xbc, 0x15, 0x28, 0xa6, 0xad, 0x00, 0xda, 0x86, 0xb5, 0xc9, 0x79, 0x10, 0x50, 0x37, 0xea,
+	0xc7, 0x1f, 0x4f, 0x89, 0x2f, 0x2f, 0xb2, 0xf1, 0x7d, 0x58, 0xef, 0x07, 0xd4, 0x8e, 0x28, 0x93,
+	0x1c, 0xe7, 0x80, 0x97, 0xd1, 0x8b, 0xdf, 0x01, 0xa4, 0xbe, 0x99, 0x76, 0x5a, 0x3c, 0x41, 0xc4,
+	0x9d, 0x16, 0x5f, 0xe4, 0x2c, 0x8c, 0xa0, 0xb5, 0xe7, 0x84, 0x11, 0xe3, 0xc4, 0x25, 0x02, 0xbf,
+	0x0b, 0xeb, 0x0a, 0x4f, 0xca, 0x78, 0x03, 0xca, 0xec, 0x05, 0x51, 0x38, 0x12, 0x21, 0x82, 0x87,
+	0x7f, 0x0c, 0x1b, 0x03, 0x1a, 0x4e, 0x02, 0xe7, 0x38, 0x63, 0x72, 0x4e, 0xee, 0xc0, 0xef, 0xc1,
+	0x66, 0x76, 0xeb, 0xf5, 0x36, 0xde, 0x86, 0x75, 0xd1, 0xc6, 0x5c, 0x27, 0x7b, 0x13, 0x90, 0xba,
+	0x51, 0xf6, 0x79, 0x03, 0xd6, 0x79, 0x86, 0x67, 0x76, 0x30, 0xbd, 0xe2, 0xdd, 0x85, 0xbc, 0x55,
+	0x5c, 0xcc, 0x5b, 0xf8, 0x36, 0xac, 0x25, 0x52, 0xae, 0x6c, 0xa2, 0xbe, 0x62, 0xdd, 0x77, 0xa8,
+	0x34, 0x96, 0x79, 0xda, 0xae, 0xae, 0xb8, 0x6a, 0x7e, 0x2d, 0x65, 0xf3, 0xab, 0xe8, 0xa3, 0x43,
+	0xb5, 0xed, 0xbc, 0x22, 0x72, 0x7f, 0xd7, 0xa0, 0xb2, 0x4f, 0xe7, 0xc7, 0x34, 0x60, 0x7d, 0xa5,
+	0x3d, 0x9d, 0x06, 0x34, 0x0c, 0xa5, 0x21, 0x31, 0xc9, 0x3e, 0xf8, 0x17, 0xd4, 0x39, 0x3d, 0x8b,
+	0xbd, 0x96, 0x14, 0xba, 0x99, 0xed, 0xed, 0x1a, 0x44, 0x48, 0xca, 0xb6, 0x77, 0x5b, 0x50, 0x73,
+	0xdc, 0x89, 0x1d, 0xb8, 0xa2, 0x9c, 0xe8, 0xdc, 0x5a, 0x95, 0x85, 0xef, 0xc5, 0xcd, 0x56, 0x15,
+	0xca, 0xbd, 0xbd, 0xe1, 0x13, 0xb3, 0x55, 0x60, 0x7d, 0xd7, 0xe8, 0xf1, 0xe8, 0xc8, 0xec, 0x8f,
+	0x5b, 0x1a, 0xef, 0xbb, 0xcc, 0x1e, 0x6b, 0xb4, 0x56, 0x41, 0xdf, 0x33, 0x1f, 0x8c, 0x5b, 0x25,
+	0x76, 0x92, 0xec, 0x0a, 0x0a, 0x8d, 0xc9, 0xc5, 0xbc, 0x0f, 0x1b, 0x19, 0xae, 0x0c, 0xc0, 0xff,
+	0xc3, 0xca, 0x5c, 0xb0, 0xe4, 0xe5, 0x5c, 0x91, 0xa6, 0x5a, 0x31, 0x1f, 0x0f, 0xa0, 0x76, 0xa4,
+	0x34, 0xdf, 0x08, 0xf4, 0x93, 0xc0, 0x9b, 0xc7, 0x47, 0xc2, 0x9e, 0x99, 0x94, 0x73, 0x9f, 0xb5,
+	0x33, 0x61, 0xb7, 0xb8, 0x20, 0x45, 0xf2, 0xf1, 0x7b, 0x50, 0x3f, 0x52, 0xbb, 0x68, 0xe5, 0x15,
+	0xed, 0x92, 0x57, 0x9e, 0x42, 0x53, 0x2a, 0xbe, 0x4a, 0x77, 0x07, 0x2a, 0x91, 0x1d, 0x9c, 0xd2,
+	0x48, 0xde, 0x05, 0x49, 0xa9, 0x0a, 0x4a, 0x97, 0x28, 0x20, 0x50, 0x7b, 0xe4, 0x39, 0x49, 0xa7,
+	0xf0, 0x7f, 0x50, 0x11, 0x3e, 0xcb, 0xeb, 0x90, 0xbc, 0x20, 0xd9, 0xcc, 0x07, 0xb1, 0xff, 0xe5,
+	0x83, 0x77, 0x07, 0xda, 0x16, 0xf5, 0x6d, 0x27, 0xb0, 0x64, 0xa1, 0x56, 0x5c, 0x49, 0x6e, 0x9e,
+	0xd2, 0x19, 0x2e, 0x6e, 0xbe, 0xf2, 0x73, 0xf1, 0x60, 0x7d, 0x9f, 0x06, 0xcf, 0x66, 0x74, 0x1c,
+	0x50, 0x7a, 0x85, 0xe0, 0xeb, 0x9b, 0x54, 0x1e, 0xd5, 0x92, 0x12, 0xd5, 0x4d, 0x28, 0xbb, 0xde,
+	0x94, 0x0a, 0x58, 0xaa, 0x61, 0x09, 0x02, 0xdf, 0x05, 0xa4, 0x2a, 0x4c, 0xfa, 0xe8, 0x0a, 0xab,
+	0xde, 0xf2, 0x24, 0x75, 0x4b, 0x52, 0xf8, 0x23, 0xa8, 0x7e, 0x4e, 0x2f, 0x06, 0xce, 0xe9, 0xa5,
+	0x73, 0x74, 0x3a, 0xf3, 0x16, 0x17, 0x67, 0xde, 0xef, 0x34, 0xa8, 0x0d, 0x9c, 0x93, 0x93, 0x1f,
+	0xd6, 0xad, 0x0e, 0x54, 0x66, 0xd4, 0x7e, 0x9e, 0xf8, 0x25, 0x29, 0xf4, 0x36, 0xac, 0x4c, 0xb9,
+	0x9d, 0x61, 0xb7, 0xcc, 0x4f, 0x12, 0x48, 0x62, 0xba, 0x15, 0x2f, 0xe1, 0x1e, 0xd4, 0x85, 0x49,
+	0xd7, 0xe3, 0x4c, 0x2c, 0x31, 0xd8, 0x2e, 0x83, 0xe8, 0x8a, 0x7c, 0x58, 0x90, 0x14, 0xfe, 0x35,
+	0x6c, 0xa6, 0xcd, 0xff, 0x43, 0x2f, 0xfa, 0x5e, 0xee, 0x3d, 0xa3, 0x17, 0xe2, 0x72, 0xb3, 0x91,
+	0x92, 0x5e, 0x84, 0xf8, 0x06, 0xb4, 0x17, 0xa4, 0xcb, 0x3c, 0xfe, 0x02, 0x56, 0xc7, 0x9e, 0xef,
+	0xcd, 0xbc, 0x53, 0xde, 0x0b, 0x50, 0xdf, 0x9b, 0x9c, 0xc5, 0x53, 0x06, 0x27, 0x58, 0xde, 0x0c,
+	0xe3, 0x66, 0x4d, 0xe8, 0x4a, 0xe8, 0x4c, 0xdf, 0x59, 0x5a, 0xe8, 0x3b, 0xff, 0x17, 0xca, 0x3e,
+	0xa5, 0x81, 0x08, 0xa8, 0xf2, 0x05, 0x08, 0x2e, 0x4b, 0x46, 0xbb, 0x34, 0x8a, 0x75, 0xc7, 0xc9,
+	0xe8, 0x63, 0xd8, 0xc8, 0x70, 0x65, 0x3c, 0x6f, 0xc1, 0x6a, 0x24, 0x79, 0x32, 0xa6, 0x55, 0x92,
+	0x6c, 0x4a, 0x96, 0xf0, 0x5d, 0xd8, 0xfc, 0x92, 0x0d, 0xe5, 0x0b, 0x52, 0xf3, 0x1d, 0xc3, 0x9f,
+	0x42, 0x7b, 0x61, 0xf7, 0xab, 0x69, 0xfb, 0x5b, 0x09, 0x56, 0xf7, 0xcf, 0x23, 0x31, 0xb2, 0xbc,
+	0x09, 0x45, 0xcf, 0xe7, 0xbb, 0x9b, 0x3b, 0x75, 0x12, 0xb3, 0xc9, 0xa1, 0x6f, 0x15, 0x3d, 0x3f,
+	0x6f, 0x98, 0xb8, 0x06, 0x79, 0x88, 0x6f, 0x90, 0xbe, 0x7c, 0x83, 0xe2, 0x61, 0xb3, 0xac, 0x0c,
+	0x9b, 0xd9, 0xf9, 0xa3, 0xb2, 0x34, 0x51, 0xa5, 0xc0, 0xc8, 0xca, 0x65, 0xc0, 0xc8, 0x6a, 0x16,
+	0x18, 0xb9, 0x03, 0xe0, 0x27, 0xad, 0x50, 0xb7, 0xba, 0xdc, 0x1d, 0x29, 0xcb, 0xfc, 0x8e, 0xb0,
+	0x58, 0xbb, 0x13, 0xca, 0x7b, 0x6d, 0xdd, 0x4a, 0x68, 0xfc, 0x7b, 0x0d, 0x8a, 0x87, 0x3e, 0x6b,
+	0x1e, 0x47, 0xe6, 0xb8, 0x55, 0x60, 0xed, 0x9f, 0x65, 0xee, 0x1f, 0x3e, 0x61, 0x98, 0xc0, 0x06,
+	0xac, 0xf5, 0xf7, 0xcc, 0x9e, 0xf5, 0xf4, 0xa0, 0xb7, 0x6f, 0x8e, 0x8e, 0x7a, 0x7d, 0xb3, 0x55,
+	0x64, 0xcc, 0xe1, 0xc1, 0x93, 0xde, 0xde, 0x70, 0xd0, 0x1b, 0x9b, 0x4f, 0xc7, 0xbd, 0xdd, 0x51,
+	0xab, 0x84, 0x10, 0x34, 0x47, 0xe6, 0xf8, 0xe9, 0xae, 0x79, 0x60, 0x5a, 0xbd, 0xf1, 0xf0, 0xf0,
+	0xa0, 0xa5, 0xb3, 0x8d, 0x03, 0x73, 0xcf, 0x1c, 0x9b, 0x4f, 0xf7, 0x7b, 0xe3, 0xfe, 0x43, 0xd6,
+	0x77, 0x96, 0xd1, 0x1a, 0xd4, 0xfa, 0x96, 0xc9, 0xde, 0x3c, 0x3a, 0x3c, 0xdc, 0x6b, 0x55, 0x18,
+	0x43, 0xee, 0xe2, 0x8c, 0x15, 0x7c, 0x17, 0x5a, 0x32, 0x8d, 0x46, 0x49, 0x6e, 0xec, 0xc2, 0x8a,
+	0xbc, 0xb8, 0x71, 0x21, 0x97, 0x24, 0xfe, 0x87, 0x06, 0xeb, 0xca, 0x76, 0x79, 0x45, 0x54, 0x87,
+	0xb5, 0xac, 0xc3, 0x3c, 0x95, 0xd8, 0x11, 0x0d, 0x23, 0x99, 0xbb, 0x24, 0x95, 0x4d, 0x6b, 0x25,
+	0x39, 0x54, 0xc5, 0x0c, 0x74, 0x1b, 0xaa, 0x73, 0x79, 0x6b, 0xe2, 0x4f, 0xa6, 0x9a, 0xdc, 0x23,
+	0x2b, 0x5d, 0xe3, 0xaa, 0x5d, 0xdb, 0x0f, 0xcf, 0xbc, 0x48, 0x82, 0xcb, 0x09, 0x8d, 0x30, 0xd4,
+	0xe3, 0xe7, 0x81, 0xe7, 0xc6, 0x10, 0x73, 0x86, 0x87, 0x7d, 0xa8, 0xf3, 0x6b, 0xaf, 0x24, 0x18,
+	0x9e, 0x2e, 0xb4, 0x34, 0x5d, 0x30, 0x1d, 0xe2, 0x82, 0xc8, 0xba, 0x5d, 0xb5, 0x12, 0xfa, 0x35,
+	0x10, 0xb3, 0x7f, 0x6a, 0x00, 0x5c, 0xa5, 0xf9, 0x9c, 0xba, 0xec, 0x77, 0x05, 0x3d, 0xba, 0xf0,
+	0xa9, 0xfc, 0x58, 0x5a, 0x24, 0x5d, 0x22, 0xe3, 0x0b, 0x9f, 0x5a, 0x7c, 0x35, 0x2e, 0x0b, 0xc5,
+	0xcc, 0xa8, 0x92, 0xf3, 0x2b, 0x49, 0x07, 0x2a, 0x73, 0x27, 0x0c, 0xe9, 0x54, 0xb6, 0x49, 0x92,
+	0xe2, 0x0d, 0xa2, 0x37, 0x15, 0x60, 0x18, 0x6b, 0x10, 0xbd, 0x29, 0xc5, 0x9f, 0x83, 0xce, 0x34,
+	0xe4, 0xdf, 0xc5, 0x2a, 0x94, 0xcd, 0x27, 0xc3, 0xfe, 0x58, 0x4c, 0x28, 0xe6, 0x2f, 0x8f, 0x86,
+	0x96, 0xd9, 0x2a, 0x31, 0x36, 0xbf, 0xa2, 0x2d, 0x1d, 0xd5, 0x61, 0xf5, 0xf0, 0x89, 0x69, 0x3d,
+	0xd8, 0x3b, 0xfc, 0xb2, 0x55, 0xc6, 0xef, 0x43, 0x43, 0xc6, 0x51, 0xde, 0x89, 0x9b, 0x50, 0xa1,
+	0xcc, 0x8b, 0xb8, 0xe6, 0xd7, 0x14, 0xcf, 0x2c, 0xb9, 0x84, 0x7f, 0x04, 0xf5, 0x71, 0x60, 0x4f,
+	0x9e, 0x29, 0x88, 0xe5, 0x64, 0xe6, 0x50, 0x09, 0xed, 0x54, 0x2d, 0x49, 0x61, 0x0b, 0xea, 0x49,
+	0xc2, 0x56, 0x21, 0x91, 0x97, 0x2f, 0x03, 0x32, 0x80, 0xa5, 0x24, 0x80, 0xd8, 0x87, 0x86, 0xd4,
+	0x9d, 0x36, 0x0f, 0x6c, 0xd6, 0x15, 0x59, 0x6e, 0xd5, 0x12, 0x04, 0xba, 0x07, 0x0d, 0x47, 0x51,
+	0x1d, 0x77, 0x6e, 0x0d, 0xa2, 0x1a, 0x64, 0x65, 0xf7, 0x30, 0x51, 0x27, 0xb3, 0xf3, 0xf0, 0x4c,
+	0xfe, 0x52, 0x22, 0x08, 0xfc, 0x05, 0xd4, 0xd8, 0x50, 0xfe, 0xfa, 0xb5, 0x6c, 0xd9, 0x89, 0x03,
+	0xa8, 0x0b, 0x91, 0xa9, 0x0f, 0x27, 0x6c, 0xfc, 0x8f, 0x7d, 0xe0, 0xc4, 0x25, 0xbf, 0xb3, 0xc5,
+	0x99, 0xb3, 0x94, 0x66, 0xce, 0x9d, 0x7f, 0xd7, 0xa0, 0xba, 0x1f, 0xff, 0x80, 0x87, 0x30, 0x94,
+	0x76, 0x69, 0x84, 0x6a, 0x24, 0xfd, 0x49, 0xcb, 0xa8, 0x13, 0xe5, 0x27, 0x24, 0x5c, 0x60, 0x7b,
+	0x46, 0x7c, 0xcf, 0x48, 0xdd, 0x33, 0xca, 0xec, 0xe9, 0x43, 0x33, 0xfb, 0x1b, 0x03, 0xea, 0x90,
+	0xdc, 0x1f, 0x47, 0x8c, 0x1b, 0x24, 0xff, 0xc7, 0x08, 0x5c, 0x40, 0x77, 0xa0, 0x22, 0xd0, 0x7d,
+	0xd4, 0x24, 0x99, 0xdf, 0x1e, 0x8c, 0x35, 0x92, 0x85, 0xfd, 0xa5, 0xc6, 0x0c, 0x24, 0xcf, 0x34,
+	0xe6, 0xc1, 0xfd, 0xc6, 0x8d, 0x25, 0x7e, 0x22, 0xe4, 0x16, 0xe8, 0x0c, 0x7c, 0x47, 0x75, 0xa2,
+	0x80, 0xf8, 0x46, 0x83, 0xa8, 0x88, 0xbc, 0xd0, 0x95, 0x85, 0xc0, 0x51, 0x87, 0xe4, 0x02, 0xf2,
+	0xc6, 0x0d, 0x92, 0x8f, 0x95, 0x0b, 0xef, 0x04, 0x9a, 0x8b, 0x9a, 0x24, 0x83, 0xfe, 0x1a, 0x6b,
+	0x24, 0x0b, 0xf3, 0xe2, 0x02, 0x7a, 0x1f, 0xaa, 0x09, 0x4c, 0x8b, 0xd6, 0xc9, 0x22, 0xb8, 0x6b,
+	0x20, 0xb2, 0x84, 0xe2, 0x0a, 0x3b, 0xb3, 0x80, 0x2a, 0xea, 0x90, 0x5c, 0x00, 0xd7, 0xb8, 0x41,
+	0xf2, 0x91, 0x57, 0x5c, 0x40, 0xbf, 0xe0, 0xe0, 0x74, 0x8a, 0xf9, 0xa1, 0x36, 0xc9, 0x43, 0x5d,
+	0x8d, 0x0e, 0xc9, 0x85, 0x50, 0x85, 0x84, 0xd1, 0x82, 0x84, 0x51, 0xbe, 0x84, 0xd1, 0x25, 0x12,
+	0xfa, 0xd0, 0xcc, 0x02, 0x8f, 0xa8, 0x43, 0x72, 0x41, 0x4d, 0xe3, 0x06, 0xc9, 0x47, 0x28, 0x71,
+	0x01, 0x7d, 0x00, 0x90, 0xc2, 0x18, 0x08, 0x91, 0x25, 0x34, 0xc4, 0xd8, 0x20, 0xcb, 0x38, 0x87,
+	0x08, 0x7e, 0x02, 0x5d, 0xa0, 0x75, 0xb2, 0x08, 0x6d, 0x18, 0x88, 0x2c, 0x21, 0x1b, 0xb8, 0x80,
+	0x3e, 0x81, 0xba, 0x8a, 0x49, 0xa0, 0x4d, 0x92, 0x83, 0x66, 0x18, 0x6d, 0x92, 0x07, 0x5c, 0x08,
+	0x6b, 0x53, 0xd8, 0x01, 0x21, 0xb2, 0x04, 0x56, 0x18, 0x1b, 0x24, 0x07, 0x97, 0x28, 0x20, 0x02,
+	0x2b, 0x12, 0x53, 0x40, 0x6b, 0x44, 0x3e, 0xc5, 0xaf, 0xb4, 0xc8, 0x02, 0xdc, 0x10, 0x7f, 0x65,
+	0x1c, 0x4b, 0x6a, 0x12, 0xf1, 0xa0, 0x7e, 0x65, 0x61, 0xf6, 0xe6, 0x7f, 0x08, 0x35, 0x65, 0x58,
+	0x46, 0x1b, 0x64, 0x79, 0xa0, 0x36, 0x36, 0x49, 0xce, 0x3c, 0x2d, 0x0e, 0x31, 0x3b, 0xc4, 0xa1,
+	0x0e, 0xc9, 0x1d, 0x01, 0x8d, 0x1b, 0x24, 0x7f, 0xda, 0x13, 0x77, 0x29, 0xd3, 0xc8, 0xa3, 0x36,
+	0xc9, 0x1b, 0x1b, 0x8c, 0x0e, 0xc9, 0xef, 0xf7, 0xb9, 0x0b, 0x4a, 0x8b, 0x8d, 0x36, 0xc8, 0x72,
+	0x1b, 0x6e, 0x6c, 0x92, 0x9c, 0x2e, 0x1c, 0x17, 0xd0, 0x67, 0xb2, 0xe6, 0x25, 0x6f, 0xb7, 0x49,
+	0x5e, 0xc3, 0x6d, 0x74, 0x48, 0x6e, 0x67, 0x8d, 0x0b, 0xef, 0x6a, 0xe8, 0xa7, 0x50, 0x4d, 0xfa,
+	0x29, 0xb4, 0x4e, 0x16, 0x5b, 0x31, 0x03, 0x91, 0xa5, 0x76, 0x8b, 0xbf, 0xf7, 0x13, 0x28, 0x73,
+	0xa1, 0xa8, 0x41, 0xd4, 0xfe, 0xc5, 0x68, 0x92, 0x4c, 0x19, 0x8e, 0xf7, 0xf2, 0x4a, 0x87, 0x1a,
+	0x44, 0xad, 0xb6, 0x46, 0x93, 0x64, 0x0a, 0x20, 0xdb, 0xbb, 0xf3, 0x3b, 0x0d, 0x40, 0x1e, 0xd6,
+	0x99, 0xe3, 0xb3, 0x14, 0xc8, 0xb0, 0x05, 0x54, 0x27, 0x0a, 0xb6, 0x61, 0x34, 0xc8, 0xd1, 0x62,
+	0xf6, 0x5a, 0x91, 0xeb, 0x68, 0x8d, 0x64, 0xc1, 0x88, 0xe5, 0xcd, 0xb7, 0x40, 0x67, 0xf0, 0x00,
+	0xaa, 0x13, 0x05, 0x55, 0x30, 0x1a, 0x44, 0xc5, 0x0c, 0x70, 0x61, 0xe7, 0x1d, 0xa8, 0x1c, 0x0a,
+	0x58, 0xf8, 0x16, 0xe8, 0x1c, 0xcc, 0xae, 0x13, 0xa5, 0x7c, 0x1a, 0x0d, 0xa2, 0x56, 0x3e, 0x5c,
+	0xd8, 0xf1, 0xa0, 0xd6, 0x73, 0x23, 0x87, 0x61, 0xbb, 0x9e, 0x7f, 0xc1, 0x3e, 0x99, 0x74, 0x08,
+	0x47, 0x88, 0x2c, 0x41, 0x00, 0xc6, 0x06, 0x59, 0x9e, 0xd2, 0x71, 0x01, 0xdd, 0x06, 0x9d, 0x8d,
+	0xaf, 0xa8, 0x4e, 0x94, 0xc1, 0xda, 0x68, 0x10, 0x75, 0xa6, 0x65, 0xb1, 0x3a, 0xae, 0xf0, 0x3f,
+	0xb8, 0xdc, 0xfb, 0xcf, 0x00, 0x2c, 0x57, 0x88, 0xdd, 0xf3, 0x22, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
 
 // MemcachedClient is the client API for Memcached service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type MemcachedClient interface {
-	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
-	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error)
-	CompareAndSwap(ctx context.Context, in *CompareAndSwapRequest, opts ...grpc.CallOption) (*CompareAndSwapResponse, error)
-	Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveResponse, error)
-	Clear(ctx context.Context, in *ClearRequest, opts ...grpc.CallOption) (*ClearResponse, error)
-	Size(ctx context.Context, in *SizeRequest, opts ...grpc.CallOption) (*SizeResponse, error)
+type MemcachedClient interface {
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error)
+	CompareAndSwap(ctx context.Context, in *CompareAndSwapRequest, opts ...grpc.CallOption) (*CompareAndSwapResponse, error)
+	Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveResponse, error)
+	ClearNamespace(ctx context.Context, in *ClearNamespaceRequest, opts ...grpc.CallOption) (*ClearNamespaceResponse, error)
+	Size(ctx context.Context, in *SizeRequest, opts ...grpc.CallOption) (*SizeResponse, error)
+	DeleteMatching(ctx context.Context, in *DeleteMatchingRequest, opts ...grpc.CallOption) (*DeleteMatchingResponse, error)
+	GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*GetJobResponse, error)
+	CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error)
+	InvalidateTags(ctx context.Context, in *InvalidateTagsRequest, opts ...grpc.CallOption) (*InvalidateTagsResponse, error)
+	GetGeneration(ctx context.Context, in *GetGenerationRequest, opts ...grpc.CallOption) (*GetGenerationResponse, error)
+	SetGeneration(ctx context.Context, in *SetGenerationRequest, opts ...grpc.CallOption) (*SetGenerationResponse, error)
+	BumpGeneration(ctx context.Context, in *BumpGenerationRequest, opts ...grpc.CallOption) (*BumpGenerationResponse, error)
+	CreatePool(ctx context.Context, in *CreatePoolRequest, opts ...grpc.CallOption) (*CreatePoolResponse, error)
+	ListPools(ctx context.Context, in *ListPoolsRequest, opts ...grpc.CallOption) (*ListPoolsResponse, error)
+	DescribePool(ctx context.Context, in *DescribePoolRequest, opts ...grpc.CallOption) (*DescribePoolResponse, error)
+	DeletePool(ctx context.Context, in *DeletePoolRequest, opts ...grpc.CallOption) (*DeletePoolResponse, error)
+	Reshard(ctx context.Context, in *ReshardRequest, opts ...grpc.CallOption) (*ReshardResponse, error)
+	Resize(ctx context.Context, in *ResizeRequest, opts ...grpc.CallOption) (*ResizeResponse, error)
+	ListMembers(ctx context.Context, in *ListMembersRequest, opts ...grpc.CallOption) (*ListMembersResponse, error)
+	RepairReplicas(ctx context.Context, in *RepairReplicasRequest, opts ...grpc.CallOption) (*RepairReplicasResponse, error)
+	InvalidateHot(ctx context.Context, in *InvalidateHotRequest, opts ...grpc.CallOption) (*InvalidateHotResponse, error)
+	GetTopology(ctx context.Context, in *GetTopologyRequest, opts ...grpc.CallOption) (*GetTopologyResponse, error)
+	WatchTopology(ctx context.Context, in *WatchTopologyRequest, opts ...grpc.CallOption) (Memcached_WatchTopologyClient, error)
+	Replicate(ctx context.Context, in *ReplicateRequest, opts ...grpc.CallOption) (Memcached_ReplicateClient, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Memcached_WatchClient, error)
+	Track(ctx context.Context, in *TrackRequest, opts ...grpc.CallOption) (Memcached_TrackClient, error)
+}
+
+type memcachedClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMemcachedClient(cc *grpc.ClientConn) MemcachedClient {
+	return &memcachedClient{cc}
+}
+
+func (c *memcachedClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error) {
+	out := new(SetResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/Set", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) CompareAndSwap(ctx context.Context, in *CompareAndSwapRequest, opts ...grpc.CallOption) (*CompareAndSwapResponse, error) {
+	out := new(CompareAndSwapResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/CompareAndSwap", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveResponse, error) {
+	out := new(RemoveResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/Remove", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) ClearNamespace(ctx context.Context, in *ClearNamespaceRequest, opts ...grpc.CallOption) (*ClearNamespaceResponse, error) {
+	out := new(ClearNamespaceResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/ClearNamespace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) Size(ctx context.Context, in *SizeRequest, opts ...grpc.CallOption) (*SizeResponse, error) {
+	out := new(SizeResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/Size", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) DeleteMatching(ctx context.Context, in *DeleteMatchingRequest, opts ...grpc.CallOption) (*DeleteMatchingResponse, error) {
+	out := new(DeleteMatchingResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/DeleteMatching", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*GetJobResponse, error) {
+	out := new(GetJobResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/GetJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error) {
+	out := new(CancelJobResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/CancelJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) InvalidateTags(ctx context.Context, in *InvalidateTagsRequest, opts ...grpc.CallOption) (*InvalidateTagsResponse, error) {
+	out := new(InvalidateTagsResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/InvalidateTags", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) GetGeneration(ctx context.Context, in *GetGenerationRequest, opts ...grpc.CallOption) (*GetGenerationResponse, error) {
+	out := new(GetGenerationResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/GetGeneration", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) SetGeneration(ctx context.Context, in *SetGenerationRequest, opts ...grpc.CallOption) (*SetGenerationResponse, error) {
+	out := new(SetGenerationResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/SetGeneration", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) BumpGeneration(ctx context.Context, in *BumpGenerationRequest, opts ...grpc.CallOption) (*BumpGenerationResponse, error) {
+	out := new(BumpGenerationResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/BumpGeneration", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) CreatePool(ctx context.Context, in *CreatePoolRequest, opts ...grpc.CallOption) (*CreatePoolResponse, error) {
+	out := new(CreatePoolResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/CreatePool", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) ListPools(ctx context.Context, in *ListPoolsRequest, opts ...grpc.CallOption) (*ListPoolsResponse, error) {
+	out := new(ListPoolsResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/ListPools", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) DescribePool(ctx context.Context, in *DescribePoolRequest, opts ...grpc.CallOption) (*DescribePoolResponse, error) {
+	out := new(DescribePoolResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/DescribePool", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) DeletePool(ctx context.Context, in *DeletePoolRequest, opts ...grpc.CallOption) (*DeletePoolResponse, error) {
+	out := new(DeletePoolResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/DeletePool", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) Reshard(ctx context.Context, in *ReshardRequest, opts ...grpc.CallOption) (*ReshardResponse, error) {
+	out := new(ReshardResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/Reshard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) Resize(ctx context.Context, in *ResizeRequest, opts ...grpc.CallOption) (*ResizeResponse, error) {
+	out := new(ResizeResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/Resize", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) ListMembers(ctx context.Context, in *ListMembersRequest, opts ...grpc.CallOption) (*ListMembersResponse, error) {
+	out := new(ListMembersResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/ListMembers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) RepairReplicas(ctx context.Context, in *RepairReplicasRequest, opts ...grpc.CallOption) (*RepairReplicasResponse, error) {
+	out := new(RepairReplicasResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/RepairReplicas", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) InvalidateHot(ctx context.Context, in *InvalidateHotRequest, opts ...grpc.CallOption) (*InvalidateHotResponse, error) {
+	out := new(InvalidateHotResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/InvalidateHot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) GetTopology(ctx context.Context, in *GetTopologyRequest, opts ...grpc.CallOption) (*GetTopologyResponse, error) {
+	out := new(GetTopologyResponse)
+	err := c.cc.Invoke(ctx, "/Memcached/GetTopology", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memcachedClient) WatchTopology(ctx context.Context, in *WatchTopologyRequest, opts ...grpc.CallOption) (Memcached_WatchTopologyClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Memcached_serviceDesc.Streams[0], "/Memcached/WatchTopology", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &memcachedWatchTopologyClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Memcached_WatchTopologyClient interface {
+	Recv() (*WatchTopologyResponse, error)
+	grpc.ClientStream
+}
+
+type memcachedWatchTopologyClient struct {
+	grpc.ClientStream
+}
+
+func (x *memcachedWatchTopologyClient) Recv() (*WatchTopologyResponse, error) {
+	m := new(WatchTopologyResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *memcachedClient) Replicate(ctx context.Context, in *ReplicateRequest, opts ...grpc.CallOption) (Memcached_ReplicateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Memcached_serviceDesc.Streams[1], "/Memcached/Replicate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &memcachedReplicateClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Memcached_ReplicateClient interface {
+	Recv() (*ReplicateResponse, error)
+	grpc.ClientStream
+}
+
+type memcachedReplicateClient struct {
+	grpc.ClientStream
+}
+
+func (x *memcachedReplicateClient) Recv() (*ReplicateResponse, error) {
+	m := new(ReplicateResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *memcachedClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Memcached_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Memcached_serviceDesc.Streams[2], "/Memcached/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &memcachedWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Memcached_WatchClient interface {
+	Recv() (*WatchResponse, error)
+	grpc.ClientStream
+}
+
+type memcachedWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *memcachedWatchClient) Recv() (*WatchResponse, error) {
+	m := new(WatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *memcachedClient) Track(ctx context.Context, in *TrackRequest, opts ...grpc.CallOption) (Memcached_TrackClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Memcached_serviceDesc.Streams[3], "/Memcached/Track", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &memcachedTrackClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Memcached_TrackClient interface {
+	Recv() (*TrackResponse, error)
+	grpc.ClientStream
+}
+
+type memcachedTrackClient struct {
+	grpc.ClientStream
+}
+
+func (x *memcachedTrackClient) Recv() (*TrackResponse, error) {
+	m := new(TrackResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MemcachedServer is the server API for Memcached service.
+type MemcachedServer interface {
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	Set(context.Context, *SetRequest) (*SetResponse, error)
+	CompareAndSwap(context.Context, *CompareAndSwapRequest) (*CompareAndSwapResponse, error)
+	Remove(context.Context, *RemoveRequest) (*RemoveResponse, error)
+	ClearNamespace(context.Context, *ClearNamespaceRequest) (*ClearNamespaceResponse, error)
+	Size(context.Context, *SizeRequest) (*SizeResponse, error)
+	DeleteMatching(context.Context, *DeleteMatchingRequest) (*DeleteMatchingResponse, error)
+	GetJob(context.Context, *GetJobRequest) (*GetJobResponse, error)
+	CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error)
+	InvalidateTags(context.Context, *InvalidateTagsRequest) (*InvalidateTagsResponse, error)
+	GetGeneration(context.Context, *GetGenerationRequest) (*GetGenerationResponse, error)
+	SetGeneration(context.Context, *SetGenerationRequest) (*SetGenerationResponse, error)
+	BumpGeneration(context.Context, *BumpGenerationRequest) (*BumpGenerationResponse, error)
+	CreatePool(context.Context, *CreatePoolRequest) (*CreatePoolResponse, error)
+	ListPools(context.Context, *ListPoolsRequest) (*ListPoolsResponse, error)
+	DescribePool(context.Context, *DescribePoolRequest) (*DescribePoolResponse, error)
+	DeletePool(context.Context, *DeletePoolRequest) (*DeletePoolResponse, error)
+	Reshard(context.Context, *ReshardRequest) (*ReshardResponse, error)
+	Resize(context.Context, *ResizeRequest) (*ResizeResponse, error)
+	ListMembers(context.Context, *ListMembersRequest) (*ListMembersResponse, error)
+	RepairReplicas(context.Context, *RepairReplicasRequest) (*RepairReplicasResponse, error)
+	InvalidateHot(context.Context, *InvalidateHotRequest) (*InvalidateHotResponse, error)
+	GetTopology(context.Context, *GetTopologyRequest) (*GetTopologyResponse, error)
+	WatchTopology(*WatchTopologyRequest, Memcached_WatchTopologyServer) error
+	Replicate(*ReplicateRequest, Memcached_ReplicateServer) error
+	Watch(*WatchRequest, Memcached_WatchServer) error
+	Track(*TrackRequest, Memcached_TrackServer) error
+}
+
+// UnimplementedMemcachedServer can be embedded to have forward compatible implementations.
+type UnimplementedMemcachedServer struct {
+}
+
+func (*UnimplementedMemcachedServer) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (*UnimplementedMemcachedServer) Set(ctx context.Context, req *SetRequest) (*SetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Set not implemented")
+}
+func (*UnimplementedMemcachedServer) CompareAndSwap(ctx context.Context, req *CompareAndSwapRequest) (*CompareAndSwapResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompareAndSwap not implemented")
+}
+func (*UnimplementedMemcachedServer) Remove(ctx context.Context, req *RemoveRequest) (*RemoveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Remove not implemented")
+}
+func (*UnimplementedMemcachedServer) ClearNamespace(ctx context.Context, req *ClearNamespaceRequest) (*ClearNamespaceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearNamespace not implemented")
+}
+func (*UnimplementedMemcachedServer) Size(ctx context.Context, req *SizeRequest) (*SizeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Size not implemented")
+}
+func (*UnimplementedMemcachedServer) DeleteMatching(ctx context.Context, req *DeleteMatchingRequest) (*DeleteMatchingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMatching not implemented")
+}
+func (*UnimplementedMemcachedServer) GetJob(ctx context.Context, req *GetJobRequest) (*GetJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJob not implemented")
+}
+func (*UnimplementedMemcachedServer) CancelJob(ctx context.Context, req *CancelJobRequest) (*CancelJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelJob not implemented")
+}
+func (*UnimplementedMemcachedServer) InvalidateTags(ctx context.Context, req *InvalidateTagsRequest) (*InvalidateTagsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InvalidateTags not implemented")
+}
+func (*UnimplementedMemcachedServer) GetGeneration(ctx context.Context, req *GetGenerationRequest) (*GetGenerationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGeneration not implemented")
+}
+func (*UnimplementedMemcachedServer) SetGeneration(ctx context.Context, req *SetGenerationRequest) (*SetGenerationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetGeneration not implemented")
+}
+func (*UnimplementedMemcachedServer) BumpGeneration(ctx context.Context, req *BumpGenerationRequest) (*BumpGenerationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BumpGeneration not implemented")
+}
+func (*UnimplementedMemcachedServer) CreatePool(ctx context.Context, req *CreatePoolRequest) (*CreatePoolResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePool not implemented")
+}
+func (*UnimplementedMemcachedServer) ListPools(ctx context.Context, req *ListPoolsRequest) (*ListPoolsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPools not implemented")
+}
+func (*UnimplementedMemcachedServer) DescribePool(ctx context.Context, req *DescribePoolRequest) (*DescribePoolResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DescribePool not implemented")
+}
+func (*UnimplementedMemcachedServer) DeletePool(ctx context.Context, req *DeletePoolRequest) (*DeletePoolResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePool not implemented")
+}
+func (*UnimplementedMemcachedServer) Reshard(ctx context.Context, req *ReshardRequest) (*ReshardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reshard not implemented")
+}
+func (*UnimplementedMemcachedServer) Resize(ctx context.Context, req *ResizeRequest) (*ResizeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Resize not implemented")
+}
+func (*UnimplementedMemcachedServer) ListMembers(ctx context.Context, req *ListMembersRequest) (*ListMembersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMembers not implemented")
+}
+func (*UnimplementedMemcachedServer) RepairReplicas(ctx context.Context, req *RepairReplicasRequest) (*RepairReplicasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RepairReplicas not implemented")
+}
+func (*UnimplementedMemcachedServer) InvalidateHot(ctx context.Context, req *InvalidateHotRequest) (*InvalidateHotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InvalidateHot not implemented")
+}
+func (*UnimplementedMemcachedServer) GetTopology(ctx context.Context, req *GetTopologyRequest) (*GetTopologyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTopology not implemented")
+}
+func (*UnimplementedMemcachedServer) WatchTopology(req *WatchTopologyRequest, srv Memcached_WatchTopologyServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTopology not implemented")
+}
+func (*UnimplementedMemcachedServer) Replicate(req *ReplicateRequest, srv Memcached_ReplicateServer) error {
+	return status.Errorf(codes.Unimplemented, "method Replicate not implemented")
+}
+func (*UnimplementedMemcachedServer) Watch(req *WatchRequest, srv Memcached_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (*UnimplementedMemcachedServer) Track(req *TrackRequest, srv Memcached_TrackServer) error {
+	return status.Errorf(codes.Unimplemented, "method Track not implemented")
+}
+
+func RegisterMemcachedServer(s *grpc.Server, srv MemcachedServer) {
+	s.RegisterService(&_Memcached_serviceDesc, srv)
+}
+
+func _Memcached_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/Set",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).Set(ctx, req.(*SetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_CompareAndSwap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareAndSwapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).CompareAndSwap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/CompareAndSwap",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).CompareAndSwap(ctx, req.(*CompareAndSwapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_Remove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).Remove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/Remove",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).Remove(ctx, req.(*RemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_ClearNamespace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearNamespaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).ClearNamespace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/ClearNamespace",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).ClearNamespace(ctx, req.(*ClearNamespaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_Size_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).Size(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/Size",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).Size(ctx, req.(*SizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_DeleteMatching_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMatchingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).DeleteMatching(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/DeleteMatching",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).DeleteMatching(ctx, req.(*DeleteMatchingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_GetJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).GetJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/GetJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).GetJob(ctx, req.(*GetJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).CancelJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/CancelJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).CancelJob(ctx, req.(*CancelJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_InvalidateTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvalidateTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).InvalidateTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/InvalidateTags",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).InvalidateTags(ctx, req.(*InvalidateTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_GetGeneration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGenerationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).GetGeneration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/GetGeneration",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).GetGeneration(ctx, req.(*GetGenerationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_SetGeneration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetGenerationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).SetGeneration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/SetGeneration",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).SetGeneration(ctx, req.(*SetGenerationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_BumpGeneration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BumpGenerationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).BumpGeneration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/BumpGeneration",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).BumpGeneration(ctx, req.(*BumpGenerationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_CreatePool_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePoolRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).CreatePool(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/CreatePool",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).CreatePool(ctx, req.(*CreatePoolRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_ListPools_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPoolsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).ListPools(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/ListPools",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).ListPools(ctx, req.(*ListPoolsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_DescribePool_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribePoolRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).DescribePool(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/DescribePool",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).DescribePool(ctx, req.(*DescribePoolRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_DeletePool_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePoolRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).DeletePool(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/DeletePool",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).DeletePool(ctx, req.(*DeletePoolRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_Reshard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReshardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).Reshard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/Reshard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).Reshard(ctx, req.(*ReshardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_Resize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).Resize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/Resize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).Resize(ctx, req.(*ResizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_ListMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).ListMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/ListMembers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).ListMembers(ctx, req.(*ListMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_RepairReplicas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RepairReplicasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).RepairReplicas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/RepairReplicas",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).RepairReplicas(ctx, req.(*RepairReplicasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_InvalidateHot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvalidateHotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).InvalidateHot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/InvalidateHot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).InvalidateHot(ctx, req.(*InvalidateHotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_GetTopology_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTopologyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemcachedServer).GetTopology(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Memcached/GetTopology",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemcachedServer).GetTopology(ctx, req.(*GetTopologyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Memcached_WatchTopology_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchTopologyRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MemcachedServer).WatchTopology(m, &memcachedWatchTopologyServer{stream})
+}
+
+type Memcached_WatchTopologyServer interface {
+	Send(*WatchTopologyResponse) error
+	grpc.ServerStream
+}
+
+type memcachedWatchTopologyServer struct {
+	grpc.ServerStream
+}
+
+func (x *memcachedWatchTopologyServer) Send(m *WatchTopologyResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Memcached_Replicate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReplicateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MemcachedServer).Replicate(m, &memcachedReplicateServer{stream})
+}
+
+type Memcached_ReplicateServer interface {
+	Send(*ReplicateResponse) error
+	grpc.ServerStream
+}
+
+type memcachedReplicateServer struct {
+	grpc.ServerStream
+}
+
+func (x *memcachedReplicateServer) Send(m *ReplicateResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Memcached_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MemcachedServer).Watch(m, &memcachedWatchServer{stream})
+}
+
+type Memcached_WatchServer interface {
+	Send(*WatchResponse) error
+	grpc.ServerStream
+}
+
+type memcachedWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *memcachedWatchServer) Send(m *WatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Memcached_Track_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TrackRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MemcachedServer).Track(m, &memcachedTrackServer{stream})
+}
+
+type Memcached_TrackServer interface {
+	Send(*TrackResponse) error
+	grpc.ServerStream
+}
+
+type memcachedTrackServer struct {
+	grpc.ServerStream
+}
+
+func (x *memcachedTrackServer) Send(m *TrackResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Memcached_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "Memcached",
+	HandlerType: (*MemcachedServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _Memcached_Get_Handler,
+		},
+		{
+			MethodName: "Set",
+			Handler:    _Memcached_Set_Handler,
+		},
+		{
+			MethodName: "CompareAndSwap",
+			Handler:    _Memcached_CompareAndSwap_Handler,
+		},
+		{
+			MethodName: "Remove",
+			Handler:    _Memcached_Remove_Handler,
+		},
+		{
+			MethodName: "ClearNamespace",
+			Handler:    _Memcached_ClearNamespace_Handler,
+		},
+		{
+			MethodName: "Size",
+			Handler:    _Memcached_Size_Handler,
+		},
+		{
+			MethodName: "DeleteMatching",
+			Handler:    _Memcached_DeleteMatching_Handler,
+		},
+		{
+			MethodName: "GetJob",
+			Handler:    _Memcached_GetJob_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _Memcached_CancelJob_Handler,
+		},
+		{
+			MethodName: "InvalidateTags",
+			Handler:    _Memcached_InvalidateTags_Handler,
+		},
+		{
+			MethodName: "GetGeneration",
+			Handler:    _Memcached_GetGeneration_Handler,
+		},
+		{
+			MethodName: "SetGeneration",
+			Handler:    _Memcached_SetGeneration_Handler,
+		},
+		{
+			MethodName: "BumpGeneration",
+			Handler:    _Memcached_BumpGeneration_Handler,
+		},
+		{
+			MethodName: "CreatePool",
+			Handler:    _Memcached_CreatePool_Handler,
+		},
+		{
+			MethodName: "ListPools",
+			Handler:    _Memcached_ListPools_Handler,
+		},
+		{
+			MethodName: "DescribePool",
+			Handler:    _Memcached_DescribePool_Handler,
+		},
+		{
+			MethodName: "DeletePool",
+			Handler:    _Memcached_DeletePool_Handler,
+		},
+		{
+			MethodName: "Reshard",
+			Handler:    _Memcached_Reshard_Handler,
+		},
+		{
+			MethodName: "Resize",
+			Handler:    _Memcached_Resize_Handler,
+		},
+		{
+			MethodName: "ListMembers",
+			Handler:    _Memcached_ListMembers_Handler,
+		},
+		{
+			MethodName: "RepairReplicas",
+			Handler:    _Memcached_RepairReplicas_Handler,
+		},
+		{
+			MethodName: "InvalidateHot",
+			Handler:    _Memcached_InvalidateHot_Handler,
+		},
+		{
+			MethodName: "GetTopology",
+			Handler:    _Memcached_GetTopology_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchTopology",
+			Handler:       _Memcached_WatchTopology_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Replicate",
+			Handler:       _Memcached_Replicate_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Memcached_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Track",
+			Handler:       _Memcached_Track_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "memcached.proto",
+}
+
+// MembershipClient is the client API for Membership service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type MembershipClient interface {
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	PingReq(ctx context.Context, in *PingReqRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	Join(ctx context.Context, in *JoinRequest, opts ...grpc.CallOption) (*JoinResponse, error)
 }
 
-type memcachedClient struct {
+type membershipClient struct {
 	cc *grpc.ClientConn
 }
 
-func NewMemcachedClient(cc *grpc.ClientConn) MemcachedClient {
-	return &memcachedClient{cc}
-}
-
-func (c *memcachedClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
-	out := new(GetResponse)
-	err := c.cc.Invoke(ctx, "/Memcached/Get", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
-}
-
-func (c *memcachedClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error) {
-	out := new(SetResponse)
-	err := c.cc.Invoke(ctx, "/Memcached/Set", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
-}
-
-func (c *memcachedClient) CompareAndSwap(ctx context.Context, in *CompareAndSwapRequest, opts ...grpc.CallOption) (*CompareAndSwapResponse, error) {
-	out := new(CompareAndSwapResponse)
-	err := c.cc.Invoke(ctx, "/Memcached/CompareAndSwap", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+func NewMembershipClient(cc *grpc.ClientConn) MembershipClient {
+	return &membershipClient{cc}
 }
 
-func (c *memcachedClient) Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveResponse, error) {
-	out := new(RemoveResponse)
-	err := c.cc.Invoke(ctx, "/Memcached/Remove", in, out, opts...)
+func (c *membershipClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, "/Membership/Ping", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *memcachedClient) Clear(ctx context.Context, in *ClearRequest, opts ...grpc.CallOption) (*ClearResponse, error) {
-	out := new(ClearResponse)
-	err := c.cc.Invoke(ctx, "/Memcached/Clear", in, out, opts...)
+func (c *membershipClient) PingReq(ctx context.Context, in *PingReqRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, "/Membership/PingReq", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *memcachedClient) Size(ctx context.Context, in *SizeRequest, opts ...grpc.CallOption) (*SizeResponse, error) {
-	out := new(SizeResponse)
-	err := c.cc.Invoke(ctx, "/Memcached/Size", in, out, opts...)
+func (c *membershipClient) Join(ctx context.Context, in *JoinRequest, opts ...grpc.CallOption) (*JoinResponse, error) {
+	out := new(JoinResponse)
+	err := c.cc.Invoke(ctx, "/Membership/Join", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-// MemcachedServer is the server API for Memcached service.
-type MemcachedServer interface {
-	Get(context.Context, *GetRequest) (*GetResponse, error)
-	Set(context.Context, *SetRequest) (*SetResponse, error)
-	CompareAndSwap(context.Context, *CompareAndSwapRequest) (*CompareAndSwapResponse, error)
-	Remove(context.Context, *RemoveRequest) (*RemoveResponse, error)
-	Clear(context.Context, *ClearRequest) (*ClearResponse, error)
-	Size(context.Context, *SizeRequest) (*SizeResponse, error)
+// MembershipServer is the server API for Membership service.
+type MembershipServer interface {
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	PingReq(context.Context, *PingReqRequest) (*PingResponse, error)
+	Join(context.Context, *JoinRequest) (*JoinResponse, error)
 }
 
-// UnimplementedMemcachedServer can be embedded to have forward compatible implementations.
-type UnimplementedMemcachedServer struct {
+// UnimplementedMembershipServer can be embedded to have forward compatible implementations.
+type UnimplementedMembershipServer struct {
 }
 
-func (*UnimplementedMemcachedServer) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
-}
-func (*UnimplementedMemcachedServer) Set(ctx context.Context, req *SetRequest) (*SetResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Set not implemented")
-}
-func (*UnimplementedMemcachedServer) CompareAndSwap(ctx context.Context, req *CompareAndSwapRequest) (*CompareAndSwapResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method CompareAndSwap not implemented")
-}
-func (*UnimplementedMemcachedServer) Remove(ctx context.Context, req *RemoveRequest) (*RemoveResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Remove not implemented")
+func (*UnimplementedMembershipServer) Ping(ctx context.Context, req *PingRequest) (*PingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
-func (*UnimplementedMemcachedServer) Clear(ctx context.Context, req *ClearRequest) (*ClearResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Clear not implemented")
+func (*UnimplementedMembershipServer) PingReq(ctx context.Context, req *PingReqRequest) (*PingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PingReq not implemented")
 }
-func (*UnimplementedMemcachedServer) Size(ctx context.Context, req *SizeRequest) (*SizeResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Size not implemented")
+func (*UnimplementedMembershipServer) Join(ctx context.Context, req *JoinRequest) (*JoinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Join not implemented")
 }
 
-func RegisterMemcachedServer(s *grpc.Server, srv MemcachedServer) {
-	s.RegisterService(&_Memcached_serviceDesc, srv)
+func RegisterMembershipServer(s *grpc.Server, srv MembershipServer) {
+	s.RegisterService(&_Membership_serviceDesc, srv)
 }
 
-func _Memcached_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetRequest)
+func _Membership_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MemcachedServer).Get(ctx, in)
+		return srv.(MembershipServer).Ping(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/Memcached/Get",
+		FullMethod: "/Membership/Ping",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MemcachedServer).Get(ctx, req.(*GetRequest))
+		return srv.(MembershipServer).Ping(ctx, req.(*PingRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Memcached_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SetRequest)
+func _Membership_PingReq_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingReqRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MemcachedServer).Set(ctx, in)
+		return srv.(MembershipServer).PingReq(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/Memcached/Set",
+		FullMethod: "/Membership/PingReq",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MemcachedServer).Set(ctx, req.(*SetRequest))
+		return srv.(MembershipServer).PingReq(ctx, req.(*PingReqRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Memcached_CompareAndSwap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CompareAndSwapRequest)
+func _Membership_Join_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MemcachedServer).CompareAndSwap(ctx, in)
+		return srv.(MembershipServer).Join(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/Memcached/CompareAndSwap",
+		FullMethod: "/Membership/Join",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MemcachedServer).CompareAndSwap(ctx, req.(*CompareAndSwapRequest))
+		return srv.(MembershipServer).Join(ctx, req.(*JoinRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Memcached_Remove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(RemoveRequest)
+var _Membership_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "Membership",
+	HandlerType: (*MembershipServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Ping",
+			Handler:    _Membership_Ping_Handler,
+		},
+		{
+			MethodName: "PingReq",
+			Handler:    _Membership_PingReq_Handler,
+		},
+		{
+			MethodName: "Join",
+			Handler:    _Membership_Join_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "memcached.proto",
+}
+
+// OriginClient is the client API for Origin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type OriginClient interface {
+	Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*LoadResponse, error)
+}
+
+type originClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewOriginClient(cc *grpc.ClientConn) OriginClient {
+	return &originClient{cc}
+}
+
+func (c *originClient) Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*LoadResponse, error) {
+	out := new(LoadResponse)
+	err := c.cc.Invoke(ctx, "/Origin/Load", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OriginServer is the server API for Origin service.
+type OriginServer interface {
+	Load(context.Context, *LoadRequest) (*LoadResponse, error)
+}
+
+// UnimplementedOriginServer can be embedded to have forward compatible implementations.
+type UnimplementedOriginServer struct {
+}
+
+func (*UnimplementedOriginServer) Load(ctx context.Context, req *LoadRequest) (*LoadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Load not implemented")
+}
+
+func RegisterOriginServer(s *grpc.Server, srv OriginServer) {
+	s.RegisterService(&_Origin_serviceDesc, srv)
+}
+
+func _Origin_Load_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MemcachedServer).Remove(ctx, in)
+		return srv.(OriginServer).Load(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/Memcached/Remove",
+		FullMethod: "/Origin/Load",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MemcachedServer).Remove(ctx, req.(*RemoveRequest))
+		return srv.(OriginServer).Load(ctx, req.(*LoadRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Memcached_Clear_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ClearRequest)
-	if err := dec(in); err != nil {
+var _Origin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "Origin",
+	HandlerType: (*OriginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Load",
+			Handler:    _Origin_Load_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "memcached.proto",
+}
+
+// AntiEntropyClient is the client API for AntiEntropy service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AntiEntropyClient interface {
+	MerkleTree(ctx context.Context, in *MerkleTreeRequest, opts ...grpc.CallOption) (*MerkleTreeResponse, error)
+	Diff(ctx context.Context, in *DiffRequest, opts ...grpc.CallOption) (AntiEntropy_DiffClient, error)
+}
+
+type antiEntropyClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAntiEntropyClient(cc *grpc.ClientConn) AntiEntropyClient {
+	return &antiEntropyClient{cc}
+}
+
+func (c *antiEntropyClient) MerkleTree(ctx context.Context, in *MerkleTreeRequest, opts ...grpc.CallOption) (*MerkleTreeResponse, error) {
+	out := new(MerkleTreeResponse)
+	err := c.cc.Invoke(ctx, "/AntiEntropy/MerkleTree", in, out, opts...)
+	if err != nil {
 		return nil, err
 	}
-	if interceptor == nil {
-		return srv.(MemcachedServer).Clear(ctx, in)
+	return out, nil
+}
+
+func (c *antiEntropyClient) Diff(ctx context.Context, in *DiffRequest, opts ...grpc.CallOption) (AntiEntropy_DiffClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_AntiEntropy_serviceDesc.Streams[0], "/AntiEntropy/Diff", opts...)
+	if err != nil {
+		return nil, err
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/Memcached/Clear",
+	x := &antiEntropyDiffClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MemcachedServer).Clear(ctx, req.(*ClearRequest))
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
 	}
-	return interceptor(ctx, in, info, handler)
+	return x, nil
 }
 
-func _Memcached_Size_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SizeRequest)
+type AntiEntropy_DiffClient interface {
+	Recv() (*DiffResponse, error)
+	grpc.ClientStream
+}
+
+type antiEntropyDiffClient struct {
+	grpc.ClientStream
+}
+
+func (x *antiEntropyDiffClient) Recv() (*DiffResponse, error) {
+	m := new(DiffResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AntiEntropyServer is the server API for AntiEntropy service.
+type AntiEntropyServer interface {
+	MerkleTree(context.Context, *MerkleTreeRequest) (*MerkleTreeResponse, error)
+	Diff(*DiffRequest, AntiEntropy_DiffServer) error
+}
+
+// UnimplementedAntiEntropyServer can be embedded to have forward compatible implementations.
+type UnimplementedAntiEntropyServer struct {
+}
+
+func (*UnimplementedAntiEntropyServer) MerkleTree(ctx context.Context, req *MerkleTreeRequest) (*MerkleTreeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MerkleTree not implemented")
+}
+func (*UnimplementedAntiEntropyServer) Diff(req *DiffRequest, srv AntiEntropy_DiffServer) error {
+	return status.Errorf(codes.Unimplemented, "method Diff not implemented")
+}
+
+func RegisterAntiEntropyServer(s *grpc.Server, srv AntiEntropyServer) {
+	s.RegisterService(&_AntiEntropy_serviceDesc, srv)
+}
+
+func _AntiEntropy_MerkleTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MerkleTreeRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MemcachedServer).Size(ctx, in)
+		return srv.(AntiEntropyServer).MerkleTree(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/Memcached/Size",
+		FullMethod: "/AntiEntropy/MerkleTree",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MemcachedServer).Size(ctx, req.(*SizeRequest))
+		return srv.(AntiEntropyServer).MerkleTree(ctx, req.(*MerkleTreeRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-var _Memcached_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "Memcached",
-	HandlerType: (*MemcachedServer)(nil),
+func _AntiEntropy_Diff_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DiffRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AntiEntropyServer).Diff(m, &antiEntropyDiffServer{stream})
+}
+
+type AntiEntropy_DiffServer interface {
+	Send(*DiffResponse) error
+	grpc.ServerStream
+}
+
+type antiEntropyDiffServer struct {
+	grpc.ServerStream
+}
+
+func (x *antiEntropyDiffServer) Send(m *DiffResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _AntiEntropy_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "AntiEntropy",
+	HandlerType: (*AntiEntropyServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "Get",
-			Handler:    _Memcached_Get_Handler,
-		},
-		{
-			MethodName: "Set",
-			Handler:    _Memcached_Set_Handler,
-		},
-		{
-			MethodName: "CompareAndSwap",
-			Handler:    _Memcached_CompareAndSwap_Handler,
-		},
-		{
-			MethodName: "Remove",
-			Handler:    _Memcached_Remove_Handler,
-		},
-		{
-			MethodName: "Clear",
-			Handler:    _Memcached_Clear_Handler,
+			MethodName: "MerkleTree",
+			Handler:    _AntiEntropy_MerkleTree_Handler,
 		},
+	},
+	Streams: []grpc.StreamDesc{
 		{
-			MethodName: "Size",
-			Handler:    _Memcached_Size_Handler,
+			StreamName:    "Diff",
+			Handler:       _AntiEntropy_Diff_Handler,
+			ServerStreams: true,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "memcached.proto",
 }